@@ -0,0 +1,140 @@
+// Command aof-check validates an append only file, in the spirit of
+// redis-check-aof. It accepts a single AOF file or a multi-part manifest:
+//
+//	aof-check appendonlydir/appendonly.aof.manifest
+//	aof-check --fix appendonlydir/appendonly.aof.1.incr.aof
+//
+// With --fix a truncated tail is cut off at the last complete command. Only
+// the last file of a multi-part AOF may be truncated this way; corruption in
+// an earlier file would silently drop the commands after it.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codecrafters-redis-go/internal/aof"
+)
+
+func main() {
+	fix := flag.Bool("fix", false, "Truncate the file to the last valid command")
+	yes := flag.Bool("y", false, "Do not ask for confirmation before truncating")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [--fix] [-y] <file.aof | file.manifest>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	path := flag.Arg(0)
+	var ok bool
+	if strings.HasSuffix(path, ".manifest") {
+		ok = checkManifest(path, *fix, *yes)
+	} else {
+		ok = checkFile(path, true, *fix, *yes)
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// checkManifest checks every file listed in a manifest, base first
+func checkManifest(path string, fix, yes bool) bool {
+	manifest, err := aof.LoadManifest(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return false
+	}
+	if manifest == nil {
+		fmt.Fprintf(os.Stderr, "Manifest %s does not exist\n", path)
+		return false
+	}
+
+	files := manifest.Files()
+	if len(files) == 0 {
+		fmt.Println("The manifest lists no AOF files")
+		return false
+	}
+
+	dir := filepath.Dir(path)
+	for i, entry := range files {
+		fmt.Printf("Checking %s file %s\n", typeName(entry.Type), entry.Name)
+		filePath := filepath.Join(dir, entry.Name)
+		if _, err := os.Stat(filePath); os.IsNotExist(err) && entry.Type == aof.TypeIncr {
+			// The server creates incremental files lazily
+			fmt.Println("AOF file is empty")
+			continue
+		}
+		if !checkFile(filePath, i == len(files)-1, fix, yes) {
+			return false
+		}
+	}
+
+	fmt.Printf("All AOF files and manifest are valid\n")
+	return true
+}
+
+// checkFile checks one AOF file. last reports whether truncation may fix it.
+func checkFile(path string, last, fix, yes bool) bool {
+	result, err := aof.CheckFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot check %s: %v\n", path, err)
+		return false
+	}
+
+	fmt.Printf("AOF analyzed: filename=%s, size=%d, ok_up_to=%d, ok_up_to_line=%d, diff=%d\n",
+		path, result.Size, result.ValidUpTo, result.Line, result.Size-result.ValidUpTo)
+	if result.Valid() {
+		fmt.Printf("AOF %s is valid\n", path)
+		return true
+	}
+
+	fmt.Printf("AOF %s format error: %v\n", path, result.Err)
+	if !last {
+		fmt.Printf("AOF %s is not the last file and cannot be fixed by truncation\n", path)
+		return false
+	}
+	if !fix {
+		fmt.Printf("AOF %s is not valid. Use the --fix option to try fixing it.\n", path)
+		return false
+	}
+
+	if !yes && !confirm(fmt.Sprintf("This will shrink the AOF %s from %d bytes, with %d bytes, to %d bytes", path, result.Size, result.Size-result.ValidUpTo, result.ValidUpTo)) {
+		fmt.Println("Aborted")
+		return false
+	}
+	if err := os.Truncate(path, result.ValidUpTo); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to truncate AOF %s: %v\n", path, err)
+		return false
+	}
+	fmt.Printf("Successfully truncated AOF %s\n", path)
+	return true
+}
+
+// confirm asks the user a yes/no question on stdin
+func confirm(question string) bool {
+	fmt.Printf("%s\nContinue? [y/N]: ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// typeName names a manifest file type for the report
+func typeName(fileType aof.FileType) string {
+	switch fileType {
+	case aof.TypeBase:
+		return "BASE"
+	case aof.TypeIncr:
+		return "INCR"
+	default:
+		return "HISTORY"
+	}
+}
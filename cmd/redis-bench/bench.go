@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+
+	"github.com/codecrafters-redis-go/internal/resp"
+)
+
+// randPlaceholder is replaced with a random key number when -r is set
+const randPlaceholder = "__rand_int__"
+
+// benchmarks maps test names to the command they send
+var benchmarks = map[string][]string{
+	"ping":  {"PING"},
+	"set":   {"SET", "key:" + randPlaceholder, "__data__"},
+	"get":   {"GET", "key:" + randPlaceholder},
+	"incr":  {"INCR", "counter:" + randPlaceholder},
+	"lpush": {"LPUSH", "mylist", "__data__"},
+	"rpush": {"RPUSH", "mylist", "__data__"},
+	"lpop":  {"LPOP", "mylist"},
+	"rpop":  {"RPOP", "mylist"},
+	"sadd":  {"SADD", "myset", "element:" + randPlaceholder},
+	"hset":  {"HSET", "myhash", "element:" + randPlaceholder, "__data__"},
+	"xadd":  {"XADD", "mystream", "*", "field", "__data__"},
+	"mset": {
+		"MSET",
+		"key:" + randPlaceholder, "__data__", "key:" + randPlaceholder, "__data__",
+		"key:" + randPlaceholder, "__data__", "key:" + randPlaceholder, "__data__",
+		"key:" + randPlaceholder, "__data__", "key:" + randPlaceholder, "__data__",
+		"key:" + randPlaceholder, "__data__", "key:" + randPlaceholder, "__data__",
+		"key:" + randPlaceholder, "__data__", "key:" + randPlaceholder, "__data__",
+	},
+}
+
+// defaultTests is the order tests run in when -t is not given
+var defaultTests = []string{"ping", "set", "get", "incr", "lpush", "rpush", "lpop", "rpop", "sadd", "hset", "xadd", "mset"}
+
+// generator produces the commands of a test
+type generator struct {
+	template []string
+	data     string
+	keyspace int
+	random   *rand.Rand
+	args     []resp.Value
+}
+
+// newGenerator creates a generator for template
+func newGenerator(template []string, opts options, random *rand.Rand) *generator {
+	return &generator{
+		template: template,
+		data:     strings.Repeat("x", opts.dataSize),
+		keyspace: opts.keyspace,
+		random:   random,
+		args:     make([]resp.Value, len(template)),
+	}
+}
+
+// next returns the next command to send
+func (gen *generator) next() resp.Value {
+	for i, arg := range gen.template {
+		switch {
+		case arg == "__data__":
+			arg = gen.data
+		case strings.Contains(arg, randPlaceholder):
+			key := "000000000000"
+			if gen.keyspace > 0 {
+				key = fmt.Sprintf("%012d", gen.random.Intn(gen.keyspace))
+			}
+			arg = strings.Replace(arg, randPlaceholder, key, 1)
+		}
+		gen.args[i] = resp.BulkStringValue(arg)
+	}
+	return resp.ArrayValue(gen.args...)
+}
+
+// conn is a benchmark connection
+type conn struct {
+	conn    net.Conn
+	writer  *bufio.Writer
+	encoder *resp.Encoder
+	parser  *resp.Parser
+}
+
+// dial connects to addr and authenticates if password is set
+func dial(addr, password string) (*conn, error) {
+	netConn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to %s: %w", addr, err)
+	}
+
+	writer := bufio.NewWriter(netConn)
+	c := &conn{
+		conn:    netConn,
+		writer:  writer,
+		encoder: resp.NewEncoder(writer),
+		parser:  resp.NewParser(netConn),
+	}
+
+	if password != "" {
+		c.encoder.Encode(resp.ArrayValue(resp.BulkStringValue("AUTH"), resp.BulkStringValue(password)))
+		if err := c.writer.Flush(); err != nil {
+			c.close()
+			return nil, err
+		}
+		reply, err := c.parser.Parse()
+		if err != nil {
+			c.close()
+			return nil, err
+		}
+		if reply.Type == resp.Error {
+			c.close()
+			return nil, fmt.Errorf("AUTH failed: %s", reply.Str)
+		}
+	}
+
+	return c, nil
+}
+
+// roundTrip sends count commands in one write and reads their replies,
+// returning the number of error replies
+func (c *conn) roundTrip(gen *generator, count int) (int, error) {
+	for i := 0; i < count; i++ {
+		if err := c.encoder.Encode(gen.next()); err != nil {
+			return 0, err
+		}
+	}
+	if err := c.writer.Flush(); err != nil {
+		return 0, err
+	}
+
+	errors := 0
+	for i := 0; i < count; i++ {
+		reply, err := c.parser.Parse()
+		if err != nil {
+			return errors, err
+		}
+		if reply.Type == resp.Error {
+			errors++
+		}
+	}
+	return errors, nil
+}
+
+func (c *conn) close() {
+	c.conn.Close()
+}
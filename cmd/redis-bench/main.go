@@ -0,0 +1,230 @@
+// Command redis-bench measures server throughput and latency in the style of
+// redis-benchmark:
+//
+//	redis-bench -p 6379 -c 50 -n 100000 -P 16 -t set,get -r 100000
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// options holds the command line flags
+type options struct {
+	addr     string
+	password string
+	clients  int
+	requests int
+	pipeline int
+	dataSize int
+	keyspace int
+	tests    []string
+	quiet    bool
+}
+
+// result is the outcome of one test
+type result struct {
+	name      string
+	elapsed   time.Duration
+	completed int
+	errors    int64
+	latencies []time.Duration
+}
+
+func main() {
+	var opts options
+	host := flag.String("h", "127.0.0.1", "Server hostname")
+	port := flag.Int("p", 6379, "Server port")
+	tests := flag.String("t", strings.Join(defaultTests, ","), "Comma separated list of tests to run")
+	flag.StringVar(&opts.password, "a", "", "Password to AUTH with")
+	flag.IntVar(&opts.clients, "c", 50, "Number of parallel connections")
+	flag.IntVar(&opts.requests, "n", 100000, "Total number of requests per test")
+	flag.IntVar(&opts.pipeline, "P", 1, "Pipeline <numreq> requests")
+	flag.IntVar(&opts.dataSize, "d", 3, "Data size of SET/GET values in bytes")
+	flag.IntVar(&opts.keyspace, "r", 0, "Use random keys in the range [0, keyspace), 0 uses a single key")
+	flag.BoolVar(&opts.quiet, "q", false, "Quiet, only show one summary line per test")
+	flag.Parse()
+
+	opts.addr = net.JoinHostPort(*host, strconv.Itoa(*port))
+	if opts.clients < 1 || opts.requests < 1 || opts.pipeline < 1 {
+		fmt.Fprintln(os.Stderr, "-c, -n and -P must be positive")
+		os.Exit(1)
+	}
+
+	for _, name := range strings.Split(*tests, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := benchmarks[name]; !ok {
+			fmt.Fprintf(os.Stderr, "Unknown test %q, available: %s\n", name, strings.Join(defaultTests, ","))
+			os.Exit(1)
+		}
+		opts.tests = append(opts.tests, name)
+	}
+
+	for _, name := range opts.tests {
+		res, err := run(opts, name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", strings.ToUpper(name), err)
+			os.Exit(1)
+		}
+		report(opts, res)
+	}
+}
+
+// run executes one test across opts.clients connections
+func run(opts options, name string) (*result, error) {
+	conns := make([]*conn, opts.clients)
+	for i := range conns {
+		c, err := dial(opts.addr, opts.password)
+		if err != nil {
+			for _, open := range conns[:i] {
+				open.close()
+			}
+			return nil, err
+		}
+		conns[i] = c
+	}
+
+	var (
+		remaining = int64(opts.requests)
+		errors    atomic.Int64
+		mu        sync.Mutex
+		latencies = make([]time.Duration, 0, opts.requests)
+		failure   error
+		wg        sync.WaitGroup
+	)
+
+	start := time.Now()
+	for i, c := range conns {
+		wg.Add(1)
+		go func(c *conn, seed int64) {
+			defer wg.Done()
+			defer c.close()
+
+			random := rand.New(rand.NewSource(seed))
+			generator := newGenerator(benchmarks[name], opts, random)
+			local := make([]time.Duration, 0, opts.requests/opts.clients+opts.pipeline)
+
+			for {
+				// Claim up to a pipeline's worth of the remaining requests
+				batch := int64(opts.pipeline)
+				left := atomic.AddInt64(&remaining, -batch)
+				if left+batch <= 0 {
+					break
+				}
+				if left < 0 {
+					batch += left
+				}
+
+				batchStart := time.Now()
+				errorCount, err := c.roundTrip(generator, int(batch))
+				if err != nil {
+					mu.Lock()
+					failure = err
+					mu.Unlock()
+					return
+				}
+				errors.Add(int64(errorCount))
+
+				// Every request in a pipeline waits for the whole batch
+				latency := time.Since(batchStart)
+				for j := int64(0); j < batch; j++ {
+					local = append(local, latency)
+				}
+			}
+
+			mu.Lock()
+			latencies = append(latencies, local...)
+			mu.Unlock()
+		}(c, time.Now().UnixNano()+int64(i))
+	}
+	wg.Wait()
+
+	if failure != nil {
+		return nil, failure
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	return &result{
+		name:      strings.ToUpper(name),
+		elapsed:   time.Since(start),
+		completed: len(latencies),
+		errors:    errors.Load(),
+		latencies: latencies,
+	}, nil
+}
+
+// percentile returns the latency below which p percent of requests completed
+func (res *result) percentile(p float64) time.Duration {
+	if len(res.latencies) == 0 {
+		return 0
+	}
+	index := int(float64(len(res.latencies))*p/100+0.5) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(res.latencies) {
+		index = len(res.latencies) - 1
+	}
+	return res.latencies[index]
+}
+
+// average returns the mean latency
+func (res *result) average() time.Duration {
+	if len(res.latencies) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, latency := range res.latencies {
+		total += latency
+	}
+	return total / time.Duration(len(res.latencies))
+}
+
+// throughput returns completed requests per second
+func (res *result) throughput() float64 {
+	return float64(res.completed) / res.elapsed.Seconds()
+}
+
+// msec formats a duration in milliseconds
+func msec(duration time.Duration) string {
+	return strconv.FormatFloat(float64(duration.Microseconds())/1000, 'f', 3, 64)
+}
+
+// report prints the result of a test
+func report(opts options, res *result) {
+	if opts.quiet {
+		fmt.Printf("%s: %.2f requests per second, p50=%s msec", res.name, res.throughput(), msec(res.percentile(50)))
+		if res.errors > 0 {
+			fmt.Printf(", %d error replies", res.errors)
+		}
+		fmt.Println()
+		return
+	}
+
+	fmt.Printf("====== %s ======\n", res.name)
+	fmt.Printf("  %d requests completed in %.2f seconds\n", res.completed, res.elapsed.Seconds())
+	fmt.Printf("  %d parallel clients\n", opts.clients)
+	fmt.Printf("  %d bytes payload\n", opts.dataSize)
+	fmt.Printf("  pipeline %d\n", opts.pipeline)
+	if res.errors > 0 {
+		fmt.Printf("  %d error replies\n", res.errors)
+	}
+	fmt.Println()
+	fmt.Println("Latency summary (msec):")
+	fmt.Printf("  avg=%s p50=%s p95=%s p99=%s max=%s\n",
+		msec(res.average()), msec(res.percentile(50)), msec(res.percentile(95)),
+		msec(res.percentile(99)), msec(res.percentile(100)))
+	fmt.Printf("Throughput summary: %.2f requests per second\n\n", res.throughput())
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-redis-go/internal/resp"
+)
+
+// formatHuman formats a reply the way redis-cli prints it to a terminal
+func formatHuman(value resp.Value) string {
+	var builder strings.Builder
+	writeHuman(&builder, value, "")
+	return builder.String()
+}
+
+// writeHuman writes value; indent prefixes every line after the first
+func writeHuman(builder *strings.Builder, value resp.Value, indent string) {
+	switch value.Type {
+	case resp.SimpleString:
+		builder.WriteString(value.Str + "\n")
+	case resp.Error:
+		builder.WriteString("(error) " + value.Str + "\n")
+	case resp.Integer:
+		builder.WriteString("(integer) " + strconv.FormatInt(value.Integer, 10) + "\n")
+	case resp.BulkString:
+		if value.IsNull {
+			builder.WriteString("(nil)\n")
+			return
+		}
+		builder.WriteString(quote(value.Str) + "\n")
+	case resp.Null:
+		builder.WriteString("(nil)\n")
+	case resp.Verbatim:
+		builder.WriteString(value.String() + "\n")
+	case resp.Double:
+		builder.WriteString("(double) " + resp.FormatDouble(value.Double) + "\n")
+	case resp.Boolean:
+		if value.Bool {
+			builder.WriteString("(true)\n")
+		} else {
+			builder.WriteString("(false)\n")
+		}
+	case resp.Map:
+		if len(value.Array) == 0 {
+			builder.WriteString("(empty hash)\n")
+			return
+		}
+
+		count := len(value.Array) / 2
+		width := len(strconv.Itoa(count))
+		for i := 0; i < count; i++ {
+			prefix := fmt.Sprintf("%*d# ", width, i+1)
+			if i > 0 {
+				builder.WriteString(indent)
+			}
+			builder.WriteString(prefix)
+			key := strings.TrimSuffix(formatHuman(value.Array[2*i]), "\n")
+			builder.WriteString(key + " => ")
+			writeHuman(builder, value.Array[2*i+1], indent+strings.Repeat(" ", len(prefix)+len(key)+4))
+		}
+	case resp.Array, resp.Push:
+		if value.IsNull {
+			builder.WriteString("(nil)\n")
+			return
+		}
+		if len(value.Array) == 0 {
+			builder.WriteString("(empty array)\n")
+			return
+		}
+
+		width := len(strconv.Itoa(len(value.Array)))
+		for i, element := range value.Array {
+			prefix := fmt.Sprintf("%*d) ", width, i+1)
+			if i > 0 {
+				builder.WriteString(indent)
+			}
+			builder.WriteString(prefix)
+			writeHuman(builder, element, indent+strings.Repeat(" ", len(prefix)))
+		}
+	}
+}
+
+// formatRaw formats a reply for scripts: strings are printed verbatim
+func formatRaw(value resp.Value) string {
+	var builder strings.Builder
+	writeRaw(&builder, value)
+	return builder.String()
+}
+
+func writeRaw(builder *strings.Builder, value resp.Value) {
+	switch value.Type {
+	case resp.Array, resp.Map, resp.Push:
+		for _, element := range value.Array {
+			writeRaw(builder, element)
+		}
+	case resp.Integer:
+		builder.WriteString(strconv.FormatInt(value.Integer, 10) + "\n")
+	default:
+		builder.WriteString(value.String() + "\n")
+	}
+}
+
+// quote returns str in double quotes with non-printable bytes escaped
+func quote(str string) string {
+	var builder strings.Builder
+	builder.WriteByte('"')
+	for i := 0; i < len(str); i++ {
+		char := str[i]
+		switch char {
+		case '\\', '"':
+			builder.WriteByte('\\')
+			builder.WriteByte(char)
+		case '\n':
+			builder.WriteString("\\n")
+		case '\r':
+			builder.WriteString("\\r")
+		case '\t':
+			builder.WriteString("\\t")
+		case '\a':
+			builder.WriteString("\\a")
+		case '\b':
+			builder.WriteString("\\b")
+		default:
+			if char < 0x20 || char >= 0x7f {
+				fmt.Fprintf(&builder, "\\x%02x", char)
+			} else {
+				builder.WriteByte(char)
+			}
+		}
+	}
+	builder.WriteByte('"')
+	return builder.String()
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// errInterrupted is returned by readLine when the user presses Ctrl-C
+var errInterrupted = errors.New("interrupted")
+
+// maxHistory is how many lines the editor remembers
+const maxHistory = 100
+
+// lineEditor reads lines with cursor movement and history when stdin is a
+// terminal, and plain lines otherwise
+type lineEditor struct {
+	input    *bufio.Reader
+	out      io.Writer
+	terminal bool
+	history  []string
+}
+
+// newLineEditor creates an editor reading from stdin
+func newLineEditor() *lineEditor {
+	return &lineEditor{
+		input:    bufio.NewReader(os.Stdin),
+		out:      os.Stdout,
+		terminal: isTerminal(int(os.Stdin.Fd())),
+	}
+}
+
+// readLine prints prompt and returns the line entered, without the newline.
+// It returns io.EOF on Ctrl-D at an empty line or at the end of input.
+func (editor *lineEditor) readLine(prompt string) (string, error) {
+	if !editor.terminal {
+		return editor.readPlain(prompt)
+	}
+
+	restore, err := makeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return editor.readPlain(prompt)
+	}
+	defer restore()
+
+	line, err := editor.edit(prompt)
+	fmt.Fprint(editor.out, "\r\n")
+	if err == nil && strings.TrimSpace(line) != "" {
+		editor.addHistory(line)
+	}
+	return line, err
+}
+
+// readPlain reads a line without any editing support
+func (editor *lineEditor) readPlain(prompt string) (string, error) {
+	if editor.terminal {
+		fmt.Fprint(editor.out, prompt)
+	}
+	line, err := editor.input.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// addHistory appends line to the history, dropping the oldest entry when full
+func (editor *lineEditor) addHistory(line string) {
+	if n := len(editor.history); n > 0 && editor.history[n-1] == line {
+		return
+	}
+	editor.history = append(editor.history, line)
+	if len(editor.history) > maxHistory {
+		editor.history = editor.history[1:]
+	}
+}
+
+// edit runs the editing loop in raw mode
+func (editor *lineEditor) edit(prompt string) (string, error) {
+	var line []rune
+	cursor := 0
+	historyIndex := len(editor.history)
+	pending := ""
+
+	refresh := func() {
+		fmt.Fprintf(editor.out, "\r%s%s\x1b[K\r\x1b[%dC", prompt, string(line), len([]rune(prompt))+cursor)
+	}
+	replace := func(text string) {
+		line = []rune(text)
+		cursor = len(line)
+	}
+	refresh()
+
+	for {
+		char, _, err := editor.input.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch char {
+		case '\r', '\n':
+			return string(line), nil
+		case 3: // Ctrl-C
+			return "", errInterrupted
+		case 4: // Ctrl-D
+			if len(line) == 0 {
+				return "", io.EOF
+			}
+			if cursor < len(line) {
+				line = append(line[:cursor], line[cursor+1:]...)
+			}
+		case 127, 8: // Backspace
+			if cursor > 0 {
+				line = append(line[:cursor-1], line[cursor:]...)
+				cursor--
+			}
+		case 1: // Ctrl-A
+			cursor = 0
+		case 5: // Ctrl-E
+			cursor = len(line)
+		case 2: // Ctrl-B
+			if cursor > 0 {
+				cursor--
+			}
+		case 6: // Ctrl-F
+			if cursor < len(line) {
+				cursor++
+			}
+		case 11: // Ctrl-K
+			line = line[:cursor]
+		case 21: // Ctrl-U
+			line = line[cursor:]
+			cursor = 0
+		case 23: // Ctrl-W
+			start := cursor
+			for start > 0 && line[start-1] == ' ' {
+				start--
+			}
+			for start > 0 && line[start-1] != ' ' {
+				start--
+			}
+			line = append(line[:start], line[cursor:]...)
+			cursor = start
+		case 12: // Ctrl-L
+			fmt.Fprint(editor.out, "\x1b[H\x1b[2J")
+		case 16, 14: // Ctrl-P, Ctrl-N
+			if char == 16 {
+				historyIndex, pending = editor.moveHistory(historyIndex, -1, string(line), pending)
+			} else {
+				historyIndex, pending = editor.moveHistory(historyIndex, 1, string(line), pending)
+			}
+			replace(editor.historyLine(historyIndex, pending))
+		case 27: // Escape sequence
+			seq := editor.readEscape()
+			switch seq {
+			case "[A":
+				historyIndex, pending = editor.moveHistory(historyIndex, -1, string(line), pending)
+				replace(editor.historyLine(historyIndex, pending))
+			case "[B":
+				historyIndex, pending = editor.moveHistory(historyIndex, 1, string(line), pending)
+				replace(editor.historyLine(historyIndex, pending))
+			case "[C":
+				if cursor < len(line) {
+					cursor++
+				}
+			case "[D":
+				if cursor > 0 {
+					cursor--
+				}
+			case "[H", "OH", "[1~":
+				cursor = 0
+			case "[F", "OF", "[4~":
+				cursor = len(line)
+			case "[3~":
+				if cursor < len(line) {
+					line = append(line[:cursor], line[cursor+1:]...)
+				}
+			}
+		default:
+			if char >= 32 {
+				line = append(line[:cursor], append([]rune{char}, line[cursor:]...)...)
+				cursor++
+			}
+		}
+		refresh()
+	}
+}
+
+// readEscape reads the rest of an escape sequence after ESC
+func (editor *lineEditor) readEscape() string {
+	first, err := editor.input.ReadByte()
+	if err != nil {
+		return ""
+	}
+	second, err := editor.input.ReadByte()
+	if err != nil {
+		return ""
+	}
+	seq := string([]byte{first, second})
+	if first == '[' && second >= '0' && second <= '9' {
+		if third, err := editor.input.ReadByte(); err == nil {
+			seq += string(third)
+		}
+	}
+	return seq
+}
+
+// moveHistory moves through the history by delta. The line being edited is
+// kept as pending while browsing.
+func (editor *lineEditor) moveHistory(index, delta int, current, pending string) (int, string) {
+	if index == len(editor.history) {
+		pending = current
+	}
+	index += delta
+	if index < 0 {
+		index = 0
+	}
+	if index > len(editor.history) {
+		index = len(editor.history)
+	}
+	return index, pending
+}
+
+// historyLine returns the history entry at index, or pending past the end
+func (editor *lineEditor) historyLine(index int, pending string) string {
+	if index >= len(editor.history) {
+		return pending
+	}
+	return editor.history[index]
+}
@@ -0,0 +1,276 @@
+// Command redis-cli is a command line client for the server.
+//
+// Without command arguments it starts an interactive prompt with line
+// editing and history. With arguments it runs a single command:
+//
+//	redis-cli -p 6380 SET greeting hello
+//	echo -n payload | redis-cli -x SET blob
+//	cat commands.txt | redis-cli --pipe
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-redis-go/internal/resp"
+)
+
+// client is a connection to the server
+type client struct {
+	conn    net.Conn
+	writer  *bufio.Writer
+	encoder *resp.Encoder
+	parser  *resp.Parser
+}
+
+// options holds the command line flags
+type options struct {
+	host     string
+	port     int
+	user     string
+	password string
+	stdinArg bool
+	pipe     bool
+	raw      bool
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.host, "h", "127.0.0.1", "Server hostname")
+	flag.IntVar(&opts.port, "p", 6379, "Server port")
+	flag.StringVar(&opts.user, "user", "", "Username to AUTH with (requires -a)")
+	flag.StringVar(&opts.password, "a", "", "Password to AUTH with")
+	flag.BoolVar(&opts.stdinArg, "x", false, "Read the last argument from stdin")
+	flag.BoolVar(&opts.pipe, "pipe", false, "Send raw commands from stdin to the server (mass insertion)")
+	flag.BoolVar(&opts.raw, "raw", false, "Print replies without formatting, the default when stdout is not a terminal")
+	flag.Parse()
+
+	addr := net.JoinHostPort(opts.host, strconv.Itoa(opts.port))
+
+	switch {
+	case opts.pipe:
+		os.Exit(runPipe(addr, opts))
+	case flag.NArg() > 0:
+		os.Exit(runCommand(addr, opts, flag.Args()))
+	default:
+		os.Exit(runInteractive(addr, opts))
+	}
+}
+
+// connect dials the server and authenticates if a password was given
+func connect(addr string, opts options) (*client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := bufio.NewWriter(conn)
+	c := &client{
+		conn:    conn,
+		writer:  writer,
+		encoder: resp.NewEncoder(writer),
+		parser:  resp.NewParser(conn),
+	}
+
+	if opts.password != "" {
+		args := []string{"AUTH", opts.password}
+		if opts.user != "" {
+			args = []string{"AUTH", opts.user, opts.password}
+		}
+		reply, err := c.do(args)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if reply.Type == resp.Error {
+			fmt.Fprintf(os.Stderr, "AUTH failed: %s\n", reply.Str)
+		}
+	}
+
+	return c, nil
+}
+
+// do sends a command and reads its reply
+func (c *client) do(args []string) (resp.Value, error) {
+	values := make([]resp.Value, len(args))
+	for i, arg := range args {
+		values[i] = resp.BulkStringValue(arg)
+	}
+	if err := c.encoder.Encode(resp.ArrayValue(values...)); err != nil {
+		return resp.Value{}, err
+	}
+	if err := c.writer.Flush(); err != nil {
+		return resp.Value{}, err
+	}
+	return c.parser.Parse()
+}
+
+// format formats the reply to args for stdout
+func format(args []string, reply resp.Value, raw bool) string {
+	// INFO is meant to be read as text, like redis-cli does
+	if raw || strings.EqualFold(args[0], "INFO") {
+		return formatRaw(reply)
+	}
+	return formatHuman(reply)
+}
+
+// runCommand runs a single command given on the command line
+func runCommand(addr string, opts options, args []string) int {
+	if opts.stdinArg {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
+			return 1
+		}
+		args = append(args, string(data))
+	}
+
+	c, err := connect(addr, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not connect to Redis at %s: %v\n", addr, err)
+		return 1
+	}
+	defer c.conn.Close()
+
+	reply, err := c.do(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	raw := opts.raw || !isTerminal(int(os.Stdout.Fd()))
+	fmt.Print(format(args, reply, raw))
+	if reply.Type == resp.Error {
+		return 1
+	}
+	return 0
+}
+
+// runInteractive runs the prompt loop, reconnecting when the connection drops
+func runInteractive(addr string, opts options) int {
+	editor := newLineEditor()
+
+	c, err := connect(addr, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not connect to Redis at %s: %v\n", addr, err)
+	}
+
+	for {
+		prompt := addr + "> "
+		if c == nil {
+			prompt = "not connected> "
+		}
+
+		line, err := editor.readLine(prompt)
+		if errors.Is(err, errInterrupted) {
+			continue
+		}
+		if err != nil {
+			if c != nil {
+				c.conn.Close()
+			}
+			return 0
+		}
+
+		args, err := resp.SplitArgs(line)
+		if err != nil {
+			fmt.Println("Invalid argument(s)")
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+		if name := strings.ToLower(args[0]); name == "quit" || name == "exit" {
+			if c != nil {
+				c.conn.Close()
+			}
+			return 0
+		}
+
+		if c == nil {
+			if c, err = connect(addr, opts); err != nil {
+				fmt.Fprintf(os.Stderr, "Could not connect to Redis at %s: %v\n", addr, err)
+				c = nil
+				continue
+			}
+		}
+
+		reply, err := c.do(args)
+		if err != nil {
+			// The server went away; the next command reconnects
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			c.conn.Close()
+			c = nil
+			continue
+		}
+		fmt.Print(format(args, reply, opts.raw))
+	}
+}
+
+// runPipe streams the raw protocol on stdin to the server. It ends with an
+// ECHO of a random marker, so the reply to the marker means every earlier
+// reply has been read.
+func runPipe(addr string, opts options) int {
+	c, err := connect(addr, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not connect to Redis at %s: %v\n", addr, err)
+		return 1
+	}
+	defer c.conn.Close()
+
+	random := make([]byte, 10)
+	rand.Read(random)
+	marker := hex.EncodeToString(random)
+
+	sendErr := make(chan error, 1)
+	go func() {
+		if _, err := io.Copy(c.writer, os.Stdin); err != nil {
+			sendErr <- err
+			return
+		}
+		values := []resp.Value{resp.BulkStringValue("ECHO"), resp.BulkStringValue(marker)}
+		if err := c.encoder.Encode(resp.ArrayValue(values...)); err != nil {
+			sendErr <- err
+			return
+		}
+		sendErr <- c.writer.Flush()
+	}()
+
+	errorCount, replies := 0, 0
+	for {
+		reply, err := c.parser.Parse()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading reply: %v\n", err)
+			return 1
+		}
+		if reply.Type == resp.BulkString && reply.Str == marker {
+			break
+		}
+		replies++
+		if reply.Type == resp.Error {
+			errorCount++
+			fmt.Fprintln(os.Stderr, reply.Str)
+		}
+	}
+
+	if err := <-sendErr; err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing to the server: %v\n", err)
+		return 1
+	}
+
+	fmt.Println("All data transferred. Last reply received from server.")
+	fmt.Printf("errors: %d, replies: %d\n", errorCount, replies)
+	if errorCount > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,532 @@
+package aof
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/logger"
+	"github.com/codecrafters-redis-go/internal/resp"
+)
+
+// Fsync policies for appendfsync
+const (
+	FsyncAlways   = "always"
+	FsyncEverySec = "everysec"
+	FsyncNo       = "no"
+)
+
+// ErrRewriteInProgress is returned when a rewrite is requested while one is running
+var ErrRewriteInProgress = errors.New("Background append only file rewriting already in progress")
+
+// AOF is a multi-part append only file: a manifest listing one base file
+// and the incremental files appended to since the base was written.
+//
+// Appended commands are collected in a batch that a background goroutine
+// writes with a single write call, so commands from many clients share one
+// write and, with appendfsync everysec, one fsync per second that never
+// holds up appends. With appendfsync always the appending caller writes the
+// batch and fsyncs before returning.
+type AOF struct {
+	ioMu      sync.Mutex // Held while writing the batch or switching files, taken before mu
+	mu        sync.Mutex
+	dir       string // Directory holding the manifest and its files
+	filename  string // Base name, e.g. "appendonly.aof"
+	fsync     string
+	manifest  *Manifest
+	file      *os.File // Current incremental file
+	batch     batch    // Commands appended but not yet written
+	spare     []byte   // A written batch buffer kept for reuse
+	encoder   *resp.Encoder
+	dirty     bool // Data written since the last fsync
+	rewriting bool
+	wake      chan struct{} // Signals the writer goroutine of a new batch
+	done      chan struct{}
+	closed    bool
+
+	syncing       atomic.Bool  // A background fsync is running
+	delayedFsyncs atomic.Int64 // Seconds an fsync was due while the previous one still ran
+}
+
+// maxSpareBatch is the largest batch buffer kept for reuse
+const maxSpareBatch = 1 << 20
+
+// batch collects encoded commands in memory
+type batch struct {
+	data []byte
+}
+
+func (b *batch) Write(data []byte) (int, error) {
+	b.data = append(b.data, data...)
+	return len(data), nil
+}
+
+// Open opens (or creates) the multi-part AOF stored in dir/dirname
+func Open(dir, dirname, filename, fsync string) (*AOF, error) {
+	path := filepath.Join(dir, dirname)
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create AOF directory: %w", err)
+	}
+
+	manifest, err := LoadManifest(filepath.Join(path, manifestName(filename)))
+	if err != nil {
+		return nil, err
+	}
+
+	aof := &AOF{
+		dir:      path,
+		filename: filename,
+		fsync:    fsync,
+		manifest: manifest,
+		wake:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	aof.encoder = resp.NewEncoder(&aof.batch)
+
+	if aof.manifest == nil {
+		// Fresh AOF: start with a single empty incremental file
+		aof.manifest = &Manifest{
+			Incrs: []ManifestFile{{Name: incrName(filename, 1), Seq: 1, Type: TypeIncr}},
+		}
+		if err := aof.saveManifest(); err != nil {
+			return nil, err
+		}
+	} else if aof.manifest.LastIncr() == nil {
+		seq := aof.manifest.nextIncrSeq()
+		aof.manifest.Incrs = append(aof.manifest.Incrs, ManifestFile{Name: incrName(filename, seq), Seq: seq, Type: TypeIncr})
+		if err := aof.saveManifest(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := aof.openIncr(aof.manifest.LastIncr().Name); err != nil {
+		return nil, err
+	}
+
+	if fsync != FsyncAlways {
+		go aof.writeBatches()
+	}
+
+	return aof, nil
+}
+
+// openIncr opens the named incremental file for appending
+func (aof *AOF) openIncr(name string) error {
+	file, err := os.OpenFile(filepath.Join(aof.dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open AOF file: %w", err)
+	}
+
+	aof.file = file
+	return nil
+}
+
+// saveManifest writes the in-memory manifest to disk
+func (aof *AOF) saveManifest() error {
+	if err := aof.manifest.Save(filepath.Join(aof.dir, manifestName(aof.filename))); err != nil {
+		return fmt.Errorf("failed to write AOF manifest: %w", err)
+	}
+	return nil
+}
+
+// Load replays every file listed in the manifest, base first. A truncated
+// tail in the last incremental file is cut off so later appends stay valid;
+// corruption anywhere else is an error. Commands between MULTI and EXEC are
+// applied once the EXEC is read, and a transaction cut off by the truncation
+// is discarded with it.
+func (aof *AOF) Load(apply func(resp.Value)) (int, error) {
+	files := aof.manifest.Files()
+	total := 0
+
+	for i, entry := range files {
+		last := i == len(files)-1
+		count, err := aof.loadFile(entry, last, apply)
+		total += count
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+// loadFile replays a single AOF file
+func (aof *AOF) loadFile(entry ManifestFile, last bool, apply func(resp.Value)) (int, error) {
+	path := filepath.Join(aof.dir, entry.Name)
+	file, err := os.Open(path)
+	if os.IsNotExist(err) && entry.Type == TypeIncr {
+		// An incremental file that was never written to
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to open AOF file %s: %w", entry.Name, err)
+	}
+	defer file.Close()
+
+	parser := resp.NewParser(file)
+	var valid int64 // Bytes replayed, excluding a transaction still being read
+	var transaction []resp.Value
+	inTransaction := false
+	count := 0
+
+	for {
+		value, err := parser.Parse()
+		if err == io.EOF && !inTransaction {
+			return count, nil
+		}
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			if !last {
+				return count, fmt.Errorf("bad file format reading AOF file %s at offset %d: %w", entry.Name, valid, err)
+			}
+			logger.Warn("AOF file %s truncated at offset %d (%v), discarding the incomplete tail", entry.Name, valid, err)
+			if err := os.Truncate(path, valid); err != nil {
+				return count, fmt.Errorf("failed to truncate AOF file %s: %w", entry.Name, err)
+			}
+			return count, nil
+		}
+
+		// The bytes actually read, as inline commands or non-canonical RESP
+		// don't re-encode to the same size
+		offset := parser.Consumed()
+		name, _ := value.GetCommand()
+		switch {
+		case strings.EqualFold(name, "MULTI"):
+			inTransaction = true
+			continue
+		case strings.EqualFold(name, "EXEC"):
+			for _, command := range transaction {
+				apply(command)
+			}
+			count += len(transaction)
+			transaction, inTransaction = transaction[:0], false
+		case inTransaction:
+			transaction = append(transaction, value)
+			continue
+		default:
+			apply(value)
+			count++
+		}
+		valid = offset
+	}
+}
+
+// Append adds a command to the current incremental file. Unless fsync is
+// always, the command is only queued and written shortly after.
+func (aof *AOF) Append(command resp.Value) error {
+	aof.mu.Lock()
+	if aof.closed {
+		aof.mu.Unlock()
+		return nil
+	}
+	err := aof.encoder.Encode(command)
+	aof.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if aof.fsync == FsyncAlways {
+		// Concurrent appenders queue behind ioMu, and the first of them
+		// writes and fsyncs the commands of all
+		aof.ioMu.Lock()
+		defer aof.ioMu.Unlock()
+		aof.mu.Lock()
+		defer aof.mu.Unlock()
+		return aof.flushLocked()
+	}
+
+	select {
+	case aof.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Flush writes the queued commands and fsyncs the current incremental file
+func (aof *AOF) Flush() error {
+	aof.ioMu.Lock()
+	defer aof.ioMu.Unlock()
+	aof.mu.Lock()
+	defer aof.mu.Unlock()
+	return aof.flushLocked()
+}
+
+// flushLocked writes the batch and fsyncs. ioMu and mu must be held.
+func (aof *AOF) flushLocked() error {
+	if aof.closed {
+		return nil
+	}
+	if len(aof.batch.data) > 0 {
+		if _, err := aof.file.Write(aof.batch.data); err != nil {
+			return err
+		}
+		aof.batch.data = aof.batch.data[:0]
+		aof.dirty = true
+	}
+	if !aof.dirty {
+		return nil
+	}
+	aof.dirty = false
+	return aof.file.Sync()
+}
+
+// write writes the batch without holding mu, so appends continue meanwhile.
+// ioMu must be held, which keeps the file from being switched.
+func (aof *AOF) write() error {
+	aof.mu.Lock()
+	if aof.closed || len(aof.batch.data) == 0 {
+		aof.mu.Unlock()
+		return nil
+	}
+	data, file := aof.batch.data, aof.file
+	aof.batch.data, aof.spare = aof.spare, nil
+	aof.mu.Unlock()
+
+	_, err := file.Write(data)
+
+	aof.mu.Lock()
+	if cap(data) <= maxSpareBatch {
+		aof.spare = data[:0]
+	}
+	if err == nil {
+		aof.dirty = true
+	}
+	aof.mu.Unlock()
+	return err
+}
+
+// writeBatches writes queued commands as they arrive and, for appendfsync
+// everysec, starts an fsync once per second
+func (aof *AOF) writeBatches() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-aof.wake:
+			aof.ioMu.Lock()
+			err := aof.write()
+			aof.ioMu.Unlock()
+			if err != nil {
+				logger.Error("Failed to write to the AOF: %v", err)
+			}
+		case <-ticker.C:
+			if aof.fsync == FsyncEverySec {
+				aof.fsyncInBackground()
+			}
+		case <-aof.done:
+			return
+		}
+	}
+}
+
+// fsyncInBackground starts an fsync of the data written since the last one.
+// While the previous fsync still runs the disk is behind, so the second is
+// skipped and counted in aof_delayed_fsync.
+func (aof *AOF) fsyncInBackground() {
+	aof.mu.Lock()
+	if !aof.dirty || aof.closed {
+		aof.mu.Unlock()
+		return
+	}
+	if !aof.syncing.CompareAndSwap(false, true) {
+		aof.mu.Unlock()
+		aof.delayedFsyncs.Add(1)
+		logger.Warn("Asynchronous AOF fsync is taking too long (disk is busy?), skipping this fsync")
+		return
+	}
+	aof.dirty = false
+	file := aof.file
+	aof.mu.Unlock()
+
+	go func() {
+		defer aof.syncing.Store(false)
+		// A rewrite or Close may close the file, both fsync it first
+		if err := file.Sync(); err != nil && !errors.Is(err, os.ErrClosed) {
+			logger.Error("Failed to fsync AOF: %v", err)
+		}
+	}()
+}
+
+// DelayedFsyncs returns how often an fsync was due while the previous one
+// was still running, reported as aof_delayed_fsync
+func (aof *AOF) DelayedFsyncs() int64 {
+	return aof.delayedFsyncs.Load()
+}
+
+// Rewrite replaces the base and incremental files with a new base built from
+// snapshot. New writes go to a fresh incremental file from the moment the
+// snapshot is taken, so no history has to be copied.
+func (aof *AOF) Rewrite(snapshot func() []resp.Value) error {
+	aof.ioMu.Lock()
+	aof.mu.Lock()
+	if aof.closed {
+		aof.mu.Unlock()
+		aof.ioMu.Unlock()
+		return nil
+	}
+	if aof.rewriting {
+		aof.mu.Unlock()
+		aof.ioMu.Unlock()
+		return ErrRewriteInProgress
+	}
+	aof.rewriting = true
+
+	// Switch appends to a new incremental file and capture the dataset at the same point
+	aof.dirty = true // Sync the old file even if the background fsync just did
+	if err := aof.flushLocked(); err != nil {
+		aof.rewriting = false
+		aof.mu.Unlock()
+		aof.ioMu.Unlock()
+		return err
+	}
+	previous := aof.file
+	seq := aof.manifest.nextIncrSeq()
+	newIncr := ManifestFile{Name: incrName(aof.filename, seq), Seq: seq, Type: TypeIncr}
+	if err := aof.openIncr(newIncr.Name); err != nil {
+		aof.rewriting = false
+		aof.mu.Unlock()
+		aof.ioMu.Unlock()
+		return err
+	}
+	previous.Close()
+	aof.manifest.Incrs = append(aof.manifest.Incrs, newIncr)
+	if err := aof.saveManifest(); err != nil {
+		aof.rewriting = false
+		aof.mu.Unlock()
+		aof.ioMu.Unlock()
+		return err
+	}
+	commands := snapshot()
+	aof.mu.Unlock()
+	aof.ioMu.Unlock()
+
+	err := aof.writeBase(commands, newIncr)
+
+	aof.mu.Lock()
+	aof.rewriting = false
+	aof.mu.Unlock()
+	return err
+}
+
+// writeBase writes the new base file and retires the files it supersedes
+func (aof *AOF) writeBase(commands []resp.Value, newIncr ManifestFile) error {
+	aof.mu.Lock()
+	seq := aof.manifest.nextBaseSeq()
+	aof.mu.Unlock()
+
+	base := ManifestFile{Name: baseName(aof.filename, seq), Seq: seq, Type: TypeBase}
+	if err := writeCommands(filepath.Join(aof.dir, base.Name), commands); err != nil {
+		return fmt.Errorf("failed to write AOF base file: %w", err)
+	}
+
+	aof.mu.Lock()
+	defer aof.mu.Unlock()
+
+	// Everything before the new incremental file is now covered by the base
+	var history []ManifestFile
+	if aof.manifest.Base != nil {
+		old := *aof.manifest.Base
+		old.Type = TypeHistory
+		history = append(history, old)
+	}
+	var incrs []ManifestFile
+	for _, incr := range aof.manifest.Incrs {
+		if incr.Seq < newIncr.Seq {
+			incr.Type = TypeHistory
+			history = append(history, incr)
+		} else {
+			incrs = append(incrs, incr)
+		}
+	}
+
+	aof.manifest.Base = &base
+	aof.manifest.Incrs = incrs
+	aof.manifest.History = append(aof.manifest.History, history...)
+	if err := aof.saveManifest(); err != nil {
+		return err
+	}
+
+	aof.deleteHistory()
+	logger.Info("Background AOF rewrite finished successfully")
+	return nil
+}
+
+// deleteHistory removes files retired by a rewrite and drops them from the manifest
+func (aof *AOF) deleteHistory() {
+	for _, entry := range aof.manifest.History {
+		if err := os.Remove(filepath.Join(aof.dir, entry.Name)); err != nil && !os.IsNotExist(err) {
+			logger.Warn("Failed to remove AOF history file %s: %v", entry.Name, err)
+		}
+	}
+	aof.manifest.History = nil
+	if err := aof.saveManifest(); err != nil {
+		logger.Warn("%v", err)
+	}
+}
+
+// writeCommands writes commands in RESP format to a new file at path
+func writeCommands(path string, commands []resp.Value) error {
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+
+	writer := bufio.NewWriter(file)
+	encoder := resp.NewEncoder(writer)
+	for _, command := range commands {
+		if err := encoder.Encode(command); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// IsRewriting returns true while a rewrite is in progress
+func (aof *AOF) IsRewriting() bool {
+	aof.mu.Lock()
+	defer aof.mu.Unlock()
+	return aof.rewriting
+}
+
+// Close flushes pending data and closes the current incremental file
+func (aof *AOF) Close() error {
+	aof.ioMu.Lock()
+	defer aof.ioMu.Unlock()
+	aof.mu.Lock()
+	defer aof.mu.Unlock()
+
+	if aof.closed {
+		return nil
+	}
+	aof.dirty = true
+	err := aof.flushLocked()
+	aof.closed = true
+	close(aof.done)
+	aof.file.Close()
+	return err
+}
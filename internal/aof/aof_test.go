@@ -0,0 +1,41 @@
+package aof
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codecrafters-redis-go/internal/resp"
+)
+
+func TestLoadTruncatesAtConsumedBytes(t *testing.T) {
+	dir := t.TempDir()
+	file, err := Open(dir, "appendonlydir", "appendonly.aof", FsyncNo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	// Lengths with leading zeros are valid RESP, but re-encode shorter
+	valid := "*02\r\n$003\r\nDEL\r\n$1\r\nk\r\n" + "*1\r\n$4\r\nPING\r\n"
+	path := filepath.Join(dir, "appendonlydir", incrName("appendonly.aof", 1))
+	if err := os.WriteFile(path, []byte(valid+"*3\r\n$3\r\nSET\r\n$1\r\nk"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	count, err := file.Load(func(command resp.Value) {
+		name, _ := command.GetCommand()
+		names = append(names, name)
+	})
+	if err != nil || count != 2 {
+		t.Fatalf("Load = %d, %v, want 2 commands (%q)", count, err, names)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != valid {
+		t.Errorf("truncated to %q, want %q", data, valid)
+	}
+}
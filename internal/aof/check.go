@@ -0,0 +1,148 @@
+package aof
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CheckResult describes how much of an AOF file holds well formed commands
+type CheckResult struct {
+	Size      int64 // File size in bytes
+	ValidUpTo int64 // Offset just past the last complete command outside a transaction
+	Line      int   // Line the valid prefix ends on
+	Commands  int   // Number of complete commands
+	Err       error // Why checking stopped before the end, nil if the file is valid
+}
+
+// Valid returns true if the whole file is made of complete commands
+func (result CheckResult) Valid() bool {
+	return result.Err == nil
+}
+
+// CheckFile parses the AOF file at path command by command and reports the
+// offset of the first byte that is not part of a complete, well formed
+// command. A MULTI without its EXEC is not complete.
+func CheckFile(path string) (CheckResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return CheckResult{}, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return CheckResult{}, err
+	}
+
+	checker := &checker{reader: bufio.NewReader(file), line: 1}
+	result := CheckResult{Size: info.Size(), Line: 1}
+	pending := 0 // Commands read since an unmatched MULTI
+	for {
+		name, err := checker.command()
+		if err == io.EOF && pending > 0 {
+			err = errors.New("reached EOF before reading EXEC for MULTI")
+		}
+		if err == io.EOF && checker.offset == result.ValidUpTo {
+			return result, nil
+		}
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			result.Err = err
+			return result, nil
+		}
+
+		switch {
+		case strings.EqualFold(name, "MULTI"):
+			pending = 1
+			continue
+		case strings.EqualFold(name, "EXEC") || pending == 0:
+			result.Commands += pending + 1
+			pending = 0
+		default:
+			pending++
+			continue
+		}
+		result.ValidUpTo = checker.offset
+		result.Line = checker.line
+	}
+}
+
+// checker reads RESP commands while tracking the byte offset and line
+type checker struct {
+	reader *bufio.Reader
+	offset int64
+	line   int
+}
+
+// command reads one array of bulk strings and returns the command name
+func (c *checker) command() (string, error) {
+	count, err := c.header('*')
+	if err != nil {
+		return "", err
+	}
+	if count < 1 {
+		return "", fmt.Errorf("invalid argument count %d", count)
+	}
+
+	var name string
+	for i := 0; i < count; i++ {
+		length, err := c.header('$')
+		if err != nil {
+			return "", err
+		}
+		if length < 0 {
+			return "", fmt.Errorf("invalid bulk length %d", length)
+		}
+		data := make([]byte, length+2)
+		n, err := io.ReadFull(c.reader, data)
+		c.advance(data[:n])
+		if err != nil {
+			return "", err
+		}
+		if data[length] != '\r' || data[length+1] != '\n' {
+			return "", errors.New("expected CRLF after bulk string")
+		}
+		if i == 0 {
+			name = string(data[:length])
+		}
+	}
+	return name, nil
+}
+
+// header reads a "<prefix><number>\r\n" line
+func (c *checker) header(prefix byte) (int, error) {
+	line, err := c.reader.ReadString('\n')
+	c.advance([]byte(line))
+	if err != nil {
+		if line == "" {
+			return 0, io.EOF
+		}
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	if len(line) < 3 || line[0] != prefix || line[len(line)-2] != '\r' {
+		return 0, fmt.Errorf("expected '%c' line, got %q", prefix, line)
+	}
+	number, err := strconv.Atoi(line[1 : len(line)-2])
+	if err != nil {
+		return 0, fmt.Errorf("invalid number in %q", line)
+	}
+	return number, nil
+}
+
+// advance accounts for data having been read
+func (c *checker) advance(data []byte) {
+	c.offset += int64(len(data))
+	for _, b := range data {
+		if b == '\n' {
+			c.line++
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package aof
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FileType identifies the role of a file listed in the manifest
+type FileType string
+
+const (
+	TypeBase    FileType = "b" // Snapshot the incremental files apply on top of
+	TypeHistory FileType = "h" // Superseded by a rewrite, pending deletion
+	TypeIncr    FileType = "i" // Commands appended since the base was written
+)
+
+// ManifestFile is one entry of the manifest
+type ManifestFile struct {
+	Name string
+	Seq  int
+	Type FileType
+}
+
+// Manifest lists the files making up a multi-part AOF, in replay order
+type Manifest struct {
+	Base    *ManifestFile
+	Incrs   []ManifestFile
+	History []ManifestFile
+}
+
+// manifestName returns the manifest file name for the given AOF base name
+func manifestName(filename string) string {
+	return filename + ".manifest"
+}
+
+// baseName returns the name of the base file with the given sequence number
+func baseName(filename string, seq int) string {
+	return fmt.Sprintf("%s.%d.base.aof", filename, seq)
+}
+
+// incrName returns the name of the incremental file with the given sequence number
+func incrName(filename string, seq int) string {
+	return fmt.Sprintf("%s.%d.incr.aof", filename, seq)
+}
+
+// LoadManifest reads the manifest from path. It returns nil and no error if
+// the manifest does not exist.
+func LoadManifest(path string) (*Manifest, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open AOF manifest: %w", err)
+	}
+	defer file.Close()
+
+	manifest := &Manifest{}
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		entry, err := parseManifestLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AOF manifest line %d: %w", lineNum, err)
+		}
+
+		switch entry.Type {
+		case TypeBase:
+			if manifest.Base != nil {
+				return nil, fmt.Errorf("invalid AOF manifest line %d: duplicate base file", lineNum)
+			}
+			manifest.Base = &entry
+		case TypeIncr:
+			manifest.Incrs = append(manifest.Incrs, entry)
+		case TypeHistory:
+			manifest.History = append(manifest.History, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read AOF manifest: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// parseManifestLine parses a "file <name> seq <n> type <b|h|i>" line
+func parseManifestLine(line string) (ManifestFile, error) {
+	fields := strings.Fields(line)
+	if len(fields)%2 != 0 {
+		return ManifestFile{}, fmt.Errorf("odd number of fields")
+	}
+
+	var entry ManifestFile
+	for i := 0; i < len(fields); i += 2 {
+		switch fields[i] {
+		case "file":
+			entry.Name = fields[i+1]
+		case "seq":
+			seq, err := strconv.Atoi(fields[i+1])
+			if err != nil || seq <= 0 {
+				return ManifestFile{}, fmt.Errorf("invalid seq %q", fields[i+1])
+			}
+			entry.Seq = seq
+		case "type":
+			entry.Type = FileType(fields[i+1])
+		}
+		// Unknown keys are ignored for forward compatibility
+	}
+
+	if entry.Name == "" || strings.ContainsAny(entry.Name, "/\\") {
+		return ManifestFile{}, fmt.Errorf("invalid file name %q", entry.Name)
+	}
+	switch entry.Type {
+	case TypeBase, TypeIncr, TypeHistory:
+	default:
+		return ManifestFile{}, fmt.Errorf("invalid type %q", entry.Type)
+	}
+
+	return entry, nil
+}
+
+// Files returns the files to replay, base first
+func (manifest *Manifest) Files() []ManifestFile {
+	files := make([]ManifestFile, 0, len(manifest.Incrs)+1)
+	if manifest.Base != nil {
+		files = append(files, *manifest.Base)
+	}
+	return append(files, manifest.Incrs...)
+}
+
+// LastIncr returns the incremental file new commands are appended to
+func (manifest *Manifest) LastIncr() *ManifestFile {
+	if len(manifest.Incrs) == 0 {
+		return nil
+	}
+	return &manifest.Incrs[len(manifest.Incrs)-1]
+}
+
+// nextIncrSeq returns the next unused incremental sequence number
+func (manifest *Manifest) nextIncrSeq() int {
+	seq := 0
+	for _, incr := range manifest.Incrs {
+		if incr.Seq > seq {
+			seq = incr.Seq
+		}
+	}
+	return seq + 1
+}
+
+// nextBaseSeq returns the next unused base sequence number
+func (manifest *Manifest) nextBaseSeq() int {
+	if manifest.Base == nil {
+		return 1
+	}
+	return manifest.Base.Seq + 1
+}
+
+// Save atomically writes the manifest to path
+func (manifest *Manifest) Save(path string) error {
+	var builder strings.Builder
+	write := func(entry ManifestFile) {
+		fmt.Fprintf(&builder, "file %s seq %d type %s\n", entry.Name, entry.Seq, entry.Type)
+	}
+
+	if manifest.Base != nil {
+		write(*manifest.Base)
+	}
+	for _, entry := range manifest.History {
+		write(entry)
+	}
+	for _, entry := range manifest.Incrs {
+		write(entry)
+	}
+
+	return writeFileAtomic(path, []byte(builder.String()))
+}
+
+// writeFileAtomic writes data to a temporary file and renames it over path
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Exists returns true if a manifest is present in dir/dirname
+func Exists(dir, dirname, filename string) bool {
+	_, err := os.Stat(filepath.Join(dir, dirname, manifestName(filename)))
+	return err == nil
+}
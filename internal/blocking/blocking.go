@@ -0,0 +1,215 @@
+// Package blocking keeps track of clients blocked by commands such as WAIT
+// or BLPOP until a key they wait on is written, a replication offset is
+// acknowledged, their timeout expires or they disconnect.
+package blocking
+
+import (
+	"sync"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/clock"
+)
+
+// Outcome says why a blocked client was released
+type Outcome int
+
+const (
+	Served       Outcome = iota // The condition the client waited for was met
+	TimedOut                    // The timeout expired first
+	Disconnected                // The client went away
+	Shutdown                    // The server is shutting down
+)
+
+// waiter is a single blocked client
+type waiter struct {
+	keys     []string
+	try      func(key string) bool // Keyed waiters: serves the client from key
+	check    func() bool           // Offset waiters: true once the offset is reached
+	released bool
+	woken    chan struct{} // Closed once served
+}
+
+// Manager tracks blocked clients and wakes them, oldest first, when what
+// they wait for happens
+type Manager struct {
+	mu      sync.Mutex
+	keys    map[string][]*waiter // Clients blocked on each key in FIFO order
+	offsets []*waiter            // Clients blocked on a replication offset
+	blocked int
+	closed  bool
+	done    chan struct{} // Closed by Close
+	clock   clock.Clock   // Runs the timeouts
+}
+
+// NewManager creates an empty manager timing blocked clients out by clk
+func NewManager(clk clock.Clock) *Manager {
+	return &Manager{
+		keys:  make(map[string][]*waiter),
+		done:  make(chan struct{}),
+		clock: clk,
+	}
+}
+
+// BlockOnKeys blocks until try serves the client from one of keys. try is
+// called for each key right away, then again each time SignalKey reports one
+// of them, always with the manager locked so clients blocked on the same key
+// are served one at a time in the order they blocked. A zero timeout blocks
+// until the client is served, disconnects (cancel is closed) or the server
+// shuts down.
+func (manager *Manager) BlockOnKeys(keys []string, timeout time.Duration, cancel <-chan struct{}, try func(key string) bool) Outcome {
+	manager.mu.Lock()
+	if manager.closed {
+		manager.mu.Unlock()
+		return Shutdown
+	}
+	for _, key := range keys {
+		if try(key) {
+			manager.mu.Unlock()
+			return Served
+		}
+	}
+
+	w := &waiter{keys: keys, try: try, woken: make(chan struct{})}
+	for _, key := range keys {
+		manager.keys[key] = append(manager.keys[key], w)
+	}
+	manager.blocked++
+	manager.mu.Unlock()
+
+	return manager.wait(w, timeout, cancel)
+}
+
+// SignalKey serves the clients blocked on key, oldest first. Call it once
+// the write that made key ready has been propagated.
+func (manager *Manager) SignalKey(key string) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	for _, w := range manager.keys[key] {
+		if !w.released && w.try(key) {
+			manager.release(w)
+		}
+	}
+}
+
+// SignalKeys calls SignalKey for each of keys
+func (manager *Manager) SignalKeys(keys []string) {
+	for _, key := range keys {
+		manager.SignalKey(key)
+	}
+}
+
+// BlockOnOffset blocks until check reports the replication offset the client
+// waits for was reached. check is called right away and again on every
+// SignalOffset. Timeouts and cancellation work as in BlockOnKeys.
+func (manager *Manager) BlockOnOffset(timeout time.Duration, cancel <-chan struct{}, check func() bool) Outcome {
+	manager.mu.Lock()
+	if manager.closed {
+		manager.mu.Unlock()
+		return Shutdown
+	}
+	if check() {
+		manager.mu.Unlock()
+		return Served
+	}
+
+	w := &waiter{check: check, woken: make(chan struct{})}
+	manager.offsets = append(manager.offsets, w)
+	manager.blocked++
+	manager.mu.Unlock()
+
+	return manager.wait(w, timeout, cancel)
+}
+
+// SignalOffset wakes the clients whose replication offset was reached.
+// Call it whenever a replica acknowledges an offset.
+func (manager *Manager) SignalOffset() {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	for _, w := range manager.offsets {
+		if !w.released && w.check() {
+			manager.release(w)
+		}
+	}
+}
+
+// Blocked returns the number of blocked clients
+func (manager *Manager) Blocked() int {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	return manager.blocked
+}
+
+// Close releases every blocked client with Shutdown. Clients blocking later
+// are released immediately.
+func (manager *Manager) Close() {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	if !manager.closed {
+		manager.closed = true
+		close(manager.done)
+	}
+}
+
+// wait waits for w to be served, time out, be cancelled or the manager to close
+func (manager *Manager) wait(w *waiter, timeout time.Duration, cancel <-chan struct{}) Outcome {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := clock.NewTimer(manager.clock, timeout)
+		defer timer.Stop()
+		expired = timer.C()
+	}
+
+	outcome := Served
+	select {
+	case <-w.woken:
+		return Served
+	case <-expired:
+		outcome = TimedOut
+	case <-cancel:
+		outcome = Disconnected
+	case <-manager.done:
+		outcome = Shutdown
+	}
+
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	if w.released {
+		// Served while we were giving up
+		return Served
+	}
+	if outcome == Disconnected && manager.closed {
+		// Shutting down cancels every call after closing the manager
+		outcome = Shutdown
+	}
+	manager.release(w)
+	return outcome
+}
+
+// release removes w from the queues. The manager must be locked.
+func (manager *Manager) release(w *waiter) {
+	w.released = true
+	close(w.woken)
+	manager.blocked--
+
+	for _, key := range w.keys {
+		manager.keys[key] = remove(manager.keys[key], w)
+		if len(manager.keys[key]) == 0 {
+			delete(manager.keys, key)
+		}
+	}
+	if w.check != nil {
+		manager.offsets = remove(manager.offsets, w)
+	}
+}
+
+// remove returns waiters without w
+func remove(waiters []*waiter, w *waiter) []*waiter {
+	for i, other := range waiters {
+		if other == w {
+			return append(waiters[:i:i], waiters[i+1:]...)
+		}
+	}
+	return waiters
+}
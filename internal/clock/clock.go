@@ -0,0 +1,151 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the current time. It can be replaced in tests to make
+// time-dependent behavior deterministic.
+type Clock interface {
+	Now() time.Time
+}
+
+// Timer fires once, like time.Timer
+type Timer interface {
+	// C receives the time once the timer fires
+	C() <-chan time.Time
+	// Stop prevents the timer from firing, returning false if it already did
+	Stop() bool
+}
+
+// TimerClock is a clock that also runs timers, so timeouts follow its time
+type TimerClock interface {
+	Clock
+	NewTimer(d time.Duration) Timer
+}
+
+// NewTimer starts a timer firing once d passed on clk. Clocks that don't
+// run timers get a wall clock timer.
+func NewTimer(clk Clock, d time.Duration) Timer {
+	if timers, ok := clk.(TimerClock); ok {
+		return timers.NewTimer(d)
+	}
+	return Real{}.NewTimer(d)
+}
+
+// Real is the wall clock
+type Real struct{}
+
+// Now returns the current time
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer starts a wall clock timer
+func (Real) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+// realTimer adapts a time.Timer to Timer
+type realTimer struct {
+	timer *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t realTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+// Manual is a clock that only moves when Advance or Set is called, for
+// tests of expiration and timeouts that shouldn't sleep. It is safe for
+// concurrent use.
+type Manual struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*manualTimer // Pending timers
+}
+
+// NewManual creates a clock stopped at now
+func NewManual(now time.Time) *Manual {
+	return &Manual{now: now}
+}
+
+// Now returns the time the clock is stopped at
+func (clk *Manual) Now() time.Time {
+	clk.mu.Lock()
+	defer clk.mu.Unlock()
+	return clk.now
+}
+
+// Advance moves the clock forward by d, firing the timers due by then
+func (clk *Manual) Advance(d time.Duration) {
+	clk.mu.Lock()
+	clk.set(clk.now.Add(d))
+}
+
+// Set moves the clock to now, firing the timers due by then
+func (clk *Manual) Set(now time.Time) {
+	clk.mu.Lock()
+	clk.set(now)
+}
+
+// set moves the clock and unlocks it before firing the due timers
+func (clk *Manual) set(now time.Time) {
+	clk.now = now
+	var due []*manualTimer
+	pending := clk.timers[:0]
+	for _, t := range clk.timers {
+		if !t.at.After(now) {
+			due = append(due, t)
+		} else {
+			pending = append(pending, t)
+		}
+	}
+	clk.timers = pending
+	clk.mu.Unlock()
+
+	for _, t := range due {
+		t.c <- now
+	}
+}
+
+// NewTimer starts a timer firing once the clock was advanced by d. A timer
+// that is already due fires right away.
+func (clk *Manual) NewTimer(d time.Duration) Timer {
+	clk.mu.Lock()
+	defer clk.mu.Unlock()
+	t := &manualTimer{clock: clk, at: clk.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- clk.now
+	} else {
+		clk.timers = append(clk.timers, t)
+	}
+	return t
+}
+
+// manualTimer is a timer started by a Manual clock
+type manualTimer struct {
+	clock *Manual
+	at    time.Time
+	c     chan time.Time // Buffered so firing never blocks
+}
+
+func (t *manualTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *manualTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	for i, pending := range t.clock.timers {
+		if pending == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
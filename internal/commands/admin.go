@@ -2,9 +2,14 @@ package commands
 
 import (
 	"strings"
+	"time"
 
+	"github.com/codecrafters-redis-go/internal/config"
+	"github.com/codecrafters-redis-go/internal/logger"
+	"github.com/codecrafters-redis-go/internal/pubsub"
 	"github.com/codecrafters-redis-go/internal/resp"
 	"github.com/codecrafters-redis-go/internal/storage"
+	"github.com/codecrafters-redis-go/internal/utils"
 )
 
 // ConfigCommand implements the CONFIG command
@@ -30,6 +35,11 @@ func (c *ConfigCommand) Execute(ctx Context, args []string) resp.Value {
 			return resp.ErrorValue("ERR wrong number of arguments for 'config get' command")
 		}
 		return c.handleConfigGet(ctx, args[1])
+	case "SET":
+		if len(args) < 3 || len(args)%2 == 0 {
+			return resp.ErrorValue("ERR wrong number of arguments for 'config set' command")
+		}
+		return c.handleConfigSet(ctx, args[1:])
 	default:
 		return resp.ErrorValue("ERR Unknown subcommand '" + args[0] + "'")
 	}
@@ -39,18 +49,47 @@ func (c *ConfigCommand) Execute(ctx Context, args []string) resp.Value {
 func (c *ConfigCommand) handleConfigGet(ctx Context, pattern string) resp.Value {
 	result := []resp.Value{}
 
-	// For now, only support exact matches for "dir" and "dbfilename"
-	if pattern == "dir" || pattern == "*" {
-		result = append(result, resp.BulkStringValue("dir"))
-		result = append(result, resp.BulkStringValue(ctx.Config.Dir))
+	for _, name := range ctx.Config.Names() {
+		if !utils.MatchPattern(strings.ToLower(pattern), name) {
+			continue
+		}
+		value, _ := ctx.Config.Get(name)
+		result = append(result, resp.BulkStringValue(name))
+		result = append(result, resp.BulkStringValue(value))
 	}
 
-	if pattern == "dbfilename" || pattern == "*" {
-		result = append(result, resp.BulkStringValue("dbfilename"))
-		result = append(result, resp.BulkStringValue(ctx.Config.DBFilename))
+	return resp.ArrayValue(result...)
+}
+
+// handleConfigSet handles CONFIG SET with one or more parameter value pairs.
+// Either all parameters are changed or, if one is rejected, none.
+func (c *ConfigCommand) handleConfigSet(ctx Context, pairs []string) resp.Value {
+	previous := make([]string, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		name := strings.ToLower(pairs[i])
+		value, ok := ctx.Config.Get(name)
+		if !ok || !config.IsRuntimeMutable(name) {
+			return resp.ErrorValue("ERR Unknown option or number of arguments for CONFIG SET - '" + pairs[i] + "'")
+		}
+		previous = append(previous, value)
 	}
 
-	return resp.ArrayValue(result...)
+	for i := 0; i < len(pairs); i += 2 {
+		name := strings.ToLower(pairs[i])
+		if !ctx.Config.Set(name, pairs[i+1]) {
+			// Roll back the parameters already changed
+			for j := 0; j < i; j += 2 {
+				ctx.Config.Set(strings.ToLower(pairs[j]), previous[j/2])
+			}
+			return resp.ErrorValue("ERR CONFIG SET failed (possibly related to argument '" + pairs[i] + "') - invalid argument '" + pairs[i+1] + "'")
+		}
+		if name == "loglevel" {
+			if level, err := logger.ParseLevel(pairs[i+1]); err == nil {
+				logger.SetLevel(level)
+			}
+		}
+	}
+	return resp.OK()
 }
 
 // MinArgs returns the minimum number of arguments
@@ -60,7 +99,12 @@ func (c *ConfigCommand) MinArgs() int {
 
 // MaxArgs returns the maximum number of arguments
 func (c *ConfigCommand) MaxArgs() int {
-	return 3
+	return -1
+}
+
+// Flags returns the command flags
+func (c *ConfigCommand) Flags() Flags {
+	return FlagAdmin | FlagLoading
 }
 
 // KeysCommand implements the KEYS command
@@ -76,20 +120,32 @@ func (c *KeysCommand) Name() string {
 	return "KEYS"
 }
 
+// keysBatch is the number of keys KEYS visits between checks for an interruption
+const keysBatch = 1024
+
 // Execute runs the KEYS command
 func (c *KeysCommand) Execute(ctx Context, args []string) resp.Value {
 	pattern := args[0]
 
-	// Get all matching keys from storage
-	keys := ctx.Storage.Keys(pattern)
-
-	// Convert to array of bulk strings
-	result := make([]resp.Value, len(keys))
-	for i, key := range keys {
-		result[i] = resp.BulkStringValue(key)
+	// Walk the keyspace a batch at a time, so shutdown or the deadline of
+	// an in-process call can interrupt a large one
+	var keys []string
+	for cursor := uint64(0); ; {
+		if err := ctx.Err(); err != nil {
+			return resp.ErrorValue("ERR KEYS interrupted: " + err.Error())
+		}
+		cursor = ctx.Storage.Scan(cursor, keysBatch, func(key string, value interface{}, expiry *time.Time) {
+			if pattern == "*" || utils.MatchPattern(pattern, key) {
+				keys = append(keys, key)
+			}
+		})
+		if cursor == 0 {
+			break
+		}
 	}
 
-	return resp.ArrayValue(result...)
+	// Streamed, a large keyspace doesn't need a Value per key
+	return resp.BulkStringsValue(keys)
 }
 
 // MinArgs returns the minimum number of arguments
@@ -102,6 +158,58 @@ func (c *KeysCommand) MaxArgs() int {
 	return 1
 }
 
+// Flags returns the command flags
+func (c *KeysCommand) Flags() Flags {
+	return FlagReadOnly
+}
+
+// DelCommand implements the DEL command
+type DelCommand struct{}
+
+// NewDelCommand creates a new DEL command
+func NewDelCommand() *DelCommand {
+	return &DelCommand{}
+}
+
+// Name returns the command name
+func (c *DelCommand) Name() string {
+	return "DEL"
+}
+
+// Execute runs the DEL command
+func (c *DelCommand) Execute(ctx Context, args []string) resp.Value {
+	deleted := 0
+	for _, key := range args {
+		if ctx.Storage.Delete(key) {
+			deleted++
+			ctx.markDirty(1)
+			ctx.signalModifiedKey(key)
+			ctx.notifyKeyspaceEvent(pubsub.ClassGeneric, "del", key)
+		}
+	}
+	return resp.IntegerValue(int64(deleted))
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *DelCommand) MinArgs() int {
+	return 1
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *DelCommand) MaxArgs() int {
+	return -1
+}
+
+// Flags returns the command flags
+func (c *DelCommand) Flags() Flags {
+	return FlagWrite
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *DelCommand) KeySpec() KeySpec {
+	return KeySpec{First: 0, Last: -1, Step: 1}
+}
+
 // TypeCommand implements the TYPE command
 type TypeCommand struct{}
 
@@ -117,25 +225,12 @@ func (c *TypeCommand) Execute(ctx Context, args []string) resp.Value {
 	key := args[0]
 
 	// Check if key exists
-	val, exists := ctx.Storage.Get(key)
+	val, exists := ctx.lookup(key)
 	if !exists {
 		return resp.SimpleStringValue("none")
 	}
 
-	// Determine the type
-	switch v := val.(type) {
-	case string:
-		return resp.SimpleStringValue("string")
-	case storage.StringValue:
-		return resp.SimpleStringValue("string")
-	case *storage.Stream:
-		return resp.SimpleStringValue("stream")
-	case storage.ValueType:
-		return resp.SimpleStringValue(v.Type())
-	default:
-		// Default to string for unknown types
-		return resp.SimpleStringValue("string")
-	}
+	return resp.SimpleStringValue(storage.TypeOf(val))
 }
 
 func (c *TypeCommand) MinArgs() int {
@@ -145,3 +240,12 @@ func (c *TypeCommand) MinArgs() int {
 func (c *TypeCommand) MaxArgs() int {
 	return 1
 }
+
+func (c *TypeCommand) Flags() Flags {
+	return FlagReadOnly
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *TypeCommand) KeySpec() KeySpec {
+	return singleKey
+}
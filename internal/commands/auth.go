@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"crypto/subtle"
+
+	"github.com/codecrafters-redis-go/internal/errors"
+	"github.com/codecrafters-redis-go/internal/resp"
+)
+
+// defaultUser is the only user; it authenticates with requirepass
+const defaultUser = "default"
+
+// AuthCommand implements the AUTH command
+type AuthCommand struct{}
+
+// NewAuthCommand creates a new AUTH command
+func NewAuthCommand() *AuthCommand {
+	return &AuthCommand{}
+}
+
+// Name returns the command name
+func (c *AuthCommand) Name() string {
+	return "AUTH"
+}
+
+// Execute runs the AUTH command
+func (c *AuthCommand) Execute(ctx Context, args []string) resp.Value {
+	user, password := defaultUser, args[0]
+	if len(args) == 2 {
+		user, password = args[0], args[1]
+	}
+
+	if ctx.Config.GetRequirePass() == "" && len(args) == 1 {
+		return resp.ErrorValue("ERR AUTH <password> called without any password configured for the default user. Are you sure your configuration is correct?")
+	}
+	return authenticate(ctx, user, password)
+}
+
+// authenticate logs the client in as user and replies OK, or with an error
+// if the password is wrong
+func authenticate(ctx Context, user, password string) resp.Value {
+	// Without requirepass the default user accepts any password
+	requirePass := ctx.Config.GetRequirePass()
+	if user != defaultUser || requirePass != "" && subtle.ConstantTimeCompare([]byte(password), []byte(requirePass)) != 1 {
+		return resp.ErrorValue(errors.ErrWrongPass.Error())
+	}
+
+	if client := ctx.Call.Client; client != nil {
+		client.mu.Lock()
+		client.Authenticated = true
+		client.User = user
+		client.mu.Unlock()
+	}
+	return resp.OK()
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *AuthCommand) MinArgs() int {
+	return 1
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *AuthCommand) MaxArgs() int {
+	return 2
+}
+
+// Flags returns the command flags
+func (c *AuthCommand) Flags() Flags {
+	return FlagLoading
+}
@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/blocking"
+	"github.com/codecrafters-redis-go/internal/errors"
+	"github.com/codecrafters-redis-go/internal/resp"
+)
+
+// parseBlockingTimeout parses the timeout of a blocking command given in
+// seconds, such as BLPOP's. Fractions are honored down to the millisecond
+// and zero means block forever.
+func parseBlockingTimeout(arg string) (time.Duration, error) {
+	seconds, err := strconv.ParseFloat(arg, 64)
+	if err != nil || math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+		return 0, errors.ErrTimeoutNotFloat
+	}
+	if seconds < 0 {
+		return 0, errors.ErrTimeoutNegative
+	}
+	if seconds*1000 >= float64(math.MaxInt64/int64(time.Millisecond)) {
+		return 0, errors.ErrTimeoutOutOfRange
+	}
+	return time.Duration(seconds*1000) * time.Millisecond, nil
+}
+
+// blockedReply is the reply of a blocking command released without being
+// served: timedOut, usually a null, when its timeout expired or the server
+// drains its clients to shut down, or an error when its client was killed
+func blockedReply(outcome blocking.Outcome, timedOut resp.Value) resp.Value {
+	if outcome == blocking.Disconnected {
+		return resp.ErrorValue("UNBLOCKED client disconnected or was killed while blocked")
+	}
+	return timedOut
+}
+
+// markBlocked flags the caller as blocked in CLIENT LIST until the returned
+// function is called
+func markBlocked(ctx Context) func() {
+	if ctx.Call == nil || ctx.Call.Client == nil {
+		return func() {}
+	}
+	client := ctx.Call.Client
+	client.setBlocked(true)
+	return func() { client.setBlocked(false) }
+}
@@ -0,0 +1,61 @@
+package commands_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-redis-go/pkg/redisserver"
+	"github.com/codecrafters-redis-go/pkg/redistest"
+)
+
+func TestBlockedClientsAtShutdown(t *testing.T) {
+	cfg := redisserver.NewConfig()
+	cfg.Port = 0
+	cfg.Dir = t.TempDir()
+	srv, err := redisserver.Start(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+
+	client, err := redistest.Dial(srv.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	type result struct {
+		reply redisserver.Reply
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		reply, err := client.Do("BLPOP", "queue", "0")
+		done <- result{reply, err}
+	}()
+
+	// Wait for the client to block before shutting down
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		info := do(t, srv, "INFO", "clients").Str
+		if strings.Contains(info, "blocked_clients:1\r\n") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("BLPOP never blocked")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := srv.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case result := <-done:
+		if result.err != nil || !result.reply.IsNull {
+			t.Errorf("BLPOP at shutdown = %+v, %v, want a null reply", result.reply, result.err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("BLPOP was not released at shutdown")
+	}
+}
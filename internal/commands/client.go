@@ -0,0 +1,471 @@
+package commands
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/errors"
+	"github.com/codecrafters-redis-go/internal/resp"
+	"github.com/codecrafters-redis-go/internal/utils"
+)
+
+// clientRegistry is implemented by servers that keep track of their clients
+type clientRegistry interface {
+	// Clients returns the connected clients in connection order
+	Clients() []*Client
+	// KillClients closes the connections of the clients match selects, the
+	// caller's only once its reply was written, and returns how many
+	KillClients(match func(*Client) bool, caller *Client) int
+}
+
+// ClientCommand implements the CLIENT command
+type ClientCommand struct{}
+
+// NewClientCommand creates a new CLIENT command
+func NewClientCommand() *ClientCommand {
+	return &ClientCommand{}
+}
+
+// Name returns the command name
+func (c *ClientCommand) Name() string {
+	return "CLIENT"
+}
+
+// Execute runs the CLIENT command
+func (c *ClientCommand) Execute(ctx Context, args []string) resp.Value {
+	switch strings.ToUpper(args[0]) {
+	case "PAUSE":
+		return c.handlePause(ctx, args[1:])
+	case "UNPAUSE":
+		if len(args) != 1 {
+			return resp.ErrorValue("ERR wrong number of arguments for 'client|unpause' command")
+		}
+		ctx.Pause.Unpause(PauseByClient)
+		return resp.OK()
+	case "NO-TOUCH":
+		return c.handleNoTouch(ctx, args[1:])
+	case "ID":
+		if len(args) != 1 {
+			return resp.ErrorValue("ERR wrong number of arguments for 'client|id' command")
+		}
+		if ctx.Call.Client == nil {
+			return resp.ErrorValue("ERR CLIENT ID is not allowed in this context")
+		}
+		return resp.IntegerValue(ctx.Call.Client.ID)
+	case "GETNAME":
+		if len(args) != 1 {
+			return resp.ErrorValue("ERR wrong number of arguments for 'client|getname' command")
+		}
+		if ctx.Call.Client == nil || ctx.Call.Client.Name == "" {
+			return resp.NullBulkString()
+		}
+		return resp.BulkStringValue(ctx.Call.Client.Name)
+	case "SETNAME":
+		return c.handleSetName(ctx, args[1:])
+	case "SETINFO":
+		return c.handleSetInfo(ctx, args[1:])
+	case "LIST":
+		return c.handleList(ctx, args[1:])
+	case "INFO":
+		if len(args) != 1 {
+			return resp.ErrorValue("ERR wrong number of arguments for 'client|info' command")
+		}
+		return c.handleInfo(ctx)
+	case "REPLY":
+		return c.handleReply(ctx, args[1:])
+	case "KILL":
+		return c.handleKill(ctx, args[1:])
+	case "TRACKING":
+		return c.handleTracking(ctx, args[1:])
+	case "TRACKINGINFO":
+		if len(args) != 1 {
+			return resp.ErrorValue("ERR wrong number of arguments for 'client|trackinginfo' command")
+		}
+		return c.handleTrackingInfo(ctx)
+	default:
+		return resp.ErrorValue("ERR unknown subcommand '" + args[0] + "'. Try CLIENT HELP.")
+	}
+}
+
+// handlePause handles CLIENT PAUSE timeout [WRITE|ALL]
+func (c *ClientCommand) handlePause(ctx Context, args []string) resp.Value {
+	if len(args) < 1 || len(args) > 2 {
+		return resp.ErrorValue("ERR wrong number of arguments for 'client|pause' command")
+	}
+
+	timeout, ok := utils.ParseInt64(args[0])
+	if !ok || timeout < 0 || timeout > math.MaxInt64/int64(time.Millisecond) {
+		return resp.ErrorValue("ERR timeout is not an integer or out of range")
+	}
+
+	all := true
+	if len(args) == 2 {
+		switch strings.ToUpper(args[1]) {
+		case "WRITE":
+			all = false
+		case "ALL":
+		default:
+			return resp.ErrorValue(errors.ErrSyntaxError.Error())
+		}
+	}
+
+	if timeout == 0 {
+		// A zero duration would hold clients until CLIENT UNPAUSE
+		ctx.Pause.Unpause(PauseByClient)
+		return resp.OK()
+	}
+	ctx.Pause.Pause(PauseByClient, all, time.Duration(timeout)*time.Millisecond)
+	return resp.OK()
+}
+
+// handleKill handles CLIENT KILL ip:port and CLIENT KILL [ID id] [ADDR ip:port]
+// [LADDR ip:port] [USER name] [MAXAGE seconds] [SKIPME yes|no]. A client
+// blocked in a command is woken as disconnected.
+func (c *ClientCommand) handleKill(ctx Context, args []string) resp.Value {
+	if len(args) < 1 {
+		return resp.ErrorValue("ERR wrong number of arguments for 'client|kill' command")
+	}
+	registry, ok := ctx.Server.(clientRegistry)
+	if !ok {
+		return resp.ErrorValue("ERR CLIENT KILL is not supported in this context")
+	}
+	caller := ctx.Call.Client
+
+	if len(args) == 1 {
+		// The old form kills one client by address, even the caller
+		addr := args[0]
+		if registry.KillClients(func(client *Client) bool { return client.Addr == addr }, caller) == 0 {
+			return resp.ErrorValue("ERR No such client")
+		}
+		return resp.OK()
+	}
+
+	if len(args)%2 != 0 {
+		return resp.ErrorValue(errors.ErrSyntaxError.Error())
+	}
+	var filters []func(ClientInfo) bool
+	skipMe := true
+	for i := 0; i < len(args); i += 2 {
+		value := args[i+1]
+		switch option := strings.ToUpper(args[i]); option {
+		case "ID":
+			id, ok := utils.ParseInt64(value)
+			if !ok || id <= 0 {
+				return resp.ErrorValue("ERR client-id should be greater than 0")
+			}
+			filters = append(filters, func(info ClientInfo) bool { return info.ID == id })
+		case "ADDR":
+			filters = append(filters, func(info ClientInfo) bool { return info.Addr == value })
+		case "LADDR":
+			filters = append(filters, func(info ClientInfo) bool { return info.LocalAddr == value })
+		case "USER":
+			filters = append(filters, func(info ClientInfo) bool { return info.User == value })
+		case "MAXAGE":
+			seconds, ok := utils.ParseInt64(value)
+			if !ok || seconds < 0 || seconds > math.MaxInt64/int64(time.Second) {
+				return resp.ErrorValue(errors.ErrNotInteger.Error())
+			}
+			maxAge := time.Duration(seconds) * time.Second
+			filters = append(filters, func(info ClientInfo) bool { return info.Age >= maxAge })
+		case "SKIPME":
+			switch strings.ToLower(value) {
+			case "yes":
+				skipMe = true
+			case "no":
+				skipMe = false
+			default:
+				return resp.ErrorValue(errors.ErrSyntaxError.Error())
+			}
+		case "TYPE":
+			clientType, ok := parseClientType(value)
+			if !ok {
+				return resp.ErrorValue("ERR Unknown client type '" + value + "'")
+			}
+			filters = append(filters, func(info ClientInfo) bool { return info.Type() == clientType })
+		default:
+			return resp.ErrorValue(errors.ErrSyntaxError.Error())
+		}
+	}
+
+	killed := registry.KillClients(func(client *Client) bool {
+		if skipMe && client == caller {
+			return false
+		}
+		info := client.Info(nil)
+		for _, filter := range filters {
+			if !filter(info) {
+				return false
+			}
+		}
+		return true
+	}, caller)
+	return resp.IntegerValue(int64(killed))
+}
+
+// handleList handles CLIENT LIST [TYPE type] [ID id [id ...]]
+func (c *ClientCommand) handleList(ctx Context, args []string) resp.Value {
+	registry, ok := ctx.Server.(clientRegistry)
+	if !ok {
+		return resp.ErrorValue("ERR CLIENT LIST is not supported in this context")
+	}
+
+	var ids map[int64]bool
+	clientType := ""
+	for i := 0; i < len(args); {
+		switch strings.ToUpper(args[i]) {
+		case "TYPE":
+			if i+1 >= len(args) {
+				return resp.ErrorValue(errors.ErrSyntaxError.Error())
+			}
+			if clientType, ok = parseClientType(args[i+1]); !ok {
+				return resp.ErrorValue("ERR Unknown client type '" + args[i+1] + "'")
+			}
+			i += 2
+		case "ID":
+			// The IDs run to the end of the arguments
+			if i+1 >= len(args) {
+				return resp.ErrorValue(errors.ErrSyntaxError.Error())
+			}
+			ids = make(map[int64]bool)
+			for _, arg := range args[i+1:] {
+				id, ok := utils.ParseInt64(arg)
+				if !ok || id <= 0 {
+					return resp.ErrorValue("ERR Invalid client ID")
+				}
+				ids[id] = true
+			}
+			i = len(args)
+		default:
+			return resp.ErrorValue(errors.ErrSyntaxError.Error())
+		}
+	}
+
+	var list strings.Builder
+	for _, client := range registry.Clients() {
+		if ids != nil && !ids[client.ID] {
+			continue
+		}
+		info := client.Info(ctx.PubSub)
+		if clientType != "" && info.Type() != clientType {
+			continue
+		}
+		list.WriteString(info.String() + "\n")
+	}
+	return resp.VerbatimValue("txt", list.String())
+}
+
+// parseClientType parses the type of CLIENT LIST TYPE and CLIENT KILL TYPE,
+// accepting slave for replica
+func parseClientType(name string) (string, bool) {
+	switch strings.ToLower(name) {
+	case ClientTypeNormal:
+		return ClientTypeNormal, true
+	case ClientTypeReplica, "slave":
+		return ClientTypeReplica, true
+	case ClientTypePubSub:
+		return ClientTypePubSub, true
+	case ClientTypeMaster:
+		return ClientTypeMaster, true
+	default:
+		return "", false
+	}
+}
+
+// handleInfo handles CLIENT INFO, the CLIENT LIST line of the caller
+func (c *ClientCommand) handleInfo(ctx Context) resp.Value {
+	client := ctx.Call.Client
+	if client == nil {
+		return resp.ErrorValue("ERR CLIENT INFO is not allowed in this context")
+	}
+	return resp.VerbatimValue("txt", client.Info(ctx.PubSub).String()+"\n")
+}
+
+// handleSetName handles CLIENT SETNAME name, an empty name clears it
+func (c *ClientCommand) handleSetName(ctx Context, args []string) resp.Value {
+	if len(args) != 1 {
+		return resp.ErrorValue("ERR wrong number of arguments for 'client|setname' command")
+	}
+	client := ctx.Call.Client
+	if client == nil {
+		return resp.ErrorValue("ERR CLIENT SETNAME is not allowed in this context")
+	}
+	if !validClientName(args[0]) {
+		return resp.ErrorValue("ERR Client names cannot contain spaces, newlines or special characters.")
+	}
+	client.setName(args[0])
+	return resp.OK()
+}
+
+// handleSetInfo handles CLIENT SETINFO LIB-NAME name and CLIENT SETINFO
+// LIB-VER version, which client libraries send on connect. An empty value
+// clears the attribute.
+func (c *ClientCommand) handleSetInfo(ctx Context, args []string) resp.Value {
+	if len(args) != 2 {
+		return resp.ErrorValue("ERR wrong number of arguments for 'client|setinfo' command")
+	}
+	client := ctx.Call.Client
+	if client == nil {
+		return resp.ErrorValue("ERR CLIENT SETINFO is not allowed in this context")
+	}
+
+	attribute, value := strings.ToLower(args[0]), args[1]
+	if attribute != "lib-name" && attribute != "lib-ver" {
+		return resp.ErrorValue("ERR Unrecognized option '" + args[0] + "'")
+	}
+	if !validClientName(value) {
+		return resp.ErrorValue("ERR " + attribute + " cannot contain spaces, newlines or special characters.")
+	}
+
+	client.mu.Lock()
+	if attribute == "lib-name" {
+		client.LibName = value
+	} else {
+		client.LibVersion = value
+	}
+	client.mu.Unlock()
+	return resp.OK()
+}
+
+// handleReply handles CLIENT REPLY ON|OFF|SKIP. The server stops sending
+// replies, including this one unless it is ON.
+func (c *ClientCommand) handleReply(ctx Context, args []string) resp.Value {
+	if len(args) != 1 {
+		return resp.ErrorValue("ERR wrong number of arguments for 'client|reply' command")
+	}
+	client := ctx.Call.Client
+	if client == nil {
+		return resp.ErrorValue("ERR CLIENT REPLY is not allowed in this context")
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "ON":
+		client.ReplyMode = ReplyOn
+	case "OFF":
+		client.ReplyMode = ReplyOff
+	case "SKIP":
+		client.ReplyMode = ReplySkip
+	default:
+		return resp.ErrorValue(errors.ErrSyntaxError.Error())
+	}
+	return resp.OK()
+}
+
+// handleNoTouch handles CLIENT NO-TOUCH ON|OFF
+func (c *ClientCommand) handleNoTouch(ctx Context, args []string) resp.Value {
+	if len(args) != 1 {
+		return resp.ErrorValue("ERR wrong number of arguments for 'client|no-touch' command")
+	}
+	client := ctx.Call.Client
+	if client == nil {
+		return resp.ErrorValue("ERR CLIENT NO-TOUCH is not allowed in this context")
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "ON":
+		client.NoTouch = true
+	case "OFF":
+		client.NoTouch = false
+	default:
+		return resp.ErrorValue(errors.ErrSyntaxError.Error())
+	}
+	return resp.OK()
+}
+
+// handleTracking handles CLIENT TRACKING ON|OFF [BCAST] [PREFIX prefix ...]
+// [NOLOOP]. Only broadcast mode is implemented: the server would otherwise
+// have to remember every key each client read.
+func (c *ClientCommand) handleTracking(ctx Context, args []string) resp.Value {
+	if len(args) < 1 {
+		return resp.ErrorValue("ERR wrong number of arguments for 'client|tracking' command")
+	}
+	client := ctx.Call.Client
+	if client == nil || client.Tracking == nil || ctx.Tracking == nil {
+		return resp.ErrorValue("ERR CLIENT TRACKING is not allowed in this context")
+	}
+
+	var prefixes []string
+	bcast, noLoop := false, false
+	for i := 1; i < len(args); i++ {
+		switch option := strings.ToUpper(args[i]); option {
+		case "BCAST":
+			bcast = true
+		case "NOLOOP":
+			noLoop = true
+		case "PREFIX":
+			if i+1 >= len(args) {
+				return resp.ErrorValue(errors.ErrSyntaxError.Error())
+			}
+			i++
+			prefixes = append(prefixes, args[i])
+		case "REDIRECT", "OPTIN", "OPTOUT":
+			return resp.ErrorValue("ERR " + option + " is not supported, only BCAST tracking is")
+		default:
+			return resp.ErrorValue(errors.ErrSyntaxError.Error())
+		}
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "ON":
+		if !bcast {
+			if len(prefixes) > 0 {
+				return resp.ErrorValue("ERR PREFIX option requires BCAST mode to be enabled")
+			}
+			return resp.ErrorValue("ERR only BCAST tracking is supported")
+		}
+		if err := ctx.Tracking.Enable(client.Tracking, prefixes, noLoop); err != nil {
+			return resp.ErrorValue(err.Error())
+		}
+	case "OFF":
+		ctx.Tracking.Disable(client.Tracking)
+	default:
+		return resp.ErrorValue(errors.ErrSyntaxError.Error())
+	}
+	return resp.OK()
+}
+
+// handleTrackingInfo handles CLIENT TRACKINGINFO
+func (c *ClientCommand) handleTrackingInfo(ctx Context) resp.Value {
+	client := ctx.Call.Client
+	if client == nil || client.Tracking == nil || ctx.Tracking == nil {
+		return resp.ErrorValue("ERR CLIENT TRACKINGINFO is not allowed in this context")
+	}
+
+	enabled, noLoop, prefixes := ctx.Tracking.Info(client.Tracking)
+	flags := []resp.Value{resp.BulkStringValue("off")}
+	redirect := int64(-1)
+	if enabled {
+		flags = []resp.Value{resp.BulkStringValue("on"), resp.BulkStringValue("bcast")}
+		if noLoop {
+			flags = append(flags, resp.BulkStringValue("noloop"))
+		}
+		redirect = 0
+	}
+	sort.Strings(prefixes)
+	prefixValues := make([]resp.Value, len(prefixes))
+	for i, prefix := range prefixes {
+		prefixValues[i] = resp.BulkStringValue(prefix)
+	}
+
+	return resp.MapValue(
+		resp.BulkStringValue("flags"), resp.ArrayValue(flags...),
+		resp.BulkStringValue("redirect"), resp.IntegerValue(redirect),
+		resp.BulkStringValue("prefixes"), resp.ArrayValue(prefixValues...),
+	)
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *ClientCommand) MinArgs() int {
+	return 1
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *ClientCommand) MaxArgs() int {
+	return -1
+}
+
+// Flags returns the command flags
+func (c *ClientCommand) Flags() Flags {
+	return FlagLoading
+}
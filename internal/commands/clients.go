@@ -0,0 +1,257 @@
+package commands
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/pubsub"
+	"github.com/codecrafters-redis-go/internal/resp"
+	"github.com/codecrafters-redis-go/internal/tracking"
+)
+
+// ReplyMode says whether replies are sent to a client, see CLIENT REPLY
+type ReplyMode int
+
+const (
+	ReplyOn   ReplyMode = iota // Every reply is sent
+	ReplyOff                   // No reply is sent
+	ReplySkip                  // The reply to the next command is not sent
+)
+
+// Output writes to a client connection. It is safe for concurrent use, so
+// pub/sub messages and invalidations can be sent between replies.
+type Output interface {
+	// Encode writes a reply or a pub/sub message
+	Encode(value resp.Value) error
+	// Push writes an out of band message, dropped on RESP2 connections
+	Push(value resp.Value) error
+	// SetProtocol selects the RESP version of everything written later
+	SetProtocol(version int)
+	// Pending returns the bytes written but not sent to the client yet
+	Pending() int64
+}
+
+// Client holds the state of one connection. Only the connection's goroutine
+// changes it. Fields other connections read with Info are changed holding
+// mu, so the connection itself can read them without locking.
+type Client struct {
+	mu sync.Mutex
+
+	ID            int64     // Unique and increasing, see CLIENT ID
+	Addr          string    // Remote address, reported by SLOWLOG GET
+	LocalAddr     string    // Address the client connected to
+	Created       time.Time // When the connection was accepted
+	Name          string    // Set with CLIENT SETNAME or HELLO SETNAME, guarded by mu
+	DB            int       // Selected database, only 0 exists
+	Authenticated bool      // Guarded by mu, like User
+	User          string
+	Protocol      int       // RESP version replies are encoded in, 2 or 3, guarded by mu
+	ReplyMode     ReplyMode // CLIENT REPLY, applied by the server when replying
+	NoTouch       bool      // CLIENT NO-TOUCH: reads don't count as key accesses
+	Replica       bool      // The connection streams the replication feed to a replica, guarded by mu
+	ListeningPort string    // Announced by a replica with REPLCONF listening-port
+	LibName       string    // Client library, set with CLIENT SETINFO LIB-NAME, guarded by mu
+	LibVersion    string    // Set with CLIENT SETINFO LIB-VER, guarded by mu
+	LastCommand   string    // Last command looked up, e.g. "get" or "client|list", guarded by mu
+	Blocked       bool      // Waiting in a blocking command such as WAIT, guarded by mu
+
+	Multi   []resp.Value        // Commands queued by MULTI, nil outside a transaction, guarded by mu
+	Watched map[string]struct{} // Keys watched for the next transaction, guarded by mu
+
+	Output     Output             // Writes replies and pushes, nil for clients without a connection
+	Subscriber *pubsub.Subscriber // Receives pub/sub messages, nil if the client can't subscribe
+	Tracking   *tracking.Client   // Receives invalidations, nil if the client can't track keys
+}
+
+// NewClient creates the state of a connection speaking RESP2
+func NewClient(id int64, addr, localAddr string) *Client {
+	return &Client{
+		ID:        id,
+		Addr:      addr,
+		LocalAddr: localAddr,
+		Created:   time.Now(),
+		Protocol:  2,
+	}
+}
+
+// SetReplica marks the connection as a replica once it started a sync
+func (client *Client) SetReplica() {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.Replica = true
+}
+
+// IsReplica returns true if the connection streams the replication feed.
+// Unlike the Replica field it may be read from any goroutine.
+func (client *Client) IsReplica() bool {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	return client.Replica
+}
+
+// setName sets the name reported by CLIENT GETNAME and CLIENT LIST
+func (client *Client) setName(name string) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.Name = name
+}
+
+// setBlocked records whether the client waits in a blocking command
+func (client *Client) setBlocked(blocked bool) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.Blocked = blocked
+}
+
+// containerCommands are the commands CLIENT LIST reports together with
+// their subcommand, e.g. "client|list"
+var containerCommands = map[string]bool{
+	"CLIENT": true, "COMMAND": true, "CONFIG": true, "LATENCY": true, "MEMORY": true, "OBJECT": true, "SLOWLOG": true,
+}
+
+// setLastCommand records the command the client sent last, with the
+// arguments it was called with
+func (client *Client) setLastCommand(name string, args []string) {
+	last := strings.ToLower(name)
+	if containerCommands[name] && len(args) > 0 {
+		last += "|" + strings.ToLower(args[0])
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.LastCommand = last
+}
+
+// ClientInfo is a consistent copy of the client fields CLIENT LIST reports
+type ClientInfo struct {
+	ID          int64
+	Addr        string
+	LocalAddr   string
+	Name        string
+	Age         time.Duration
+	DB          int
+	User        string
+	Protocol    int
+	Replica     bool
+	Channels    int
+	Patterns    int
+	LibName     string
+	LibVersion  string
+	LastCommand string
+	Flags       string // One letter per state, see clientFlags
+	Multi       int    // Commands queued by MULTI, -1 outside a transaction
+	Watched     int    // Keys watched with WATCH
+	Subscribed  bool   // In RESP2 subscribe mode, only pub/sub commands are allowed
+	OutputMem   int64  // Reply bytes not sent yet
+}
+
+// Info returns the fields of the client CLIENT LIST reports. It may be
+// called from any goroutine.
+func (client *Client) Info(hub *pubsub.Hub) ClientInfo {
+	client.mu.Lock()
+	info := ClientInfo{
+		ID:          client.ID,
+		Addr:        client.Addr,
+		LocalAddr:   client.LocalAddr,
+		Name:        client.Name,
+		Age:         time.Since(client.Created),
+		DB:          client.DB,
+		User:        client.User,
+		Protocol:    client.Protocol,
+		Replica:     client.Replica,
+		LibName:     client.LibName,
+		LibVersion:  client.LibVersion,
+		LastCommand: client.LastCommand,
+		Multi:       -1,
+		Watched:     len(client.Watched),
+	}
+	if client.Multi != nil {
+		info.Multi = len(client.Multi)
+	}
+	blocked := client.Blocked
+	client.mu.Unlock()
+
+	if client.Subscriber != nil {
+		info.Subscribed = client.Subscriber.Subscribed()
+	}
+	if client.Output != nil {
+		info.OutputMem = client.Output.Pending()
+	}
+	info.Flags = clientFlags(info, blocked)
+
+	if info.User == "" {
+		info.User = defaultUser
+	}
+	if hub != nil && client.Subscriber != nil {
+		info.Channels = len(hub.Channels(client.Subscriber))
+		info.Patterns = len(hub.Patterns(client.Subscriber))
+	}
+	return info
+}
+
+// Client types, as filtered by CLIENT LIST TYPE and CLIENT KILL TYPE
+const (
+	ClientTypeNormal  = "normal"
+	ClientTypeReplica = "replica"
+	ClientTypePubSub  = "pubsub"
+	ClientTypeMaster  = "master"
+)
+
+// Type returns the client type. The link of a replica to its master is not
+// a client connection, so no client has type master.
+func (info ClientInfo) Type() string {
+	switch {
+	case info.Replica:
+		return ClientTypeReplica
+	case info.Subscribed && info.Protocol == 2:
+		return ClientTypePubSub
+	default:
+		return ClientTypeNormal
+	}
+}
+
+// clientFlags returns the CLIENT LIST flags of a client: S for a replica, P
+// in subscribe mode, x in a MULTI, b while blocked, or N for none of them
+func clientFlags(info ClientInfo, blocked bool) string {
+	var flags []byte
+	if info.Replica {
+		flags = append(flags, 'S')
+	}
+	if info.Subscribed {
+		flags = append(flags, 'P')
+	}
+	if info.Multi >= 0 {
+		flags = append(flags, 'x')
+	}
+	if blocked {
+		flags = append(flags, 'b')
+	}
+	if len(flags) == 0 {
+		return "N"
+	}
+	return string(flags)
+}
+
+// String formats the info as a CLIENT LIST line
+func (info ClientInfo) String() string {
+	var line strings.Builder
+	line.WriteString("id=" + strconv.FormatInt(info.ID, 10))
+	line.WriteString(" addr=" + info.Addr)
+	line.WriteString(" laddr=" + info.LocalAddr)
+	line.WriteString(" name=" + info.Name)
+	line.WriteString(" age=" + strconv.FormatInt(int64(info.Age/time.Second), 10))
+	line.WriteString(" flags=" + info.Flags)
+	line.WriteString(" db=" + strconv.Itoa(info.DB))
+	line.WriteString(" sub=" + strconv.Itoa(info.Channels))
+	line.WriteString(" psub=" + strconv.Itoa(info.Patterns))
+	line.WriteString(" multi=" + strconv.Itoa(info.Multi))
+	line.WriteString(" watch=" + strconv.Itoa(info.Watched))
+	line.WriteString(" omem=" + strconv.FormatInt(info.OutputMem, 10))
+	line.WriteString(" cmd=" + info.LastCommand)
+	line.WriteString(" user=" + info.User)
+	line.WriteString(" resp=" + strconv.Itoa(info.Protocol))
+	line.WriteString(" lib-name=" + info.LibName)
+	line.WriteString(" lib-ver=" + info.LibVersion)
+	return line.String()
+}
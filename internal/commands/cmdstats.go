@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBuckets is the number of latency histogram buckets. Bucket i
+// counts the calls that took at most 2^i microseconds, the last one also
+// counts anything slower.
+const latencyBuckets = 40
+
+// commandStat holds the counters of one command
+type commandStat struct {
+	calls       int64
+	duration    time.Duration
+	failedCalls int64
+	histogram   [latencyBuckets]int64 // Calls per latency bucket, see latencyBucket
+}
+
+// latencyBucket returns the histogram bucket of a call that took duration
+func latencyBucket(duration time.Duration) int {
+	usec := duration.Microseconds()
+	if usec <= 1 {
+		return 0
+	}
+	return min(bits.Len64(uint64(usec-1)), latencyBuckets-1)
+}
+
+// CommandStats collects per-command call counts and latency for INFO commandstats
+type CommandStats struct {
+	mu    sync.Mutex
+	stats map[string]*commandStat
+}
+
+// NewCommandStats creates empty command statistics
+func NewCommandStats() *CommandStats {
+	return &CommandStats{stats: make(map[string]*commandStat)}
+}
+
+// Record adds one call of the named command
+func (s *CommandStats) Record(name string, duration time.Duration, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stat, ok := s.stats[name]
+	if !ok {
+		stat = &commandStat{}
+		s.stats[name] = stat
+	}
+	stat.calls++
+	stat.duration += duration
+	stat.histogram[latencyBucket(duration)]++
+	if failed {
+		stat.failedCalls++
+	}
+}
+
+// Reset clears all counters
+func (s *CommandStats) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats = make(map[string]*commandStat)
+}
+
+// Info returns one cmdstat_<name> field per command that has been called
+func (s *CommandStats) Info() []InfoField {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.stats))
+	for name := range s.stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]InfoField, 0, len(names))
+	for _, name := range names {
+		stat := s.stats[name]
+		usec := stat.duration.Microseconds()
+		fields = append(fields, InfoField{
+			Name: "cmdstat_" + strings.ToLower(name),
+			Value: fmt.Sprintf("calls=%d,usec=%d,usec_per_call=%.2f,failed_calls=%d",
+				stat.calls, usec, float64(usec)/float64(stat.calls), stat.failedCalls),
+		})
+	}
+	return fields
+}
+
+// LatencyHistogram is the latency distribution of one command
+type LatencyHistogram struct {
+	Name    string // Lowercase command name
+	Calls   int64
+	Buckets []LatencyBucket // From 1 microsecond up to the slowest call
+}
+
+// LatencyBucket is a histogram bucket
+type LatencyBucket struct {
+	Usec  int64 // Upper bound of the bucket in microseconds, a power of two
+	Calls int64 // Calls that took at most Usec, including those of lower buckets
+}
+
+// Histograms returns the latency histograms of the named commands, matched
+// case-insensitively, or of every command if names is empty. Commands that
+// were not called are left out.
+func (s *CommandStats) Histograms(names []string) []LatencyHistogram {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[strings.ToLower(name)] = true
+	}
+
+	var histograms []LatencyHistogram
+	for name, stat := range s.stats {
+		name = strings.ToLower(name)
+		if len(wanted) > 0 && !wanted[name] {
+			continue
+		}
+		histogram := LatencyHistogram{Name: name, Calls: stat.calls}
+		var cumulative int64
+		for i := 0; cumulative < stat.calls; i++ {
+			cumulative += stat.histogram[i]
+			histogram.Buckets = append(histogram.Buckets, LatencyBucket{Usec: 1 << i, Calls: cumulative})
+		}
+		histograms = append(histograms, histogram)
+	}
+	sort.Slice(histograms, func(i, j int) bool { return histograms[i].Name < histograms[j].Name })
+	return histograms
+}
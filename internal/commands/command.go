@@ -0,0 +1,218 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codecrafters-redis-go/internal/resp"
+)
+
+// CommandCommand implements the COMMAND command
+type CommandCommand struct{}
+
+// NewCommandCommand creates a new COMMAND command
+func NewCommandCommand() *CommandCommand {
+	return &CommandCommand{}
+}
+
+// Name returns the command name
+func (c *CommandCommand) Name() string {
+	return "COMMAND"
+}
+
+// Execute runs the COMMAND command
+func (c *CommandCommand) Execute(ctx Context, args []string) resp.Value {
+	if len(args) == 0 {
+		all := ctx.Commands.Commands()
+		values := make([]resp.Value, len(all))
+		for i, cmd := range all {
+			values[i] = commandInfo(cmd)
+		}
+		return resp.ArrayValue(values...)
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "COUNT":
+		if len(args) != 1 {
+			return resp.ErrorValue("ERR wrong number of arguments for 'command|count' command")
+		}
+		return resp.IntegerValue(int64(len(ctx.Commands.Commands())))
+
+	case "LIST":
+		if len(args) != 1 {
+			return resp.ErrorValue("ERR syntax error")
+		}
+		all := ctx.Commands.Commands()
+		values := make([]resp.Value, len(all))
+		for i, cmd := range all {
+			values[i] = resp.BulkStringValue(strings.ToLower(cmd.Name()))
+		}
+		return resp.ArrayValue(values...)
+
+	case "INFO":
+		values := make([]resp.Value, 0, len(args)-1)
+		for _, name := range args[1:] {
+			cmd, ok := ctx.Commands.GetCommand(name)
+			if !ok {
+				values = append(values, resp.NullArray())
+				continue
+			}
+			values = append(values, commandInfo(cmd))
+		}
+		return resp.ArrayValue(values...)
+
+	case "GETKEYS":
+		if len(args) < 2 {
+			return resp.ErrorValue("ERR wrong number of arguments for 'command|getkeys' command")
+		}
+		cmd, ok := ctx.Commands.GetCommand(args[1])
+		if !ok {
+			return resp.ErrorValue("ERR Invalid command specified")
+		}
+		cmdArgs := args[2:]
+		if len(cmdArgs) < cmd.MinArgs() || cmd.MaxArgs() >= 0 && len(cmdArgs) > cmd.MaxArgs() {
+			return resp.ErrorValue("ERR Invalid number of arguments specified for command")
+		}
+		keys := Keys(cmd, cmdArgs)
+		if len(keys) == 0 {
+			return resp.ErrorValue("ERR The command has no key arguments")
+		}
+		values := make([]resp.Value, len(keys))
+		for i, key := range keys {
+			values[i] = resp.BulkStringValue(key)
+		}
+		return resp.ArrayValue(values...)
+
+	case "HELP":
+		return resp.ArrayValue(
+			resp.SimpleStringValue("COMMAND <subcommand> [<arg> [value] [opt] ...]. Subcommands are:"),
+			resp.SimpleStringValue("(no subcommand)"),
+			resp.SimpleStringValue("    Return details about all commands."),
+			resp.SimpleStringValue("COUNT"),
+			resp.SimpleStringValue("    Return the total number of commands in this server."),
+			resp.SimpleStringValue("LIST"),
+			resp.SimpleStringValue("    Return a list of all commands in this server."),
+			resp.SimpleStringValue("INFO [<command-name> ...]"),
+			resp.SimpleStringValue("    Return details about the given commands."),
+			resp.SimpleStringValue("GETKEYS <full-command>"),
+			resp.SimpleStringValue("    Return the keys from a full command."),
+			resp.SimpleStringValue("HELP"),
+			resp.SimpleStringValue("    Print this help."),
+		)
+
+	default:
+		return resp.ErrorValue(fmt.Sprintf("ERR unknown subcommand '%s'. Try COMMAND HELP.", args[0]))
+	}
+}
+
+// commandInfo returns the COMMAND INFO entry of cmd
+func commandInfo(cmd Command) resp.Value {
+	// Arity counts the name and is negative for a minimum
+	arity := int64(cmd.MinArgs() + 1)
+	if cmd.MaxArgs() != cmd.MinArgs() {
+		arity = -arity
+	}
+
+	flags := commandFlagNames(cmd.Flags())
+	var first, last, step int64
+	keySpecs := []resp.Value{}
+	if keyed, ok := cmd.(KeyedCommand); ok {
+		spec := keyed.KeySpec()
+		if spec.Movable() {
+			flags = append(flags, resp.SimpleStringValue("movablekeys"))
+		} else {
+			first, last, step = int64(spec.First+1), int64(spec.Last), int64(spec.Step)
+			if spec.Last >= 0 {
+				last++
+			}
+		}
+		keySpecs = append(keySpecs, keySpecInfo(spec))
+	}
+
+	return resp.ArrayValue(
+		resp.BulkStringValue(strings.ToLower(cmd.Name())),
+		resp.IntegerValue(arity),
+		resp.ArrayValue(flags...),
+		resp.IntegerValue(first),
+		resp.IntegerValue(last),
+		resp.IntegerValue(step),
+		resp.ArrayValue(),
+		resp.ArrayValue(),
+		resp.ArrayValue(keySpecs...),
+		resp.ArrayValue(),
+	)
+}
+
+// keySpecInfo returns spec in the key specification form of COMMAND INFO
+func keySpecInfo(spec KeySpec) resp.Value {
+	if spec.Movable() {
+		return resp.MapValue(
+			resp.BulkStringValue("begin_search"),
+			resp.MapValue(resp.BulkStringValue("type"), resp.BulkStringValue("unknown")),
+			resp.BulkStringValue("find_keys"),
+			resp.MapValue(resp.BulkStringValue("type"), resp.BulkStringValue("unknown")),
+		)
+	}
+
+	// Like Redis, lastkey is relative to the first key, or to the end if negative
+	lastKey := int64(spec.Last)
+	if spec.Last >= 0 {
+		lastKey = int64(spec.Last - spec.First)
+	}
+	return resp.MapValue(
+		resp.BulkStringValue("begin_search"),
+		resp.MapValue(
+			resp.BulkStringValue("type"), resp.BulkStringValue("index"),
+			resp.BulkStringValue("spec"), resp.MapValue(
+				resp.BulkStringValue("index"), resp.IntegerValue(int64(spec.First+1)),
+			),
+		),
+		resp.BulkStringValue("find_keys"),
+		resp.MapValue(
+			resp.BulkStringValue("type"), resp.BulkStringValue("range"),
+			resp.BulkStringValue("spec"), resp.MapValue(
+				resp.BulkStringValue("lastkey"), resp.IntegerValue(lastKey),
+				resp.BulkStringValue("keystep"), resp.IntegerValue(int64(spec.Step)),
+				resp.BulkStringValue("limit"), resp.IntegerValue(0),
+			),
+		),
+	)
+}
+
+// commandFlagNames returns the names COMMAND INFO reports for flags
+func commandFlagNames(flags Flags) []resp.Value {
+	names := []struct {
+		flag Flags
+		name string
+	}{
+		{FlagWrite, "write"},
+		{FlagReadOnly, "readonly"},
+		{FlagDenyOOM, "denyoom"},
+		{FlagAdmin, "admin"},
+		{FlagPubSub, "pubsub"},
+		{FlagBlocking, "blocking"},
+		{FlagLoading, "loading"},
+	}
+	values := []resp.Value{}
+	for _, entry := range names {
+		if flags.Has(entry.flag) {
+			values = append(values, resp.SimpleStringValue(entry.name))
+		}
+	}
+	return values
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *CommandCommand) MinArgs() int {
+	return 0
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *CommandCommand) MaxArgs() int {
+	return -1
+}
+
+// Flags returns the command flags
+func (c *CommandCommand) Flags() Flags {
+	return FlagLoading
+}
@@ -0,0 +1,287 @@
+package commands
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/codecrafters-redis-go/internal/errors"
+	"github.com/codecrafters-redis-go/internal/pubsub"
+	"github.com/codecrafters-redis-go/internal/resp"
+	"github.com/codecrafters-redis-go/internal/storage"
+	"github.com/codecrafters-redis-go/internal/utils"
+)
+
+// incrBy adds delta to the integer stored at key, creating it at 0 if it is
+// missing, and replies with the new value. The read and the write happen
+// under one storage lock, so concurrent increments are never lost.
+func incrBy(ctx Context, key string, delta int64) resp.Value {
+	var result int64
+	err := ctx.Storage.Update(key, func(val interface{}, exists bool) (interface{}, error) {
+		var current int64
+		if exists {
+			str, err := storage.AsString(val)
+			if err != nil {
+				return nil, err
+			}
+			var ok bool
+			if current, ok = utils.ParseInt64(str); !ok {
+				return nil, errors.ErrNotInteger
+			}
+		}
+		var ok bool
+		if result, ok = utils.AddInt64(current, delta); !ok {
+			return nil, errors.ErrOverflow
+		}
+		return strconv.FormatInt(result, 10), nil
+	})
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+
+	ctx.markDirty(1)
+	ctx.signalModifiedKey(key)
+	ctx.notifyKeyspaceEvent(pubsub.ClassString, "incrby", key)
+	return resp.IntegerValue(result)
+}
+
+// IncrCommand implements the INCR command
+type IncrCommand struct{}
+
+// NewIncrCommand creates a new INCR command
+func NewIncrCommand() *IncrCommand {
+	return &IncrCommand{}
+}
+
+// Name returns the command name
+func (c *IncrCommand) Name() string {
+	return "INCR"
+}
+
+// Execute runs the INCR command
+func (c *IncrCommand) Execute(ctx Context, args []string) resp.Value {
+	return incrBy(ctx, args[0], 1)
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *IncrCommand) MinArgs() int {
+	return 1
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *IncrCommand) MaxArgs() int {
+	return 1
+}
+
+// Flags returns the command flags
+func (c *IncrCommand) Flags() Flags {
+	return FlagWrite | FlagDenyOOM
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *IncrCommand) KeySpec() KeySpec {
+	return singleKey
+}
+
+// DecrCommand implements the DECR command
+type DecrCommand struct{}
+
+// NewDecrCommand creates a new DECR command
+func NewDecrCommand() *DecrCommand {
+	return &DecrCommand{}
+}
+
+// Name returns the command name
+func (c *DecrCommand) Name() string {
+	return "DECR"
+}
+
+// Execute runs the DECR command
+func (c *DecrCommand) Execute(ctx Context, args []string) resp.Value {
+	return incrBy(ctx, args[0], -1)
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *DecrCommand) MinArgs() int {
+	return 1
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *DecrCommand) MaxArgs() int {
+	return 1
+}
+
+// Flags returns the command flags
+func (c *DecrCommand) Flags() Flags {
+	return FlagWrite | FlagDenyOOM
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *DecrCommand) KeySpec() KeySpec {
+	return singleKey
+}
+
+// IncrByCommand implements the INCRBY command
+type IncrByCommand struct{}
+
+// NewIncrByCommand creates a new INCRBY command
+func NewIncrByCommand() *IncrByCommand {
+	return &IncrByCommand{}
+}
+
+// Name returns the command name
+func (c *IncrByCommand) Name() string {
+	return "INCRBY"
+}
+
+// Execute runs the INCRBY command
+func (c *IncrByCommand) Execute(ctx Context, args []string) resp.Value {
+	delta, ok := utils.ParseInt64(args[1])
+	if !ok {
+		return resp.ErrorValue(errors.ErrNotInteger.Error())
+	}
+	return incrBy(ctx, args[0], delta)
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *IncrByCommand) MinArgs() int {
+	return 2
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *IncrByCommand) MaxArgs() int {
+	return 2
+}
+
+// Flags returns the command flags
+func (c *IncrByCommand) Flags() Flags {
+	return FlagWrite | FlagDenyOOM
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *IncrByCommand) KeySpec() KeySpec {
+	return singleKey
+}
+
+// DecrByCommand implements the DECRBY command
+type DecrByCommand struct{}
+
+// NewDecrByCommand creates a new DECRBY command
+func NewDecrByCommand() *DecrByCommand {
+	return &DecrByCommand{}
+}
+
+// Name returns the command name
+func (c *DecrByCommand) Name() string {
+	return "DECRBY"
+}
+
+// Execute runs the DECRBY command
+func (c *DecrByCommand) Execute(ctx Context, args []string) resp.Value {
+	delta, ok := utils.ParseInt64(args[1])
+	if !ok {
+		return resp.ErrorValue(errors.ErrNotInteger.Error())
+	}
+	// The smallest int64 has no positive counterpart to add instead
+	if delta == math.MinInt64 {
+		return resp.ErrorValue("ERR decrement would overflow")
+	}
+	return incrBy(ctx, args[0], -delta)
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *DecrByCommand) MinArgs() int {
+	return 2
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *DecrByCommand) MaxArgs() int {
+	return 2
+}
+
+// Flags returns the command flags
+func (c *DecrByCommand) Flags() Flags {
+	return FlagWrite | FlagDenyOOM
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *DecrByCommand) KeySpec() KeySpec {
+	return singleKey
+}
+
+// IncrByFloatCommand implements the INCRBYFLOAT command
+type IncrByFloatCommand struct{}
+
+// NewIncrByFloatCommand creates a new INCRBYFLOAT command
+func NewIncrByFloatCommand() *IncrByFloatCommand {
+	return &IncrByFloatCommand{}
+}
+
+// Name returns the command name
+func (c *IncrByFloatCommand) Name() string {
+	return "INCRBYFLOAT"
+}
+
+// Execute runs the INCRBYFLOAT command. Like Redis it adds with long double
+// precision, and the result is stored in the same form it is replied with,
+// so GET reads back exactly what the client saw.
+func (c *IncrByFloatCommand) Execute(ctx Context, args []string) resp.Value {
+	key := args[0]
+	delta, ok := utils.ParseLongDouble(args[1])
+	if !ok {
+		return resp.ErrorValue(errors.ErrNotFloat.Error())
+	}
+
+	var result string
+	err := ctx.Storage.Update(key, func(val interface{}, exists bool) (interface{}, error) {
+		sum := new(big.Float).Set(delta)
+		if exists {
+			str, err := storage.AsString(val)
+			if err != nil {
+				return nil, err
+			}
+			current, ok := utils.ParseLongDouble(str)
+			if !ok {
+				return nil, errors.ErrNotFloat
+			}
+			sum.Add(current, delta)
+		}
+		// The result must read back as a float for the next increment
+		if f, _ := sum.Float64(); math.IsInf(f, 0) {
+			return nil, errors.ErrNaNOrInfinity
+		}
+		result = utils.FormatLongDouble(sum)
+		return result, nil
+	})
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+
+	// Replicas and AOF replay store the same digits instead of redoing the
+	// addition, whose rounding could differ
+	ctx.replicateAs("SET", key, result, "KEEPTTL")
+	ctx.markDirty(1)
+	ctx.signalModifiedKey(key)
+	ctx.notifyKeyspaceEvent(pubsub.ClassString, "incrbyfloat", key)
+	return resp.BulkStringValue(result)
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *IncrByFloatCommand) MinArgs() int {
+	return 2
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *IncrByFloatCommand) MaxArgs() int {
+	return 2
+}
+
+// Flags returns the command flags
+func (c *IncrByFloatCommand) Flags() Flags {
+	return FlagWrite | FlagDenyOOM
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *IncrByFloatCommand) KeySpec() KeySpec {
+	return singleKey
+}
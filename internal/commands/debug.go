@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-redis-go/internal/resp"
+)
+
+// DebugCommand implements the DEBUG command
+type DebugCommand struct{}
+
+// NewDebugCommand creates a new DEBUG command
+func NewDebugCommand() *DebugCommand {
+	return &DebugCommand{}
+}
+
+// Name returns the command name
+func (c *DebugCommand) Name() string {
+	return "DEBUG"
+}
+
+// Execute runs the DEBUG command
+func (c *DebugCommand) Execute(ctx Context, args []string) resp.Value {
+	switch strings.ToUpper(args[0]) {
+	case "CONFIG":
+		// The effective configuration after defaults and flags were merged
+		return resp.BulkStringValue(ctx.Config.Dump())
+	case "GOROUTINES":
+		// The stack of every goroutine, like a SIGQUIT dump without the exit
+		var stacks bytes.Buffer
+		pprof.Lookup("goroutine").WriteTo(&stacks, 2)
+		return resp.BulkStringValue(stacks.String())
+	case "MEMSTATS":
+		return resp.BulkStringValue(memStats())
+	case "HELP":
+		return resp.ArrayValue(
+			resp.SimpleStringValue("DEBUG <subcommand> [<arg> [value] [opt] ...]. Subcommands are:"),
+			resp.SimpleStringValue("CONFIG"),
+			resp.SimpleStringValue("    Return the effective configuration."),
+			resp.SimpleStringValue("GOROUTINES"),
+			resp.SimpleStringValue("    Return the stack traces of all goroutines."),
+			resp.SimpleStringValue("MEMSTATS"),
+			resp.SimpleStringValue("    Return the Go runtime memory statistics."),
+			resp.SimpleStringValue("HELP"),
+			resp.SimpleStringValue("    Print this help."),
+		)
+	default:
+		return resp.ErrorValue(fmt.Sprintf("ERR unknown subcommand '%s'. Try DEBUG HELP.", args[0]))
+	}
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *DebugCommand) MinArgs() int {
+	return 1
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *DebugCommand) MaxArgs() int {
+	return -1
+}
+
+// Flags returns the command flags
+func (c *DebugCommand) Flags() Flags {
+	return FlagAdmin | FlagLoading
+}
+
+// memStats formats the Go runtime memory statistics as INFO style lines
+func memStats() string {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	fields := []struct {
+		name  string
+		value uint64
+	}{
+		{"goroutines", uint64(runtime.NumGoroutine())},
+		{"heap_alloc", stats.HeapAlloc},
+		{"heap_sys", stats.HeapSys},
+		{"heap_idle", stats.HeapIdle},
+		{"heap_inuse", stats.HeapInuse},
+		{"heap_released", stats.HeapReleased},
+		{"heap_objects", stats.HeapObjects},
+		{"total_alloc", stats.TotalAlloc},
+		{"sys", stats.Sys},
+		{"mallocs", stats.Mallocs},
+		{"frees", stats.Frees},
+		{"stack_inuse", stats.StackInuse},
+		{"next_gc", stats.NextGC},
+		{"num_gc", uint64(stats.NumGC)},
+		{"pause_total_ns", stats.PauseTotalNs},
+	}
+	var lines strings.Builder
+	for _, field := range fields {
+		lines.WriteString(field.name + ":" + strconv.FormatUint(field.value, 10) + "\r\n")
+	}
+	return lines.String()
+}
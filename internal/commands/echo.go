@@ -35,3 +35,8 @@ func (c *EchoCommand) MinArgs() int {
 func (c *EchoCommand) MaxArgs() int {
 	return 1
 }
+
+// Flags returns the command flags
+func (c *EchoCommand) Flags() Flags {
+	return 0
+}
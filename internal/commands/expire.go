@@ -0,0 +1,262 @@
+package commands
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/errors"
+	"github.com/codecrafters-redis-go/internal/pubsub"
+	"github.com/codecrafters-redis-go/internal/resp"
+	"github.com/codecrafters-redis-go/internal/utils"
+)
+
+// ExpireCommand implements EXPIRE, PEXPIRE, EXPIREAT and PEXPIREAT, which
+// only differ in how they read the expiry
+type ExpireCommand struct {
+	name     string
+	unit     time.Duration
+	absolute bool // The expiry is a unix time rather than a time to live
+}
+
+// NewExpireCommand creates a new EXPIRE command
+func NewExpireCommand() *ExpireCommand {
+	return &ExpireCommand{name: "EXPIRE", unit: time.Second}
+}
+
+// NewPExpireCommand creates a new PEXPIRE command
+func NewPExpireCommand() *ExpireCommand {
+	return &ExpireCommand{name: "PEXPIRE", unit: time.Millisecond}
+}
+
+// NewExpireAtCommand creates a new EXPIREAT command
+func NewExpireAtCommand() *ExpireCommand {
+	return &ExpireCommand{name: "EXPIREAT", unit: time.Second, absolute: true}
+}
+
+// NewPExpireAtCommand creates a new PEXPIREAT command
+func NewPExpireAtCommand() *ExpireCommand {
+	return &ExpireCommand{name: "PEXPIREAT", unit: time.Millisecond, absolute: true}
+}
+
+// Name returns the command name
+func (c *ExpireCommand) Name() string {
+	return c.name
+}
+
+// Execute runs the command. It replies 1 if the TTL was set, or 0 if the
+// key is missing or NX, XX, GT or LT ruled it out; a persistent key counts
+// as expiring never for GT and LT. An expiry in the past deletes the key.
+func (c *ExpireCommand) Execute(ctx Context, args []string) resp.Value {
+	key := args[0]
+	n, ok := utils.ParseInt64(args[1])
+	if !ok {
+		return resp.ErrorValue(errors.ErrNotInteger.Error())
+	}
+
+	var nx, xx, gt, lt bool
+	for _, arg := range args[2:] {
+		switch strings.ToUpper(arg) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "GT":
+			gt = true
+		case "LT":
+			lt = true
+		default:
+			return resp.ErrorValue("ERR Unsupported option " + arg)
+		}
+	}
+	if nx && (xx || gt || lt) {
+		return resp.ErrorValue("ERR NX and XX, GT or LT options at the same time are not compatible")
+	}
+	if gt && lt {
+		return resp.ErrorValue("ERR GT and LT options at the same time are not compatible")
+	}
+
+	// Expiries are kept in milliseconds, which the argument must fit in
+	// once scaled and made absolute
+	perUnit := int64(c.unit / time.Millisecond)
+	invalid := errors.InvalidExpireTime("'" + strings.ToLower(c.name) + "' command")
+	if n > math.MaxInt64/perUnit || n < math.MinInt64/perUnit {
+		return resp.ErrorValue(invalid.Error())
+	}
+	ms := n * perUnit
+	if !c.absolute {
+		if ms, ok = utils.AddInt64(ms, ctx.Clock.Now().UnixMilli()); !ok {
+			return resp.ErrorValue(invalid.Error())
+		}
+	}
+	expiry := time.UnixMilli(ms)
+
+	changed, removed := ctx.Storage.ExpireIf(key, expiry, func(current *time.Time) bool {
+		switch {
+		case nx && current != nil, xx && current == nil:
+			return false
+		case gt:
+			return current != nil && expiry.After(*current)
+		case lt:
+			return current == nil || expiry.Before(*current)
+		}
+		return true
+	})
+	if !changed {
+		return resp.IntegerValue(0)
+	}
+
+	ctx.markDirty(1)
+	ctx.signalModifiedKey(key)
+	if removed {
+		ctx.replicateAs("DEL", key)
+		ctx.notifyKeyspaceEvent(pubsub.ClassGeneric, "del", key)
+	} else {
+		// Replicas apply the same deadline whenever they receive it
+		ctx.replicateAs("PEXPIREAT", key, strconv.FormatInt(ms, 10))
+		ctx.notifyKeyspaceEvent(pubsub.ClassGeneric, "expire", key)
+	}
+	return resp.IntegerValue(1)
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *ExpireCommand) MinArgs() int {
+	return 2
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *ExpireCommand) MaxArgs() int {
+	return -1
+}
+
+// Flags returns the command flags
+func (c *ExpireCommand) Flags() Flags {
+	return FlagWrite
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *ExpireCommand) KeySpec() KeySpec {
+	return singleKey
+}
+
+// TTLCommand implements TTL, PTTL, EXPIRETIME and PEXPIRETIME, which read
+// the expiry of a key as a time to live or a unix time, in seconds or
+// milliseconds
+type TTLCommand struct {
+	name     string
+	unit     time.Duration
+	absolute bool
+}
+
+// NewTTLCommand creates a new TTL command
+func NewTTLCommand() *TTLCommand {
+	return &TTLCommand{name: "TTL", unit: time.Second}
+}
+
+// NewPTTLCommand creates a new PTTL command
+func NewPTTLCommand() *TTLCommand {
+	return &TTLCommand{name: "PTTL", unit: time.Millisecond}
+}
+
+// NewExpireTimeCommand creates a new EXPIRETIME command
+func NewExpireTimeCommand() *TTLCommand {
+	return &TTLCommand{name: "EXPIRETIME", unit: time.Second, absolute: true}
+}
+
+// NewPExpireTimeCommand creates a new PEXPIRETIME command
+func NewPExpireTimeCommand() *TTLCommand {
+	return &TTLCommand{name: "PEXPIRETIME", unit: time.Millisecond, absolute: true}
+}
+
+// Name returns the command name
+func (c *TTLCommand) Name() string {
+	return c.name
+}
+
+// Execute runs the command. It replies -2 for a missing key and -1 for a
+// key without a TTL; seconds are rounded to the nearest.
+func (c *TTLCommand) Execute(ctx Context, args []string) resp.Value {
+	expiry, exists := ctx.Storage.Expiry(args[0])
+	if !exists {
+		return resp.IntegerValue(-2)
+	}
+	if expiry == nil {
+		return resp.IntegerValue(-1)
+	}
+
+	ms := expiry.UnixMilli()
+	if !c.absolute {
+		ms = max(ms-ctx.Clock.Now().UnixMilli(), 0)
+	}
+	if c.unit == time.Second {
+		return resp.IntegerValue((ms + 500) / 1000)
+	}
+	return resp.IntegerValue(ms)
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *TTLCommand) MinArgs() int {
+	return 1
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *TTLCommand) MaxArgs() int {
+	return 1
+}
+
+// Flags returns the command flags
+func (c *TTLCommand) Flags() Flags {
+	return FlagReadOnly
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *TTLCommand) KeySpec() KeySpec {
+	return singleKey
+}
+
+// PersistCommand implements the PERSIST command
+type PersistCommand struct{}
+
+// NewPersistCommand creates a new PERSIST command
+func NewPersistCommand() *PersistCommand {
+	return &PersistCommand{}
+}
+
+// Name returns the command name
+func (c *PersistCommand) Name() string {
+	return "PERSIST"
+}
+
+// Execute runs the PERSIST command, replying 1 if it removed a TTL and 0 if
+// the key is missing or has none
+func (c *PersistCommand) Execute(ctx Context, args []string) resp.Value {
+	key := args[0]
+	if !ctx.Storage.Persist(key) {
+		return resp.IntegerValue(0)
+	}
+	ctx.markDirty(1)
+	ctx.signalModifiedKey(key)
+	ctx.notifyKeyspaceEvent(pubsub.ClassGeneric, "persist", key)
+	return resp.IntegerValue(1)
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *PersistCommand) MinArgs() int {
+	return 1
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *PersistCommand) MaxArgs() int {
+	return 1
+}
+
+// Flags returns the command flags
+func (c *PersistCommand) Flags() Flags {
+	return FlagWrite
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *PersistCommand) KeySpec() KeySpec {
+	return singleKey
+}
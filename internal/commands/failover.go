@@ -0,0 +1,171 @@
+package commands
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/errors"
+	"github.com/codecrafters-redis-go/internal/resp"
+	"github.com/codecrafters-redis-go/internal/utils"
+)
+
+// FailoverOptions are the FAILOVER arguments
+type FailoverOptions struct {
+	Host    string // Target replica, empty to pick one
+	Port    string
+	Timeout time.Duration // How long to wait for the target to catch up, 0 waits forever
+	Force   bool          // Fail over even if the target did not catch up in time
+}
+
+// topologyChanger is implemented by servers that can change their role at runtime
+type topologyChanger interface {
+	ReplicaOf(host, port string) error
+	Failover(opts FailoverOptions) error
+	AbortFailover() error
+}
+
+// ReplicaOfCommand implements the REPLICAOF command
+type ReplicaOfCommand struct{}
+
+// NewReplicaOfCommand creates a new REPLICAOF command
+func NewReplicaOfCommand() *ReplicaOfCommand {
+	return &ReplicaOfCommand{}
+}
+
+// Name returns the command name
+func (c *ReplicaOfCommand) Name() string {
+	return "REPLICAOF"
+}
+
+// Execute runs the REPLICAOF command. REPLICAOF NO ONE promotes the server
+// to master.
+func (c *ReplicaOfCommand) Execute(ctx Context, args []string) resp.Value {
+	server, ok := ctx.Server.(topologyChanger)
+	if !ok {
+		return resp.ErrorValue("ERR " + ctx.Call.Name + " is not supported in this context")
+	}
+
+	host, port := args[0], args[1]
+	if strings.EqualFold(host, "no") && strings.EqualFold(port, "one") {
+		host, port = "", ""
+	} else if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		return resp.ErrorValue("ERR Invalid master port")
+	}
+
+	if err := server.ReplicaOf(host, port); err != nil {
+		return resp.ErrorValue("ERR " + err.Error())
+	}
+	return resp.OK()
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *ReplicaOfCommand) MinArgs() int {
+	return 2
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *ReplicaOfCommand) MaxArgs() int {
+	return 2
+}
+
+// Flags returns the command flags
+func (c *ReplicaOfCommand) Flags() Flags {
+	return FlagAdmin
+}
+
+// SlaveOfCommand implements SLAVEOF, the name of REPLICAOF Sentinel sends
+type SlaveOfCommand struct {
+	ReplicaOfCommand
+}
+
+// NewSlaveOfCommand creates a new SLAVEOF command
+func NewSlaveOfCommand() *SlaveOfCommand {
+	return &SlaveOfCommand{}
+}
+
+// Name returns the command name
+func (c *SlaveOfCommand) Name() string {
+	return "SLAVEOF"
+}
+
+// FailoverCommand implements the FAILOVER command
+type FailoverCommand struct{}
+
+// NewFailoverCommand creates a new FAILOVER command
+func NewFailoverCommand() *FailoverCommand {
+	return &FailoverCommand{}
+}
+
+// Name returns the command name
+func (c *FailoverCommand) Name() string {
+	return "FAILOVER"
+}
+
+// Execute runs FAILOVER [TO host port [FORCE]] [TIMEOUT ms] and FAILOVER
+// ABORT. The failover itself runs in the background.
+func (c *FailoverCommand) Execute(ctx Context, args []string) resp.Value {
+	server, ok := ctx.Server.(topologyChanger)
+	if !ok {
+		return resp.ErrorValue("ERR FAILOVER is not supported in this context")
+	}
+
+	var opts FailoverOptions
+	for i := 0; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "ABORT":
+			if len(args) != 1 {
+				return resp.ErrorValue(errors.ErrSyntaxError.Error())
+			}
+			if err := server.AbortFailover(); err != nil {
+				return resp.ErrorValue("ERR " + err.Error())
+			}
+			return resp.OK()
+		case "TO":
+			if i+2 >= len(args) {
+				return resp.ErrorValue(errors.ErrSyntaxError.Error())
+			}
+			opts.Host, opts.Port = args[i+1], args[i+2]
+			i += 2
+		case "TIMEOUT":
+			if i+1 >= len(args) {
+				return resp.ErrorValue(errors.ErrSyntaxError.Error())
+			}
+			ms, ok := utils.ParseInt64(args[i+1])
+			if !ok || ms <= 0 || ms > math.MaxInt64/int64(time.Millisecond) {
+				return resp.ErrorValue("ERR FAILOVER timeout must be greater than 0")
+			}
+			opts.Timeout = time.Duration(ms) * time.Millisecond
+			i++
+		case "FORCE":
+			opts.Force = true
+		default:
+			return resp.ErrorValue(errors.ErrSyntaxError.Error())
+		}
+	}
+
+	if opts.Force && (opts.Timeout == 0 || opts.Host == "") {
+		return resp.ErrorValue("ERR FAILOVER with force option requires both a timeout and target HOST and IP.")
+	}
+
+	if err := server.Failover(opts); err != nil {
+		return resp.ErrorValue("ERR " + err.Error())
+	}
+	return resp.OK()
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *FailoverCommand) MinArgs() int {
+	return 0
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *FailoverCommand) MaxArgs() int {
+	return 6
+}
+
+// Flags returns the command flags
+func (c *FailoverCommand) Flags() Flags {
+	return FlagAdmin
+}
@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-redis-go/internal/resp"
+)
+
+// serverVersion is the Redis version this server is compatible with
+const serverVersion = "7.2.0"
+
+// HelloCommand implements the HELLO command
+type HelloCommand struct{}
+
+// NewHelloCommand creates a new HELLO command
+func NewHelloCommand() *HelloCommand {
+	return &HelloCommand{}
+}
+
+// Name returns the command name
+func (c *HelloCommand) Name() string {
+	return "HELLO"
+}
+
+// Execute runs HELLO [protover [AUTH username password] [SETNAME clientname]]
+func (c *HelloCommand) Execute(ctx Context, args []string) resp.Value {
+	client := ctx.Call.Client
+	protocol := 2
+	if client != nil && client.Protocol == 3 {
+		protocol = 3
+	}
+
+	if len(args) > 0 {
+		version, err := strconv.Atoi(args[0])
+		if err != nil {
+			return resp.ErrorValue("ERR Protocol version is not an integer or out of range")
+		}
+		if version != 2 && version != 3 {
+			return resp.ErrorValue("NOPROTO unsupported protocol version")
+		}
+		protocol = version
+	}
+
+	var user, password, name string
+	authenticating, naming := false, false
+	for i := 1; i < len(args); i++ {
+		switch {
+		case strings.EqualFold(args[i], "AUTH") && i+2 < len(args):
+			user, password = args[i+1], args[i+2]
+			authenticating = true
+			i += 2
+		case strings.EqualFold(args[i], "SETNAME") && i+1 < len(args):
+			name = args[i+1]
+			naming = true
+			i++
+		default:
+			return resp.ErrorValue("ERR Syntax error in HELLO option '" + args[i] + "'")
+		}
+	}
+
+	if naming && !validClientName(name) {
+		return resp.ErrorValue("ERR Client names cannot contain spaces, newlines or special characters.")
+	}
+	if authenticating {
+		if reply := authenticate(ctx, user, password); reply.Type == resp.Error {
+			return reply
+		}
+	} else if client != nil && !client.Authenticated && ctx.Config.GetRequirePass() != "" {
+		return resp.ErrorValue("NOAUTH HELLO must be called with the client already authenticated, otherwise the HELLO <proto> AUTH <user> <pass> option can be used to authenticate the client and select the RESP protocol version at the same time")
+	}
+
+	var id int64
+	if client != nil {
+		client.mu.Lock()
+		client.Protocol = protocol
+		if naming {
+			client.Name = name
+		}
+		client.mu.Unlock()
+		id = client.ID
+
+		// Messages delivered from now on, and this reply, use the new protocol
+		if client.Output != nil {
+			client.Output.SetProtocol(protocol)
+		}
+	}
+
+	role := "master"
+	if ctx.Config.IsReplica() {
+		role = "replica"
+	}
+	return resp.MapValue(
+		resp.BulkStringValue("server"), resp.BulkStringValue("redis"),
+		resp.BulkStringValue("version"), resp.BulkStringValue(serverVersion),
+		resp.BulkStringValue("proto"), resp.IntegerValue(int64(protocol)),
+		resp.BulkStringValue("id"), resp.IntegerValue(id),
+		resp.BulkStringValue("mode"), resp.BulkStringValue("standalone"),
+		resp.BulkStringValue("role"), resp.BulkStringValue(role),
+		resp.BulkStringValue("modules"), resp.ArrayValue(),
+	)
+}
+
+// validClientName returns true if name only has printable characters other than space
+func validClientName(name string) bool {
+	for i := 0; i < len(name); i++ {
+		if name[i] <= ' ' || name[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *HelloCommand) MinArgs() int {
+	return 0
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *HelloCommand) MaxArgs() int {
+	return -1
+}
+
+// Flags returns the command flags
+func (c *HelloCommand) Flags() Flags {
+	return FlagLoading
+}
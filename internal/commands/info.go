@@ -1,11 +1,74 @@
 package commands
 
 import (
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/codecrafters-redis-go/internal/replication"
 	"github.com/codecrafters-redis-go/internal/resp"
 )
 
+// LoadingInfo is a snapshot of dataset loading progress
+type LoadingInfo struct {
+	Loading     bool
+	StartTime   time.Time
+	TotalBytes  int64
+	LoadedBytes int64
+}
+
+// loadingInfoProvider is implemented by servers that load their dataset asynchronously
+type loadingInfoProvider interface {
+	LoadingInfo() LoadingInfo
+}
+
+// InfoField is a single "name:value" line of an INFO section
+type InfoField struct {
+	Name  string
+	Value string
+}
+
+// statsProvider is implemented by servers that keep INFO stats counters
+type statsProvider interface {
+	StatsInfo() []InfoField
+}
+
+// serverInfoProvider is implemented by servers that report their process and run id
+type serverInfoProvider interface {
+	ServerInfo() []InfoField
+}
+
+// clientsInfoProvider is implemented by servers that report connected and blocked clients
+type clientsInfoProvider interface {
+	ClientsInfo() []InfoField
+}
+
+// replicasInfoProvider is implemented by servers that report their replicas
+type replicasInfoProvider interface {
+	ReplicasInfo() []InfoField
+	MasterReplOffset() int64
+}
+
+// failoverStateProvider is implemented by servers that support FAILOVER
+type failoverStateProvider interface {
+	FailoverState() string
+}
+
+// appendOnlyInfoProvider is implemented by servers that can maintain an append only file
+type appendOnlyInfoProvider interface {
+	AppendOnlyInfo() (enabled bool, rewriting bool, delayedFsyncs int64)
+}
+
+// saveInfoProvider is implemented by servers that snapshot on save rules
+type saveInfoProvider interface {
+	SaveInfo() (changes int64, lastSave time.Time, lastOK bool)
+}
+
+// masterLinkProvider is implemented by servers that can report their link to master
+type masterLinkProvider interface {
+	MasterLinkInfo() (replication.LinkInfo, bool)
+}
+
 // InfoCommand implements the INFO command
 type InfoCommand struct{}
 
@@ -27,33 +90,223 @@ func (c *InfoCommand) Execute(ctx Context, args []string) resp.Value {
 	}
 
 	info := c.buildInfo(ctx, section)
-	return resp.BulkStringValue(info)
+	return resp.VerbatimValue("txt", info)
 }
 
 // buildInfo constructs the INFO response
 func (c *InfoCommand) buildInfo(ctx Context, section string) string {
 	var info strings.Builder
 
+	if section == "all" || section == "server" {
+		c.writeServerInfo(ctx, &info)
+	}
+
+	if section == "all" || section == "clients" {
+		c.writeClientsInfo(ctx, &info)
+	}
+
+	if section == "all" || section == "persistence" {
+		c.writePersistenceInfo(ctx, &info)
+	}
+
+	if section == "all" || section == "stats" {
+		c.writeStatsInfo(ctx, &info)
+	}
+
 	if section == "all" || section == "replication" {
-		info.WriteString("# Replication\r\n")
-
-		if ctx.Config.IsReplica() {
-			// Replica mode
-			info.WriteString("role:slave\r\n")
-			// TODO: Add more replica-specific info in later stages
-		} else {
-			// Master mode
-			info.WriteString("role:master\r\n")
-			info.WriteString("master_replid:")
-			info.WriteString(c.getMasterReplID())
-			info.WriteString("\r\n")
-			info.WriteString("master_repl_offset:0\r\n")
-		}
+		c.writeReplicationInfo(ctx, &info)
+	}
+
+	if section == "all" || section == "commandstats" {
+		c.writeCommandStatsInfo(ctx, &info)
 	}
 
 	return strings.TrimSpace(info.String())
 }
 
+// writeServerInfo writes the server section. Sentinel tells restarted
+// servers apart by their run_id.
+func (c *InfoCommand) writeServerInfo(ctx Context, info *strings.Builder) {
+	info.WriteString("# Server\r\n")
+	info.WriteString("redis_version:" + serverVersion + "\r\n")
+	info.WriteString("redis_mode:standalone\r\n")
+	if provider, ok := ctx.Server.(serverInfoProvider); ok {
+		for _, field := range provider.ServerInfo() {
+			info.WriteString(field.Name + ":" + field.Value + "\r\n")
+		}
+	}
+	info.WriteString("\r\n")
+}
+
+// writeReplicationInfo writes the replication section, with the fields
+// Sentinel reads to monitor masters and pick the replica to promote
+func (c *InfoCommand) writeReplicationInfo(ctx Context, info *strings.Builder) {
+	info.WriteString("# Replication\r\n")
+
+	var offset int64
+	if ctx.Config.IsReplica() {
+		info.WriteString("role:slave\r\n")
+		offset = c.writeMasterLinkInfo(ctx, info)
+		info.WriteString("slave_priority:" + strconv.Itoa(ctx.Config.GetReplicaPriority()) + "\r\n")
+		info.WriteString("slave_read_only:" + boolFlag(ctx.Config.IsReadOnlyReplica()) + "\r\n")
+		info.WriteString("replica_announced:1\r\n")
+	} else {
+		info.WriteString("role:master\r\n")
+	}
+
+	provider, ok := ctx.Server.(replicasInfoProvider)
+	if ok {
+		for _, field := range provider.ReplicasInfo() {
+			info.WriteString(field.Name + ":" + field.Value + "\r\n")
+		}
+		if !ctx.Config.IsReplica() {
+			offset = provider.MasterReplOffset()
+		}
+	} else {
+		info.WriteString("connected_slaves:0\r\n")
+	}
+	if provider, ok := ctx.Server.(failoverStateProvider); ok {
+		info.WriteString("master_failover_state:" + provider.FailoverState() + "\r\n")
+	}
+	info.WriteString("master_replid:" + c.getMasterReplID() + "\r\n")
+	info.WriteString("master_repl_offset:" + strconv.FormatInt(offset, 10) + "\r\n")
+	info.WriteString("\r\n")
+}
+
+// writePersistenceInfo writes the persistence section including loading progress
+func (c *InfoCommand) writePersistenceInfo(ctx Context, info *strings.Builder) {
+	var loading LoadingInfo
+	if provider, ok := ctx.Server.(loadingInfoProvider); ok {
+		loading = provider.LoadingInfo()
+	}
+
+	aofEnabled, aofRewriting, aofDelayedFsyncs := false, false, int64(0)
+	if provider, ok := ctx.Server.(appendOnlyInfoProvider); ok {
+		aofEnabled, aofRewriting, aofDelayedFsyncs = provider.AppendOnlyInfo()
+	}
+
+	info.WriteString("# Persistence\r\n")
+	if provider, ok := ctx.Server.(saveInfoProvider); ok {
+		changes, lastSave, lastOK := provider.SaveInfo()
+		status := "ok"
+		if !lastOK {
+			status = "err"
+		}
+		info.WriteString("rdb_changes_since_last_save:" + strconv.FormatInt(changes, 10) + "\r\n")
+		info.WriteString("rdb_last_save_time:" + strconv.FormatInt(lastSave.Unix(), 10) + "\r\n")
+		info.WriteString("rdb_last_bgsave_status:" + status + "\r\n")
+	}
+	info.WriteString("aof_enabled:" + boolFlag(aofEnabled) + "\r\n")
+	info.WriteString("aof_rewrite_in_progress:" + boolFlag(aofRewriting) + "\r\n")
+	if aofEnabled {
+		info.WriteString("aof_delayed_fsync:" + strconv.FormatInt(aofDelayedFsyncs, 10) + "\r\n")
+	}
+	if !loading.Loading {
+		info.WriteString("loading:0\r\n")
+		info.WriteString("\r\n")
+		return
+	}
+
+	elapsed := time.Since(loading.StartTime).Seconds()
+	percent := 0.0
+	eta := 1
+	if loading.TotalBytes > 0 {
+		percent = float64(loading.LoadedBytes) / float64(loading.TotalBytes) * 100
+		if loading.LoadedBytes > 0 {
+			remaining := loading.TotalBytes - loading.LoadedBytes
+			eta = int(elapsed * float64(remaining) / float64(loading.LoadedBytes))
+		}
+	}
+
+	info.WriteString("loading:1\r\n")
+	info.WriteString("loading_start_time:" + strconv.FormatInt(loading.StartTime.Unix(), 10) + "\r\n")
+	info.WriteString("loading_total_bytes:" + strconv.FormatInt(loading.TotalBytes, 10) + "\r\n")
+	info.WriteString("loading_loaded_bytes:" + strconv.FormatInt(loading.LoadedBytes, 10) + "\r\n")
+	info.WriteString("loading_loaded_perc:" + strconv.FormatFloat(percent, 'f', 2, 64) + "\r\n")
+	info.WriteString("loading_eta_seconds:" + strconv.Itoa(eta) + "\r\n")
+	info.WriteString("\r\n")
+}
+
+// writeClientsInfo writes the clients section
+func (c *InfoCommand) writeClientsInfo(ctx Context, info *strings.Builder) {
+	provider, ok := ctx.Server.(clientsInfoProvider)
+	if !ok {
+		return
+	}
+
+	info.WriteString("# Clients\r\n")
+	for _, field := range provider.ClientsInfo() {
+		info.WriteString(field.Name + ":" + field.Value + "\r\n")
+	}
+	info.WriteString("\r\n")
+}
+
+// writeStatsInfo writes the stats section
+func (c *InfoCommand) writeStatsInfo(ctx Context, info *strings.Builder) {
+	provider, ok := ctx.Server.(statsProvider)
+	if !ok {
+		return
+	}
+
+	info.WriteString("# Stats\r\n")
+	for _, field := range provider.StatsInfo() {
+		info.WriteString(field.Name + ":" + field.Value + "\r\n")
+	}
+	info.WriteString("\r\n")
+}
+
+// writeCommandStatsInfo writes the per-command call counts and latency
+func (c *InfoCommand) writeCommandStatsInfo(ctx Context, info *strings.Builder) {
+	if ctx.CommandStats == nil {
+		return
+	}
+
+	info.WriteString("# Commandstats\r\n")
+	for _, field := range ctx.CommandStats.Info() {
+		info.WriteString(field.Name + ":" + field.Value + "\r\n")
+	}
+	info.WriteString("\r\n")
+}
+
+// writeMasterLinkInfo writes the master link fields reported by replicas
+// and returns the replication offset reached
+func (c *InfoCommand) writeMasterLinkInfo(ctx Context, info *strings.Builder) int64 {
+	provider, ok := ctx.Server.(masterLinkProvider)
+	if !ok {
+		return 0
+	}
+
+	link, ok := provider.MasterLinkInfo()
+	if !ok {
+		return 0
+	}
+
+	linkStatus := "down"
+	if link.State == replication.StateConnected {
+		linkStatus = "up"
+	}
+	syncInProgress := boolFlag(link.State == replication.StateSync)
+
+	info.WriteString("master_host:" + link.MasterHost + "\r\n")
+	info.WriteString("master_port:" + link.MasterPort + "\r\n")
+	info.WriteString("master_link_status:" + linkStatus + "\r\n")
+	info.WriteString("master_last_io_seconds_ago:" + strconv.Itoa(link.LastIOSecondsAgo) + "\r\n")
+	info.WriteString("master_sync_in_progress:" + syncInProgress + "\r\n")
+	info.WriteString("slave_repl_offset:" + strconv.FormatInt(link.Offset, 10) + "\r\n")
+	if link.State != replication.StateConnected {
+		info.WriteString("master_link_down_since_seconds:" + strconv.Itoa(link.DownSeconds) + "\r\n")
+	}
+	return link.Offset
+}
+
+// boolFlag formats a boolean as the 0/1 used by INFO fields
+func boolFlag(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
+
 // getMasterReplID returns the master replication ID
 func (c *InfoCommand) getMasterReplID() string {
 	// Fixed replication ID for now
@@ -69,3 +322,8 @@ func (c *InfoCommand) MinArgs() int {
 func (c *InfoCommand) MaxArgs() int {
 	return 1
 }
+
+// Flags returns the command flags
+func (c *InfoCommand) Flags() Flags {
+	return FlagLoading
+}
@@ -1,9 +1,14 @@
 package commands
 
 import (
+	"github.com/codecrafters-redis-go/internal/blocking"
+	"github.com/codecrafters-redis-go/internal/clock"
 	"github.com/codecrafters-redis-go/internal/config"
+	"github.com/codecrafters-redis-go/internal/pubsub"
 	"github.com/codecrafters-redis-go/internal/resp"
 	"github.com/codecrafters-redis-go/internal/storage"
+	"github.com/codecrafters-redis-go/internal/tracing"
+	"github.com/codecrafters-redis-go/internal/tracking"
 )
 
 // ServerAccessor provides access to server functionality without circular dependency
@@ -24,14 +29,48 @@ type Command interface {
 
 	// MaxArgs returns the maximum number of arguments (-1 for unlimited)
 	MaxArgs() int
+
+	// Flags describes how the command behaves
+	Flags() Flags
+}
+
+// Flags describe a command's behavior. Propagation, AOF logging and the
+// admission checks all decide based on them.
+type Flags uint32
+
+const (
+	FlagWrite            Flags = 1 << iota // Modifies the dataset, propagated to replicas and the AOF
+	FlagReadOnly                           // Only reads the dataset
+	FlagAdmin                              // Administrative command
+	FlagPubSub                             // Pub/Sub related command
+	FlagBlocking                           // May block waiting on other clients
+	FlagDenyOOM                            // May grow memory, rejected above maxmemory
+	FlagLoading                            // Allowed while the dataset is loading
+	FlagNonDeterministic                   // Effect depends on time or randomness, must propagate a rewritten form
+)
+
+// Has returns true if all of flag are set
+func (flags Flags) Has(flag Flags) bool {
+	return flags&flag == flag
 }
 
 // Context provides shared resources to commands
 type Context struct {
 	Storage       *storage.Storage
 	Config        *config.Config
-	PropagateFunc func(resp.Value) // Function to propagate commands to replicas
-	Server        ServerAccessor   // Access to server functions
+	PropagateFunc func(resp.Value)  // Function to propagate commands to replicas
+	Server        ServerAccessor    // Access to server functions
+	CommandStats  *CommandStats     // Per-command call counts and latency
+	Slowlog       *Slowlog          // Recent slow commands
+	Call          *Call             // The invocation being executed
+	Blocking      *blocking.Manager // Clients blocked on keys or replication offsets
+	PubSub        *pubsub.Hub       // Channel and pattern subscriptions
+	Notifier      *pubsub.Notifier  // Publishes keyspace events
+	Pause         *Pause            // Holds client commands during CLIENT PAUSE and failovers
+	Tracking      *tracking.Table   // Prefixes tracked for client side caching
+	Commands      *Registry         // The registered commands, for COMMAND
+	Tracer        tracing.Tracer    // Records a span per command, tracing.Noop unless configured
+	Clock         clock.Clock       // Tells the time relative expiries and stream IDs use
 }
 
 // Validator provides argument validation for commands
@@ -39,5 +78,6 @@ type Validator interface {
 	Validate(args []string) error
 }
 
-// Middleware represents a command middleware function
+// Middleware represents a command middleware function. A middleware wraps a
+// command and may reject the call, inspect ctx.Call or post-process the reply.
 type Middleware func(Command) Command
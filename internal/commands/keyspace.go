@@ -0,0 +1,326 @@
+package commands
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/errors"
+	"github.com/codecrafters-redis-go/internal/pubsub"
+	"github.com/codecrafters-redis-go/internal/resp"
+	"github.com/codecrafters-redis-go/internal/storage"
+	"github.com/codecrafters-redis-go/internal/utils"
+)
+
+// ExistsCommand implements the EXISTS command
+type ExistsCommand struct{}
+
+// NewExistsCommand creates a new EXISTS command
+func NewExistsCommand() *ExistsCommand {
+	return &ExistsCommand{}
+}
+
+// Name returns the command name
+func (c *ExistsCommand) Name() string {
+	return "EXISTS"
+}
+
+// Execute runs the EXISTS command, replying how many of the keys exist. A
+// key given more than once is counted every time.
+func (c *ExistsCommand) Execute(ctx Context, args []string) resp.Value {
+	return resp.IntegerValue(int64(ctx.Storage.Exists(args...)))
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *ExistsCommand) MinArgs() int {
+	return 1
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *ExistsCommand) MaxArgs() int {
+	return -1
+}
+
+// Flags returns the command flags
+func (c *ExistsCommand) Flags() Flags {
+	return FlagReadOnly
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *ExistsCommand) KeySpec() KeySpec {
+	return KeySpec{First: 0, Last: -1, Step: 1}
+}
+
+// TouchCommand implements the TOUCH command
+type TouchCommand struct{}
+
+// NewTouchCommand creates a new TOUCH command
+func NewTouchCommand() *TouchCommand {
+	return &TouchCommand{}
+}
+
+// Name returns the command name
+func (c *TouchCommand) Name() string {
+	return "TOUCH"
+}
+
+// Execute runs the TOUCH command, resetting the idle time of the keys and
+// replying how many of them exist. Unlike other commands, it touches the
+// keys even for a client in CLIENT NO-TOUCH mode.
+func (c *TouchCommand) Execute(ctx Context, args []string) resp.Value {
+	return resp.IntegerValue(int64(ctx.Storage.Touch(args...)))
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *TouchCommand) MinArgs() int {
+	return 1
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *TouchCommand) MaxArgs() int {
+	return -1
+}
+
+// Flags returns the command flags
+func (c *TouchCommand) Flags() Flags {
+	return FlagReadOnly
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *TouchCommand) KeySpec() KeySpec {
+	return KeySpec{First: 0, Last: -1, Step: 1}
+}
+
+// CopyCommand implements the COPY command
+type CopyCommand struct{}
+
+// NewCopyCommand creates a new COPY command
+func NewCopyCommand() *CopyCommand {
+	return &CopyCommand{}
+}
+
+// Name returns the command name
+func (c *CopyCommand) Name() string {
+	return "COPY"
+}
+
+// Execute runs the COPY command. The destination gets its own copy of the
+// value along with the TTL of the source, and the reply is 0 if the
+// source is missing or the destination exists without REPLACE.
+func (c *CopyCommand) Execute(ctx Context, args []string) resp.Value {
+	src, dst := args[0], args[1]
+
+	replace := false
+	for i := 2; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "REPLACE":
+			replace = true
+		case "DB":
+			if i+1 >= len(args) {
+				return resp.ErrorValue(errors.ErrSyntaxError.Error())
+			}
+			db, ok := utils.ParseInt64(args[i+1])
+			if !ok {
+				return resp.ErrorValue(errors.ErrNotInteger.Error())
+			}
+			// Only database 0 is served
+			if db != 0 {
+				return resp.ErrorValue("ERR DB index is out of range")
+			}
+			i++ // Skip the next argument
+		default:
+			return resp.ErrorValue(errors.ErrSyntaxError.Error())
+		}
+	}
+	if src == dst {
+		return resp.ErrorValue("ERR source and destination objects are the same")
+	}
+
+	copied, created := ctx.Storage.Copy(src, dst, replace)
+	if !copied {
+		return resp.IntegerValue(0)
+	}
+
+	ctx.markDirty(1)
+	ctx.signalModifiedKey(dst)
+	if created {
+		ctx.notifyKeyspaceEvent(pubsub.ClassNew, "new", dst)
+	}
+	ctx.notifyKeyspaceEvent(pubsub.ClassGeneric, "copy_to", dst)
+	return resp.IntegerValue(1)
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *CopyCommand) MinArgs() int {
+	return 2
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *CopyCommand) MaxArgs() int {
+	return -1
+}
+
+// Flags returns the command flags
+func (c *CopyCommand) Flags() Flags {
+	return FlagWrite | FlagDenyOOM
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *CopyCommand) KeySpec() KeySpec {
+	return KeySpec{First: 0, Last: 1, Step: 1}
+}
+
+// flushNotifier is implemented by servers that refuse to save a dataset that
+// failed to load, which an explicit flush allows again
+type flushNotifier interface {
+	DatasetFlushed()
+}
+
+// FlushCommand implements FLUSHALL and FLUSHDB, which are the same with a
+// single database
+type FlushCommand struct {
+	name string
+}
+
+// NewFlushAllCommand creates a new FLUSHALL command
+func NewFlushAllCommand() *FlushCommand {
+	return &FlushCommand{name: "FLUSHALL"}
+}
+
+// NewFlushDBCommand creates a new FLUSHDB command
+func NewFlushDBCommand() *FlushCommand {
+	return &FlushCommand{name: "FLUSHDB"}
+}
+
+// Name returns the command name
+func (c *FlushCommand) Name() string {
+	return c.name
+}
+
+// Execute runs the command, removing every key. With ASYNC the old entries
+// are released in the background where the backend supports it.
+func (c *FlushCommand) Execute(ctx Context, args []string) resp.Value {
+	async := false
+	if len(args) == 1 {
+		switch strings.ToUpper(args[0]) {
+		case "ASYNC":
+			async = true
+		case "SYNC":
+		default:
+			return resp.ErrorValue(errors.ErrSyntaxError.Error())
+		}
+	}
+
+	var removed int
+	if async {
+		removed = ctx.Storage.FlushAsync()
+	} else {
+		removed = ctx.Storage.Flush()
+	}
+
+	if notifier, ok := ctx.Server.(flushNotifier); ok {
+		notifier.DatasetFlushed()
+	}
+
+	// Propagated even if the keyspace was already empty, like in Redis, so
+	// the AOF records every flush
+	ctx.markDirty(max(removed, 1))
+	if ctx.Tracking != nil {
+		ctx.Tracking.InvalidateAll()
+	}
+	return resp.SimpleStringValue("OK")
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *FlushCommand) MinArgs() int {
+	return 0
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *FlushCommand) MaxArgs() int {
+	return 1
+}
+
+// Flags returns the command flags
+func (c *FlushCommand) Flags() Flags {
+	return FlagWrite
+}
+
+// ScanCommand implements the SCAN command
+type ScanCommand struct{}
+
+// NewScanCommand creates a new SCAN command
+func NewScanCommand() *ScanCommand {
+	return &ScanCommand{}
+}
+
+// Name returns the command name
+func (c *ScanCommand) Name() string {
+	return "SCAN"
+}
+
+// maxScanCount caps the COUNT hint, so one call can't hold a reply for a
+// huge share of the keyspace
+const maxScanCount = 1 << 20
+
+// Execute runs the SCAN command. It replies the cursor to continue from, 0
+// once the keyspace was walked, and the keys visited on the way that match
+// the MATCH pattern and TYPE. COUNT is a hint of how many keys to visit, so
+// a call may reply fewer keys, or none, before the walk is over.
+func (c *ScanCommand) Execute(ctx Context, args []string) resp.Value {
+	cursor, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return resp.ErrorValue("ERR invalid cursor")
+	}
+
+	pattern, typeName := "*", ""
+	count := int64(10)
+	for i := 1; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			return resp.ErrorValue(errors.ErrSyntaxError.Error())
+		}
+		switch value := args[i+1]; strings.ToUpper(args[i]) {
+		case "MATCH":
+			pattern = value
+		case "COUNT":
+			n, ok := utils.ParseInt64(value)
+			if !ok {
+				return resp.ErrorValue(errors.ErrNotInteger.Error())
+			}
+			if n < 1 {
+				return resp.ErrorValue(errors.ErrSyntaxError.Error())
+			}
+			count = n
+		case "TYPE":
+			typeName = value
+		default:
+			return resp.ErrorValue(errors.ErrSyntaxError.Error())
+		}
+	}
+
+	keys := []resp.Value{}
+	next := ctx.Storage.Scan(cursor, int(min(count, int64(maxScanCount))), func(key string, value interface{}, expiry *time.Time) {
+		if typeName != "" && !strings.EqualFold(storage.TypeOf(value), typeName) {
+			return
+		}
+		if pattern == "*" || utils.MatchPattern(pattern, key) {
+			keys = append(keys, resp.BulkStringValue(key))
+		}
+	})
+	return resp.ArrayValue(resp.BulkStringValue(strconv.FormatUint(next, 10)), resp.ArrayValue(keys...))
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *ScanCommand) MinArgs() int {
+	return 1
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *ScanCommand) MaxArgs() int {
+	return -1
+}
+
+// Flags returns the command flags
+func (c *ScanCommand) Flags() Flags {
+	return FlagReadOnly
+}
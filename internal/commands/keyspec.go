@@ -0,0 +1,68 @@
+package commands
+
+// KeySpec says which arguments of a command are keys. Positions index the
+// arguments after the command name; COMMAND INFO reports them one higher,
+// counting the name. A negative Last counts back from the end, -1 being the
+// last argument. Commands whose keys can't be described by a range, like
+// EVAL with its numkeys or XREAD with keys after STREAMS, set Find instead.
+type KeySpec struct {
+	First int
+	Last  int
+	Step  int
+	Find  func(args []string) []int // Positions of the keys in args
+}
+
+// KeyedCommand is implemented by commands taking key arguments. COMMAND
+// GETKEYS and everything else that needs the keys of a call go through
+// Keys, so they all agree on what the keys are.
+type KeyedCommand interface {
+	KeySpec() KeySpec
+}
+
+// singleKey is the spec of commands whose first argument is their only key
+var singleKey = KeySpec{First: 0, Last: 0, Step: 1}
+
+// Movable returns true if the key positions depend on the arguments
+func (spec KeySpec) Movable() bool {
+	return spec.Find != nil
+}
+
+// positions returns the positions of the keys in args
+func (spec KeySpec) positions(args []string) []int {
+	if spec.Find != nil {
+		return spec.Find(args)
+	}
+
+	last := spec.Last
+	if last < 0 {
+		last += len(args)
+	}
+	if last >= len(args) {
+		last = len(args) - 1
+	}
+	step := spec.Step
+	if step <= 0 {
+		step = 1
+	}
+	var positions []int
+	for i := spec.First; i <= last; i += step {
+		positions = append(positions, i)
+	}
+	return positions
+}
+
+// Keys returns the key arguments of a call to cmd with args, or nil if cmd
+// takes no keys
+func Keys(cmd Command, args []string) []string {
+	keyed, ok := cmd.(KeyedCommand)
+	if !ok {
+		return nil
+	}
+	var keys []string
+	for _, i := range keyed.KeySpec().positions(args) {
+		if i >= 0 && i < len(args) {
+			keys = append(keys, args[i])
+		}
+	}
+	return keys
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codecrafters-redis-go/internal/resp"
+)
+
+// LatencyCommand implements the LATENCY command. Only the latency histograms
+// of the commands are kept, there is no latency monitor.
+type LatencyCommand struct{}
+
+// NewLatencyCommand creates a new LATENCY command
+func NewLatencyCommand() *LatencyCommand {
+	return &LatencyCommand{}
+}
+
+// Name returns the command name
+func (c *LatencyCommand) Name() string {
+	return "LATENCY"
+}
+
+// Execute runs the LATENCY command
+func (c *LatencyCommand) Execute(ctx Context, args []string) resp.Value {
+	switch strings.ToUpper(args[0]) {
+	case "HISTOGRAM":
+		return latencyHistograms(ctx, args[1:])
+	case "HELP":
+		return resp.ArrayValue(
+			resp.SimpleStringValue("LATENCY <subcommand> [<arg> [value] [opt] ...]. Subcommands are:"),
+			resp.SimpleStringValue("HISTOGRAM [COMMAND ...]"),
+			resp.SimpleStringValue("    Return a cumulative distribution of latencies in the format of a histogram for the specified command names."),
+			resp.SimpleStringValue("    If no commands are specified then all histograms are replied."),
+			resp.SimpleStringValue("HELP"),
+			resp.SimpleStringValue("    Print this help."),
+		)
+	default:
+		return resp.ErrorValue(fmt.Sprintf("ERR unknown subcommand '%s'. Try LATENCY HELP.", args[0]))
+	}
+}
+
+// latencyHistograms returns the LATENCY HISTOGRAM map: for each command its
+// calls and histogram_usec, a map from bucket upper bounds to the number of
+// calls that took at most that long
+func latencyHistograms(ctx Context, names []string) resp.Value {
+	var commands []resp.Value
+	for _, histogram := range ctx.CommandStats.Histograms(names) {
+		buckets := make([]resp.Value, 0, 2*len(histogram.Buckets))
+		for _, bucket := range histogram.Buckets {
+			buckets = append(buckets, resp.IntegerValue(bucket.Usec), resp.IntegerValue(bucket.Calls))
+		}
+		commands = append(commands,
+			resp.BulkStringValue(histogram.Name),
+			resp.MapValue(
+				resp.BulkStringValue("calls"), resp.IntegerValue(histogram.Calls),
+				resp.BulkStringValue("histogram_usec"), resp.MapValue(buckets...),
+			),
+		)
+	}
+	return resp.MapValue(commands...)
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *LatencyCommand) MinArgs() int {
+	return 1
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *LatencyCommand) MaxArgs() int {
+	return -1
+}
+
+// Flags returns the command flags
+func (c *LatencyCommand) Flags() Flags {
+	return FlagAdmin | FlagLoading
+}
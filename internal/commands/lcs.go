@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"math"
+	"strings"
+
+	"github.com/codecrafters-redis-go/internal/errors"
+	"github.com/codecrafters-redis-go/internal/resp"
+	"github.com/codecrafters-redis-go/internal/utils"
+)
+
+// LCSCommand implements the LCS command
+type LCSCommand struct{}
+
+// NewLCSCommand creates a new LCS command
+func NewLCSCommand() *LCSCommand {
+	return &LCSCommand{}
+}
+
+// Name returns the command name
+func (c *LCSCommand) Name() string {
+	return "LCS"
+}
+
+// Execute runs the LCS command. It replies the longest common subsequence
+// of the two strings, its length with LEN, or with IDX the ranges of both
+// strings it was matched at, last match first.
+func (c *LCSCommand) Execute(ctx Context, args []string) resp.Value {
+	var getLen, getIdx, withMatchLen bool
+	var minMatchLen int64
+	for i := 2; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "LEN":
+			getLen = true
+		case "IDX":
+			getIdx = true
+		case "WITHMATCHLEN":
+			withMatchLen = true
+		case "MINMATCHLEN":
+			if i+1 >= len(args) {
+				return resp.ErrorValue(errors.ErrSyntaxError.Error())
+			}
+			n, ok := utils.ParseInt64(args[i+1])
+			if !ok {
+				return resp.ErrorValue(errors.ErrNotInteger.Error())
+			}
+			minMatchLen = max(n, 0)
+			i++ // Skip the next argument
+		default:
+			return resp.ErrorValue(errors.ErrSyntaxError.Error())
+		}
+	}
+	if getLen && getIdx {
+		return resp.ErrorValue("ERR If you want both the length and indexes, please just use IDX.")
+	}
+
+	// Missing keys compare as empty strings
+	a, _, errA := ctx.lookupString(args[0])
+	b, _, errB := ctx.lookupString(args[1])
+	if errA != nil || errB != nil {
+		return resp.ErrorValue("ERR The specified keys must hold string values")
+	}
+	if uint64(len(a)+1)*uint64(len(b)+1) > math.MaxInt/4 {
+		return resp.ErrorValue("ERR String too long for LCS")
+	}
+
+	table := newLCSTable(a, b)
+	length := table.at(len(a), len(b))
+	if getLen {
+		return resp.IntegerValue(int64(length))
+	}
+
+	// Walk the table back from the end, collecting the subsequence and the
+	// ranges it is made of
+	result := make([]byte, length)
+	var matches []resp.Value
+	idx := length
+	noRange := len(a) // aStart holds it while no range is open
+	aStart, aEnd, bStart, bEnd := noRange, 0, 0, 0
+	for i, j := len(a), len(b); i > 0 && j > 0; {
+		emit := false
+		if a[i-1] == b[j-1] {
+			result[idx-1] = a[i-1]
+			switch {
+			case aStart == noRange:
+				aStart, aEnd, bStart, bEnd = i-1, i-1, j-1, j-1
+			case aStart == i && bStart == j:
+				// Contiguous, the range extends backward
+				aStart--
+				bStart--
+			default:
+				emit = true
+			}
+			// A range touching the start of either string is complete
+			if aStart == 0 || bStart == 0 {
+				emit = true
+			}
+			idx--
+			i--
+			j--
+		} else {
+			if table.at(i-1, j) > table.at(i, j-1) {
+				i--
+			} else {
+				j--
+			}
+			if aStart != noRange {
+				emit = true
+			}
+		}
+
+		if emit {
+			matchLen := aEnd - aStart + 1
+			if getIdx && (minMatchLen == 0 || int64(matchLen) >= minMatchLen) {
+				match := []resp.Value{
+					resp.ArrayValue(resp.IntegerValue(int64(aStart)), resp.IntegerValue(int64(aEnd))),
+					resp.ArrayValue(resp.IntegerValue(int64(bStart)), resp.IntegerValue(int64(bEnd))),
+				}
+				if withMatchLen {
+					match = append(match, resp.IntegerValue(int64(matchLen)))
+				}
+				matches = append(matches, resp.ArrayValue(match...))
+			}
+			aStart = noRange
+		}
+	}
+
+	if getIdx {
+		return resp.MapValue(
+			resp.BulkStringValue("matches"), resp.ArrayValue(matches...),
+			resp.BulkStringValue("len"), resp.IntegerValue(int64(length)),
+		)
+	}
+	return resp.BulkStringValue(string(result))
+}
+
+// lcsTable holds the length of the longest common subsequence of every
+// pair of prefixes of two strings
+type lcsTable struct {
+	lengths []uint32
+	width   int
+}
+
+// newLCSTable fills the table for a and b by dynamic programming
+func newLCSTable(a, b string) lcsTable {
+	table := lcsTable{lengths: make([]uint32, (len(a)+1)*(len(b)+1)), width: len(b) + 1}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			if a[i-1] == b[j-1] {
+				table.lengths[i*table.width+j] = table.at(i-1, j-1) + 1
+			} else {
+				table.lengths[i*table.width+j] = max(table.at(i-1, j), table.at(i, j-1))
+			}
+		}
+	}
+	return table
+}
+
+// at returns the subsequence length of the first i bytes of a and the
+// first j bytes of b
+func (table lcsTable) at(i, j int) uint32 {
+	return table.lengths[i*table.width+j]
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *LCSCommand) MinArgs() int {
+	return 2
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *LCSCommand) MaxArgs() int {
+	return -1
+}
+
+// Flags returns the command flags
+func (c *LCSCommand) Flags() Flags {
+	return FlagReadOnly
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *LCSCommand) KeySpec() KeySpec {
+	return KeySpec{First: 0, Last: 1, Step: 1}
+}
@@ -0,0 +1,943 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/codecrafters-redis-go/internal/blocking"
+	"github.com/codecrafters-redis-go/internal/errors"
+	"github.com/codecrafters-redis-go/internal/pubsub"
+	"github.com/codecrafters-redis-go/internal/resp"
+	"github.com/codecrafters-redis-go/internal/storage"
+	"github.com/codecrafters-redis-go/internal/utils"
+)
+
+// lookupList returns the list stored at key, or nil if the key is missing,
+// which counts as a key miss
+func lookupList(ctx Context, key string) (*storage.List, error) {
+	val, exists, err := ctx.lookupType(key, storage.TypeList)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		ctx.notifyKeyspaceEvent(pubsub.ClassKeyMiss, "keymiss", key)
+		return nil, nil
+	}
+	return val.(*storage.List), nil
+}
+
+// updateList runs fn on the list stored at key with the storage locked, then
+// converts its encoding if it outgrew it. fn isn't run for a missing key. It
+// reports whether the list was found, and whether fn emptied it, in which
+// case the key was deleted: Redis never keeps an empty list.
+func updateList(ctx Context, key string, fn func(list *storage.List) error) (found, deleted bool, err error) {
+	listpackSize := ctx.Config.GetEncodingLimits().ListListpackSize
+	err = ctx.Storage.Update(key, func(val interface{}, exists bool) (interface{}, error) {
+		if !exists {
+			return nil, nil
+		}
+		if err := storage.CheckType(val, storage.TypeList); err != nil {
+			return nil, err
+		}
+		list := val.(*storage.List)
+		found = true
+		if err := fn(list); err != nil {
+			return nil, err
+		}
+		if list.Len() == 0 {
+			deleted = true
+			return nil, nil
+		}
+		list.FitEncoding(listpackSize)
+		return list, nil
+	})
+	return found, deleted, err
+}
+
+// parseIndex parses a list index, which may be negative to count from the
+// tail
+func parseIndex(arg string) (int, error) {
+	n, ok := utils.ParseInt64(arg)
+	if !ok {
+		return 0, errors.ErrNotInteger
+	}
+	return int(n), nil
+}
+
+// PushCommand implements LPUSH and RPUSH, which push to the head and the
+// tail of the list, and LPUSHX and RPUSHX, which only push to an existing
+// list
+type PushCommand struct {
+	name     string
+	left     bool
+	existing bool
+}
+
+// NewLPushCommand creates a new LPUSH command
+func NewLPushCommand() *PushCommand {
+	return &PushCommand{name: "LPUSH", left: true}
+}
+
+// NewRPushCommand creates a new RPUSH command
+func NewRPushCommand() *PushCommand {
+	return &PushCommand{name: "RPUSH"}
+}
+
+// NewLPushXCommand creates a new LPUSHX command
+func NewLPushXCommand() *PushCommand {
+	return &PushCommand{name: "LPUSHX", left: true, existing: true}
+}
+
+// NewRPushXCommand creates a new RPUSHX command
+func NewRPushXCommand() *PushCommand {
+	return &PushCommand{name: "RPUSHX", existing: true}
+}
+
+// Name returns the command name
+func (c *PushCommand) Name() string {
+	return c.name
+}
+
+// Execute runs the command, pushing the elements one after the other. A
+// missing key gets a new list, or is left alone by LPUSHX and RPUSHX. It
+// replies the new length, 0 if nothing was pushed.
+func (c *PushCommand) Execute(ctx Context, args []string) resp.Value {
+	key := args[0]
+	values := args[1:]
+	listpackSize := ctx.Config.GetEncodingLimits().ListListpackSize
+
+	var length int
+	var created bool
+	err := ctx.Storage.Update(key, func(val interface{}, exists bool) (interface{}, error) {
+		list := storage.NewList()
+		switch {
+		case exists:
+			if err := storage.CheckType(val, storage.TypeList); err != nil {
+				return nil, err
+			}
+			list = val.(*storage.List)
+		case c.existing:
+			return nil, nil
+		}
+		if c.left {
+			length = list.PushLeft(values...)
+		} else {
+			length = list.PushRight(values...)
+		}
+		list.FitEncoding(listpackSize)
+		created = !exists
+		return list, nil
+	})
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+	if length == 0 {
+		return resp.IntegerValue(0)
+	}
+
+	ctx.markDirty(len(values))
+	ctx.signalModifiedKey(key)
+	ctx.signalKeyReady(key)
+	if created {
+		ctx.notifyKeyspaceEvent(pubsub.ClassNew, "new", key)
+	}
+	// LPUSHX and RPUSHX fire the same events as LPUSH and RPUSH
+	event := "rpush"
+	if c.left {
+		event = "lpush"
+	}
+	ctx.notifyKeyspaceEvent(pubsub.ClassList, event, key)
+	return resp.IntegerValue(int64(length))
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *PushCommand) MinArgs() int {
+	return 2
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *PushCommand) MaxArgs() int {
+	return -1
+}
+
+// Flags returns the command flags
+func (c *PushCommand) Flags() Flags {
+	return FlagWrite | FlagDenyOOM
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *PushCommand) KeySpec() KeySpec {
+	return singleKey
+}
+
+// PopCommand implements LPOP and RPOP, which pop from the head and the tail
+// of the list
+type PopCommand struct {
+	name string
+	left bool
+}
+
+// NewLPopCommand creates a new LPOP command
+func NewLPopCommand() *PopCommand {
+	return &PopCommand{name: "LPOP", left: true}
+}
+
+// NewRPopCommand creates a new RPOP command
+func NewRPopCommand() *PopCommand {
+	return &PopCommand{name: "RPOP"}
+}
+
+// Name returns the command name
+func (c *PopCommand) Name() string {
+	return c.name
+}
+
+// Execute runs the command. Without a count it replies the popped element,
+// or null for a missing key. With one it pops up to count elements and
+// replies them as an array, or a null array for a missing key.
+func (c *PopCommand) Execute(ctx Context, args []string) resp.Value {
+	key := args[0]
+	count := 1
+	if len(args) > 1 {
+		n, ok := utils.ParseInt64(args[1])
+		if !ok {
+			return resp.ErrorValue(errors.ErrNotInteger.Error())
+		}
+		if n < 0 {
+			return resp.ErrorValue(errors.ErrNotPositive.Error())
+		}
+		count = int(n)
+	}
+
+	var popped []string
+	found, deleted, err := updateList(ctx, key, func(list *storage.List) error {
+		if c.left {
+			popped = list.PopLeft(count)
+		} else {
+			popped = list.PopRight(count)
+		}
+		return nil
+	})
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+	if !found {
+		if len(args) > 1 {
+			return resp.NullArray()
+		}
+		return resp.NullBulkString()
+	}
+
+	if len(popped) > 0 {
+		ctx.markDirty(len(popped))
+		ctx.signalModifiedKey(key)
+		ctx.notifyKeyspaceEvent(pubsub.ClassList, strings.ToLower(c.name), key)
+		if deleted {
+			ctx.notifyKeyspaceEvent(pubsub.ClassGeneric, "del", key)
+		}
+	}
+	if len(args) > 1 {
+		return resp.BulkStringsValue(popped)
+	}
+	return resp.BulkStringValue(popped[0])
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *PopCommand) MinArgs() int {
+	return 1
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *PopCommand) MaxArgs() int {
+	return 2
+}
+
+// Flags returns the command flags
+func (c *PopCommand) Flags() Flags {
+	return FlagWrite
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *PopCommand) KeySpec() KeySpec {
+	return singleKey
+}
+
+// BlockingPopCommand implements BLPOP and BRPOP, which pop like LPOP and
+// RPOP from the first of the keys holding a non-empty list, or block until
+// one does
+type BlockingPopCommand struct {
+	name string
+	left bool
+}
+
+// NewBLPopCommand creates a new BLPOP command
+func NewBLPopCommand() *BlockingPopCommand {
+	return &BlockingPopCommand{name: "BLPOP", left: true}
+}
+
+// NewBRPopCommand creates a new BRPOP command
+func NewBRPopCommand() *BlockingPopCommand {
+	return &BlockingPopCommand{name: "BRPOP"}
+}
+
+// Name returns the command name
+func (c *BlockingPopCommand) Name() string {
+	return c.name
+}
+
+// Execute runs the command. It replies the key and the element popped from
+// it, or a null array once the timeout in seconds expires; 0 blocks forever.
+// Clients blocked on the same key are served in the order they blocked. The
+// pop propagates as LPOP or RPOP, so replicas never block.
+func (c *BlockingPopCommand) Execute(ctx Context, args []string) resp.Value {
+	keys := args[:len(args)-1]
+	timeout, err := parseBlockingTimeout(args[len(args)-1])
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+	for _, key := range keys {
+		if _, _, err := ctx.lookupType(key, storage.TypeList); err != nil {
+			return resp.ErrorValue(err.Error())
+		}
+	}
+
+	popName, event := "RPOP", "rpop"
+	if c.left {
+		popName, event = "LPOP", "lpop"
+	}
+	var served resp.Value
+	try := func(key string) bool {
+		var popped []string
+		_, deleted, err := updateList(ctx, key, func(list *storage.List) error {
+			if c.left {
+				popped = list.PopLeft(1)
+			} else {
+				popped = list.PopRight(1)
+			}
+			return nil
+		})
+		// A key may have been overwritten with another type meanwhile
+		if err != nil || len(popped) == 0 {
+			return false
+		}
+
+		ctx.markDirty(1)
+		ctx.replicateAs(popName, key)
+		ctx.signalModifiedKey(key)
+		ctx.notifyKeyspaceEvent(pubsub.ClassList, event, key)
+		if deleted {
+			ctx.notifyKeyspaceEvent(pubsub.ClassGeneric, "del", key)
+		}
+		served = resp.ArrayValue(resp.BulkStringValue(key), resp.BulkStringValue(popped[0]))
+		return true
+	}
+
+	if ctx.Blocking == nil {
+		// Nothing could wake the call, so it only tries once
+		for _, key := range keys {
+			if try(key) {
+				return served
+			}
+		}
+		return resp.NullArray()
+	}
+
+	unblock := markBlocked(ctx)
+	outcome := ctx.Blocking.BlockOnKeys(keys, timeout, ctx.Done(), try)
+	unblock()
+	if outcome == blocking.Served {
+		return served
+	}
+	return blockedReply(outcome, resp.NullArray())
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *BlockingPopCommand) MinArgs() int {
+	return 2
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *BlockingPopCommand) MaxArgs() int {
+	return -1
+}
+
+// Flags returns the command flags
+func (c *BlockingPopCommand) Flags() Flags {
+	return FlagWrite | FlagBlocking
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *BlockingPopCommand) KeySpec() KeySpec {
+	return KeySpec{First: 0, Last: -2, Step: 1}
+}
+
+// MoveCommand implements LMOVE, and RPOPLPUSH, which is LMOVE from the tail
+// of the source to the head of the destination
+type MoveCommand struct {
+	name string
+	ends bool // The ends to move between are arguments
+}
+
+// NewLMoveCommand creates a new LMOVE command
+func NewLMoveCommand() *MoveCommand {
+	return &MoveCommand{name: "LMOVE", ends: true}
+}
+
+// NewRPopLPushCommand creates a new RPOPLPUSH command
+func NewRPopLPushCommand() *MoveCommand {
+	return &MoveCommand{name: "RPOPLPUSH"}
+}
+
+// Name returns the command name
+func (c *MoveCommand) Name() string {
+	return c.name
+}
+
+// parseEnd parses LEFT or RIGHT, returning true for LEFT
+func parseEnd(arg string) (bool, error) {
+	switch strings.ToUpper(arg) {
+	case "LEFT":
+		return true, nil
+	case "RIGHT":
+		return false, nil
+	}
+	return false, errors.ErrSyntaxError
+}
+
+// Execute runs the command, popping an element from the source and pushing
+// it to the destination in one step, which creates the destination if it is
+// missing. It replies the element, or null if the source is missing. With
+// the same key for both, the list is rotated.
+func (c *MoveCommand) Execute(ctx Context, args []string) resp.Value {
+	src, dst := args[0], args[1]
+	fromLeft, toLeft := false, true
+	if c.ends {
+		var err error
+		if fromLeft, err = parseEnd(args[2]); err != nil {
+			return resp.ErrorValue(err.Error())
+		}
+		if toLeft, err = parseEnd(args[3]); err != nil {
+			return resp.ErrorValue(err.Error())
+		}
+	}
+
+	listpackSize := ctx.Config.GetEncodingLimits().ListListpackSize
+	move := func(from, to *storage.List) string {
+		var popped []string
+		if fromLeft {
+			popped = from.PopLeft(1)
+		} else {
+			popped = from.PopRight(1)
+		}
+		if toLeft {
+			to.PushLeft(popped[0])
+		} else {
+			to.PushRight(popped[0])
+		}
+		to.FitEncoding(listpackSize)
+		return popped[0]
+	}
+
+	var value string
+	var found, created, deleted bool
+	var err error
+	if src == dst {
+		found, _, err = updateList(ctx, src, func(list *storage.List) error {
+			value = move(list, list)
+			return nil
+		})
+	} else {
+		err = ctx.Storage.UpdateMany([]string{src, dst}, func(values []interface{}) ([]interface{}, error) {
+			if values[0] == nil {
+				return values, nil
+			}
+			if err := storage.CheckType(values[0], storage.TypeList); err != nil {
+				return nil, err
+			}
+			from := values[0].(*storage.List)
+			to := storage.NewList()
+			if values[1] != nil {
+				if err := storage.CheckType(values[1], storage.TypeList); err != nil {
+					return nil, err
+				}
+				to = values[1].(*storage.List)
+			}
+
+			found, created = true, values[1] == nil
+			value = move(from, to)
+			if from.Len() == 0 {
+				deleted = true
+				return []interface{}{nil, to}, nil
+			}
+			from.FitEncoding(listpackSize)
+			return []interface{}{from, to}, nil
+		})
+	}
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+	if !found {
+		return resp.NullBulkString()
+	}
+
+	ctx.markDirty(1)
+	ctx.signalModifiedKey(dst)
+	ctx.signalKeyReady(dst)
+	if created {
+		ctx.notifyKeyspaceEvent(pubsub.ClassNew, "new", dst)
+	}
+	pushEvent, popEvent := "rpush", "rpop"
+	if toLeft {
+		pushEvent = "lpush"
+	}
+	if fromLeft {
+		popEvent = "lpop"
+	}
+	ctx.notifyKeyspaceEvent(pubsub.ClassList, pushEvent, dst)
+	ctx.notifyKeyspaceEvent(pubsub.ClassList, popEvent, src)
+	if deleted {
+		ctx.notifyKeyspaceEvent(pubsub.ClassGeneric, "del", src)
+	}
+	ctx.signalModifiedKey(src)
+	return resp.BulkStringValue(value)
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *MoveCommand) MinArgs() int {
+	if c.ends {
+		return 4
+	}
+	return 2
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *MoveCommand) MaxArgs() int {
+	return c.MinArgs()
+}
+
+// Flags returns the command flags
+func (c *MoveCommand) Flags() Flags {
+	return FlagWrite | FlagDenyOOM
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *MoveCommand) KeySpec() KeySpec {
+	return KeySpec{First: 0, Last: 1, Step: 1}
+}
+
+// LLenCommand implements the LLEN command
+type LLenCommand struct{}
+
+// NewLLenCommand creates a new LLEN command
+func NewLLenCommand() *LLenCommand {
+	return &LLenCommand{}
+}
+
+// Name returns the command name
+func (c *LLenCommand) Name() string {
+	return "LLEN"
+}
+
+// Execute runs the LLEN command, replying 0 for a missing key
+func (c *LLenCommand) Execute(ctx Context, args []string) resp.Value {
+	list, err := lookupList(ctx, args[0])
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+	if list == nil {
+		return resp.IntegerValue(0)
+	}
+	return resp.IntegerValue(int64(list.Len()))
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *LLenCommand) MinArgs() int {
+	return 1
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *LLenCommand) MaxArgs() int {
+	return 1
+}
+
+// Flags returns the command flags
+func (c *LLenCommand) Flags() Flags {
+	return FlagReadOnly
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *LLenCommand) KeySpec() KeySpec {
+	return singleKey
+}
+
+// LRangeCommand implements the LRANGE command
+type LRangeCommand struct{}
+
+// NewLRangeCommand creates a new LRANGE command
+func NewLRangeCommand() *LRangeCommand {
+	return &LRangeCommand{}
+}
+
+// Name returns the command name
+func (c *LRangeCommand) Name() string {
+	return "LRANGE"
+}
+
+// Execute runs the LRANGE command. It replies the elements from start to
+// stop inclusive, negative indexes counting from the tail; a range beyond
+// the ends is clamped to the list.
+func (c *LRangeCommand) Execute(ctx Context, args []string) resp.Value {
+	start, err := parseIndex(args[1])
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+	stop, err := parseIndex(args[2])
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+
+	list, err := lookupList(ctx, args[0])
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+	if list == nil {
+		return resp.ArrayValue()
+	}
+	return resp.BulkStringsValue(list.Range(start, stop))
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *LRangeCommand) MinArgs() int {
+	return 3
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *LRangeCommand) MaxArgs() int {
+	return 3
+}
+
+// Flags returns the command flags
+func (c *LRangeCommand) Flags() Flags {
+	return FlagReadOnly
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *LRangeCommand) KeySpec() KeySpec {
+	return singleKey
+}
+
+// LIndexCommand implements the LINDEX command
+type LIndexCommand struct{}
+
+// NewLIndexCommand creates a new LINDEX command
+func NewLIndexCommand() *LIndexCommand {
+	return &LIndexCommand{}
+}
+
+// Name returns the command name
+func (c *LIndexCommand) Name() string {
+	return "LINDEX"
+}
+
+// Execute runs the LINDEX command, replying null for a missing key or an
+// index out of range
+func (c *LIndexCommand) Execute(ctx Context, args []string) resp.Value {
+	index, err := parseIndex(args[1])
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+
+	list, err := lookupList(ctx, args[0])
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+	if list == nil {
+		return resp.NullBulkString()
+	}
+	value, ok := list.Index(index)
+	if !ok {
+		return resp.NullBulkString()
+	}
+	return resp.BulkStringValue(value)
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *LIndexCommand) MinArgs() int {
+	return 2
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *LIndexCommand) MaxArgs() int {
+	return 2
+}
+
+// Flags returns the command flags
+func (c *LIndexCommand) Flags() Flags {
+	return FlagReadOnly
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *LIndexCommand) KeySpec() KeySpec {
+	return singleKey
+}
+
+// LSetCommand implements the LSET command
+type LSetCommand struct{}
+
+// NewLSetCommand creates a new LSET command
+func NewLSetCommand() *LSetCommand {
+	return &LSetCommand{}
+}
+
+// Name returns the command name
+func (c *LSetCommand) Name() string {
+	return "LSET"
+}
+
+// Execute runs the LSET command, replacing the element at index. Unlike
+// other list commands it fails on a missing key.
+func (c *LSetCommand) Execute(ctx Context, args []string) resp.Value {
+	key := args[0]
+	index, err := parseIndex(args[1])
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+
+	found, _, err := updateList(ctx, key, func(list *storage.List) error {
+		if !list.Set(index, args[2]) {
+			return errors.ErrIndexOutOfRange
+		}
+		return nil
+	})
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+	if !found {
+		return resp.ErrorValue(errors.ErrNoSuchKey.Error())
+	}
+
+	ctx.markDirty(1)
+	ctx.signalModifiedKey(key)
+	ctx.notifyKeyspaceEvent(pubsub.ClassList, "lset", key)
+	return resp.SimpleStringValue("OK")
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *LSetCommand) MinArgs() int {
+	return 3
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *LSetCommand) MaxArgs() int {
+	return 3
+}
+
+// Flags returns the command flags
+func (c *LSetCommand) Flags() Flags {
+	return FlagWrite | FlagDenyOOM
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *LSetCommand) KeySpec() KeySpec {
+	return singleKey
+}
+
+// LInsertCommand implements the LINSERT command
+type LInsertCommand struct{}
+
+// NewLInsertCommand creates a new LINSERT command
+func NewLInsertCommand() *LInsertCommand {
+	return &LInsertCommand{}
+}
+
+// Name returns the command name
+func (c *LInsertCommand) Name() string {
+	return "LINSERT"
+}
+
+// Execute runs the LINSERT command, inserting the element before or after
+// the first occurrence of the pivot. It replies the new length, -1 if the
+// pivot wasn't found, or 0 for a missing key.
+func (c *LInsertCommand) Execute(ctx Context, args []string) resp.Value {
+	key := args[0]
+	var before bool
+	switch strings.ToUpper(args[1]) {
+	case "BEFORE":
+		before = true
+	case "AFTER":
+	default:
+		return resp.ErrorValue(errors.ErrSyntaxError.Error())
+	}
+	pivot, value := args[2], args[3]
+
+	var length int
+	found, _, err := updateList(ctx, key, func(list *storage.List) error {
+		length = list.Insert(pivot, value, before)
+		return nil
+	})
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+	if !found {
+		return resp.IntegerValue(0)
+	}
+	if length < 0 {
+		return resp.IntegerValue(-1)
+	}
+
+	ctx.markDirty(1)
+	ctx.signalModifiedKey(key)
+	ctx.notifyKeyspaceEvent(pubsub.ClassList, "linsert", key)
+	return resp.IntegerValue(int64(length))
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *LInsertCommand) MinArgs() int {
+	return 4
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *LInsertCommand) MaxArgs() int {
+	return 4
+}
+
+// Flags returns the command flags
+func (c *LInsertCommand) Flags() Flags {
+	return FlagWrite | FlagDenyOOM
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *LInsertCommand) KeySpec() KeySpec {
+	return singleKey
+}
+
+// LRemCommand implements the LREM command
+type LRemCommand struct{}
+
+// NewLRemCommand creates a new LREM command
+func NewLRemCommand() *LRemCommand {
+	return &LRemCommand{}
+}
+
+// Name returns the command name
+func (c *LRemCommand) Name() string {
+	return "LREM"
+}
+
+// Execute runs the LREM command. It removes count occurrences of the
+// element from the head, -count from the tail if negative, or all of them if
+// zero, and replies how many it removed.
+func (c *LRemCommand) Execute(ctx Context, args []string) resp.Value {
+	key := args[0]
+	count, err := parseIndex(args[1])
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+
+	var removed int
+	_, deleted, err := updateList(ctx, key, func(list *storage.List) error {
+		removed = list.Remove(count, args[2])
+		return nil
+	})
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+	if removed == 0 {
+		return resp.IntegerValue(0)
+	}
+
+	ctx.markDirty(removed)
+	ctx.signalModifiedKey(key)
+	ctx.notifyKeyspaceEvent(pubsub.ClassList, "lrem", key)
+	if deleted {
+		ctx.notifyKeyspaceEvent(pubsub.ClassGeneric, "del", key)
+	}
+	return resp.IntegerValue(int64(removed))
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *LRemCommand) MinArgs() int {
+	return 3
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *LRemCommand) MaxArgs() int {
+	return 3
+}
+
+// Flags returns the command flags
+func (c *LRemCommand) Flags() Flags {
+	return FlagWrite
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *LRemCommand) KeySpec() KeySpec {
+	return singleKey
+}
+
+// LTrimCommand implements the LTRIM command
+type LTrimCommand struct{}
+
+// NewLTrimCommand creates a new LTRIM command
+func NewLTrimCommand() *LTrimCommand {
+	return &LTrimCommand{}
+}
+
+// Name returns the command name
+func (c *LTrimCommand) Name() string {
+	return "LTRIM"
+}
+
+// Execute runs the LTRIM command, keeping only the elements from start to
+// stop inclusive, which LRANGE would reply. A range outside the list
+// empties it.
+func (c *LTrimCommand) Execute(ctx Context, args []string) resp.Value {
+	key := args[0]
+	start, err := parseIndex(args[1])
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+	stop, err := parseIndex(args[2])
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+
+	var removed int
+	found, deleted, err := updateList(ctx, key, func(list *storage.List) error {
+		removed = list.Trim(start, stop)
+		return nil
+	})
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+	if !found {
+		return resp.SimpleStringValue("OK")
+	}
+
+	ctx.markDirty(removed)
+	ctx.signalModifiedKey(key)
+	ctx.notifyKeyspaceEvent(pubsub.ClassList, "ltrim", key)
+	if deleted {
+		ctx.notifyKeyspaceEvent(pubsub.ClassGeneric, "del", key)
+	}
+	return resp.SimpleStringValue("OK")
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *LTrimCommand) MinArgs() int {
+	return 3
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *LTrimCommand) MaxArgs() int {
+	return 3
+}
+
+// Flags returns the command flags
+func (c *LTrimCommand) Flags() Flags {
+	return FlagWrite
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *LTrimCommand) KeySpec() KeySpec {
+	return singleKey
+}
@@ -0,0 +1,90 @@
+package commands_test
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/storage"
+	"github.com/codecrafters-redis-go/pkg/redisserver"
+)
+
+// stalledBackend stalls a load after its first key until Release, or for
+// at most a second, as it holds the storage lock meanwhile
+type stalledBackend struct {
+	*storage.MemoryBackend
+	stallOnce   sync.Once
+	releaseOnce sync.Once
+	stalled     chan struct{} // Closed when the first key was loaded
+	release     chan struct{}
+}
+
+func (backend *stalledBackend) Set(key string, entry storage.Entry) {
+	backend.MemoryBackend.Set(key, entry)
+	backend.stallOnce.Do(func() {
+		close(backend.stalled)
+		select {
+		case <-backend.release:
+		case <-time.After(time.Second):
+		}
+	})
+}
+
+// Release lets the load go on
+func (backend *stalledBackend) Release() {
+	backend.releaseOnce.Do(func() { close(backend.release) })
+}
+
+// saveDataset writes an RDB file of n keys to dir
+func saveDataset(t *testing.T, dir string, n int) {
+	t.Helper()
+	cfg := redisserver.NewConfig()
+	cfg.Dir = dir
+	srv := redisserver.New(cfg)
+	for i := range n {
+		do(t, srv, "SET", "key:"+strconv.Itoa(i), "value")
+	}
+	do(t, srv, "SAVE")
+}
+
+// startLoading starts a server loading the RDB file in dir, stalled after
+// the first key. The server is stopped when the test ends.
+func startLoading(t *testing.T, dir string) (*redisserver.Server, *stalledBackend) {
+	t.Helper()
+	backend := &stalledBackend{
+		MemoryBackend: storage.NewMemoryBackend(),
+		stalled:       make(chan struct{}),
+		release:       make(chan struct{}),
+	}
+	cfg := redisserver.NewConfig()
+	cfg.Port = 0
+	cfg.Dir = dir
+	cfg.Save = ""
+	srv, err := redisserver.Start(cfg, redisserver.WithStorage(redisserver.NewStorageWithBackend(backend)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		backend.Release()
+		srv.Stop()
+	})
+	return srv, backend
+}
+
+func TestLoadingFromStart(t *testing.T) {
+	dir := t.TempDir()
+	saveDataset(t, dir, 100)
+
+	// Commands sent as soon as Start returns already see the load
+	srv, _ := startLoading(t, dir)
+	for _, args := range [][]string{{"GET", "key:1"}, {"SET", "key:1", "new"}} {
+		_, err := srv.Do(t.Context(), args...)
+		var reply redisserver.ReplyError
+		if !errors.As(err, &reply) || !strings.HasPrefix(string(reply), "LOADING") {
+			t.Errorf("%q during the load = %v, want a LOADING error", args, err)
+		}
+	}
+}
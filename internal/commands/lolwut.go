@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/codecrafters-redis-go/internal/errors"
+	"github.com/codecrafters-redis-go/internal/resp"
+	"github.com/codecrafters-redis-go/internal/utils"
+)
+
+// LolwutCommand implements the LOLWUT command. The generative art of the
+// Redis 5 and 6 versions is not drawn, every version prints the banner.
+type LolwutCommand struct{}
+
+// NewLolwutCommand creates a new LOLWUT command
+func NewLolwutCommand() *LolwutCommand {
+	return &LolwutCommand{}
+}
+
+// Name returns the command name
+func (c *LolwutCommand) Name() string {
+	return "LOLWUT"
+}
+
+// Execute runs LOLWUT [VERSION version]
+func (c *LolwutCommand) Execute(ctx Context, args []string) resp.Value {
+	if len(args) > 0 {
+		if len(args) != 2 || !strings.EqualFold(args[0], "VERSION") {
+			return resp.ErrorValue(errors.ErrSyntaxError.Error())
+		}
+		if version, ok := utils.ParseInt64(args[1]); !ok || version < 0 {
+			return resp.ErrorValue(errors.ErrNotInteger.Error())
+		}
+	}
+	return resp.VerbatimValue("txt", "Redis ver. "+serverVersion+"\n")
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *LolwutCommand) MinArgs() int {
+	return 0
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *LolwutCommand) MaxArgs() int {
+	return -1
+}
+
+// Flags returns the command flags
+func (c *LolwutCommand) Flags() Flags {
+	return FlagReadOnly
+}
@@ -0,0 +1,24 @@
+package commands
+
+// touches returns true if the call's reads count as key accesses, which
+// CLIENT NO-TOUCH turns off
+func (ctx Context) touches() bool {
+	return ctx.Call == nil || ctx.Call.Client == nil || !ctx.Call.Client.NoTouch
+}
+
+// lookup returns the value of key, stamping it as accessed unless the
+// client asked not to
+func (ctx Context) lookup(key string) (interface{}, bool) {
+	return ctx.Storage.Lookup(key, ctx.touches())
+}
+
+// lookupType returns the value of key like lookup, or ErrWrongType if it
+// holds a type other than want
+func (ctx Context) lookupType(key, want string) (interface{}, bool, error) {
+	return ctx.Storage.LookupType(key, want, ctx.touches())
+}
+
+// lookupString returns the string value of key like lookupType
+func (ctx Context) lookupString(key string) (string, bool, error) {
+	return ctx.Storage.LookupString(key, ctx.touches())
+}
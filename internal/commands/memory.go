@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/codecrafters-redis-go/internal/resp"
+)
+
+// MemoryCommand implements the MEMORY command
+type MemoryCommand struct{}
+
+// NewMemoryCommand creates a new MEMORY command
+func NewMemoryCommand() *MemoryCommand {
+	return &MemoryCommand{}
+}
+
+// Name returns the command name
+func (c *MemoryCommand) Name() string {
+	return "MEMORY"
+}
+
+// Execute runs the MEMORY command
+func (c *MemoryCommand) Execute(ctx Context, args []string) resp.Value {
+	switch strings.ToUpper(args[0]) {
+	case "STATS":
+		if len(args) != 1 {
+			return resp.ErrorValue("ERR wrong number of arguments for 'memory|stats' command")
+		}
+		return memoryStats(ctx)
+	case "PURGE":
+		if len(args) != 1 {
+			return resp.ErrorValue("ERR wrong number of arguments for 'memory|purge' command")
+		}
+		// Rebuild a sparse key index first so its buckets are garbage too
+		ctx.Storage.Compact()
+		debug.FreeOSMemory()
+		return resp.OK()
+	case "HELP":
+		return resp.ArrayValue(
+			resp.SimpleStringValue("MEMORY <subcommand> [<arg> [value] [opt] ...]. Subcommands are:"),
+			resp.SimpleStringValue("STATS"),
+			resp.SimpleStringValue("    Return information about the memory usage of the server."),
+			resp.SimpleStringValue("PURGE"),
+			resp.SimpleStringValue("    Compact the key index and return free memory to the operating system."),
+			resp.SimpleStringValue("HELP"),
+			resp.SimpleStringValue("    Print this help."),
+		)
+	default:
+		return resp.ErrorValue(fmt.Sprintf("ERR unknown subcommand '%s'. Try MEMORY HELP.", args[0]))
+	}
+}
+
+// memoryStats returns the MEMORY STATS map. The allocator fields describe
+// the Go heap: allocated is live objects, active the spans holding them and
+// resident what the heap keeps from the operating system.
+func memoryStats(ctx Context) resp.Value {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	index := ctx.Storage.IndexStats()
+
+	allocated := stats.HeapAlloc
+	active := stats.HeapInuse
+	resident := stats.HeapSys - stats.HeapReleased
+	bytesPerKey := int64(0)
+	if index.Keys > 0 {
+		bytesPerKey = int64(allocated) / int64(index.Keys)
+	}
+	// How much of its room the key index uses, 1 right after a compaction
+	indexUsage := 1.0
+	if index.Capacity > 0 {
+		indexUsage = ratio(uint64(index.Keys), uint64(index.Capacity))
+	}
+	lastCompaction := int64(0)
+	if !index.LastCompaction.IsZero() {
+		lastCompaction = index.LastCompaction.Unix()
+	}
+
+	field := func(name string, value resp.Value) []resp.Value {
+		return []resp.Value{resp.BulkStringValue(name), value}
+	}
+	var pairs []resp.Value
+	pairs = append(pairs, field("total.allocated", resp.IntegerValue(int64(allocated)))...)
+	pairs = append(pairs, field("keys.count", resp.IntegerValue(int64(index.Keys)))...)
+	pairs = append(pairs, field("keys.bytes-per-key", resp.IntegerValue(bytesPerKey))...)
+	pairs = append(pairs, field("keys.index-capacity", resp.IntegerValue(int64(index.Capacity)))...)
+	pairs = append(pairs, field("keys.index-usage", resp.DoubleValue(indexUsage))...)
+	pairs = append(pairs, field("keys.index-compactions", resp.IntegerValue(index.Compactions))...)
+	pairs = append(pairs, field("keys.index-last-compaction", resp.IntegerValue(lastCompaction))...)
+	pairs = append(pairs, field("allocator.allocated", resp.IntegerValue(int64(allocated)))...)
+	pairs = append(pairs, field("allocator.active", resp.IntegerValue(int64(active)))...)
+	pairs = append(pairs, field("allocator.resident", resp.IntegerValue(int64(resident)))...)
+	pairs = append(pairs, field("allocator-fragmentation.ratio", resp.DoubleValue(ratio(active, allocated)))...)
+	pairs = append(pairs, field("allocator-fragmentation.bytes", resp.IntegerValue(int64(active)-int64(allocated)))...)
+	pairs = append(pairs, field("fragmentation", resp.DoubleValue(ratio(resident, allocated)))...)
+	pairs = append(pairs, field("fragmentation.bytes", resp.IntegerValue(int64(resident)-int64(allocated)))...)
+	return resp.MapValue(pairs...)
+}
+
+// ratio returns a/b rounded to two decimals, or 0 if b is 0
+func ratio(a, b uint64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(int64(float64(a)/float64(b)*100+0.5)) / 100
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *MemoryCommand) MinArgs() int {
+	return 1
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *MemoryCommand) MaxArgs() int {
+	return -1
+}
+
+// Flags returns the command flags
+func (c *MemoryCommand) Flags() Flags {
+	return FlagReadOnly
+}
@@ -0,0 +1,328 @@
+package commands
+
+import (
+	"context"
+	"runtime/metrics"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/errors"
+	"github.com/codecrafters-redis-go/internal/logger"
+	"github.com/codecrafters-redis-go/internal/pubsub"
+	"github.com/codecrafters-redis-go/internal/resp"
+	"github.com/codecrafters-redis-go/internal/tracing"
+	"github.com/codecrafters-redis-go/internal/tracking"
+)
+
+// Call is a single command invocation flowing through the middleware pipeline.
+// A Call without a Client comes from the server itself (AOF replay or the
+// master link) and is trusted.
+type Call struct {
+	Client    *Client
+	Command   resp.Value
+	Name      string        // Upper-cased command name
+	Propagate bool          // Set for successful writes that must reach replicas and the AOF
+	Rewritten *resp.Value   // Deterministic form propagated instead of Command, see replicateAs
+	Effects   []resp.Value  // Extra commands propagated ahead of the call itself, see alsoPropagate
+	Dirty     int           // Keyspace changes made, only calls that changed something propagate
+	Duration  time.Duration // Time spent executing, set by TimingMiddleware
+
+	Ctx       context.Context // Done on shutdown, or if the client disconnects during a blocking call
+	ReadyKeys []string        // Keys that may unblock other clients once the call is propagated
+}
+
+// Propagated returns the command to send to replicas and the AOF: its
+// rewritten form if the command produced one, otherwise the command itself
+func (call *Call) Propagated() resp.Value {
+	if call.Rewritten != nil {
+		return *call.Rewritten
+	}
+	return call.Command
+}
+
+// Propagation returns every command the call propagates, in order: the
+// effects it queued, then its own form if it is a write that changed the
+// keyspace. Effects are propagated even if the call itself failed.
+func (call *Call) Propagation() []resp.Value {
+	if !call.Propagate {
+		return call.Effects
+	}
+	return append(call.Effects[:len(call.Effects):len(call.Effects)], call.Propagated())
+}
+
+// Transaction wraps commands in MULTI/EXEC when there is more than one, so
+// replicas and AOF replay apply them all or none
+func Transaction(commands []resp.Value) []resp.Value {
+	if len(commands) < 2 {
+		return commands
+	}
+	wrapped := make([]resp.Value, 0, len(commands)+2)
+	wrapped = append(wrapped, resp.ArrayValue(resp.BulkStringValue("MULTI")))
+	wrapped = append(wrapped, commands...)
+	return append(wrapped, resp.ArrayValue(resp.BulkStringValue("EXEC")))
+}
+
+// Done returns a channel closed once the call should give up: on shutdown,
+// when the client of a blocking call disconnects or when its deadline
+// passes. It is nil, never closed, for calls without a context.
+func (ctx Context) Done() <-chan struct{} {
+	if ctx.Call == nil || ctx.Call.Ctx == nil {
+		return nil
+	}
+	return ctx.Call.Ctx.Done()
+}
+
+// Err returns why the call should give up, or nil while it may go on.
+// Commands walking the whole keyspace check it between batches.
+func (ctx Context) Err() error {
+	if ctx.Call == nil || ctx.Call.Ctx == nil {
+		return nil
+	}
+	return ctx.Call.Ctx.Err()
+}
+
+// alsoPropagate queues a command to propagate with the call, for writes
+// beyond what the call's own form replays, e.g. keys evicted while it ran
+func (ctx Context) alsoPropagate(args ...string) {
+	if ctx.Call == nil {
+		return
+	}
+	ctx.Call.Effects = append(ctx.Call.Effects, commandValue(args))
+}
+
+// markDirty records that the call changed n keys. Writes that turn out to
+// be no-ops leave it at zero and are neither propagated nor counted towards
+// the save rules.
+func (ctx Context) markDirty(n int) {
+	if ctx.Call != nil {
+		ctx.Call.Dirty += n
+	}
+}
+
+// replicateAs records the form the call propagates as. Commands whose
+// effect depends on the clock or on randomness use it so replicas and AOF
+// replay apply exactly the same change, e.g. a relative expiry becomes an
+// absolute one.
+func (ctx Context) replicateAs(args ...string) {
+	if ctx.Call == nil {
+		return
+	}
+	rewritten := commandValue(args)
+	ctx.Call.Rewritten = &rewritten
+}
+
+// commandValue builds a command as an array of bulk strings
+func commandValue(args []string) resp.Value {
+	values := make([]resp.Value, len(args))
+	for i, arg := range args {
+		values[i] = resp.BulkStringValue(arg)
+	}
+	return resp.ArrayValue(values...)
+}
+
+// notifyKeyspaceEvent publishes a keyspace event if its class is enabled
+func (ctx Context) notifyKeyspaceEvent(class pubsub.EventClass, event, key string) {
+	if ctx.Notifier != nil {
+		ctx.Notifier.Notify(class, event, key)
+	}
+}
+
+// signalModifiedKey invalidates key for the clients tracking it. Every
+// write calls it for each key it changed.
+func (ctx Context) signalModifiedKey(key string) {
+	if ctx.Tracking == nil {
+		return
+	}
+	var origin *tracking.Client
+	if ctx.Call != nil && ctx.Call.Client != nil {
+		origin = ctx.Call.Client.Tracking
+	}
+	ctx.Tracking.Invalidate(key, origin)
+}
+
+// signalKeyReady records that key may now serve clients blocked on it. The
+// server wakes them after the call has been propagated, so their own writes
+// reach replicas and the AOF after the one that unblocked them.
+func (ctx Context) signalKeyReady(key string) {
+	if ctx.Call == nil {
+		if ctx.Blocking != nil {
+			ctx.Blocking.SignalKey(key)
+		}
+		return
+	}
+	ctx.Call.ReadyKeys = append(ctx.Call.ReadyKeys, key)
+}
+
+// middlewareCommand replaces the Execute method of the command it wraps
+type middlewareCommand struct {
+	Command
+	execute func(ctx Context, args []string) resp.Value
+}
+
+// Execute runs the wrapped execute function
+func (c middlewareCommand) Execute(ctx Context, args []string) resp.Value {
+	return c.execute(ctx, args)
+}
+
+// wrap returns next with its Execute replaced by execute
+func wrap(next Command, execute func(ctx Context, args []string) resp.Value) Command {
+	return middlewareCommand{Command: next, execute: execute}
+}
+
+// TracingMiddleware records a span per command with its name, key count,
+// reply size and error, if any. The command runs with the span in
+// ctx.Call.Ctx, so spans it starts from there are children of it.
+func TracingMiddleware() Middleware {
+	return func(next Command) Command {
+		return wrap(next, func(ctx Context, args []string) resp.Value {
+			if !tracing.Enabled(ctx.Tracer) {
+				return next.Execute(ctx, args)
+			}
+
+			attrs := []tracing.Attribute{
+				tracing.String("db.system.name", "redis"),
+				tracing.String("db.operation.name", ctx.Call.Name),
+			}
+			// next is wrapped by the middlewares inside this one, only the
+			// registered command knows its keys
+			if cmd, ok := ctx.Commands.GetCommand(ctx.Call.Name); ok {
+				attrs = append(attrs, tracing.Int("db.redis.key_count", len(Keys(cmd, args))))
+			}
+			if client := ctx.Call.Client; client != nil {
+				attrs = append(attrs, tracing.String("client.address", client.Addr), tracing.Int("db.redis.client_id", int(client.ID)))
+			}
+
+			parent := ctx.Call.Ctx
+			spanCtx, span := tracing.Start(parent, ctx.Tracer, ctx.Call.Name, attrs...)
+			ctx.Call.Ctx = spanCtx
+			response := next.Execute(ctx, args)
+			ctx.Call.Ctx = parent
+
+			// Streamed replies are only sized as they are written
+			if size, ok := replySize(response); ok {
+				span.SetAttributes(tracing.Int("db.redis.reply_size", size))
+			}
+			if response.Type == resp.Error {
+				code, _, _ := strings.Cut(response.Str, " ")
+				span.SetAttributes(tracing.String("db.response.status_code", code), tracing.String("error.type", code))
+				span.SetError(response.Str)
+			}
+			span.End()
+			return response
+		})
+	}
+}
+
+// replySize returns the encoded length of reply, or false if it holds a
+// streamed array
+func replySize(reply resp.Value) (int, bool) {
+	if reply.Stream != nil {
+		return 0, false
+	}
+	for _, element := range reply.Array {
+		if _, ok := replySize(element); !ok {
+			return 0, false
+		}
+	}
+	return resp.EncodedLen(reply), true
+}
+
+// AuthMiddleware rejects commands other than AUTH and HELLO, which can
+// authenticate as well, from unauthenticated clients while requirepass is set
+func AuthMiddleware() Middleware {
+	return func(next Command) Command {
+		return wrap(next, func(ctx Context, args []string) resp.Value {
+			client := ctx.Call.Client
+			if client != nil && !client.Authenticated && ctx.Config.GetRequirePass() != "" && ctx.Call.Name != "AUTH" && ctx.Call.Name != "HELLO" {
+				return resp.ErrorValue(errors.ErrNoAuth.Error())
+			}
+			return next.Execute(ctx, args)
+		})
+	}
+}
+
+// ReadOnlyReplicaMiddleware rejects writes from clients while the server is
+// a read-only replica. Writes arriving over the master link are applied.
+func ReadOnlyReplicaMiddleware() Middleware {
+	return func(next Command) Command {
+		return wrap(next, func(ctx Context, args []string) resp.Value {
+			if ctx.Call.Client != nil && next.Flags().Has(FlagWrite) && ctx.Config.IsReadOnlyReplica() {
+				return resp.ErrorValue(errors.ErrReadOnlyReplica.Error())
+			}
+			return next.Execute(ctx, args)
+		})
+	}
+}
+
+// OOMMiddleware rejects commands that may grow memory while used memory is
+// above maxmemory
+func OOMMiddleware() Middleware {
+	return func(next Command) Command {
+		return wrap(next, func(ctx Context, args []string) resp.Value {
+			if next.Flags().Has(FlagDenyOOM) {
+				if maxMemory := ctx.Config.GetMaxMemory(); maxMemory > 0 && usedMemory() > maxMemory {
+					return resp.ErrorValue(errors.ErrOOM.Error())
+				}
+			}
+			return next.Execute(ctx, args)
+		})
+	}
+}
+
+// usedMemory returns the bytes occupied by live heap objects
+func usedMemory() uint64 {
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
+
+// PropagationMiddleware marks successful writes that changed the keyspace
+// for propagation to replicas and the append only file
+func PropagationMiddleware() Middleware {
+	return func(next Command) Command {
+		return wrap(next, func(ctx Context, args []string) resp.Value {
+			response := next.Execute(ctx, args)
+			if response.Type != resp.Error && next.Flags().Has(FlagWrite) && ctx.Call.Dirty > 0 {
+				if next.Flags().Has(FlagNonDeterministic) && ctx.Call.Rewritten == nil {
+					// Replaying it verbatim would make replicas diverge
+					logger.Error("%s did not provide a deterministic form to propagate", ctx.Call.Name)
+					return response
+				}
+				ctx.Call.Propagate = true
+			}
+			return response
+		})
+	}
+}
+
+// SlowlogMiddleware records calls that took longer than slowlog-log-slower-than.
+// It relies on TimingMiddleware running inside it.
+func SlowlogMiddleware() Middleware {
+	return func(next Command) Command {
+		return wrap(next, func(ctx Context, args []string) resp.Value {
+			response := next.Execute(ctx, args)
+			threshold, maxLen := ctx.Config.SlowlogSettings()
+			if threshold >= 0 && ctx.Call.Duration >= threshold {
+				ctx.Slowlog.Add(ctx.Call, maxLen)
+			}
+			return response
+		})
+	}
+}
+
+// TimingMiddleware measures how long the command takes and records it in the
+// per-command statistics
+func TimingMiddleware() Middleware {
+	return func(next Command) Command {
+		return wrap(next, func(ctx Context, args []string) resp.Value {
+			start := time.Now()
+			response := next.Execute(ctx, args)
+			ctx.Call.Duration = time.Since(start)
+			ctx.CommandStats.Record(ctx.Call.Name, ctx.Call.Duration, response.Type == resp.Error)
+			return response
+		})
+	}
+}
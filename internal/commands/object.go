@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-redis-go/internal/resp"
+	"github.com/codecrafters-redis-go/internal/storage"
+)
+
+// embstrSizeLimit is the longest string Redis stores as embstr
+const embstrSizeLimit = 44
+
+// ObjectCommand implements the OBJECT command
+type ObjectCommand struct{}
+
+// NewObjectCommand creates a new OBJECT command
+func NewObjectCommand() *ObjectCommand {
+	return &ObjectCommand{}
+}
+
+// Name returns the command name
+func (c *ObjectCommand) Name() string {
+	return "OBJECT"
+}
+
+// Execute runs the OBJECT command. Inspecting a key does not count as an
+// access to it.
+func (c *ObjectCommand) Execute(ctx Context, args []string) resp.Value {
+	switch strings.ToUpper(args[0]) {
+	case "ENCODING":
+		if len(args) != 2 {
+			return resp.ErrorValue("ERR wrong number of arguments for 'object|encoding' command")
+		}
+		value, exists := ctx.Storage.Lookup(args[1], false)
+		if !exists {
+			return resp.NullBulkString()
+		}
+		return resp.BulkStringValue(objectEncoding(value))
+	case "IDLETIME":
+		if len(args) != 2 {
+			return resp.ErrorValue("ERR wrong number of arguments for 'object|idletime' command")
+		}
+		idle, exists := ctx.Storage.IdleTime(args[1])
+		if !exists {
+			return resp.NullBulkString()
+		}
+		return resp.IntegerValue(int64(idle.Seconds()))
+	case "HELP":
+		return resp.ArrayValue(
+			resp.SimpleStringValue("OBJECT <subcommand> [<arg> [value] [opt] ...]. Subcommands are:"),
+			resp.SimpleStringValue("ENCODING <key>"),
+			resp.SimpleStringValue("    Return the kind of internal representation used in order to store the value"),
+			resp.SimpleStringValue("    associated with a <key>."),
+			resp.SimpleStringValue("IDLETIME <key>"),
+			resp.SimpleStringValue("    Return the idle time of the key, that is the approximated number of"),
+			resp.SimpleStringValue("    seconds elapsed since the last access to the key."),
+			resp.SimpleStringValue("HELP"),
+			resp.SimpleStringValue("    Print this help."),
+		)
+	default:
+		return resp.ErrorValue("ERR unknown subcommand '" + args[0] + "'. Try OBJECT HELP.")
+	}
+}
+
+// objectEncoding names the representation Redis would use for value. Lists
+// keep track of theirs as they are written, streams have just one.
+func objectEncoding(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return stringEncoding(v)
+	case storage.StringValue:
+		return stringEncoding(v.Value)
+	case *storage.List:
+		return v.Encoding()
+	}
+	if storage.TypeOf(value) == storage.TypeStream {
+		return "stream"
+	}
+	return "raw"
+}
+
+// stringEncoding is "int" for strings holding a canonical 64-bit integer,
+// "embstr" for short strings and "raw" for the rest
+func stringEncoding(s string) string {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil && strconv.FormatInt(n, 10) == s {
+		return "int"
+	}
+	if len(s) <= embstrSizeLimit {
+		return "embstr"
+	}
+	return "raw"
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *ObjectCommand) MinArgs() int {
+	return 1
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *ObjectCommand) MaxArgs() int {
+	return 2
+}
+
+// Flags returns the command flags
+func (c *ObjectCommand) Flags() Flags {
+	return FlagReadOnly
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *ObjectCommand) KeySpec() KeySpec {
+	return KeySpec{Find: func(args []string) []int {
+		// Every subcommand but HELP takes a key after its name
+		if len(args) < 2 {
+			return nil
+		}
+		return []int{1}
+	}}
+}
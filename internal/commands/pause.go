@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"sync"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/resp"
+)
+
+// PausePurpose identifies who paused clients, so CLIENT UNPAUSE does not end
+// the pause held by a failover and vice versa
+type PausePurpose int
+
+const (
+	PauseByClient   PausePurpose = iota // CLIENT PAUSE
+	PauseByFailover                     // FAILOVER waiting for the target to catch up
+)
+
+// pauseEntry is the pause requested for one purpose
+type pauseEntry struct {
+	all   bool      // Hold every command, not only writes
+	until time.Time // Zero holds until Unpause
+}
+
+// Pause holds client commands while CLIENT PAUSE or a failover is in effect.
+// Paused commands wait and run once the pause ends instead of failing.
+type Pause struct {
+	mu      sync.Mutex
+	entries map[PausePurpose]pauseEntry
+	changed chan struct{} // Closed and replaced whenever entries change
+}
+
+// NewPause creates a pause that holds nothing
+func NewPause() *Pause {
+	return &Pause{
+		entries: make(map[PausePurpose]pauseEntry),
+		changed: make(chan struct{}),
+	}
+}
+
+// Pause holds writes, or every command if all is set, for the given
+// duration; zero holds them until Unpause. Pausing again for the same
+// purpose replaces the previous pause.
+func (pause *Pause) Pause(purpose PausePurpose, all bool, duration time.Duration) {
+	entry := pauseEntry{all: all}
+	if duration > 0 {
+		entry.until = time.Now().Add(duration)
+	}
+
+	pause.mu.Lock()
+	defer pause.mu.Unlock()
+	pause.entries[purpose] = entry
+	pause.notify()
+}
+
+// Unpause ends the pause held for purpose
+func (pause *Pause) Unpause(purpose PausePurpose) {
+	pause.mu.Lock()
+	defer pause.mu.Unlock()
+	delete(pause.entries, purpose)
+	pause.notify()
+}
+
+// Wait blocks while commands with flags are held, or until cancel is closed
+func (pause *Pause) Wait(flags Flags, cancel <-chan struct{}) {
+	for {
+		pause.mu.Lock()
+		held, until := pause.holds(flags, time.Now())
+		changed := pause.changed
+		pause.mu.Unlock()
+		if !held {
+			return
+		}
+
+		// Re-check when the earliest timed pause ends or the pauses change
+		var timer *time.Timer
+		var expired <-chan time.Time
+		if !until.IsZero() {
+			timer = time.NewTimer(time.Until(until))
+			expired = timer.C
+		}
+		select {
+		case <-changed:
+		case <-expired:
+		case <-cancel:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		select {
+		case <-cancel:
+			return
+		default:
+		}
+	}
+}
+
+// holds reports whether commands with flags are held at now and, if so,
+// when the earliest timed pause holding them ends, zero if none is timed.
+// The pause must be locked.
+func (pause *Pause) holds(flags Flags, now time.Time) (bool, time.Time) {
+	held := false
+	var until time.Time
+	for _, entry := range pause.entries {
+		if !entry.until.IsZero() && !now.Before(entry.until) {
+			continue
+		}
+		if !entry.all && !flags.Has(FlagWrite) {
+			continue
+		}
+		held = true
+		if !entry.until.IsZero() && (until.IsZero() || entry.until.Before(until)) {
+			until = entry.until
+		}
+	}
+	return held, until
+}
+
+// notify wakes the waiters so they re-check. The pause must be locked.
+func (pause *Pause) notify() {
+	close(pause.changed)
+	pause.changed = make(chan struct{})
+}
+
+// PauseMiddleware holds client commands while clients are paused. The
+// master link and AOF replay are never held.
+func PauseMiddleware() Middleware {
+	return func(next Command) Command {
+		return wrap(next, func(ctx Context, args []string) resp.Value {
+			if ctx.Call.Client != nil && ctx.Pause != nil {
+				ctx.Pause.Wait(next.Flags(), ctx.Done())
+			}
+			return next.Execute(ctx, args)
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/codecrafters-redis-go/internal/errors"
+	"github.com/codecrafters-redis-go/internal/resp"
+)
+
+// ShutdownOptions are the SHUTDOWN modifiers
+type ShutdownOptions struct {
+	Save   bool // Save even if no save rules are configured
+	NoSave bool // Skip the final save even if save rules are configured
+	Now    bool // Don't wait for replicas to catch up
+	Force  bool // Shut down even if the final save fails
+}
+
+// persister is implemented by servers that can write snapshots and shut down
+type persister interface {
+	Save() error
+	Shutdown(opts ShutdownOptions) error
+}
+
+// appendOnlyRewriter is implemented by servers that maintain an append only file
+type appendOnlyRewriter interface {
+	RewriteAppendOnlyFile() error
+}
+
+// BgRewriteAofCommand implements the BGREWRITEAOF command
+type BgRewriteAofCommand struct{}
+
+// NewBgRewriteAofCommand creates a new BGREWRITEAOF command
+func NewBgRewriteAofCommand() *BgRewriteAofCommand {
+	return &BgRewriteAofCommand{}
+}
+
+// Name returns the command name
+func (c *BgRewriteAofCommand) Name() string {
+	return "BGREWRITEAOF"
+}
+
+// Execute runs the BGREWRITEAOF command
+func (c *BgRewriteAofCommand) Execute(ctx Context, args []string) resp.Value {
+	rewriter, ok := ctx.Server.(appendOnlyRewriter)
+	if !ok {
+		return resp.ErrorValue("ERR BGREWRITEAOF is not supported in this context")
+	}
+
+	if err := rewriter.RewriteAppendOnlyFile(); err != nil {
+		return resp.ErrorValue("ERR " + err.Error())
+	}
+
+	return resp.SimpleStringValue("Background append only file rewriting started")
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *BgRewriteAofCommand) MinArgs() int {
+	return 0
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *BgRewriteAofCommand) MaxArgs() int {
+	return 0
+}
+
+// Flags returns the command flags
+func (c *BgRewriteAofCommand) Flags() Flags {
+	return FlagAdmin
+}
+
+// SaveCommand implements the SAVE command
+type SaveCommand struct{}
+
+// NewSaveCommand creates a new SAVE command
+func NewSaveCommand() *SaveCommand {
+	return &SaveCommand{}
+}
+
+// Name returns the command name
+func (c *SaveCommand) Name() string {
+	return "SAVE"
+}
+
+// Execute runs the SAVE command
+func (c *SaveCommand) Execute(ctx Context, args []string) resp.Value {
+	server, ok := ctx.Server.(persister)
+	if !ok {
+		return resp.ErrorValue("ERR SAVE is not supported in this context")
+	}
+
+	if err := server.Save(); err != nil {
+		return resp.ErrorValue("ERR " + err.Error())
+	}
+
+	return resp.OK()
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *SaveCommand) MinArgs() int {
+	return 0
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *SaveCommand) MaxArgs() int {
+	return 0
+}
+
+// Flags returns the command flags
+func (c *SaveCommand) Flags() Flags {
+	return FlagAdmin
+}
+
+// ShutdownCommand implements the SHUTDOWN command
+type ShutdownCommand struct{}
+
+// NewShutdownCommand creates a new SHUTDOWN command
+func NewShutdownCommand() *ShutdownCommand {
+	return &ShutdownCommand{}
+}
+
+// Name returns the command name
+func (c *ShutdownCommand) Name() string {
+	return "SHUTDOWN"
+}
+
+// Execute runs the SHUTDOWN command
+func (c *ShutdownCommand) Execute(ctx Context, args []string) resp.Value {
+	var opts ShutdownOptions
+	for _, arg := range args {
+		switch strings.ToUpper(arg) {
+		case "SAVE":
+			opts.Save = true
+		case "NOSAVE":
+			opts.NoSave = true
+		case "NOW":
+			opts.Now = true
+		case "FORCE":
+			opts.Force = true
+		default:
+			return resp.ErrorValue(errors.ErrSyntaxError.Error())
+		}
+	}
+	if opts.Save && opts.NoSave {
+		return resp.ErrorValue(errors.ErrSyntaxError.Error())
+	}
+
+	server, ok := ctx.Server.(persister)
+	if !ok {
+		return resp.ErrorValue("ERR SHUTDOWN is not supported in this context")
+	}
+
+	if err := server.Shutdown(opts); err != nil {
+		return resp.ErrorValue("ERR Errors trying to SHUTDOWN. Check logs.")
+	}
+
+	// Unlike Redis, which exits without replying, the server stops once the
+	// in-flight replies are drained, so the client receives this OK first
+	return resp.OK()
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *ShutdownCommand) MinArgs() int {
+	return 0
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *ShutdownCommand) MaxArgs() int {
+	return 4
+}
+
+// Flags returns the command flags
+func (c *ShutdownCommand) Flags() Flags {
+	return FlagAdmin | FlagLoading
+}
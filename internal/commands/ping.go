@@ -19,6 +19,15 @@ func (c *PingCommand) Name() string {
 
 // Execute runs the PING command
 func (c *PingCommand) Execute(ctx Context, args []string) resp.Value {
+	// Subscribed clients can only tell replies from messages by their shape
+	if inSubscribeMode(ctx) {
+		message := ""
+		if len(args) > 0 {
+			message = args[0]
+		}
+		return resp.ArrayValue(resp.BulkStringValue("pong"), resp.BulkStringValue(message))
+	}
+
 	if len(args) == 0 {
 		return resp.Pong()
 	}
@@ -35,3 +44,8 @@ func (c *PingCommand) MinArgs() int {
 func (c *PingCommand) MaxArgs() int {
 	return 1
 }
+
+// Flags returns the command flags
+func (c *PingCommand) Flags() Flags {
+	return FlagLoading
+}
@@ -49,3 +49,8 @@ func (c *PsyncCommand) MinArgs() int {
 func (c *PsyncCommand) MaxArgs() int {
 	return 2
 }
+
+// Flags returns the command flags
+func (c *PsyncCommand) Flags() Flags {
+	return FlagAdmin
+}
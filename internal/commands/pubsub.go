@@ -0,0 +1,227 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/codecrafters-redis-go/internal/pubsub"
+	"github.com/codecrafters-redis-go/internal/resp"
+)
+
+// subscribeModeCommands are the only commands a RESP2 client may issue while subscribed
+var subscribeModeCommands = map[string]bool{
+	"SUBSCRIBE":    true,
+	"UNSUBSCRIBE":  true,
+	"PSUBSCRIBE":   true,
+	"PUNSUBSCRIBE": true,
+	"PING":         true,
+	"QUIT":         true,
+	"RESET":        true,
+}
+
+// inSubscribeMode returns true if the calling client has subscriptions and
+// speaks RESP2. RESP3 clients receive messages as pushes, which can't be
+// mistaken for replies, so they may keep issuing any command.
+func inSubscribeMode(ctx Context) bool {
+	client := ctx.Call.Client
+	return client != nil && client.Protocol != 3 && client.Subscriber != nil && ctx.PubSub.Count(client.Subscriber) > 0
+}
+
+// SubscribeModeMiddleware rejects commands a subscribed RESP2 client may not issue
+func SubscribeModeMiddleware() Middleware {
+	return func(next Command) Command {
+		return wrap(next, func(ctx Context, args []string) resp.Value {
+			if !subscribeModeCommands[ctx.Call.Name] && inSubscribeMode(ctx) {
+				return resp.ErrorValue("ERR Can't execute '" + strings.ToLower(ctx.Call.Name) +
+					"': only (P|S)SUBSCRIBE / (P|S)UNSUBSCRIBE / PING / QUIT / RESET are allowed in this context")
+			}
+			return next.Execute(ctx, args)
+		})
+	}
+}
+
+// subscriber returns the calling client's subscriber, or an error reply for
+// callers that can't receive messages
+func subscriber(ctx Context) (*pubsub.Subscriber, resp.Value, bool) {
+	client := ctx.Call.Client
+	if client == nil || client.Subscriber == nil {
+		return nil, resp.ErrorValue("ERR " + ctx.Call.Name + " is not allowed in this context"), false
+	}
+	return client.Subscriber, resp.Value{}, true
+}
+
+// replyEach sends every reply but the last directly to the subscriber and
+// returns the last, for commands answering once per argument
+func replyEach(sub *pubsub.Subscriber, replies []resp.Value) resp.Value {
+	for _, reply := range replies[:len(replies)-1] {
+		sub.Deliver(reply)
+	}
+	return replies[len(replies)-1]
+}
+
+// subscriptionReply is the confirmation sent for each (un)subscribed
+// channel, a push like the messages that follow it
+func subscriptionReply(kind string, channel *string, count int) resp.Value {
+	name := resp.NullBulkString()
+	if channel != nil {
+		name = resp.BulkStringValue(*channel)
+	}
+	return resp.PushValue(resp.BulkStringValue(kind), name, resp.IntegerValue(int64(count)))
+}
+
+// SubscribeCommand implements SUBSCRIBE and PSUBSCRIBE
+type SubscribeCommand struct {
+	pattern bool
+}
+
+// NewSubscribeCommand creates a new SUBSCRIBE command
+func NewSubscribeCommand() *SubscribeCommand {
+	return &SubscribeCommand{}
+}
+
+// NewPSubscribeCommand creates a new PSUBSCRIBE command
+func NewPSubscribeCommand() *SubscribeCommand {
+	return &SubscribeCommand{pattern: true}
+}
+
+// Name returns the command name
+func (c *SubscribeCommand) Name() string {
+	if c.pattern {
+		return "PSUBSCRIBE"
+	}
+	return "SUBSCRIBE"
+}
+
+// Execute runs the SUBSCRIBE command
+func (c *SubscribeCommand) Execute(ctx Context, args []string) resp.Value {
+	sub, errReply, ok := subscriber(ctx)
+	if !ok {
+		return errReply
+	}
+
+	replies := make([]resp.Value, 0, len(args))
+	for i := range args {
+		if c.pattern {
+			replies = append(replies, subscriptionReply("psubscribe", &args[i], ctx.PubSub.PSubscribe(sub, args[i])))
+		} else {
+			replies = append(replies, subscriptionReply("subscribe", &args[i], ctx.PubSub.Subscribe(sub, args[i])))
+		}
+	}
+	return replyEach(sub, replies)
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *SubscribeCommand) MinArgs() int {
+	return 1
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *SubscribeCommand) MaxArgs() int {
+	return -1
+}
+
+// Flags returns the command flags
+func (c *SubscribeCommand) Flags() Flags {
+	return FlagPubSub | FlagLoading
+}
+
+// UnsubscribeCommand implements UNSUBSCRIBE and PUNSUBSCRIBE
+type UnsubscribeCommand struct {
+	pattern bool
+}
+
+// NewUnsubscribeCommand creates a new UNSUBSCRIBE command
+func NewUnsubscribeCommand() *UnsubscribeCommand {
+	return &UnsubscribeCommand{}
+}
+
+// NewPUnsubscribeCommand creates a new PUNSUBSCRIBE command
+func NewPUnsubscribeCommand() *UnsubscribeCommand {
+	return &UnsubscribeCommand{pattern: true}
+}
+
+// Name returns the command name
+func (c *UnsubscribeCommand) Name() string {
+	if c.pattern {
+		return "PUNSUBSCRIBE"
+	}
+	return "UNSUBSCRIBE"
+}
+
+// Execute runs the UNSUBSCRIBE command. Without arguments it removes every
+// subscription of its kind.
+func (c *UnsubscribeCommand) Execute(ctx Context, args []string) resp.Value {
+	sub, errReply, ok := subscriber(ctx)
+	if !ok {
+		return errReply
+	}
+
+	kind, unsubscribe := "unsubscribe", ctx.PubSub.Unsubscribe
+	if c.pattern {
+		kind, unsubscribe = "punsubscribe", ctx.PubSub.PUnsubscribe
+	}
+
+	if len(args) == 0 {
+		if c.pattern {
+			args = ctx.PubSub.Patterns(sub)
+		} else {
+			args = ctx.PubSub.Channels(sub)
+		}
+		if len(args) == 0 {
+			return subscriptionReply(kind, nil, ctx.PubSub.Count(sub))
+		}
+	}
+
+	replies := make([]resp.Value, 0, len(args))
+	for i := range args {
+		replies = append(replies, subscriptionReply(kind, &args[i], unsubscribe(sub, args[i])))
+	}
+	return replyEach(sub, replies)
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *UnsubscribeCommand) MinArgs() int {
+	return 0
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *UnsubscribeCommand) MaxArgs() int {
+	return -1
+}
+
+// Flags returns the command flags
+func (c *UnsubscribeCommand) Flags() Flags {
+	return FlagPubSub | FlagLoading
+}
+
+// PublishCommand implements the PUBLISH command
+type PublishCommand struct{}
+
+// NewPublishCommand creates a new PUBLISH command
+func NewPublishCommand() *PublishCommand {
+	return &PublishCommand{}
+}
+
+// Name returns the command name
+func (c *PublishCommand) Name() string {
+	return "PUBLISH"
+}
+
+// Execute runs the PUBLISH command
+func (c *PublishCommand) Execute(ctx Context, args []string) resp.Value {
+	return resp.IntegerValue(int64(ctx.PubSub.Publish(args[0], args[1])))
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *PublishCommand) MinArgs() int {
+	return 2
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *PublishCommand) MaxArgs() int {
+	return 2
+}
+
+// Flags returns the command flags
+func (c *PublishCommand) Flags() Flags {
+	return FlagPubSub | FlagLoading
+}
@@ -1,45 +1,148 @@
 package commands
 
 import (
+	"sort"
 	"strings"
 	"sync"
 
+	"github.com/codecrafters-redis-go/internal/blocking"
+	"github.com/codecrafters-redis-go/internal/clock"
 	"github.com/codecrafters-redis-go/internal/config"
 	"github.com/codecrafters-redis-go/internal/errors"
+	"github.com/codecrafters-redis-go/internal/pubsub"
 	"github.com/codecrafters-redis-go/internal/resp"
 	"github.com/codecrafters-redis-go/internal/storage"
+	"github.com/codecrafters-redis-go/internal/tracing"
+	"github.com/codecrafters-redis-go/internal/tracking"
 )
 
 // Registry manages command implementations
 type Registry struct {
-	mu       sync.RWMutex
-	commands map[string]Command
-	context  *Context
+	mu          sync.RWMutex
+	commands    map[string]Command
+	middlewares []Middleware
+	context     *Context
 }
 
 // NewRegistry creates a new command registry
 func NewRegistry(cfg *config.Config, store *storage.Storage) *Registry {
+	hub := pubsub.NewHub()
 	registry := &Registry{
 		commands: make(map[string]Command),
 		context: &Context{
-			Config:  cfg,
-			Storage: store,
+			Config:       cfg,
+			Storage:      store,
+			CommandStats: NewCommandStats(),
+			Slowlog:      NewSlowlog(),
+			PubSub:       hub,
+			Notifier:     pubsub.NewNotifier(hub, cfg.GetNotifyKeyspaceEvents),
+			Pause:        NewPause(),
+			Tracking:     tracking.NewTable(),
+			Tracer:       tracing.Noop,
+			Clock:        clock.Real{},
 		},
 	}
+	registry.context.Commands = registry
+
+	// Built-in middlewares, outermost first
+	registry.Use(
+		TracingMiddleware(),
+		AuthMiddleware(),
+		SubscribeModeMiddleware(),
+		PauseMiddleware(),
+		ReadOnlyReplicaMiddleware(),
+		OOMMiddleware(),
+		PropagationMiddleware(),
+		SlowlogMiddleware(),
+		TimingMiddleware(),
+	)
 
 	// Register default commands
 	registry.RegisterCommand(NewPingCommand())
 	registry.RegisterCommand(NewEchoCommand())
 	registry.RegisterCommand(NewSetCommand())
+	registry.RegisterCommand(NewSetNXCommand())
+	registry.RegisterCommand(NewSetExCommand())
+	registry.RegisterCommand(NewPSetExCommand())
 	registry.RegisterCommand(NewGetCommand())
+	registry.RegisterCommand(NewGetDelCommand())
+	registry.RegisterCommand(NewGetExCommand())
+	registry.RegisterCommand(NewIncrCommand())
+	registry.RegisterCommand(NewDecrCommand())
+	registry.RegisterCommand(NewIncrByCommand())
+	registry.RegisterCommand(NewDecrByCommand())
+	registry.RegisterCommand(NewIncrByFloatCommand())
+	registry.RegisterCommand(NewAppendCommand())
+	registry.RegisterCommand(NewStrlenCommand())
+	registry.RegisterCommand(NewGetRangeCommand())
+	registry.RegisterCommand(NewSetRangeCommand())
+	registry.RegisterCommand(NewMSetCommand())
+	registry.RegisterCommand(NewMSetNXCommand())
+	registry.RegisterCommand(NewMGetCommand())
+	registry.RegisterCommand(NewLCSCommand())
+	registry.RegisterCommand(NewLPushCommand())
+	registry.RegisterCommand(NewRPushCommand())
+	registry.RegisterCommand(NewLPushXCommand())
+	registry.RegisterCommand(NewRPushXCommand())
+	registry.RegisterCommand(NewLPopCommand())
+	registry.RegisterCommand(NewRPopCommand())
+	registry.RegisterCommand(NewBLPopCommand())
+	registry.RegisterCommand(NewBRPopCommand())
+	registry.RegisterCommand(NewLMoveCommand())
+	registry.RegisterCommand(NewRPopLPushCommand())
+	registry.RegisterCommand(NewLLenCommand())
+	registry.RegisterCommand(NewLRangeCommand())
+	registry.RegisterCommand(NewLIndexCommand())
+	registry.RegisterCommand(NewLSetCommand())
+	registry.RegisterCommand(NewLInsertCommand())
+	registry.RegisterCommand(NewLRemCommand())
+	registry.RegisterCommand(NewLTrimCommand())
 	registry.RegisterCommand(NewConfigCommand())
 	registry.RegisterCommand(NewKeysCommand())
+	registry.RegisterCommand(NewScanCommand())
 	registry.RegisterCommand(NewInfoCommand())
+	registry.RegisterCommand(NewLolwutCommand())
 	registry.RegisterCommand(NewReplConfCommand())
 	registry.RegisterCommand(NewPsyncCommand())
 	registry.RegisterCommand(NewWaitCommand())
 	registry.RegisterCommand(NewTypeCommand())
+	registry.RegisterCommand(NewDelCommand())
+	registry.RegisterCommand(NewExistsCommand())
+	registry.RegisterCommand(NewTouchCommand())
+	registry.RegisterCommand(NewCopyCommand())
+	registry.RegisterCommand(NewFlushAllCommand())
+	registry.RegisterCommand(NewFlushDBCommand())
+	registry.RegisterCommand(NewExpireCommand())
+	registry.RegisterCommand(NewPExpireCommand())
+	registry.RegisterCommand(NewExpireAtCommand())
+	registry.RegisterCommand(NewPExpireAtCommand())
+	registry.RegisterCommand(NewTTLCommand())
+	registry.RegisterCommand(NewPTTLCommand())
+	registry.RegisterCommand(NewExpireTimeCommand())
+	registry.RegisterCommand(NewPExpireTimeCommand())
+	registry.RegisterCommand(NewPersistCommand())
+	registry.RegisterCommand(NewObjectCommand())
 	registry.RegisterCommand(NewXAddCommand())
+	registry.RegisterCommand(NewBgRewriteAofCommand())
+	registry.RegisterCommand(NewSaveCommand())
+	registry.RegisterCommand(NewShutdownCommand())
+	registry.RegisterCommand(NewAuthCommand())
+	registry.RegisterCommand(NewHelloCommand())
+	registry.RegisterCommand(NewSlowlogCommand())
+	registry.RegisterCommand(NewLatencyCommand())
+	registry.RegisterCommand(NewDebugCommand())
+	registry.RegisterCommand(NewSubscribeCommand())
+	registry.RegisterCommand(NewUnsubscribeCommand())
+	registry.RegisterCommand(NewPSubscribeCommand())
+	registry.RegisterCommand(NewPUnsubscribeCommand())
+	registry.RegisterCommand(NewPublishCommand())
+	registry.RegisterCommand(NewClientCommand())
+	registry.RegisterCommand(NewReplicaOfCommand())
+	registry.RegisterCommand(NewSlaveOfCommand())
+	registry.RegisterCommand(NewRoleCommand())
+	registry.RegisterCommand(NewFailoverCommand())
+	registry.RegisterCommand(NewCommandCommand())
+	registry.RegisterCommand(NewMemoryCommand())
 
 	return registry
 }
@@ -59,19 +162,63 @@ func (r *Registry) GetCommand(name string) (Command, bool) {
 	return cmd, ok
 }
 
-// HandleCommand processes a command and returns a response
+// Commands returns all registered commands sorted by name
+func (r *Registry) Commands() []Command {
+	r.mu.RLock()
+	all := make([]Command, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		all = append(all, cmd)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(all, func(i, j int) bool { return all[i].Name() < all[j].Name() })
+	return all
+}
+
+// CommandFlags returns the flags of the named command
+func (r *Registry) CommandFlags(name string) (Flags, bool) {
+	cmd, ok := r.GetCommand(name)
+	if !ok {
+		return 0, false
+	}
+	return cmd.Flags(), true
+}
+
+// Use appends middlewares to the pipeline every command runs through. The
+// first middleware added is the outermost.
+func (r *Registry) Use(middlewares ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+// HandleCommand processes a command issued by the server itself and returns a response
 func (r *Registry) HandleCommand(cmdValue resp.Value) resp.Value {
+	return r.Dispatch(&Call{Command: cmdValue})
+}
+
+// Dispatch runs call through the middleware pipeline and returns the response.
+// Middlewares record their results, such as call.Propagate, in call.
+func (r *Registry) Dispatch(call *Call) resp.Value {
+	cmdValue := call.Command
 	commandName, err := cmdValue.GetCommand()
 	if err != nil {
 		return resp.ErrorValue("ERR invalid command format")
 	}
+	call.Name = strings.ToUpper(commandName)
 
 	cmd, ok := r.GetCommand(commandName)
 	if !ok {
 		return resp.ErrorValue(errors.UnknownCommand(commandName).Error())
 	}
 
-	args := cmdValue.GetArgs()
+	args, err := cmdValue.Args()
+	if err != nil {
+		return resp.ErrorValue("ERR " + err.Error())
+	}
+	if call.Client != nil {
+		call.Client.setLastCommand(call.Name, args)
+	}
 
 	// Validate argument count
 	if cmd.MinArgs() > 0 && len(args) < cmd.MinArgs() {
@@ -82,8 +229,16 @@ func (r *Registry) HandleCommand(cmdValue resp.Value) resp.Value {
 		return resp.ErrorValue(errors.WrongNumberOfArguments(strings.ToLower(commandName)).Error())
 	}
 
+	r.mu.RLock()
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		cmd = r.middlewares[i](cmd)
+	}
+	r.mu.RUnlock()
+
 	// Execute the command
-	return cmd.Execute(*r.context, args)
+	ctx := *r.context
+	ctx.Call = call
+	return cmd.Execute(ctx, args)
 }
 
 // GetContext returns the command context
@@ -100,3 +255,19 @@ func (r *Registry) SetPropagateFunc(propagateFunc func(resp.Value)) {
 func (r *Registry) SetServer(server ServerAccessor) {
 	r.context.Server = server
 }
+
+// SetTracer sets the tracer recording a span per command
+func (r *Registry) SetTracer(tracer tracing.Tracer) {
+	r.context.Tracer = tracer
+}
+
+// SetClock sets the clock commands read the time from, e.g. for relative
+// expiries and stream IDs
+func (r *Registry) SetClock(clk clock.Clock) {
+	r.context.Clock = clk
+}
+
+// SetBlocking sets the manager blocking commands wait on
+func (r *Registry) SetBlocking(manager *blocking.Manager) {
+	r.context.Blocking = manager
+}
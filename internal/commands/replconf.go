@@ -59,3 +59,8 @@ func (c *ReplConfCommand) MinArgs() int {
 func (c *ReplConfCommand) MaxArgs() int {
 	return -1 // Variable number of arguments depending on subcommand
 }
+
+// Flags returns the command flags
+func (c *ReplConfCommand) Flags() Flags {
+	return FlagAdmin | FlagLoading
+}
@@ -0,0 +1,58 @@
+package commands_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-redis-go/pkg/redistest"
+)
+
+func TestFullResyncSendsDataset(t *testing.T) {
+	master := redistest.Start(t, 0, redistest.WithoutLogs()).Master
+	replica := redistest.Start(t, 0, redistest.WithoutLogs()).Master
+
+	for _, args := range [][]string{
+		{"SET", "string", "value"},
+		{"SET", "volatile", "value", "EX", "1000"},
+		{"RPUSH", "list", "a", "b", "c"},
+	} {
+		if _, err := master.Client.Do(args...); err != nil {
+			t.Fatalf("%q: %v", args, err)
+		}
+	}
+	// The full resync replaces what the replica held before
+	if _, err := replica.Client.Do("SET", "replica-only", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := replica.Client.Do("REPLICAOF", "127.0.0.1", strconv.Itoa(master.Server.Port())); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		fields, err := replica.Info("replication")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fields["master_link_status"] == "up" && fields["master_sync_in_progress"] == "0" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the replica never synced")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if reply, err := replica.Client.Do("GET", "string"); err != nil || reply.Str != "value" {
+		t.Errorf("GET string = %+v, %v, want %q", reply, err, "value")
+	}
+	if reply, err := replica.Client.Do("TTL", "volatile"); err != nil || reply.Integer <= 0 {
+		t.Errorf("TTL volatile = %+v, %v, want a TTL", reply, err)
+	}
+	if reply, err := replica.Client.Do("LRANGE", "list", "0", "-1"); err != nil || len(reply.Array) != 3 {
+		t.Errorf("LRANGE list = %+v, %v, want 3 elements", reply, err)
+	}
+	if reply, err := replica.Client.Do("EXISTS", "replica-only"); err != nil || reply.Integer != 0 {
+		t.Errorf("EXISTS replica-only = %+v, %v, want 0", reply, err)
+	}
+}
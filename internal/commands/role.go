@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"strconv"
+
+	"github.com/codecrafters-redis-go/internal/resp"
+)
+
+// ReplicaStatus describes a replica connected to this server
+type ReplicaStatus struct {
+	Host   string // Address the replica connected from
+	Port   string // Port the replica serves clients on
+	Offset int64  // Last acknowledged replication offset
+	Lag    int    // Seconds since the replica last acknowledged its offset
+}
+
+// replicaStatusProvider is implemented by servers that can list their replicas
+type replicaStatusProvider interface {
+	ReplicaStatuses() []ReplicaStatus
+	MasterReplOffset() int64
+}
+
+// RoleCommand implements the ROLE command
+type RoleCommand struct{}
+
+// NewRoleCommand creates a new ROLE command
+func NewRoleCommand() *RoleCommand {
+	return &RoleCommand{}
+}
+
+// Name returns the command name
+func (c *RoleCommand) Name() string {
+	return "ROLE"
+}
+
+// Execute runs the ROLE command. A master replies with its replication
+// offset and its replicas, a replica with its master, the state of its link
+// and the offset it reached.
+func (c *RoleCommand) Execute(ctx Context, args []string) resp.Value {
+	if ctx.Config.IsReplica() {
+		host, port := ctx.Config.GetReplicaInfo()
+		state, offset := "connect", int64(-1)
+		if provider, ok := ctx.Server.(masterLinkProvider); ok {
+			if link, ok := provider.MasterLinkInfo(); ok {
+				state, offset = link.State.String(), link.Offset
+			}
+		}
+		portNumber, _ := strconv.Atoi(port)
+		return resp.ArrayValue(
+			resp.BulkStringValue("slave"),
+			resp.BulkStringValue(host),
+			resp.IntegerValue(int64(portNumber)),
+			resp.BulkStringValue(state),
+			resp.IntegerValue(offset),
+		)
+	}
+
+	var offset int64
+	replicas := []resp.Value{}
+	if provider, ok := ctx.Server.(replicaStatusProvider); ok {
+		offset = provider.MasterReplOffset()
+		for _, replica := range provider.ReplicaStatuses() {
+			replicas = append(replicas, resp.ArrayValue(
+				resp.BulkStringValue(replica.Host),
+				resp.BulkStringValue(replica.Port),
+				resp.BulkStringValue(strconv.FormatInt(replica.Offset, 10)),
+			))
+		}
+	}
+	return resp.ArrayValue(
+		resp.BulkStringValue("master"),
+		resp.IntegerValue(offset),
+		resp.ArrayValue(replicas...),
+	)
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *RoleCommand) MinArgs() int {
+	return 0
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *RoleCommand) MaxArgs() int {
+	return 0
+}
+
+// Flags returns the command flags
+func (c *RoleCommand) Flags() Flags {
+	return FlagLoading
+}
@@ -0,0 +1,32 @@
+package commands_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codecrafters-redis-go/pkg/redisserver"
+)
+
+func TestShutdownSaveWhileLoading(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, redisserver.NewConfig().DBFilename)
+	saveDataset(t, dir, 100)
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loading, backend := startLoading(t, dir)
+	<-backend.stalled
+
+	// The final save is skipped by the time SHUTDOWN replies, the load may
+	// then go on until the server stops
+	do(t, loading, "SHUTDOWN", "SAVE")
+	backend.Release()
+	loading.Wait()
+	if data, err := os.ReadFile(path); err != nil || !bytes.Equal(data, saved) {
+		t.Errorf("%s was written during the load: %d bytes, %v, want the %d bytes saved", filepath.Base(path), len(data), err, len(saved))
+	}
+}
@@ -0,0 +1,181 @@
+package commands
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/resp"
+	"github.com/codecrafters-redis-go/internal/utils"
+)
+
+const (
+	// slowlogMaxArgs is how many arguments an entry keeps
+	slowlogMaxArgs = 32
+	// slowlogMaxArgLen is how many bytes of each argument an entry keeps
+	slowlogMaxArgLen = 128
+)
+
+// SlowlogEntry is a command that exceeded slowlog-log-slower-than
+type SlowlogEntry struct {
+	ID       int64
+	Time     time.Time
+	Duration time.Duration
+	Args     []string
+	Client   string
+}
+
+// Slowlog keeps the most recent slow commands, newest first
+type Slowlog struct {
+	mu      sync.Mutex
+	entries []SlowlogEntry
+	nextID  int64
+}
+
+// NewSlowlog creates an empty slowlog
+func NewSlowlog() *Slowlog {
+	return &Slowlog{}
+}
+
+// Add records call, keeping at most maxLen entries
+func (log *Slowlog) Add(call *Call, maxLen int) {
+	name, _ := call.Command.GetCommand()
+	args := append([]string{name}, call.Command.GetArgs()...)
+	if len(args) > slowlogMaxArgs {
+		more := len(args) - slowlogMaxArgs + 1
+		args = append(args[:slowlogMaxArgs-1], fmt.Sprintf("... (%d more arguments)", more))
+	}
+	for i, arg := range args {
+		if len(arg) > slowlogMaxArgLen {
+			args[i] = fmt.Sprintf("%s... (%d more bytes)", arg[:slowlogMaxArgLen], len(arg)-slowlogMaxArgLen)
+		}
+	}
+
+	entry := SlowlogEntry{
+		Time:     time.Now(),
+		Duration: call.Duration,
+		Args:     args,
+	}
+	if call.Client != nil {
+		entry.Client = call.Client.Addr
+	}
+
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	entry.ID = log.nextID
+	log.nextID++
+	log.entries = append([]SlowlogEntry{entry}, log.entries...)
+	if len(log.entries) > maxLen {
+		log.entries = log.entries[:maxLen]
+	}
+}
+
+// Get returns up to count of the newest entries, or all of them if count is negative
+func (log *Slowlog) Get(count int) []SlowlogEntry {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	if count < 0 || count > len(log.entries) {
+		count = len(log.entries)
+	}
+	return append([]SlowlogEntry{}, log.entries[:count]...)
+}
+
+// Len returns the number of entries
+func (log *Slowlog) Len() int {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	return len(log.entries)
+}
+
+// Reset removes all entries
+func (log *Slowlog) Reset() {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	log.entries = nil
+}
+
+// SlowlogCommand implements the SLOWLOG command
+type SlowlogCommand struct{}
+
+// NewSlowlogCommand creates a new SLOWLOG command
+func NewSlowlogCommand() *SlowlogCommand {
+	return &SlowlogCommand{}
+}
+
+// Name returns the command name
+func (c *SlowlogCommand) Name() string {
+	return "SLOWLOG"
+}
+
+// Execute runs the SLOWLOG command
+func (c *SlowlogCommand) Execute(ctx Context, args []string) resp.Value {
+	subcommand := strings.ToUpper(args[0])
+
+	switch subcommand {
+	case "GET":
+		if len(args) > 2 {
+			return resp.ErrorValue("ERR wrong number of arguments for 'slowlog|get' command")
+		}
+		count := 10
+		if len(args) == 2 {
+			n, ok := utils.ParseInt64(args[1])
+			if !ok || n < -1 {
+				return resp.ErrorValue("ERR count should be greater than or equal to -1")
+			}
+			count = int(min(n, math.MaxInt32))
+		}
+
+		entries := ctx.Slowlog.Get(count)
+		values := make([]resp.Value, len(entries))
+		for i, entry := range entries {
+			argValues := make([]resp.Value, len(entry.Args))
+			for j, arg := range entry.Args {
+				argValues[j] = resp.BulkStringValue(arg)
+			}
+			values[i] = resp.ArrayValue(
+				resp.IntegerValue(int64(entry.ID)),
+				resp.IntegerValue(entry.Time.Unix()),
+				resp.IntegerValue(entry.Duration.Microseconds()),
+				resp.ArrayValue(argValues...),
+				resp.BulkStringValue(entry.Client),
+				resp.BulkStringValue(""),
+			)
+		}
+		return resp.ArrayValue(values...)
+
+	case "LEN":
+		if len(args) != 1 {
+			return resp.ErrorValue("ERR wrong number of arguments for 'slowlog|len' command")
+		}
+		return resp.IntegerValue(int64(ctx.Slowlog.Len()))
+
+	case "RESET":
+		if len(args) != 1 {
+			return resp.ErrorValue("ERR wrong number of arguments for 'slowlog|reset' command")
+		}
+		ctx.Slowlog.Reset()
+		return resp.SimpleStringValue("OK")
+
+	default:
+		return resp.ErrorValue(fmt.Sprintf("ERR unknown subcommand '%s'. Try SLOWLOG HELP.", args[0]))
+	}
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *SlowlogCommand) MinArgs() int {
+	return 1
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *SlowlogCommand) MaxArgs() int {
+	return 2
+}
+
+// Flags returns the command flags
+func (c *SlowlogCommand) Flags() Flags {
+	return FlagAdmin | FlagLoading
+}
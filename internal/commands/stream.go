@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/codecrafters-redis-go/internal/pubsub"
 	"github.com/codecrafters-redis-go/internal/resp"
 	"github.com/codecrafters-redis-go/internal/storage"
 )
@@ -35,31 +36,43 @@ func (c *XAddCommand) Execute(ctx Context, args []string) resp.Value {
 		fields[args[i]] = args[i+1]
 	}
 
-	// Get or create stream
-	val, exists := ctx.Storage.Get(key)
-	var stream *storage.Stream
-
-	if exists {
-		// Check if it's a stream
-		var ok bool
-		stream, ok = val.(*storage.Stream)
-		if !ok {
-			return resp.ErrorValue("WRONGTYPE Operation against a key holding the wrong kind of value")
+	// Get or create the stream and add the entry, keeping the key's TTL
+	var generatedID string
+	var created bool
+	err := ctx.Storage.Update(key, func(val interface{}, exists bool) (interface{}, error) {
+		stream := storage.NewStream()
+		if exists {
+			if err := storage.CheckType(val, storage.TypeStream); err != nil {
+				return nil, err
+			}
+			stream = val.(*storage.Stream)
 		}
-	} else {
-		// Create new stream
-		stream = storage.NewStream()
-		ctx.Storage.Set(key, stream, nil)
-	}
 
-	// Parse and generate ID if needed
-	generatedID, err := parseStreamID(id, stream)
+		// Parse and generate ID if needed
+		var err error
+		generatedID, err = parseStreamID(id, stream, ctx.Clock.Now())
+		if err != nil {
+			return nil, err
+		}
+		stream.AddEntry(generatedID, fields)
+		created = !exists
+		return stream, nil
+	})
 	if err != nil {
 		return resp.ErrorValue(err.Error())
 	}
 
-	// Add entry to stream
-	stream.AddEntry(generatedID, fields)
+	ctx.markDirty(1)
+	ctx.signalModifiedKey(key)
+	ctx.signalKeyReady(key)
+	if generatedID != id {
+		// Replicas must store the ID chosen here, not generate their own
+		ctx.replicateAs(append([]string{"XADD", key, generatedID}, args[2:]...)...)
+	}
+	if created {
+		ctx.notifyKeyspaceEvent(pubsub.ClassNew, "new", key)
+	}
+	ctx.notifyKeyspaceEvent(pubsub.ClassStream, "xadd", key)
 
 	// Return the generated ID
 	return resp.BulkStringValue(generatedID)
@@ -73,8 +86,18 @@ func (c *XAddCommand) MaxArgs() int {
 	return -1 // Variable number of field-value pairs
 }
 
-// parseStreamID parses and generates a stream ID
-func parseStreamID(id string, stream *storage.Stream) (string, error) {
+func (c *XAddCommand) Flags() Flags {
+	return FlagWrite | FlagDenyOOM
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *XAddCommand) KeySpec() KeySpec {
+	return singleKey
+}
+
+// parseStreamID parses and generates a stream ID, auto generated ones
+// taking their time from now
+func parseStreamID(id string, stream *storage.Stream, now time.Time) (string, error) {
 	// Check for special case 0-0
 	if id == "0-0" {
 		return "", fmt.Errorf("ERR The ID specified in XADD must be greater than 0-0")
@@ -82,7 +105,7 @@ func parseStreamID(id string, stream *storage.Stream) (string, error) {
 
 	// Handle full auto-generation with *
 	if id == "*" {
-		ms := time.Now().UnixMilli()
+		ms := now.UnixMilli()
 		seq := uint64(0)
 
 		// If we have entries, check if we need to increment sequence
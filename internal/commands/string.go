@@ -1,13 +1,106 @@
 package commands
 
 import (
+	"math"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/codecrafters-redis-go/internal/errors"
+	"github.com/codecrafters-redis-go/internal/pubsub"
 	"github.com/codecrafters-redis-go/internal/resp"
+	"github.com/codecrafters-redis-go/internal/storage"
+	"github.com/codecrafters-redis-go/internal/utils"
 )
 
+// setArgs describe a write made by SET or one of the commands built on it
+type setArgs struct {
+	expiry   *time.Time
+	relative bool // The expiry depends on when the command runs
+	ifAbsent bool // Only write a missing key, like NX
+	ifExists bool // Only write an existing key, like XX
+	get      bool // The previous value is replied, so it must be a string
+	keepTTL  bool // The key keeps its TTL instead of taking expiry
+}
+
+// expireOption returns the unit of an EX, PX, EXAT or PXAT option and
+// whether it is a unix time rather than a time to live
+func expireOption(option string) (unit time.Duration, absolute bool) {
+	unit = time.Second
+	if option[0] == 'P' {
+		unit = time.Millisecond
+	}
+	return unit, strings.HasSuffix(option, "AT")
+}
+
+// parseExpireArg parses the expiry argument of command: a time to live in
+// unit, seconds or milliseconds, or a unix time in unit if absolute. It must
+// be positive, and a time to live must fit in a time.Duration.
+func parseExpireArg(ctx Context, command, arg string, unit time.Duration, absolute bool) (*time.Time, error) {
+	n, ok := utils.ParseInt64(arg)
+	invalid := errors.InvalidExpireTime("'" + command + "' command")
+	if !ok {
+		return nil, errors.ErrNotInteger
+	}
+	if n <= 0 {
+		return nil, invalid
+	}
+	if absolute {
+		perUnit := int64(unit / time.Millisecond)
+		if n > math.MaxInt64/perUnit {
+			return nil, invalid
+		}
+		expiry := time.UnixMilli(n * perUnit)
+		return &expiry, nil
+	}
+	if n > math.MaxInt64/int64(unit) {
+		return nil, invalid
+	}
+	expiry := ctx.Clock.Now().Add(time.Duration(n) * unit)
+	return &expiry, nil
+}
+
+// setString writes value to key as opts describe. It returns the string
+// the key held before, nil if there was none, and false if a condition such
+// as opts.ifAbsent kept the key from being written. With opts.get a key
+// holding another type is left alone and ErrWrongType returned. Whatever
+// command wrote it, the write propagates as a SET with an absolute expiry if
+// the expiry was relative.
+func setString(ctx Context, key, value string, opts setArgs) (previous *string, written bool, err error) {
+	var created bool
+	written = ctx.Storage.SetIf(key, value, opts.expiry, opts.keepTTL, func(current interface{}, exists bool) bool {
+		if exists {
+			if str, typeErr := storage.AsString(current); typeErr == nil {
+				previous = &str
+			} else if opts.get {
+				err = typeErr
+				return false
+			}
+		}
+		created = !exists
+		return !(opts.ifAbsent && exists || opts.ifExists && !exists)
+	})
+	if !written {
+		return previous, false, err
+	}
+
+	ctx.markDirty(1)
+	ctx.signalModifiedKey(key)
+
+	if opts.relative {
+		ctx.replicateAs("SET", key, value, "PXAT", strconv.FormatInt(opts.expiry.UnixMilli(), 10))
+	}
+
+	if created {
+		ctx.notifyKeyspaceEvent(pubsub.ClassNew, "new", key)
+	}
+	ctx.notifyKeyspaceEvent(pubsub.ClassString, "set", key)
+	if opts.expiry != nil {
+		ctx.notifyKeyspaceEvent(pubsub.ClassGeneric, "expire", key)
+	}
+	return previous, true, nil
+}
+
 // SetCommand implements the SET command
 type SetCommand struct{}
 
@@ -21,35 +114,63 @@ func (c *SetCommand) Name() string {
 	return "SET"
 }
 
-// Execute runs the SET command
+// Execute runs the SET command. It replies OK, or null if NX or XX kept
+// the key from being written; with GET it replies the previous value
+// instead, whether or not the key was written. A key written without
+// KEEPTTL loses the TTL it had, or takes the one EX, PX, EXAT or PXAT give.
 func (c *SetCommand) Execute(ctx Context, args []string) resp.Value {
 	key := args[0]
 	value := args[1]
 
-	var expiry *time.Time
-
+	var opts setArgs
 	// Parse optional arguments
 	for i := 2; i < len(args); i++ {
-		switch args[i] {
-		case "px", "PX":
-			if i+1 >= len(args) {
+		switch option := strings.ToUpper(args[i]); option {
+		case "NX":
+			if opts.ifExists {
 				return resp.ErrorValue(errors.ErrSyntaxError.Error())
 			}
-			ms, err := strconv.ParseInt(args[i+1], 10, 64)
-			if err != nil || ms <= 0 {
-				return resp.ErrorValue(errors.ErrInvalidExpireTime.Error())
+			opts.ifAbsent = true
+		case "XX":
+			if opts.ifAbsent {
+				return resp.ErrorValue(errors.ErrSyntaxError.Error())
 			}
-			exp := time.Now().Add(time.Duration(ms) * time.Millisecond)
-			expiry = &exp
+			opts.ifExists = true
+		case "GET":
+			opts.get = true
+		case "KEEPTTL":
+			if opts.expiry != nil {
+				return resp.ErrorValue(errors.ErrSyntaxError.Error())
+			}
+			opts.keepTTL = true
+		case "EX", "PX", "EXAT", "PXAT":
+			if opts.expiry != nil || opts.keepTTL || i+1 >= len(args) {
+				return resp.ErrorValue(errors.ErrSyntaxError.Error())
+			}
+			unit, absolute := expireOption(option)
+			expiry, err := parseExpireArg(ctx, "set", args[i+1], unit, absolute)
+			if err != nil {
+				return resp.ErrorValue(err.Error())
+			}
+			opts.expiry = expiry
+			opts.relative = !absolute
 			i++ // Skip the next argument
+		default:
+			return resp.ErrorValue(errors.ErrSyntaxError.Error())
 		}
 	}
 
-	// Store the value as a string
-	ctx.Storage.Set(key, value, expiry)
-
-	// Propagate to replicas - don't do it here, let the server handle it
-
+	previous, written, err := setString(ctx, key, value, opts)
+	switch {
+	case err != nil:
+		return resp.ErrorValue(err.Error())
+	case opts.get && previous == nil:
+		return resp.NullBulkString()
+	case opts.get:
+		return resp.BulkStringValue(*previous)
+	case !written:
+		return resp.NullBulkString()
+	}
 	return resp.SimpleStringValue("OK")
 }
 
@@ -63,6 +184,109 @@ func (c *SetCommand) MaxArgs() int {
 	return -1 // Variable number of arguments
 }
 
+// Flags returns the command flags
+func (c *SetCommand) Flags() Flags {
+	return FlagWrite | FlagDenyOOM
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *SetCommand) KeySpec() KeySpec {
+	return singleKey
+}
+
+// SetNXCommand implements the SETNX command
+type SetNXCommand struct{}
+
+// NewSetNXCommand creates a new SETNX command
+func NewSetNXCommand() *SetNXCommand {
+	return &SetNXCommand{}
+}
+
+// Name returns the command name
+func (c *SetNXCommand) Name() string {
+	return "SETNX"
+}
+
+// Execute runs the SETNX command, SET NX replying whether it wrote the key
+func (c *SetNXCommand) Execute(ctx Context, args []string) resp.Value {
+	if _, written, _ := setString(ctx, args[0], args[1], setArgs{ifAbsent: true}); !written {
+		return resp.IntegerValue(0)
+	}
+	return resp.IntegerValue(1)
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *SetNXCommand) MinArgs() int {
+	return 2
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *SetNXCommand) MaxArgs() int {
+	return 2
+}
+
+// Flags returns the command flags
+func (c *SetNXCommand) Flags() Flags {
+	return FlagWrite | FlagDenyOOM
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *SetNXCommand) KeySpec() KeySpec {
+	return singleKey
+}
+
+// SetExCommand implements the SETEX and PSETEX commands, SET EX and SET PX
+// with the time to live before the value
+type SetExCommand struct {
+	name string
+	unit time.Duration
+}
+
+// NewSetExCommand creates a new SETEX command
+func NewSetExCommand() *SetExCommand {
+	return &SetExCommand{name: "SETEX", unit: time.Second}
+}
+
+// NewPSetExCommand creates a new PSETEX command
+func NewPSetExCommand() *SetExCommand {
+	return &SetExCommand{name: "PSETEX", unit: time.Millisecond}
+}
+
+// Name returns the command name
+func (c *SetExCommand) Name() string {
+	return c.name
+}
+
+// Execute runs the SETEX or PSETEX command
+func (c *SetExCommand) Execute(ctx Context, args []string) resp.Value {
+	expiry, err := parseExpireArg(ctx, strings.ToLower(c.name), args[1], c.unit, false)
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+	setString(ctx, args[0], args[2], setArgs{expiry: expiry, relative: true})
+	return resp.SimpleStringValue("OK")
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *SetExCommand) MinArgs() int {
+	return 3
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *SetExCommand) MaxArgs() int {
+	return 3
+}
+
+// Flags returns the command flags
+func (c *SetExCommand) Flags() Flags {
+	return FlagWrite | FlagDenyOOM
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *SetExCommand) KeySpec() KeySpec {
+	return singleKey
+}
+
 // GetCommand implements the GET command
 type GetCommand struct{}
 
@@ -80,8 +304,12 @@ func (c *GetCommand) Name() string {
 func (c *GetCommand) Execute(ctx Context, args []string) resp.Value {
 	key := args[0]
 
-	value, exists := ctx.Storage.GetString(key)
+	value, exists, err := ctx.lookupString(key)
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
 	if !exists {
+		ctx.notifyKeyspaceEvent(pubsub.ClassKeyMiss, "keymiss", key)
 		return resp.NullBulkString()
 	}
 
@@ -97,3 +325,613 @@ func (c *GetCommand) MinArgs() int {
 func (c *GetCommand) MaxArgs() int {
 	return 1
 }
+
+// Flags returns the command flags
+func (c *GetCommand) Flags() Flags {
+	return FlagReadOnly
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *GetCommand) KeySpec() KeySpec {
+	return singleKey
+}
+
+// checkStringLength returns ErrStringTooLong if a command would grow a
+// string past proto-max-bulk-len, the longest value a client could SET
+func checkStringLength(ctx Context, length int64) error {
+	if length > int64(ctx.Config.ProtocolLimits().MaxBulkLen) {
+		return errors.ErrStringTooLong
+	}
+	return nil
+}
+
+// AppendCommand implements the APPEND command
+type AppendCommand struct{}
+
+// NewAppendCommand creates a new APPEND command
+func NewAppendCommand() *AppendCommand {
+	return &AppendCommand{}
+}
+
+// Name returns the command name
+func (c *AppendCommand) Name() string {
+	return "APPEND"
+}
+
+// Execute runs the APPEND command, creating the key if it is missing
+func (c *AppendCommand) Execute(ctx Context, args []string) resp.Value {
+	key := args[0]
+	suffix := args[1]
+
+	var length int
+	var created bool
+	err := ctx.Storage.Update(key, func(val interface{}, exists bool) (interface{}, error) {
+		if !exists {
+			length = len(suffix)
+			created = true
+			return suffix, nil
+		}
+		str, err := storage.AsString(val)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkStringLength(ctx, int64(len(str))+int64(len(suffix))); err != nil {
+			return nil, err
+		}
+		length = len(str) + len(suffix)
+		return str + suffix, nil
+	})
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+
+	ctx.markDirty(1)
+	ctx.signalModifiedKey(key)
+	if created {
+		ctx.notifyKeyspaceEvent(pubsub.ClassNew, "new", key)
+	}
+	ctx.notifyKeyspaceEvent(pubsub.ClassString, "append", key)
+	return resp.IntegerValue(int64(length))
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *AppendCommand) MinArgs() int {
+	return 2
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *AppendCommand) MaxArgs() int {
+	return 2
+}
+
+// Flags returns the command flags
+func (c *AppendCommand) Flags() Flags {
+	return FlagWrite | FlagDenyOOM
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *AppendCommand) KeySpec() KeySpec {
+	return singleKey
+}
+
+// StrlenCommand implements the STRLEN command
+type StrlenCommand struct{}
+
+// NewStrlenCommand creates a new STRLEN command
+func NewStrlenCommand() *StrlenCommand {
+	return &StrlenCommand{}
+}
+
+// Name returns the command name
+func (c *StrlenCommand) Name() string {
+	return "STRLEN"
+}
+
+// Execute runs the STRLEN command. A missing key has length zero.
+func (c *StrlenCommand) Execute(ctx Context, args []string) resp.Value {
+	value, _, err := ctx.lookupString(args[0])
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+	return resp.IntegerValue(int64(len(value)))
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *StrlenCommand) MinArgs() int {
+	return 1
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *StrlenCommand) MaxArgs() int {
+	return 1
+}
+
+// Flags returns the command flags
+func (c *StrlenCommand) Flags() Flags {
+	return FlagReadOnly
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *StrlenCommand) KeySpec() KeySpec {
+	return singleKey
+}
+
+// GetRangeCommand implements the GETRANGE command
+type GetRangeCommand struct{}
+
+// NewGetRangeCommand creates a new GETRANGE command
+func NewGetRangeCommand() *GetRangeCommand {
+	return &GetRangeCommand{}
+}
+
+// Name returns the command name
+func (c *GetRangeCommand) Name() string {
+	return "GETRANGE"
+}
+
+// Execute runs the GETRANGE command. Both offsets are inclusive, negative
+// ones count from the end, and a range outside the value is clamped to it.
+func (c *GetRangeCommand) Execute(ctx Context, args []string) resp.Value {
+	start, ok := utils.ParseInt64(args[1])
+	if !ok {
+		return resp.ErrorValue(errors.ErrNotInteger.Error())
+	}
+	end, ok := utils.ParseInt64(args[2])
+	if !ok {
+		return resp.ErrorValue(errors.ErrNotInteger.Error())
+	}
+
+	value, _, err := ctx.lookupString(args[0])
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+
+	length := int64(len(value))
+	if start < 0 && end < 0 && start > end {
+		return resp.BulkStringValue("")
+	}
+	if start < 0 {
+		start += length
+	}
+	if end < 0 {
+		end += length
+	}
+	start = max(start, 0)
+	end = min(max(end, 0), length-1)
+	if length == 0 || start > end {
+		return resp.BulkStringValue("")
+	}
+	return resp.BulkStringValue(value[start : end+1])
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *GetRangeCommand) MinArgs() int {
+	return 3
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *GetRangeCommand) MaxArgs() int {
+	return 3
+}
+
+// Flags returns the command flags
+func (c *GetRangeCommand) Flags() Flags {
+	return FlagReadOnly
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *GetRangeCommand) KeySpec() KeySpec {
+	return singleKey
+}
+
+// SetRangeCommand implements the SETRANGE command
+type SetRangeCommand struct{}
+
+// NewSetRangeCommand creates a new SETRANGE command
+func NewSetRangeCommand() *SetRangeCommand {
+	return &SetRangeCommand{}
+}
+
+// Name returns the command name
+func (c *SetRangeCommand) Name() string {
+	return "SETRANGE"
+}
+
+// Execute runs the SETRANGE command. Writing past the end of the value pads
+// it with zero bytes; an empty value changes nothing, not even a missing key.
+func (c *SetRangeCommand) Execute(ctx Context, args []string) resp.Value {
+	key := args[0]
+	patch := args[2]
+	offset, ok := utils.ParseInt64(args[1])
+	if !ok {
+		return resp.ErrorValue(errors.ErrNotInteger.Error())
+	}
+	if offset < 0 {
+		return resp.ErrorValue(errors.ErrOffsetOutOfRange.Error())
+	}
+
+	if patch == "" {
+		value, _, err := ctx.lookupString(key)
+		if err != nil {
+			return resp.ErrorValue(err.Error())
+		}
+		return resp.IntegerValue(int64(len(value)))
+	}
+	if err := checkStringLength(ctx, offset+int64(len(patch))); err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+
+	var length int
+	var created bool
+	err := ctx.Storage.Update(key, func(val interface{}, exists bool) (interface{}, error) {
+		var str string
+		if exists {
+			var err error
+			if str, err = storage.AsString(val); err != nil {
+				return nil, err
+			}
+		}
+		buf := []byte(str)
+		if end := int(offset) + len(patch); end > len(buf) {
+			buf = append(buf, make([]byte, end-len(buf))...)
+		}
+		copy(buf[offset:], patch)
+		length = len(buf)
+		created = !exists
+		return string(buf), nil
+	})
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+
+	ctx.markDirty(1)
+	ctx.signalModifiedKey(key)
+	if created {
+		ctx.notifyKeyspaceEvent(pubsub.ClassNew, "new", key)
+	}
+	ctx.notifyKeyspaceEvent(pubsub.ClassString, "setrange", key)
+	return resp.IntegerValue(int64(length))
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *SetRangeCommand) MinArgs() int {
+	return 3
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *SetRangeCommand) MaxArgs() int {
+	return 3
+}
+
+// Flags returns the command flags
+func (c *SetRangeCommand) Flags() Flags {
+	return FlagWrite | FlagDenyOOM
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *SetRangeCommand) KeySpec() KeySpec {
+	return singleKey
+}
+
+// keyValuePairs turns key value arguments into storage entries
+func keyValuePairs(args []string) []storage.KeyValue {
+	entries := make([]storage.KeyValue, 0, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		entries = append(entries, storage.KeyValue{Key: args[i], Value: args[i+1]})
+	}
+	return entries
+}
+
+// MSetCommand implements the MSET command
+type MSetCommand struct{}
+
+// NewMSetCommand creates a new MSET command
+func NewMSetCommand() *MSetCommand {
+	return &MSetCommand{}
+}
+
+// Name returns the command name
+func (c *MSetCommand) Name() string {
+	return "MSET"
+}
+
+// Execute runs the MSET command, writing every pair at once
+func (c *MSetCommand) Execute(ctx Context, args []string) resp.Value {
+	if len(args)%2 != 0 {
+		return resp.ErrorValue("ERR wrong number of arguments for 'mset' command")
+	}
+
+	created := ctx.Storage.SetMany(keyValuePairs(args))
+	ctx.markDirty(len(args) / 2)
+	for _, key := range created {
+		ctx.notifyKeyspaceEvent(pubsub.ClassNew, "new", key)
+	}
+	for i := 0; i < len(args); i += 2 {
+		ctx.signalModifiedKey(args[i])
+		ctx.notifyKeyspaceEvent(pubsub.ClassString, "set", args[i])
+	}
+	return resp.SimpleStringValue("OK")
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *MSetCommand) MinArgs() int {
+	return 2
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *MSetCommand) MaxArgs() int {
+	return -1
+}
+
+// Flags returns the command flags
+func (c *MSetCommand) Flags() Flags {
+	return FlagWrite | FlagDenyOOM
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *MSetCommand) KeySpec() KeySpec {
+	return KeySpec{First: 0, Last: -1, Step: 2}
+}
+
+// MSetNXCommand implements the MSETNX command
+type MSetNXCommand struct{}
+
+// NewMSetNXCommand creates a new MSETNX command
+func NewMSetNXCommand() *MSetNXCommand {
+	return &MSetNXCommand{}
+}
+
+// Name returns the command name
+func (c *MSetNXCommand) Name() string {
+	return "MSETNX"
+}
+
+// Execute runs the MSETNX command. Nothing is written if any of the keys
+// exists, whatever its type.
+func (c *MSetNXCommand) Execute(ctx Context, args []string) resp.Value {
+	if len(args)%2 != 0 {
+		return resp.ErrorValue("ERR wrong number of arguments for 'msetnx' command")
+	}
+
+	if !ctx.Storage.SetManyIfAbsent(keyValuePairs(args)) {
+		return resp.IntegerValue(0)
+	}
+	ctx.markDirty(len(args) / 2)
+	for i := 0; i < len(args); i += 2 {
+		ctx.signalModifiedKey(args[i])
+		ctx.notifyKeyspaceEvent(pubsub.ClassNew, "new", args[i])
+		ctx.notifyKeyspaceEvent(pubsub.ClassString, "set", args[i])
+	}
+	return resp.IntegerValue(1)
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *MSetNXCommand) MinArgs() int {
+	return 2
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *MSetNXCommand) MaxArgs() int {
+	return -1
+}
+
+// Flags returns the command flags
+func (c *MSetNXCommand) Flags() Flags {
+	return FlagWrite | FlagDenyOOM
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *MSetNXCommand) KeySpec() KeySpec {
+	return KeySpec{First: 0, Last: -1, Step: 2}
+}
+
+// MGetCommand implements the MGET command
+type MGetCommand struct{}
+
+// NewMGetCommand creates a new MGET command
+func NewMGetCommand() *MGetCommand {
+	return &MGetCommand{}
+}
+
+// Name returns the command name
+func (c *MGetCommand) Name() string {
+	return "MGET"
+}
+
+// Execute runs the MGET command. Keys that are missing or don't hold a
+// string reply null in their place rather than failing the whole call.
+func (c *MGetCommand) Execute(ctx Context, args []string) resp.Value {
+	values := make([]resp.Value, len(args))
+	for i, key := range args {
+		value, exists, err := ctx.lookupString(key)
+		if !exists {
+			if err == nil {
+				ctx.notifyKeyspaceEvent(pubsub.ClassKeyMiss, "keymiss", key)
+			}
+			values[i] = resp.NullBulkString()
+			continue
+		}
+		values[i] = resp.BulkStringValue(value)
+	}
+	return resp.ArrayValue(values...)
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *MGetCommand) MinArgs() int {
+	return 1
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *MGetCommand) MaxArgs() int {
+	return -1
+}
+
+// Flags returns the command flags
+func (c *MGetCommand) Flags() Flags {
+	return FlagReadOnly
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *MGetCommand) KeySpec() KeySpec {
+	return KeySpec{First: 0, Last: -1, Step: 1}
+}
+
+// checkString is the check of storage operations on string keys
+func checkString(value interface{}) error {
+	return storage.CheckType(value, storage.TypeString)
+}
+
+// GetDelCommand implements the GETDEL command
+type GetDelCommand struct{}
+
+// NewGetDelCommand creates a new GETDEL command
+func NewGetDelCommand() *GetDelCommand {
+	return &GetDelCommand{}
+}
+
+// Name returns the command name
+func (c *GetDelCommand) Name() string {
+	return "GETDEL"
+}
+
+// Execute runs the GETDEL command, reading and removing the key at once so
+// no other client can read or change it in between
+func (c *GetDelCommand) Execute(ctx Context, args []string) resp.Value {
+	key := args[0]
+
+	value, exists, err := ctx.Storage.GetDel(key, checkString)
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+	if !exists {
+		ctx.notifyKeyspaceEvent(pubsub.ClassKeyMiss, "keymiss", key)
+		return resp.NullBulkString()
+	}
+	str, _ := storage.AsString(value)
+
+	ctx.markDirty(1)
+	ctx.signalModifiedKey(key)
+	ctx.notifyKeyspaceEvent(pubsub.ClassGeneric, "del", key)
+	return resp.BulkStringValue(str)
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *GetDelCommand) MinArgs() int {
+	return 1
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *GetDelCommand) MaxArgs() int {
+	return 1
+}
+
+// Flags returns the command flags
+func (c *GetDelCommand) Flags() Flags {
+	return FlagWrite
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *GetDelCommand) KeySpec() KeySpec {
+	return singleKey
+}
+
+// GetExCommand implements the GETEX command
+type GetExCommand struct{}
+
+// NewGetExCommand creates a new GETEX command
+func NewGetExCommand() *GetExCommand {
+	return &GetExCommand{}
+}
+
+// Name returns the command name
+func (c *GetExCommand) Name() string {
+	return "GETEX"
+}
+
+// Execute runs the GETEX command. Without options it is GET; EX, PX, EXAT,
+// PXAT or PERSIST change the TTL under the same lock the value is read.
+func (c *GetExCommand) Execute(ctx Context, args []string) resp.Value {
+	key := args[0]
+
+	var expiry *time.Time
+	relative := false // The expiry depends on when the command runs
+	change := false
+	for i := 1; i < len(args); i++ {
+		if change {
+			return resp.ErrorValue(errors.ErrSyntaxError.Error())
+		}
+		change = true
+		switch option := strings.ToUpper(args[i]); option {
+		case "EX", "PX", "EXAT", "PXAT":
+			if i+1 >= len(args) {
+				return resp.ErrorValue(errors.ErrSyntaxError.Error())
+			}
+			unit, absolute := expireOption(option)
+			relative = !absolute
+			var err error
+			if expiry, err = parseExpireArg(ctx, "getex", args[i+1], unit, absolute); err != nil {
+				return resp.ErrorValue(err.Error())
+			}
+			i++ // Skip the next argument
+		case "PERSIST":
+		default:
+			return resp.ErrorValue(errors.ErrSyntaxError.Error())
+		}
+	}
+
+	var value interface{}
+	var exists, changed, removed bool
+	var err error
+	if change {
+		value, exists, changed, removed, err = ctx.Storage.GetExpire(key, checkString, expiry)
+	} else {
+		value, exists, err = ctx.lookupType(key, storage.TypeString)
+	}
+	if err != nil {
+		return resp.ErrorValue(err.Error())
+	}
+	if !exists {
+		ctx.notifyKeyspaceEvent(pubsub.ClassKeyMiss, "keymiss", key)
+		return resp.NullBulkString()
+	}
+	str, _ := storage.AsString(value)
+
+	// PERSIST on a key without a TTL changes nothing, so it is not propagated
+	if changed {
+		ctx.markDirty(1)
+		ctx.signalModifiedKey(key)
+		switch {
+		case removed:
+			ctx.replicateAs("DEL", key)
+			ctx.notifyKeyspaceEvent(pubsub.ClassGeneric, "del", key)
+		case expiry == nil:
+			ctx.notifyKeyspaceEvent(pubsub.ClassGeneric, "persist", key)
+		default:
+			if relative {
+				ctx.replicateAs("GETEX", key, "PXAT", strconv.FormatInt(expiry.UnixMilli(), 10))
+			}
+			ctx.notifyKeyspaceEvent(pubsub.ClassGeneric, "expire", key)
+		}
+	}
+	return resp.BulkStringValue(str)
+}
+
+// MinArgs returns the minimum number of arguments
+func (c *GetExCommand) MinArgs() int {
+	return 1
+}
+
+// MaxArgs returns the maximum number of arguments
+func (c *GetExCommand) MaxArgs() int {
+	return -1
+}
+
+// Flags returns the command flags
+func (c *GetExCommand) Flags() Flags {
+	return FlagWrite
+}
+
+// KeySpec returns the positions of the key arguments
+func (c *GetExCommand) KeySpec() KeySpec {
+	return singleKey
+}
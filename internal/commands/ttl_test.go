@@ -0,0 +1,177 @@
+package commands_test
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-redis-go/pkg/redisserver"
+)
+
+// newTestServer returns a server that isn't listening, with its keys
+// expiring by a manual clock
+func newTestServer(t *testing.T) (*redisserver.Server, *redisserver.ManualClock) {
+	t.Helper()
+	clk := redisserver.NewManualClock(time.Unix(1700000000, 0))
+	cfg := redisserver.NewConfig()
+	cfg.Dir = t.TempDir()
+	return redisserver.New(cfg, redisserver.WithClock(clk)), clk
+}
+
+// do runs a command, failing the test on an error reply
+func do(t *testing.T, srv *redisserver.Server, args ...string) redisserver.Reply {
+	t.Helper()
+	reply, err := srv.Do(context.Background(), args...)
+	if err != nil {
+		t.Fatalf("%q: %v", args, err)
+	}
+	return reply
+}
+
+func TestWritesAndTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   []string // Creates the key, which then gets a TTL of 10 seconds
+		command []string
+		keepTTL bool
+	}{
+		{"SET clears the TTL", []string{"SET", "key", "1"}, []string{"SET", "key", "2"}, false},
+		{"SET KEEPTTL keeps it", []string{"SET", "key", "1"}, []string{"SET", "key", "2", "KEEPTTL"}, true},
+		{"SET GET clears it", []string{"SET", "key", "1"}, []string{"SET", "key", "2", "GET"}, false},
+		{"SET XX clears it", []string{"SET", "key", "1"}, []string{"SET", "key", "2", "XX"}, false},
+		{"SET NX on an existing key keeps it", []string{"SET", "key", "1"}, []string{"SET", "key", "2", "NX"}, true},
+		{"APPEND keeps it", []string{"SET", "key", "1"}, []string{"APPEND", "key", "2"}, true},
+		{"INCR keeps it", []string{"SET", "key", "1"}, []string{"INCR", "key"}, true},
+		{"INCRBY keeps it", []string{"SET", "key", "1"}, []string{"INCRBY", "key", "5"}, true},
+		{"DECR keeps it", []string{"SET", "key", "1"}, []string{"DECR", "key"}, true},
+		{"INCRBYFLOAT keeps it", []string{"SET", "key", "1"}, []string{"INCRBYFLOAT", "key", "0.5"}, true},
+		{"SETRANGE keeps it", []string{"SET", "key", "1"}, []string{"SETRANGE", "key", "1", "x"}, true},
+		{"LPUSH keeps it", []string{"RPUSH", "key", "a"}, []string{"LPUSH", "key", "b"}, true},
+		{"RPUSH keeps it", []string{"RPUSH", "key", "a"}, []string{"RPUSH", "key", "b"}, true},
+		{"LSET keeps it", []string{"RPUSH", "key", "a"}, []string{"LSET", "key", "0", "b"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, clk := newTestServer(t)
+			do(t, srv, tt.setup...)
+			do(t, srv, "PEXPIRE", "key", "10000")
+			do(t, srv, tt.command...)
+
+			pttl := do(t, srv, "PTTL", "key").Integer
+			if tt.keepTTL && pttl != 10000 {
+				t.Fatalf("PTTL = %d, want 10000", pttl)
+			}
+			if !tt.keepTTL && pttl != -1 {
+				t.Fatalf("PTTL = %d, want -1", pttl)
+			}
+
+			// A kept TTL still expires the key
+			clk.Advance(10*time.Second + time.Millisecond)
+			want := int64(1)
+			if tt.keepTTL {
+				want = 0
+			}
+			if exists := do(t, srv, "EXISTS", "key").Integer; exists != want {
+				t.Errorf("EXISTS after the TTL = %d, want %d", exists, want)
+			}
+		})
+	}
+}
+
+func TestWritesOnExpiredKey(t *testing.T) {
+	// A write to a key whose TTL passed starts from an empty key without a TTL
+	tests := []struct {
+		name    string
+		setup   []string
+		command []string
+		want    string
+	}{
+		{"APPEND", []string{"SET", "key", "old"}, []string{"APPEND", "key", "new"}, "new"},
+		{"INCR", []string{"SET", "key", "41"}, []string{"INCR", "key"}, "1"},
+		{"SET KEEPTTL", []string{"SET", "key", "old"}, []string{"SET", "key", "new", "KEEPTTL"}, "new"},
+		{"LPUSH", []string{"RPUSH", "key", "old"}, []string{"LPUSH", "key", "new"}, "new"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, clk := newTestServer(t)
+			do(t, srv, tt.setup...)
+			do(t, srv, "PEXPIRE", "key", "1000")
+			clk.Advance(2 * time.Second)
+			do(t, srv, tt.command...)
+
+			if pttl := do(t, srv, "PTTL", "key").Integer; pttl != -1 {
+				t.Errorf("PTTL = %d, want -1", pttl)
+			}
+			var got string
+			if tt.command[0] == "LPUSH" {
+				got = do(t, srv, "LINDEX", "key", "-1").Str
+			} else {
+				got = do(t, srv, "GET", "key").Str
+			}
+			if got != tt.want {
+				t.Errorf("value = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// changes returns rdb_changes_since_last_save, the writes counted so far
+func changes(t *testing.T, srv *redisserver.Server) int64 {
+	t.Helper()
+	info := do(t, srv, "INFO", "persistence").Str
+	for _, line := range strings.Split(info, "\r\n") {
+		if value, ok := strings.CutPrefix(line, "rdb_changes_since_last_save:"); ok {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				t.Fatalf("rdb_changes_since_last_save: %v", err)
+			}
+			return n
+		}
+	}
+	t.Fatal("no rdb_changes_since_last_save in INFO persistence")
+	return 0
+}
+
+func TestGetExTTL(t *testing.T) {
+	// The clock of newTestServer is stopped at 1700000000
+	tests := []struct {
+		name    string
+		ttl     bool // The key has a TTL of 10 seconds
+		option  []string
+		pttl    int64 // PTTL after GETEX, -2 if the key is gone
+		changed bool  // GETEX counts as a write
+	}{
+		{"PERSIST removes a TTL", true, []string{"PERSIST"}, -1, true},
+		{"PERSIST without a TTL changes nothing", false, []string{"PERSIST"}, -1, false},
+		{"EX sets a TTL", false, []string{"EX", "5"}, 5000, true},
+		{"PXAT in the future sets a TTL", false, []string{"PXAT", "1700000003000"}, 3000, true},
+		{"EXAT in the past deletes", true, []string{"EXAT", "1600000000"}, -2, true},
+		{"PXAT now deletes", false, []string{"PXAT", "1700000000000"}, -2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := newTestServer(t)
+			do(t, srv, "SET", "key", "value")
+			if tt.ttl {
+				do(t, srv, "PEXPIRE", "key", "10000")
+			}
+			before := changes(t, srv)
+
+			reply := do(t, srv, append([]string{"GETEX", "key"}, tt.option...)...)
+			if reply.Str != "value" {
+				t.Errorf("GETEX = %q, want %q", reply.Str, "value")
+			}
+			if pttl := do(t, srv, "PTTL", "key").Integer; pttl != tt.pttl {
+				t.Errorf("PTTL = %d, want %d", pttl, tt.pttl)
+			}
+			if changed := changes(t, srv) > before; changed != tt.changed {
+				t.Errorf("counted as a write = %v, want %v", changed, tt.changed)
+			}
+		})
+	}
+}
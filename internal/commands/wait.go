@@ -1,11 +1,12 @@
 package commands
 
 import (
-	"strconv"
+	"math"
 	"time"
 
 	"github.com/codecrafters-redis-go/internal/logger"
 	"github.com/codecrafters-redis-go/internal/resp"
+	"github.com/codecrafters-redis-go/internal/utils"
 )
 
 // WaitCommand implements the WAIT command
@@ -26,14 +27,14 @@ func (c *WaitCommand) Execute(ctx Context, args []string) resp.Value {
 	}
 
 	// Parse numreplicas
-	numReplicas, err := strconv.Atoi(args[0])
-	if err != nil || numReplicas < 0 {
+	numReplicas, ok := utils.ParseInt64(args[0])
+	if !ok || numReplicas < 0 {
 		return resp.ErrorValue("ERR invalid numreplicas")
 	}
 
 	// Parse timeout (in milliseconds)
-	timeout, err := strconv.Atoi(args[1])
-	if err != nil || timeout < 0 {
+	timeout, ok := utils.ParseInt64(args[1])
+	if !ok || timeout < 0 || timeout > math.MaxInt64/int64(time.Millisecond) {
 		return resp.ErrorValue("ERR invalid timeout")
 	}
 
@@ -49,7 +50,7 @@ func (c *WaitCommand) Execute(ctx Context, args []string) resp.Value {
 
 	// Type assert to get the actual server with WaitForReplicas method
 	type serverWaiter interface {
-		WaitForReplicas(int, time.Duration) int
+		WaitForReplicas(int, time.Duration, <-chan struct{}) int
 	}
 
 	waiter, ok := ctx.Server.(serverWaiter)
@@ -58,17 +59,19 @@ func (c *WaitCommand) Execute(ctx Context, args []string) resp.Value {
 		replicas := ctx.Server.GetReplicas()
 		return resp.Value{
 			Type:    resp.Integer,
-			Integer: len(replicas),
+			Integer: int64(len(replicas)),
 		}
 	}
 
-	// Wait for replicas to acknowledge
-	synchronizedCount := waiter.WaitForReplicas(numReplicas, timeoutDuration)
+	// Wait for replicas to acknowledge, giving up if the client disconnects
+	unblock := markBlocked(ctx)
+	synchronizedCount := waiter.WaitForReplicas(int(min(numReplicas, math.MaxInt32)), timeoutDuration, ctx.Done())
+	unblock()
 
 	// Return the count of synchronized replicas
 	return resp.Value{
 		Type:    resp.Integer,
-		Integer: synchronizedCount,
+		Integer: int64(synchronizedCount),
 	}
 }
 
@@ -81,3 +84,8 @@ func (c *WaitCommand) MinArgs() int {
 func (c *WaitCommand) MaxArgs() int {
 	return 2
 }
+
+// Flags returns the command flags
+func (c *WaitCommand) Flags() Flags {
+	return FlagBlocking
+}
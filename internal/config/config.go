@@ -2,8 +2,16 @@ package config
 
 import (
 	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/pubsub"
+	"github.com/codecrafters-redis-go/internal/resp"
 )
 
 // Config holds the Redis server configuration
@@ -13,24 +21,259 @@ type Config struct {
 	DBFilename string
 	Port       int
 	ReplicaOf  string // Format: "host port"
+	MasterAuth string // Password used to authenticate with master
+	MasterUser string // ACL user used to authenticate with master
+
+	Daemonize  bool   // Detach from the terminal and run in the background
+	PidFile    string // Write the process id here, empty for none unless daemonized
+	Supervised string // no, upstart, systemd or auto
+
+	LogLevel       string // debug, verbose, notice, warning or nothing
+	LogFile        string // Log to this file, empty for stdout
+	SyslogEnabled  bool   // Log to syslog instead
+	SyslogIdent    string
+	SyslogFacility string
+
+	RequirePass     string // Password clients must AUTH with, empty disables authentication
+	ReplicaReadOnly bool   // Reject writes from clients while running as a replica
+	ReplicaPriority int    // Sentinel promotes replicas with lower values first, 0 never
+	SentinelMaster  string // Master name in the +switch-master message published on promotion
+
+	Timeout int // Close client connections idle for this many seconds, 0 disables
+
+	NotifyKeyspaceEvents string // Keyspace event classes published over pub/sub, empty disables
+
+	HealthPort int // Serve HTTP /healthz and /readyz probes on this port, 0 disables
+	DebugPort  int // Serve net/http/pprof on this port of the loopback interface, 0 disables
+
+	OTelEndpoint     string  // Export trace spans to this OTLP/HTTP collector, empty disables tracing
+	OTelSamplerRatio float64 // Fraction of traces recorded, from 0 to 1
+
+	SlowlogLogSlowerThan int // Log commands slower than this many microseconds, negative disables
+	SlowlogMaxLen        int // Maximum number of slowlog entries kept
+
+	MaxMemory             uint64 // Reject commands that grow memory above this many bytes, 0 means unlimited
+	MaxClients            int    // Maximum number of connected clients
+	MaxConcurrentCommands int    // Maximum commands executing at once, 0 means unlimited
+
+	OutputBufferLimits map[string]OutputBufferLimit // By client class, see client-output-buffer-limit
+
+	ProtoMaxBulkLen      uint64 // Longest request argument in bytes
+	ProtoInlineMaxSize   uint64 // Longest inline request or multibulk header line in bytes
+	ProtoMaxMultibulkLen int    // Most arguments in one request
+
+	HashMaxListpackEntries int // Hashes with more fields are converted to a hashtable
+	HashMaxListpackValue   int // Hashes with a longer field or value are converted to a hashtable
+	ListMaxListpackSize    int // Entries per list node, or -1 to -5 for 4kb to 64kb nodes
+	SetMaxIntsetEntries    int // Integer sets with more members are converted to a hashtable
+	ZSetMaxListpackEntries int // Sorted sets with more members are converted to a skiplist
+
+	Save string // Snapshot rules as "<seconds> <changes>" pairs, empty disables snapshotting
+
+	AppendOnly     bool
+	AppendDirName  string
+	AppendFilename string
+	AppendFsync    string // always, everysec or no
+
+	configFile string     // Absolute path of the config file, empty if none
+	overrides  []override // Command line parameters, applied over the file on reload
 }
 
 // New creates a new configuration with default values
 func New() *Config {
 	return &Config{
-		Dir:        ".",
-		DBFilename: "dump.rdb",
-		Port:       6379,
+		Dir:                  ".",
+		DBFilename:           "dump.rdb",
+		Port:                 6379,
+		Supervised:           "no",
+		LogLevel:             "notice",
+		SyslogIdent:          "redis",
+		SyslogFacility:       "local0",
+		ReplicaReadOnly:      true,
+		ReplicaPriority:      100,
+		SentinelMaster:       "mymaster",
+		SlowlogLogSlowerThan: 10000,
+		SlowlogMaxLen:        128,
+		OTelSamplerRatio:     1,
+		MaxClients:           10000,
+		OutputBufferLimits:   defaultOutputBufferLimits(),
+		ProtoMaxBulkLen:      512 * 1024 * 1024,
+		ProtoInlineMaxSize:   64 * 1024,
+		ProtoMaxMultibulkLen: math.MaxInt32,
+		AppendDirName:        "appendonlydir",
+		AppendFilename:       "appendonly.aof",
+		AppendFsync:          "everysec",
+
+		HashMaxListpackEntries: 128,
+		HashMaxListpackValue:   64,
+		ListMaxListpackSize:    -2,
+		SetMaxIntsetEntries:    512,
+		ZSetMaxListpackEntries: 128,
+	}
+}
+
+// yesNoFlag adapts a bool to the yes/no syntax used by redis.conf
+type yesNoFlag struct {
+	value *bool
+}
+
+func (flag yesNoFlag) String() string {
+	if flag.value == nil {
+		return ""
+	}
+	return formatYesNo(*flag.value)
+}
+
+func (flag yesNoFlag) Set(value string) error {
+	parsed, ok := parseYesNo(value)
+	if !ok {
+		return fmt.Errorf("argument must be 'yes' or 'no'")
+	}
+	*flag.value = parsed
+	return nil
+}
+
+// keyspaceEventsFlag stores a notify-keyspace-events value in its canonical form
+type keyspaceEventsFlag struct {
+	value *string
+}
+
+func (flag keyspaceEventsFlag) String() string {
+	if flag.value == nil {
+		return ""
 	}
+	return *flag.value
 }
 
-// ParseFlags parses command-line flags and updates the configuration
+func (flag keyspaceEventsFlag) Set(value string) error {
+	classes, err := pubsub.ParseEventClasses(value)
+	if err != nil {
+		return err
+	}
+	*flag.value = pubsub.FormatEventClasses(classes)
+	return nil
+}
+
+// memoryFlag adapts a byte count to the memory units used by redis.conf
+type memoryFlag struct {
+	value *uint64
+}
+
+func (flag memoryFlag) String() string {
+	if flag.value == nil {
+		return ""
+	}
+	return strconv.FormatUint(*flag.value, 10)
+}
+
+func (flag memoryFlag) Set(value string) error {
+	parsed, ok := parseMemory(value)
+	if !ok {
+		return fmt.Errorf("argument must be a memory value")
+	}
+	*flag.value = parsed
+	return nil
+}
+
+// parseMemory parses a byte count with an optional unit such as 100mb or 1gb
+func parseMemory(value string) (uint64, bool) {
+	value = strings.ToLower(value)
+	units := []struct {
+		suffix     string
+		multiplier uint64
+	}{
+		{"kb", 1024}, {"mb", 1024 * 1024}, {"gb", 1024 * 1024 * 1024},
+		{"k", 1000}, {"m", 1000 * 1000}, {"g", 1000 * 1000 * 1000},
+		{"b", 1},
+	}
+	multiplier := uint64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSuffix(value, unit.suffix)
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	number, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return number * multiplier, true
+}
+
+// parseYesNo parses a redis.conf boolean
+func parseYesNo(value string) (bool, bool) {
+	switch strings.ToLower(value) {
+	case "yes":
+		return true, true
+	case "no":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// formatYesNo formats a boolean the way CONFIG GET reports it
+func formatYesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
+
+// ParseFlags parses command-line flags and updates the configuration. A
+// config file may be given as the first argument; flags override its values.
 func (config *Config) ParseFlags() {
 	flag.StringVar(&config.Dir, "dir", config.Dir, "The directory where RDB files are stored")
 	flag.StringVar(&config.DBFilename, "dbfilename", config.DBFilename, "The name of the RDB file")
 	flag.IntVar(&config.Port, "port", config.Port, "The port to listen on")
 	flag.StringVar(&config.ReplicaOf, "replicaof", config.ReplicaOf, "Make this server a replica of <host> <port>")
+	flag.StringVar(&config.MasterAuth, "masterauth", config.MasterAuth, "Password used to authenticate with the master")
+	flag.StringVar(&config.MasterUser, "masteruser", config.MasterUser, "Username used to authenticate with the master")
+	flag.Var(yesNoFlag{&config.Daemonize}, "daemonize", "Run in the background (yes or no)")
+	flag.StringVar(&config.PidFile, "pidfile", config.PidFile, "Write the process id to this file")
+	flag.Var(keyspaceEventsFlag{&config.NotifyKeyspaceEvents}, "notify-keyspace-events", "Keyspace event classes to publish, e.g. KEA")
+	flag.IntVar(&config.HealthPort, "health-port", config.HealthPort, "Serve HTTP health probes on this port, 0 disables")
+	flag.IntVar(&config.DebugPort, "debug-port", config.DebugPort, "Serve pprof profiles on this port of 127.0.0.1, 0 disables")
+	flag.StringVar(&config.OTelEndpoint, "otel-exporter-otlp-endpoint", config.OTelEndpoint, "Export trace spans to this OTLP/HTTP collector, e.g. http://localhost:4318")
+	flag.Float64Var(&config.OTelSamplerRatio, "otel-traces-sampler-ratio", config.OTelSamplerRatio, "Fraction of traces recorded, from 0 to 1")
+	flag.StringVar(&config.Supervised, "supervised", config.Supervised, "Supervision mode: no, upstart, systemd or auto")
+	flag.StringVar(&config.LogLevel, "loglevel", config.LogLevel, "Log verbosity: debug, verbose, notice, warning or nothing")
+	flag.StringVar(&config.LogFile, "logfile", config.LogFile, "Log to this file instead of stdout")
+	flag.Var(yesNoFlag{&config.SyslogEnabled}, "syslog-enabled", "Log to syslog (yes or no)")
+	flag.StringVar(&config.SyslogIdent, "syslog-ident", config.SyslogIdent, "Syslog identity")
+	flag.StringVar(&config.SyslogFacility, "syslog-facility", config.SyslogFacility, "Syslog facility: user or local0 through local7")
+	flag.StringVar(&config.RequirePass, "requirepass", config.RequirePass, "Require clients to AUTH with this password")
+	flag.Var(yesNoFlag{&config.ReplicaReadOnly}, "replica-read-only", "Reject writes from clients while running as a replica (yes or no)")
+	flag.IntVar(&config.ReplicaPriority, "replica-priority", config.ReplicaPriority, "Sentinel promotes replicas with a lower priority first, 0 never")
+	flag.StringVar(&config.SentinelMaster, "sentinel-master-name", config.SentinelMaster, "Master name in the +switch-master message published on promotion")
+	flag.IntVar(&config.SlowlogLogSlowerThan, "slowlog-log-slower-than", config.SlowlogLogSlowerThan, "Log commands slower than N microseconds (negative to disable)")
+	flag.IntVar(&config.SlowlogMaxLen, "slowlog-max-len", config.SlowlogMaxLen, "Maximum number of slowlog entries")
+	flag.IntVar(&config.Timeout, "timeout", config.Timeout, "Close the connection after a client is idle for N seconds (0 to disable)")
+	flag.Var(memoryFlag{&config.MaxMemory}, "maxmemory", "Reject commands that grow memory above this limit, e.g. 100mb (0 for unlimited)")
+	flag.IntVar(&config.MaxClients, "maxclients", config.MaxClients, "Maximum number of connected clients")
+	flag.IntVar(&config.MaxConcurrentCommands, "max-concurrent-commands", config.MaxConcurrentCommands, "Maximum number of commands executing at once (0 for unlimited)")
+	flag.Var(outputBufferLimitsFlag{&config.OutputBufferLimits}, "client-output-buffer-limit", "Disconnect clients with more pending output, as \"<class> <hard> <soft> <soft seconds> ...\"")
+	flag.Var(memoryFlag{&config.ProtoMaxBulkLen}, "proto-max-bulk-len", "Longest request argument accepted, e.g. 512mb")
+	flag.Var(memoryFlag{&config.ProtoInlineMaxSize}, "proto-inline-max-size", "Longest inline request accepted, e.g. 64kb")
+	flag.IntVar(&config.ProtoMaxMultibulkLen, "proto-max-multibulk-len", config.ProtoMaxMultibulkLen, "Most arguments accepted in one request")
+	flag.IntVar(&config.HashMaxListpackEntries, "hash-max-listpack-entries", config.HashMaxListpackEntries, "Convert hashes with more fields to a hashtable")
+	flag.IntVar(&config.HashMaxListpackValue, "hash-max-listpack-value", config.HashMaxListpackValue, "Convert hashes with a longer field or value to a hashtable")
+	flag.IntVar(&config.ListMaxListpackSize, "list-max-listpack-size", config.ListMaxListpackSize, "Entries per list node, or -1 to -5 for nodes of 4kb to 64kb")
+	flag.IntVar(&config.SetMaxIntsetEntries, "set-max-intset-entries", config.SetMaxIntsetEntries, "Convert integer sets with more members to a hashtable")
+	flag.IntVar(&config.ZSetMaxListpackEntries, "zset-max-listpack-entries", config.ZSetMaxListpackEntries, "Convert sorted sets with more members to a skiplist")
+	flag.StringVar(&config.Save, "save", config.Save, "Snapshot rules as \"<seconds> <changes> ...\"; empty disables saving")
+	flag.Var(yesNoFlag{&config.AppendOnly}, "appendonly", "Enable the append only file (yes or no)")
+	flag.StringVar(&config.AppendDirName, "appenddirname", config.AppendDirName, "The directory inside dir holding the AOF files")
+	flag.StringVar(&config.AppendFilename, "appendfilename", config.AppendFilename, "The base name of the AOF files")
+	flag.StringVar(&config.AppendFsync, "appendfsync", config.AppendFsync, "AOF fsync policy: always, everysec or no")
 	flag.Parse()
+
+	if err := config.parseConfigFileArgs(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
 }
 
 // Get retrieves a configuration value by key
@@ -43,6 +286,84 @@ func (config *Config) Get(key string) (string, bool) {
 		return config.Dir, true
 	case "dbfilename":
 		return config.DBFilename, true
+	case "masterauth":
+		return config.MasterAuth, true
+	case "masteruser":
+		return config.MasterUser, true
+	case "daemonize":
+		return formatYesNo(config.Daemonize), true
+	case "pidfile":
+		return config.PidFile, true
+	case "supervised":
+		return config.Supervised, true
+	case "loglevel":
+		return config.LogLevel, true
+	case "logfile":
+		return config.LogFile, true
+	case "syslog-enabled":
+		return formatYesNo(config.SyslogEnabled), true
+	case "syslog-ident":
+		return config.SyslogIdent, true
+	case "syslog-facility":
+		return config.SyslogFacility, true
+	case "requirepass":
+		return config.RequirePass, true
+	case "replica-read-only":
+		return formatYesNo(config.ReplicaReadOnly), true
+	case "replica-priority":
+		return strconv.Itoa(config.ReplicaPriority), true
+	case "sentinel-master-name":
+		return config.SentinelMaster, true
+	case "timeout":
+		return strconv.Itoa(config.Timeout), true
+	case "health-port":
+		return strconv.Itoa(config.HealthPort), true
+	case "debug-port":
+		return strconv.Itoa(config.DebugPort), true
+	case "otel-exporter-otlp-endpoint":
+		return config.OTelEndpoint, true
+	case "otel-traces-sampler-ratio":
+		return strconv.FormatFloat(config.OTelSamplerRatio, 'g', -1, 64), true
+	case "notify-keyspace-events":
+		return config.NotifyKeyspaceEvents, true
+	case "slowlog-log-slower-than":
+		return strconv.Itoa(config.SlowlogLogSlowerThan), true
+	case "slowlog-max-len":
+		return strconv.Itoa(config.SlowlogMaxLen), true
+	case "maxmemory":
+		return strconv.FormatUint(config.MaxMemory, 10), true
+	case "maxclients":
+		return strconv.Itoa(config.MaxClients), true
+	case "max-concurrent-commands":
+		return strconv.Itoa(config.MaxConcurrentCommands), true
+	case "client-output-buffer-limit":
+		return outputBufferLimitsFlag{&config.OutputBufferLimits}.String(), true
+	case "proto-max-bulk-len":
+		return strconv.FormatUint(config.ProtoMaxBulkLen, 10), true
+	case "proto-inline-max-size":
+		return strconv.FormatUint(config.ProtoInlineMaxSize, 10), true
+	case "proto-max-multibulk-len":
+		return strconv.Itoa(config.ProtoMaxMultibulkLen), true
+	case "hash-max-listpack-entries":
+		return strconv.Itoa(config.HashMaxListpackEntries), true
+	case "hash-max-listpack-value":
+		return strconv.Itoa(config.HashMaxListpackValue), true
+	case "list-max-listpack-size":
+		return strconv.Itoa(config.ListMaxListpackSize), true
+	case "set-max-intset-entries":
+		return strconv.Itoa(config.SetMaxIntsetEntries), true
+	case "zset-max-listpack-entries":
+		return strconv.Itoa(config.ZSetMaxListpackEntries), true
+	case "save":
+		return config.Save, true
+	case "appendonly":
+		return formatYesNo(config.AppendOnly), true
+	case "appenddirname":
+		return config.AppendDirName, true
+	case "appendfilename":
+		return config.AppendFilename, true
+	case "appendfsync":
+		return config.AppendFsync, true
 	default:
 		return "", false
 	}
@@ -60,11 +381,300 @@ func (config *Config) Set(key, value string) bool {
 	case "dbfilename":
 		config.DBFilename = value
 		return true
+	case "masterauth":
+		config.MasterAuth = value
+		return true
+	case "masteruser":
+		config.MasterUser = value
+		return true
+	case "loglevel":
+		switch strings.ToLower(value) {
+		case "debug", "verbose", "notice", "warning", "nothing", "info", "warn", "error":
+			config.LogLevel = strings.ToLower(value)
+			return true
+		}
+		return false
+	case "requirepass":
+		config.RequirePass = value
+		return true
+	case "replica-read-only":
+		readOnly, ok := parseYesNo(value)
+		if !ok {
+			return false
+		}
+		config.ReplicaReadOnly = readOnly
+		return true
+	case "slowlog-log-slower-than":
+		threshold, err := strconv.Atoi(value)
+		if err != nil {
+			return false
+		}
+		config.SlowlogLogSlowerThan = threshold
+		return true
+	case "slowlog-max-len":
+		maxLen, err := strconv.Atoi(value)
+		if err != nil || maxLen < 0 {
+			return false
+		}
+		config.SlowlogMaxLen = maxLen
+		return true
+	case "replica-priority":
+		priority, err := strconv.Atoi(value)
+		if err != nil || priority < 0 {
+			return false
+		}
+		config.ReplicaPriority = priority
+		return true
+	case "sentinel-master-name":
+		if value == "" || strings.ContainsAny(value, " \t\r\n") {
+			return false
+		}
+		config.SentinelMaster = value
+		return true
+	case "timeout":
+		timeout, err := strconv.Atoi(value)
+		if err != nil || timeout < 0 {
+			return false
+		}
+		config.Timeout = timeout
+		return true
+	case "maxmemory":
+		maxMemory, ok := parseMemory(value)
+		if !ok {
+			return false
+		}
+		config.MaxMemory = maxMemory
+		return true
+	case "maxclients":
+		maxClients, err := strconv.Atoi(value)
+		if err != nil || maxClients < 1 {
+			return false
+		}
+		config.MaxClients = maxClients
+		return true
+	case "client-output-buffer-limit":
+		return outputBufferLimitsFlag{&config.OutputBufferLimits}.Set(value) == nil
+	case "otel-traces-sampler-ratio":
+		ratio, err := strconv.ParseFloat(value, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return false
+		}
+		config.OTelSamplerRatio = ratio
+		return true
+	case "proto-max-bulk-len", "proto-inline-max-size":
+		size, ok := parseMemory(value)
+		if !ok || size < 1 || size > math.MaxInt32 {
+			return false
+		}
+		if key == "proto-max-bulk-len" {
+			config.ProtoMaxBulkLen = size
+		} else {
+			config.ProtoInlineMaxSize = size
+		}
+		return true
+	case "proto-max-multibulk-len":
+		count, err := strconv.Atoi(value)
+		if err != nil || count < 1 {
+			return false
+		}
+		config.ProtoMaxMultibulkLen = count
+		return true
+	case "hash-max-listpack-entries", "hash-max-listpack-value", "set-max-intset-entries", "zset-max-listpack-entries":
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			return false
+		}
+		switch key {
+		case "hash-max-listpack-entries":
+			config.HashMaxListpackEntries = limit
+		case "hash-max-listpack-value":
+			config.HashMaxListpackValue = limit
+		case "set-max-intset-entries":
+			config.SetMaxIntsetEntries = limit
+		default:
+			config.ZSetMaxListpackEntries = limit
+		}
+		return true
+	case "list-max-listpack-size":
+		size, err := strconv.Atoi(value)
+		if err != nil || !validListpackSize(size) {
+			return false
+		}
+		config.ListMaxListpackSize = size
+		return true
+	case "save":
+		config.Save = value
+		return true
+	case "notify-keyspace-events":
+		return keyspaceEventsFlag{&config.NotifyKeyspaceEvents}.Set(value) == nil
 	default:
 		return false
 	}
 }
 
+// Names returns the names of all parameters understood by Get
+func (config *Config) Names() []string {
+	return []string{
+		"dir", "dbfilename", "masterauth", "masteruser", "daemonize", "pidfile", "supervised",
+		"loglevel", "logfile", "syslog-enabled", "syslog-ident", "syslog-facility", "requirepass",
+		"replica-read-only", "replica-priority", "sentinel-master-name", "timeout", "health-port", "debug-port",
+		"otel-exporter-otlp-endpoint", "otel-traces-sampler-ratio", "notify-keyspace-events", "slowlog-log-slower-than", "slowlog-max-len",
+		"maxmemory", "maxclients", "max-concurrent-commands", "client-output-buffer-limit",
+		"proto-max-bulk-len", "proto-inline-max-size", "proto-max-multibulk-len",
+		"hash-max-listpack-entries", "hash-max-listpack-value", "list-max-listpack-size",
+		"set-max-intset-entries", "zset-max-listpack-entries", "save",
+		"appendonly", "appenddirname", "appendfilename", "appendfsync",
+	}
+}
+
+// IdleTimeout returns how long a client may stay idle, or 0 if unlimited
+func (config *Config) IdleTimeout() time.Duration {
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+	return time.Duration(config.Timeout) * time.Second
+}
+
+// GetMaxMemory returns the memory limit in bytes, or 0 if unlimited
+func (config *Config) GetMaxMemory() uint64 {
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+	return config.MaxMemory
+}
+
+// GetMaxClients returns the maximum number of connected clients
+func (config *Config) GetMaxClients() int {
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+	return config.MaxClients
+}
+
+// ProtocolLimits returns the request size limits client connections enforce
+func (config *Config) ProtocolLimits() resp.Limits {
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+	return resp.Limits{
+		MaxInlineLen:    int(config.ProtoInlineMaxSize),
+		MaxBulkLen:      int(config.ProtoMaxBulkLen),
+		MaxMultibulkLen: config.ProtoMaxMultibulkLen,
+	}
+}
+
+// EncodingLimits are the sizes up to which aggregate values keep a compact
+// encoding, see the *-max-listpack-* and set-max-intset-entries parameters
+type EncodingLimits struct {
+	HashListpackEntries int
+	HashListpackValue   int
+	ListListpackSize    int // Entries per node, or -1 to -5 for 4kb to 64kb nodes
+	SetIntsetEntries    int
+	ZSetListpackEntries int
+}
+
+// GetEncodingLimits returns the encoding limits, which writes apply to the
+// key they change
+func (config *Config) GetEncodingLimits() EncodingLimits {
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+	return EncodingLimits{
+		HashListpackEntries: config.HashMaxListpackEntries,
+		HashListpackValue:   config.HashMaxListpackValue,
+		ListListpackSize:    config.ListMaxListpackSize,
+		SetIntsetEntries:    config.SetMaxIntsetEntries,
+		ZSetListpackEntries: config.ZSetMaxListpackEntries,
+	}
+}
+
+// validListpackSize returns true for a list-max-listpack-size: a positive
+// number of entries or -1 to -5
+func validListpackSize(size int) bool {
+	return size > 0 || size >= -5 && size <= -1
+}
+
+// SaveEnabled returns true if snapshot rules are configured
+func (config *Config) SaveEnabled() bool {
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+	return strings.TrimSpace(config.Save) != ""
+}
+
+// SaveRule snapshots the dataset once Changes writes happened within Seconds
+type SaveRule struct {
+	Seconds int
+	Changes int
+}
+
+// SaveRules returns the parsed snapshot rules
+func (config *Config) SaveRules() []SaveRule {
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+
+	fields := strings.Fields(config.Save)
+	rules := make([]SaveRule, 0, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		seconds, err1 := strconv.Atoi(fields[i])
+		changes, err2 := strconv.Atoi(fields[i+1])
+		if err1 == nil && err2 == nil {
+			rules = append(rules, SaveRule{Seconds: seconds, Changes: changes})
+		}
+	}
+	return rules
+}
+
+// GetReplicaPriority returns the priority Sentinel promotes this server with
+func (config *Config) GetReplicaPriority() int {
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+	return config.ReplicaPriority
+}
+
+// GetSentinelMaster returns the master name of +switch-master messages
+func (config *Config) GetSentinelMaster() string {
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+	return config.SentinelMaster
+}
+
+// GetOTelSamplerRatio returns the fraction of traces recorded
+func (config *Config) GetOTelSamplerRatio() float64 {
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+	return config.OTelSamplerRatio
+}
+
+// GetPidFile returns the pidfile path. A daemonized server without an
+// explicit pidfile uses /var/run/redis_<port>.pid like Redis does.
+func (config *Config) GetPidFile() string {
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+	if config.PidFile == "" && config.Daemonize {
+		return fmt.Sprintf("/var/run/redis_%d.pid", config.Port)
+	}
+	return config.PidFile
+}
+
+// GetRequirePass returns the password clients must authenticate with
+func (config *Config) GetRequirePass() string {
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+	return config.RequirePass
+}
+
+// IsReadOnlyReplica returns true if client writes must be rejected
+func (config *Config) IsReadOnlyReplica() bool {
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+	return config.ReplicaOf != "" && config.ReplicaReadOnly
+}
+
+// SlowlogSettings returns the slowlog threshold and maximum length
+func (config *Config) SlowlogSettings() (threshold time.Duration, maxLen int) {
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+	if config.SlowlogLogSlowerThan < 0 {
+		return -1, config.SlowlogMaxLen
+	}
+	return time.Duration(config.SlowlogLogSlowerThan) * time.Microsecond, config.SlowlogMaxLen
+}
+
 // IsReplica returns true if this server is configured as a replica
 func (config *Config) IsReplica() bool {
 	config.mu.RLock()
@@ -72,6 +682,21 @@ func (config *Config) IsReplica() bool {
 	return config.ReplicaOf != ""
 }
 
+// SetReplicaOf changes the master at runtime, as "host port" or empty to
+// become a master
+func (config *Config) SetReplicaOf(replicaOf string) {
+	config.mu.Lock()
+	defer config.mu.Unlock()
+	config.ReplicaOf = replicaOf
+}
+
+// GetMasterAuth returns the credentials used to authenticate with master
+func (config *Config) GetMasterAuth() (user string, password string) {
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+	return config.MasterUser, config.MasterAuth
+}
+
 // GetReplicaInfo parses and returns the master host and port
 func (config *Config) GetReplicaInfo() (host string, port string) {
 	config.mu.RLock()
@@ -89,3 +714,10 @@ func (config *Config) GetReplicaInfo() (host string, port string) {
 
 	return "", ""
 }
+
+// GetNotifyKeyspaceEvents returns the keyspace event classes to publish
+func (config *Config) GetNotifyKeyspaceEvents() string {
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+	return config.NotifyKeyspaceEvents
+}
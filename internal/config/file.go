@@ -0,0 +1,300 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// runtimeMutable lists the parameters a reload may change on a running server.
+// The others are only read at startup.
+var runtimeMutable = map[string]bool{
+	"loglevel":                   true,
+	"save":                       true,
+	"maxmemory":                  true,
+	"maxclients":                 true,
+	"client-output-buffer-limit": true,
+	"proto-max-bulk-len":         true,
+	"proto-inline-max-size":      true,
+	"proto-max-multibulk-len":    true,
+	"timeout":                    true,
+	"requirepass":                true,
+	"masterauth":                 true,
+	"masteruser":                 true,
+	"replica-read-only":          true,
+	"replica-priority":           true,
+	"sentinel-master-name":       true,
+	"slowlog-log-slower-than":    true,
+	"slowlog-max-len":            true,
+	"notify-keyspace-events":     true,
+	"otel-traces-sampler-ratio":  true,
+	"hash-max-listpack-entries":  true,
+	"hash-max-listpack-value":    true,
+	"list-max-listpack-size":     true,
+	"set-max-intset-entries":     true,
+	"zset-max-listpack-entries":  true,
+}
+
+// IsRuntimeMutable returns true if CONFIG SET and reloads may change name on
+// a running server
+func IsRuntimeMutable(name string) bool {
+	return runtimeMutable[name]
+}
+
+// override is a parameter given on the command line, which wins over the file
+type override struct {
+	name  string
+	value string
+}
+
+// parseConfigFileArgs handles "redis-server <file> [flags]": flags after the
+// file name are parsed as well, then the file is loaded and the explicit
+// flags are applied on top of it
+func (config *Config) parseConfigFileArgs() error {
+	if flag.NArg() == 0 {
+		return nil
+	}
+
+	path, err := filepath.Abs(flag.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := flag.CommandLine.Parse(flag.Args()[1:]); err != nil {
+		return err
+	}
+
+	flag.Visit(func(f *flag.Flag) {
+		if config.known(f.Name) {
+			config.overrides = append(config.overrides, override{name: f.Name, value: f.Value.String()})
+		}
+	})
+
+	if err := config.LoadFile(path); err != nil {
+		return err
+	}
+	for _, o := range config.overrides {
+		if err := config.apply(o.name, o.value); err != nil {
+			return err
+		}
+	}
+	config.configFile = path
+	return nil
+}
+
+// known returns true if name is a configuration parameter
+func (config *Config) known(name string) bool {
+	if name == "port" || name == "replicaof" {
+		return true
+	}
+	_, ok := config.Get(name)
+	return ok
+}
+
+// LoadFile reads parameters from a redis.conf style file: one "name value"
+// directive per line, # comments and double quoted values
+func (config *Config) LoadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	var saveRules []string
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields, err := splitConfigLine(line)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, lineNum, err)
+		}
+		name := strings.ToLower(fields[0])
+		value := strings.Join(fields[1:], " ")
+
+		// Every save directive adds a rule; "save ''" clears them
+		if name == "save" {
+			if value == "" {
+				saveRules = []string{}
+			} else {
+				saveRules = append(saveRules, value)
+			}
+			continue
+		}
+
+		if err := config.apply(name, value); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, lineNum, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if saveRules != nil {
+		config.mu.Lock()
+		config.Save = strings.Join(saveRules, " ")
+		config.mu.Unlock()
+	}
+	return nil
+}
+
+// splitConfigLine splits a directive into its words, honoring double quotes
+func splitConfigLine(line string) ([]string, error) {
+	var fields []string
+	var current strings.Builder
+	inQuotes, quoted := false, false
+
+	for i := 0; i < len(line); i++ {
+		char := line[i]
+		switch {
+		case inQuotes && char == '\\' && i+1 < len(line):
+			i++
+			current.WriteByte(line[i])
+		case char == '"':
+			inQuotes = !inQuotes
+			quoted = true
+		case !inQuotes && (char == ' ' || char == '\t'):
+			if current.Len() > 0 || quoted {
+				fields = append(fields, current.String())
+				current.Reset()
+				quoted = false
+			}
+		default:
+			current.WriteByte(char)
+		}
+	}
+	if inQuotes {
+		return nil, errors.New("unbalanced quotes")
+	}
+	if current.Len() > 0 || quoted {
+		fields = append(fields, current.String())
+	}
+	return fields, nil
+}
+
+// apply sets any parameter, including those that can only be set at startup
+func (config *Config) apply(name, value string) error {
+	config.mu.Lock()
+	handled := true
+	var err error
+	switch name {
+	case "port":
+		config.Port, err = strconv.Atoi(value)
+	case "replicaof", "slaveof":
+		config.ReplicaOf = value
+	case "daemonize":
+		err = yesNoFlag{&config.Daemonize}.Set(value)
+	case "pidfile":
+		config.PidFile = value
+	case "supervised":
+		config.Supervised = strings.ToLower(value)
+	case "health-port":
+		config.HealthPort, err = strconv.Atoi(value)
+	case "debug-port":
+		config.DebugPort, err = strconv.Atoi(value)
+	case "otel-exporter-otlp-endpoint":
+		config.OTelEndpoint = value
+	case "logfile":
+		config.LogFile = value
+	case "syslog-enabled":
+		err = yesNoFlag{&config.SyslogEnabled}.Set(value)
+	case "syslog-ident":
+		config.SyslogIdent = value
+	case "syslog-facility":
+		config.SyslogFacility = value
+	case "max-concurrent-commands":
+		config.MaxConcurrentCommands, err = strconv.Atoi(value)
+	case "appendonly":
+		err = yesNoFlag{&config.AppendOnly}.Set(value)
+	case "appenddirname":
+		config.AppendDirName = value
+	case "appendfilename":
+		config.AppendFilename = value
+	case "appendfsync":
+		config.AppendFsync = value
+	default:
+		handled = false
+	}
+	config.mu.Unlock()
+
+	if !handled {
+		if !config.Set(name, value) {
+			return fmt.Errorf("bad directive or wrong value for %q", name)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("wrong value for %q: %w", name, err)
+	}
+	return nil
+}
+
+// ConfigFile returns the path of the config file the server was started with
+func (config *Config) ConfigFile() string {
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+	return config.configFile
+}
+
+// values returns every parameter by name
+func (config *Config) values() map[string]string {
+	values := make(map[string]string)
+	for _, name := range config.Names() {
+		values[name], _ = config.Get(name)
+	}
+
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+	values["port"] = strconv.Itoa(config.Port)
+	values["replicaof"] = config.ReplicaOf
+	return values
+}
+
+// Reload re-reads the config file, with the command line overrides applied
+// on top, and applies the parameters that can change at runtime. It returns
+// the parameters it changed and those that changed but need a restart.
+func (config *Config) Reload() (changed []string, needRestart []string, err error) {
+	path := config.ConfigFile()
+	if path == "" {
+		return nil, nil, errors.New("the server was not started with a config file")
+	}
+
+	fresh := New()
+	if err := fresh.LoadFile(path); err != nil {
+		return nil, nil, err
+	}
+	for _, o := range config.overrides {
+		if err := fresh.apply(o.name, o.value); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := fresh.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	current, updated := config.values(), fresh.values()
+	names := append([]string{"port", "replicaof"}, config.Names()...)
+	for _, name := range names {
+		if current[name] == updated[name] {
+			continue
+		}
+		if !runtimeMutable[name] {
+			needRestart = append(needRestart, name)
+			continue
+		}
+		if !config.Set(name, updated[name]) {
+			return changed, needRestart, fmt.Errorf("failed to apply %q", name)
+		}
+		changed = append(changed, name)
+	}
+	return changed, needRestart, nil
+}
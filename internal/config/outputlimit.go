@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Client classes client-output-buffer-limit sets limits for
+const (
+	ClientNormal  = "normal"
+	ClientReplica = "replica"
+	ClientPubSub  = "pubsub"
+)
+
+// outputBufferClasses lists the classes in the order CONFIG GET reports them
+var outputBufferClasses = []string{ClientNormal, ClientReplica, ClientPubSub}
+
+// OutputBufferLimit bounds the output a client may have pending. The client
+// is disconnected once more than Hard bytes are pending, or more than Soft
+// bytes for longer than SoftSeconds. 0 disables a limit.
+type OutputBufferLimit struct {
+	Hard        uint64
+	Soft        uint64
+	SoftSeconds int
+}
+
+// defaultOutputBufferLimits are the limits of redis.conf: none for normal
+// clients, whose replies are bounded by what they ask for
+func defaultOutputBufferLimits() map[string]OutputBufferLimit {
+	return map[string]OutputBufferLimit{
+		ClientNormal:  {},
+		ClientReplica: {Hard: 256 * 1024 * 1024, Soft: 64 * 1024 * 1024, SoftSeconds: 60},
+		ClientPubSub:  {Hard: 32 * 1024 * 1024, Soft: 8 * 1024 * 1024, SoftSeconds: 60},
+	}
+}
+
+// outputBufferLimitsFlag parses "<class> <hard> <soft> <soft seconds>"
+// groups. Classes not mentioned keep their limits, so every directive of a
+// config file adds to the previous ones.
+type outputBufferLimitsFlag struct {
+	value *map[string]OutputBufferLimit
+}
+
+func (flag outputBufferLimitsFlag) String() string {
+	if flag.value == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(outputBufferClasses))
+	for _, class := range outputBufferClasses {
+		limit := (*flag.value)[class]
+		parts = append(parts, fmt.Sprintf("%s %d %d %d", class, limit.Hard, limit.Soft, limit.SoftSeconds))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (flag outputBufferLimitsFlag) Set(value string) error {
+	fields := strings.Fields(value)
+	if len(fields) == 0 || len(fields)%4 != 0 {
+		return fmt.Errorf("argument must be <class> <hard> <soft> <soft seconds> groups")
+	}
+
+	limits := make(map[string]OutputBufferLimit, len(*flag.value))
+	for class, limit := range *flag.value {
+		limits[class] = limit
+	}
+	for i := 0; i < len(fields); i += 4 {
+		class := strings.ToLower(fields[i])
+		if class == "slave" {
+			class = ClientReplica
+		}
+		if _, ok := limits[class]; !ok {
+			return fmt.Errorf("invalid client class %q, must be normal, replica or pubsub", fields[i])
+		}
+		hard, ok1 := parseMemory(fields[i+1])
+		soft, ok2 := parseMemory(fields[i+2])
+		seconds, err := strconv.Atoi(fields[i+3])
+		if !ok1 || !ok2 || err != nil || seconds < 0 {
+			return fmt.Errorf("invalid limits for client class %q", fields[i])
+		}
+		limits[class] = OutputBufferLimit{Hard: hard, Soft: soft, SoftSeconds: seconds}
+	}
+	*flag.value = limits
+	return nil
+}
+
+// GetOutputBufferLimit returns the output buffer limit of a client class
+func (config *Config) GetOutputBufferLimit(class string) OutputBufferLimit {
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+	return config.OutputBufferLimits[class]
+}
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Validate checks the configuration for values the server cannot run with.
+// Every problem found is reported, not just the first.
+func (config *Config) Validate() error {
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+
+	var problems []error
+	fail := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	if config.Port < 0 || config.Port > 65535 {
+		fail("port %d is out of range, must be between 0 and 65535", config.Port)
+	}
+	if config.HealthPort < 0 || config.HealthPort > 65535 {
+		fail("health-port %d is out of range, must be between 0 and 65535", config.HealthPort)
+	} else if config.HealthPort != 0 && config.HealthPort == config.Port {
+		fail("health-port %d must differ from port", config.HealthPort)
+	}
+	if config.DebugPort < 0 || config.DebugPort > 65535 {
+		fail("debug-port %d is out of range, must be between 0 and 65535", config.DebugPort)
+	} else if config.DebugPort != 0 && (config.DebugPort == config.Port || config.DebugPort == config.HealthPort) {
+		fail("debug-port %d must differ from port and health-port", config.DebugPort)
+	}
+
+	if config.OTelEndpoint != "" {
+		if endpoint, err := url.Parse(config.OTelEndpoint); err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			fail("otel-exporter-otlp-endpoint %q must be an http or https URL", config.OTelEndpoint)
+		}
+	}
+	if config.OTelSamplerRatio < 0 || config.OTelSamplerRatio > 1 {
+		fail("otel-traces-sampler-ratio %g must be between 0 and 1", config.OTelSamplerRatio)
+	}
+
+	if info, err := os.Stat(config.Dir); err != nil {
+		fail("dir %q: %v", config.Dir, errors.Unwrap(err))
+	} else if !info.IsDir() {
+		fail("dir %q is not a directory", config.Dir)
+	}
+
+	for _, file := range []struct{ name, value string }{
+		{"dbfilename", config.DBFilename},
+		{"appendfilename", config.AppendFilename},
+		{"appenddirname", config.AppendDirName},
+	} {
+		if file.value == "" || strings.ContainsAny(file.value, `/\`) {
+			fail("%s %q must be a plain file name", file.name, file.value)
+		}
+	}
+
+	if config.ReplicaOf != "" {
+		parts := strings.Fields(config.ReplicaOf)
+		if len(parts) != 2 {
+			fail("replicaof %q must be \"<host> <port>\"", config.ReplicaOf)
+		} else if port, err := strconv.Atoi(parts[1]); err != nil || port < 1 || port > 65535 {
+			fail("replicaof port %q is invalid", parts[1])
+		} else if !validHost(parts[0]) {
+			fail("replicaof host %q is neither an IP address nor a hostname", parts[0])
+		} else if isLocalHost(parts[0]) && port == config.Port {
+			fail("replicaof %q points at this server", config.ReplicaOf)
+		}
+	}
+	if config.MasterUser != "" && config.MasterAuth == "" {
+		fail("masteruser is set but masterauth is empty")
+	}
+
+	switch config.AppendFsync {
+	case "always", "everysec", "no":
+	default:
+		fail("appendfsync %q must be always, everysec or no", config.AppendFsync)
+	}
+
+	if err := validateSave(config.Save); err != nil {
+		fail("save %q: %v", config.Save, err)
+	}
+
+	if config.Timeout < 0 {
+		fail("timeout %d must not be negative", config.Timeout)
+	}
+	if config.ReplicaPriority < 0 {
+		fail("replica-priority %d must not be negative", config.ReplicaPriority)
+	}
+	if config.SentinelMaster == "" || strings.ContainsAny(config.SentinelMaster, " \t\r\n") {
+		fail("sentinel-master-name %q must be a non-empty name without spaces", config.SentinelMaster)
+	}
+	if config.MaxClients < 1 {
+		fail("maxclients %d must be at least 1", config.MaxClients)
+	}
+	if config.MaxConcurrentCommands < 0 {
+		fail("max-concurrent-commands %d must not be negative", config.MaxConcurrentCommands)
+	}
+	if config.ProtoMaxBulkLen < 1 || config.ProtoMaxBulkLen > math.MaxInt32 {
+		fail("proto-max-bulk-len %d must be between 1 and %d", config.ProtoMaxBulkLen, math.MaxInt32)
+	}
+	if config.ProtoInlineMaxSize < 1 || config.ProtoInlineMaxSize > math.MaxInt32 {
+		fail("proto-inline-max-size %d must be between 1 and %d", config.ProtoInlineMaxSize, math.MaxInt32)
+	}
+	if config.ProtoMaxMultibulkLen < 1 {
+		fail("proto-max-multibulk-len %d must be at least 1", config.ProtoMaxMultibulkLen)
+	}
+	if config.SlowlogMaxLen < 0 {
+		fail("slowlog-max-len %d must not be negative", config.SlowlogMaxLen)
+	}
+	for name, limit := range map[string]int{
+		"hash-max-listpack-entries": config.HashMaxListpackEntries,
+		"hash-max-listpack-value":   config.HashMaxListpackValue,
+		"set-max-intset-entries":    config.SetMaxIntsetEntries,
+		"zset-max-listpack-entries": config.ZSetMaxListpackEntries,
+	} {
+		if limit < 0 {
+			fail("%s %d must not be negative", name, limit)
+		}
+	}
+	if !validListpackSize(config.ListMaxListpackSize) {
+		fail("list-max-listpack-size %d must be a positive number of entries or -1 to -5", config.ListMaxListpackSize)
+	}
+
+	switch config.Supervised {
+	case "no", "upstart", "systemd", "auto":
+	default:
+		fail("supervised %q must be no, upstart, systemd or auto", config.Supervised)
+	}
+
+	switch strings.ToLower(config.LogLevel) {
+	case "debug", "verbose", "notice", "warning", "nothing", "info", "warn", "error":
+	default:
+		fail("loglevel %q must be debug, verbose, notice, warning or nothing", config.LogLevel)
+	}
+	if config.SyslogEnabled && config.LogFile != "" {
+		fail("syslog-enabled and logfile are both set")
+	}
+
+	return errors.Join(problems...)
+}
+
+// validateSave checks "<seconds> <changes>" pairs
+func validateSave(save string) error {
+	fields := strings.Fields(save)
+	if len(fields)%2 != 0 {
+		return errors.New("rules must be <seconds> <changes> pairs")
+	}
+	for _, field := range fields {
+		if n, err := strconv.Atoi(field); err != nil || n < 0 {
+			return fmt.Errorf("%q is not a non-negative integer", field)
+		}
+	}
+	return nil
+}
+
+// validHost returns true if host is an IP address or a syntactically valid
+// DNS name. Names are only resolved when connecting, so they may change.
+func validHost(host string) bool {
+	if net.ParseIP(host) != nil {
+		return true
+	}
+	if len(host) == 0 || len(host) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(strings.TrimSuffix(host, "."), ".") {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, char := range label {
+			if !(char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' || char >= '0' && char <= '9' || char == '-' || char == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isLocalHost returns true if host names this machine
+func isLocalHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsUnspecified())
+}
+
+// Dump returns the effective configuration in redis.conf syntax
+func (config *Config) Dump() string {
+	config.mu.RLock()
+	port, replicaOf := config.Port, config.ReplicaOf
+	config.mu.RUnlock()
+
+	var builder strings.Builder
+	builder.WriteString("# Effective configuration\n")
+	builder.WriteString("port " + strconv.Itoa(port) + "\n")
+	if replicaOf != "" {
+		builder.WriteString("replicaof " + replicaOf + "\n")
+	}
+	for _, name := range config.Names() {
+		value, _ := config.Get(name)
+		if value == "" || strings.ContainsAny(value, " \t\"'") {
+			value = strconv.Quote(value)
+		}
+		builder.WriteString(name + " " + value + "\n")
+	}
+	return builder.String()
+}
@@ -0,0 +1,45 @@
+// Package daemon detaches the server from its terminal and manages its pidfile.
+//
+// Go programs cannot safely fork, so Daemonize re-executes the binary in a new
+// session with the same arguments. The child is recognized by an environment
+// variable and carries on starting the server while the parent exits.
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// envDaemonized is set in the environment of the re-executed child
+const envDaemonized = "REDIS_GO_DAEMONIZED"
+
+// IsChild returns true in the process started by Daemonize
+func IsChild() bool {
+	return os.Getenv(envDaemonized) == "1"
+}
+
+// WritePidFile writes the current process id to path
+func WritePidFile(path string) error {
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644); err != nil {
+		return fmt.Errorf("failed to write pidfile: %w", err)
+	}
+	return nil
+}
+
+// RemovePidFile removes path if it still holds the current process id, so a
+// newer instance's pidfile is left alone
+func RemovePidFile(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(string(data)) != strconv.Itoa(os.Getpid()) {
+		return nil
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package daemon
+
+import "errors"
+
+// Daemonize is not supported on this platform; run the server under a
+// service manager instead
+func Daemonize() error {
+	return errors.New("daemonize is not supported on this platform")
+}
+
+func stopSelf() error {
+	return errors.New("upstart supervision is not supported on this platform")
+}
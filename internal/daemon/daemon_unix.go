@@ -0,0 +1,43 @@
+//go:build unix
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// Daemonize starts a detached copy of the current process in a new session
+// with stdin, stdout and stderr redirected to /dev/null. The caller should
+// exit once it returns without error.
+func Daemonize() error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate executable: %w", err)
+	}
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer devNull.Close()
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Env = append(os.Environ(), envDaemonized+"=1")
+	cmd.Stdin = devNull
+	cmd.Stdout = devNull
+	cmd.Stderr = devNull
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start daemon: %w", err)
+	}
+	return cmd.Process.Release()
+}
+
+// stopSelf stops the process with SIGSTOP, the upstart readiness signal
+func stopSelf() error {
+	return syscall.Kill(os.Getpid(), syscall.SIGSTOP)
+}
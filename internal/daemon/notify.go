@@ -0,0 +1,73 @@
+package daemon
+
+import (
+	"net"
+	"os"
+)
+
+// Supervision modes accepted by the supervised setting
+const (
+	SupervisedNo      = "no"
+	SupervisedUpstart = "upstart"
+	SupervisedSystemd = "systemd"
+	SupervisedAuto    = "auto" // systemd or upstart, detected from the environment
+)
+
+// ResolveSupervised turns auto into the supervisor found in the environment
+func ResolveSupervised(mode string) string {
+	if mode != SupervisedAuto {
+		return mode
+	}
+	switch {
+	case os.Getenv("NOTIFY_SOCKET") != "":
+		return SupervisedSystemd
+	case os.Getenv("UPSTART_JOB") != "":
+		return SupervisedUpstart
+	default:
+		return SupervisedNo
+	}
+}
+
+// NotifyReady tells the supervisor the server accepts connections. Upstart
+// expects the process to stop itself with SIGSTOP, systemd a READY=1 message.
+func NotifyReady(mode string) error {
+	switch ResolveSupervised(mode) {
+	case SupervisedSystemd:
+		return sdNotify("STATUS=Ready to accept connections\nREADY=1\n")
+	case SupervisedUpstart:
+		os.Unsetenv("UPSTART_JOB")
+		return stopSelf()
+	default:
+		return nil
+	}
+}
+
+// NotifyStopping tells systemd the server is shutting down
+func NotifyStopping(mode string) error {
+	if ResolveSupervised(mode) != SupervisedSystemd {
+		return nil
+	}
+	return sdNotify("STOPPING=1\n")
+}
+
+// sdNotify sends state to the socket named by $NOTIFY_SOCKET, the protocol
+// implemented by sd_notify(3)
+func sdNotify(state string) error {
+	path := os.Getenv("NOTIFY_SOCKET")
+	if path == "" {
+		return nil
+	}
+	if path[0] == '@' {
+		// Abstract socket namespace
+		path = "\x00" + path[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	return err
+}
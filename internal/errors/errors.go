@@ -19,6 +19,23 @@ var (
 	ErrInvalidExpireTime      = RedisError{Code: "ERR", Message: "invalid expire time"}
 	ErrSyntaxError            = RedisError{Code: "ERR", Message: "syntax error"}
 	ErrUnsupportedParameter   = RedisError{Code: "ERR", Message: "unsupported CONFIG parameter"}
+	ErrNoAuth                 = RedisError{Code: "NOAUTH", Message: "Authentication required."}
+	ErrWrongPass              = RedisError{Code: "WRONGPASS", Message: "invalid username-password pair or user is disabled."}
+	ErrOOM                    = RedisError{Code: "OOM", Message: "command not allowed when used memory > 'maxmemory'."}
+	ErrReadOnlyReplica        = RedisError{Code: "READONLY", Message: "You can't write against a read only replica."}
+	ErrNotInteger             = RedisError{Code: "ERR", Message: "value is not an integer or out of range"}
+	ErrOverflow               = RedisError{Code: "ERR", Message: "increment or decrement would overflow"}
+	ErrNotFloat               = RedisError{Code: "ERR", Message: "value is not a valid float"}
+	ErrNaNOrInfinity          = RedisError{Code: "ERR", Message: "increment would produce NaN or Infinity"}
+	ErrOffsetOutOfRange       = RedisError{Code: "ERR", Message: "offset is out of range"}
+	ErrStringTooLong          = RedisError{Code: "ERR", Message: "string exceeds maximum allowed size (proto-max-bulk-len)"}
+	ErrNoSuchKey              = RedisError{Code: "ERR", Message: "no such key"}
+	ErrIndexOutOfRange        = RedisError{Code: "ERR", Message: "index out of range"}
+	ErrNotPositive            = RedisError{Code: "ERR", Message: "value is out of range, must be positive"}
+	ErrTimeoutNotFloat        = RedisError{Code: "ERR", Message: "timeout is not a float or out of range"}
+	ErrTimeoutNegative        = RedisError{Code: "ERR", Message: "timeout is negative"}
+	ErrTimeoutOutOfRange      = RedisError{Code: "ERR", Message: "timeout is out of range"}
+	ErrWrongType              = RedisError{Code: "WRONGTYPE", Message: "Operation against a key holding the wrong kind of value"}
 )
 
 // WrongNumberOfArguments returns an error for incorrect argument count
@@ -2,8 +2,10 @@ package logger
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"strings"
 )
 
 // Level represents the logging level
@@ -14,12 +16,85 @@ const (
 	LevelInfo
 	LevelWarn
 	LevelError
+	LevelNone // Disables logging
 )
 
+// ParseLevel parses a log level. Besides debug, info, warn and error it
+// accepts the redis.conf names verbose, notice, warning and nothing.
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(name) {
+	case "debug", "verbose":
+		return LevelDebug, nil
+	case "info", "notice":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	case "nothing":
+		return LevelNone, nil
+	default:
+		return LevelInfo, fmt.Errorf("invalid log level %q", name)
+	}
+}
+
+// Options selects where the package-level logger writes and how verbosely
+type Options struct {
+	Level          string // Log level name, see ParseLevel
+	File           string // Log file path, empty for stdout
+	Syslog         bool   // Log to syslog instead of a file
+	SyslogIdent    string
+	SyslogFacility string // user or local0 through local7
+}
+
+// Configure applies options to the package-level logger
+func Configure(options Options) error {
+	level, err := ParseLevel(options.Level)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case options.Syslog:
+		writer, err := newSyslogWriter(options.SyslogIdent, options.SyslogFacility)
+		if err != nil {
+			return err
+		}
+		defaultLogger.syslog = writer
+	case options.File != "":
+		file, err := os.OpenFile(options.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("can't open the log file: %w", err)
+		}
+		SetOutput(file)
+	}
+
+	SetLevel(level)
+	return nil
+}
+
+// Interface is implemented by loggers that can be injected in place of the
+// package-level logger
+type Interface interface {
+	Debug(format string, args ...interface{})
+	Info(format string, args ...interface{})
+	Warn(format string, args ...interface{})
+	Error(format string, args ...interface{})
+}
+
 // Logger provides structured logging
 type Logger struct {
 	level  Level
 	logger *log.Logger
+	syslog syslogWriter // Replaces logger when set
+}
+
+// syslogWriter is the subset of *syslog.Writer the logger uses
+type syslogWriter interface {
+	Debug(msg string) error
+	Info(msg string) error
+	Warning(msg string) error
+	Err(msg string) error
 }
 
 var defaultLogger = &Logger{
@@ -27,11 +102,21 @@ var defaultLogger = &Logger{
 	logger: log.New(os.Stdout, "", log.LstdFlags),
 }
 
+// Default returns the package-level logger
+func Default() Interface {
+	return defaultLogger
+}
+
 // SetLevel sets the global log level
 func SetLevel(level Level) {
 	defaultLogger.level = level
 }
 
+// SetOutput sets the destination of the global logger
+func SetOutput(writer io.Writer) {
+	defaultLogger.logger.SetOutput(writer)
+}
+
 // Debug logs a debug message
 func Debug(format string, args ...interface{}) {
 	defaultLogger.log(LevelDebug, format, args...)
@@ -52,6 +137,26 @@ func Error(format string, args ...interface{}) {
 	defaultLogger.log(LevelError, format, args...)
 }
 
+// Debug logs a debug message
+func (l *Logger) Debug(format string, args ...interface{}) {
+	l.log(LevelDebug, format, args...)
+}
+
+// Info logs an info message
+func (l *Logger) Info(format string, args ...interface{}) {
+	l.log(LevelInfo, format, args...)
+}
+
+// Warn logs a warning message
+func (l *Logger) Warn(format string, args ...interface{}) {
+	l.log(LevelWarn, format, args...)
+}
+
+// Error logs an error message
+func (l *Logger) Error(format string, args ...interface{}) {
+	l.log(LevelError, format, args...)
+}
+
 func (l *Logger) log(level Level, format string, args ...interface{}) {
 	if level < l.level {
 		return
@@ -70,5 +175,18 @@ func (l *Logger) log(level Level, format string, args ...interface{}) {
 	}
 
 	msg := fmt.Sprintf(format, args...)
+	if l.syslog != nil {
+		switch level {
+		case LevelDebug:
+			l.syslog.Debug(msg)
+		case LevelInfo:
+			l.syslog.Info(msg)
+		case LevelWarn:
+			l.syslog.Warning(msg)
+		default:
+			l.syslog.Err(msg)
+		}
+		return
+	}
 	l.logger.Printf("%s%s", prefix, msg)
 }
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package logger
+
+import "errors"
+
+// newSyslogWriter fails: syslog is only available on Unix systems
+func newSyslogWriter(ident, facility string) (syslogWriter, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
@@ -0,0 +1,134 @@
+package pubsub
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// EventClass selects the keyspace events that are published, one bit per
+// notify-keyspace-events flag
+type EventClass uint32
+
+const (
+	ClassKeyspace EventClass = 1 << iota // K: publish to __keyspace@<db>__:<key>
+	ClassKeyevent                        // E: publish to __keyevent@<db>__:<event>
+	ClassGeneric                         // g: DEL, EXPIRE, RENAME, ...
+	ClassString                          // $
+	ClassList                            // l
+	ClassSet                             // s
+	ClassHash                            // h
+	ClassZSet                            // z
+	ClassExpired                         // x
+	ClassEvicted                         // e
+	ClassStream                          // t
+	ClassKeyMiss                         // m: reads of missing keys
+	ClassNew                             // n: key creation
+
+	// ClassAll is what the A flag stands for; key-miss and new key events
+	// must be asked for explicitly
+	ClassAll = ClassGeneric | ClassString | ClassList | ClassSet | ClassHash |
+		ClassZSet | ClassExpired | ClassEvicted | ClassStream
+)
+
+// classFlags maps each class to its flag character, in the order FormatEventClasses uses
+var classFlags = []struct {
+	class EventClass
+	flag  byte
+}{
+	{ClassGeneric, 'g'}, {ClassString, '$'}, {ClassList, 'l'}, {ClassSet, 's'},
+	{ClassHash, 'h'}, {ClassZSet, 'z'}, {ClassExpired, 'x'}, {ClassEvicted, 'e'},
+	{ClassStream, 't'}, {ClassKeyspace, 'K'}, {ClassKeyevent, 'E'},
+	{ClassKeyMiss, 'm'}, {ClassNew, 'n'},
+}
+
+// ParseEventClasses parses a notify-keyspace-events value such as "KEA" or
+// "Ex". An empty value disables notifications.
+func ParseEventClasses(flags string) (EventClass, error) {
+	var classes EventClass
+	for i := 0; i < len(flags); i++ {
+		if flags[i] == 'A' {
+			classes |= ClassAll
+			continue
+		}
+		found := false
+		for _, entry := range classFlags {
+			if entry.flag == flags[i] {
+				classes |= entry.class
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("invalid notify-keyspace-events flag %q", flags[i])
+		}
+	}
+	return classes, nil
+}
+
+// FormatEventClasses formats classes the way CONFIG GET reports them
+func FormatEventClasses(classes EventClass) string {
+	var builder strings.Builder
+	if classes&ClassAll == ClassAll {
+		builder.WriteByte('A')
+	}
+	for _, entry := range classFlags {
+		if classes&entry.class == 0 || (entry.class&ClassAll != 0 && classes&ClassAll == ClassAll) {
+			continue
+		}
+		builder.WriteByte(entry.flag)
+	}
+	return builder.String()
+}
+
+// Notifier publishes keyspace events for the classes enabled by
+// notify-keyspace-events
+type Notifier struct {
+	hub   *Hub
+	flags func() string // Current notify-keyspace-events value
+
+	mu      sync.Mutex
+	parsed  string // Value classes was parsed from
+	classes EventClass
+}
+
+// NewNotifier creates a notifier publishing on hub. flags returns the
+// current notify-keyspace-events value, so changes apply immediately.
+func NewNotifier(hub *Hub, flags func() string) *Notifier {
+	return &Notifier{hub: hub, flags: flags}
+}
+
+// Enabled returns true if events of class are published
+func (notifier *Notifier) Enabled(class EventClass) bool {
+	classes := notifier.enabledClasses()
+	return classes&class != 0 && classes&(ClassKeyspace|ClassKeyevent) != 0
+}
+
+// Notify publishes event on key if its class is enabled
+func (notifier *Notifier) Notify(class EventClass, event, key string) {
+	classes := notifier.enabledClasses()
+	if classes&class == 0 {
+		return
+	}
+	if classes&ClassKeyspace != 0 {
+		notifier.hub.Publish("__keyspace@0__:"+key, event)
+	}
+	if classes&ClassKeyevent != 0 {
+		notifier.hub.Publish("__keyevent@0__:"+event, key)
+	}
+}
+
+// enabledClasses returns the enabled classes, parsing the config value again only
+// when it changed
+func (notifier *Notifier) enabledClasses() EventClass {
+	flags := notifier.flags()
+
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+	if flags != notifier.parsed {
+		// The config only accepts valid values
+		notifier.classes, _ = ParseEventClasses(flags)
+		notifier.parsed = flags
+	}
+	return notifier.classes
+}
@@ -0,0 +1,213 @@
+// Package pubsub routes PUBLISH messages to the clients subscribed to a
+// channel or to a pattern matching it.
+package pubsub
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/codecrafters-redis-go/internal/resp"
+	"github.com/codecrafters-redis-go/internal/utils"
+)
+
+// Subscriber is a client able to receive messages. Its deliver function
+// must be safe to call from any goroutine.
+type Subscriber struct {
+	deliver  func(resp.Value)
+	channels map[string]struct{} // Guarded by the hub lock
+	patterns map[string]struct{}
+	count    atomic.Int32 // Channels and patterns, readable without the hub lock
+}
+
+// NewSubscriber creates a subscriber sending messages with deliver
+func NewSubscriber(deliver func(resp.Value)) *Subscriber {
+	return &Subscriber{
+		deliver:  deliver,
+		channels: make(map[string]struct{}),
+		patterns: make(map[string]struct{}),
+	}
+}
+
+// Deliver sends value to the subscriber
+func (sub *Subscriber) Deliver(value resp.Value) {
+	sub.deliver(value)
+}
+
+// Subscribed returns true if sub has any subscription. Unlike Hub.Count it
+// takes no lock, so it may be checked on every write to the client.
+func (sub *Subscriber) Subscribed() bool {
+	return sub.count.Load() > 0
+}
+
+// counted updates the subscription count of sub and returns it. Callers
+// hold the hub lock.
+func (sub *Subscriber) counted() int {
+	count := len(sub.channels) + len(sub.patterns)
+	sub.count.Store(int32(count))
+	return count
+}
+
+// Hub holds the subscriptions of all clients
+type Hub struct {
+	mu       sync.RWMutex
+	channels map[string]map[*Subscriber]struct{}
+	patterns map[string]map[*Subscriber]struct{}
+}
+
+// NewHub creates a hub without subscriptions
+func NewHub() *Hub {
+	return &Hub{
+		channels: make(map[string]map[*Subscriber]struct{}),
+		patterns: make(map[string]map[*Subscriber]struct{}),
+	}
+}
+
+// Subscribe subscribes sub to channel and returns its subscription count
+func (hub *Hub) Subscribe(sub *Subscriber, channel string) int {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	add(hub.channels, sub, channel)
+	sub.channels[channel] = struct{}{}
+	return sub.counted()
+}
+
+// Unsubscribe removes the subscription of sub to channel and returns its
+// subscription count
+func (hub *Hub) Unsubscribe(sub *Subscriber, channel string) int {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	del(hub.channels, sub, channel)
+	delete(sub.channels, channel)
+	return sub.counted()
+}
+
+// PSubscribe subscribes sub to the channels matching pattern and returns
+// its subscription count
+func (hub *Hub) PSubscribe(sub *Subscriber, pattern string) int {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	add(hub.patterns, sub, pattern)
+	sub.patterns[pattern] = struct{}{}
+	return sub.counted()
+}
+
+// PUnsubscribe removes the subscription of sub to pattern and returns its
+// subscription count
+func (hub *Hub) PUnsubscribe(sub *Subscriber, pattern string) int {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	del(hub.patterns, sub, pattern)
+	delete(sub.patterns, pattern)
+	return sub.counted()
+}
+
+// Channels returns the channels sub is subscribed to
+func (hub *Hub) Channels(sub *Subscriber) []string {
+	hub.mu.RLock()
+	defer hub.mu.RUnlock()
+	return names(sub.channels)
+}
+
+// Patterns returns the patterns sub is subscribed to
+func (hub *Hub) Patterns(sub *Subscriber) []string {
+	hub.mu.RLock()
+	defer hub.mu.RUnlock()
+	return names(sub.patterns)
+}
+
+// Count returns the number of channels and patterns sub is subscribed to
+func (hub *Hub) Count(sub *Subscriber) int {
+	hub.mu.RLock()
+	defer hub.mu.RUnlock()
+	return len(sub.channels) + len(sub.patterns)
+}
+
+// Remove drops every subscription of sub, used when its client disconnects
+func (hub *Hub) Remove(sub *Subscriber) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	for channel := range sub.channels {
+		del(hub.channels, sub, channel)
+	}
+	for pattern := range sub.patterns {
+		del(hub.patterns, sub, pattern)
+	}
+	sub.channels = make(map[string]struct{})
+	sub.patterns = make(map[string]struct{})
+	sub.counted()
+}
+
+// Publish sends message to the subscribers of channel and of the patterns
+// matching it, and returns how many clients received it
+func (hub *Hub) Publish(channel, message string) int {
+	type delivery struct {
+		sub   *Subscriber
+		value resp.Value
+	}
+
+	hub.mu.RLock()
+	var deliveries []delivery
+	for sub := range hub.channels[channel] {
+		deliveries = append(deliveries, delivery{sub, resp.PushValue(
+			resp.BulkStringValue("message"),
+			resp.BulkStringValue(channel),
+			resp.BulkStringValue(message),
+		)})
+	}
+	for pattern, subs := range hub.patterns {
+		if !utils.MatchPattern(pattern, channel) {
+			continue
+		}
+		for sub := range subs {
+			deliveries = append(deliveries, delivery{sub, resp.PushValue(
+				resp.BulkStringValue("pmessage"),
+				resp.BulkStringValue(pattern),
+				resp.BulkStringValue(channel),
+				resp.BulkStringValue(message),
+			)})
+		}
+	}
+	hub.mu.RUnlock()
+
+	// Deliver without the lock, a slow client must not hold up subscriptions
+	for _, d := range deliveries {
+		d.sub.Deliver(d.value)
+	}
+	return len(deliveries)
+}
+
+// HasSubscribers returns true if anybody could receive a message, letting
+// publishers skip building messages nobody reads
+func (hub *Hub) HasSubscribers() bool {
+	hub.mu.RLock()
+	defer hub.mu.RUnlock()
+	return len(hub.channels) > 0 || len(hub.patterns) > 0
+}
+
+func add(index map[string]map[*Subscriber]struct{}, sub *Subscriber, name string) {
+	subs, ok := index[name]
+	if !ok {
+		subs = make(map[*Subscriber]struct{})
+		index[name] = subs
+	}
+	subs[sub] = struct{}{}
+}
+
+func del(index map[string]map[*Subscriber]struct{}, sub *Subscriber, name string) {
+	subs, ok := index[name]
+	if !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(index, name)
+	}
+}
+
+func names(set map[string]struct{}) []string {
+	result := make([]string, 0, len(set))
+	for name := range set {
+		result = append(result, name)
+	}
+	return result
+}
@@ -1,11 +1,14 @@
 package rdb
 
 import (
+	"bufio"
 	"encoding/binary"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/codecrafters-redis-go/internal/storage"
@@ -32,6 +35,14 @@ const (
 
 	// Value types
 	valueTypeString = 0
+	valueTypeList   = 1
+
+	maxStringLen      = 512 * 1024 * 1024 // Longest string loaded, like proto-max-bulk-len
+	preallocStringLen = 64 * 1024         // Longest string allocated before it is read
+
+	// list-max-listpack-size the encoding of a loaded list is fitted to.
+	// The next write to the list fits it to the configured size.
+	defaultListpackSize = -2
 )
 
 // Loader loads data from RDB files
@@ -40,15 +51,52 @@ type Loader struct {
 	storage *storage.Storage
 }
 
+// Progress reports how far a load has advanced. It is safe for concurrent use.
+type Progress struct {
+	totalBytes  atomic.Int64
+	loadedBytes atomic.Int64
+}
+
+// TotalBytes returns the size of the payload being loaded (0 if unknown)
+func (progress *Progress) TotalBytes() int64 {
+	return progress.totalBytes.Load()
+}
+
+// LoadedBytes returns the number of bytes consumed so far
+func (progress *Progress) LoadedBytes() int64 {
+	return progress.loadedBytes.Load()
+}
+
+// countingReader counts the bytes read into a Progress
+type countingReader struct {
+	reader   io.Reader
+	progress *Progress
+}
+
+func (counter *countingReader) Read(buf []byte) (int, error) {
+	n, err := counter.reader.Read(buf)
+	counter.progress.loadedBytes.Add(int64(n))
+	return n, err
+}
+
 // LoadFile loads an RDB file into storage
 func LoadFile(dir, filename string, store *storage.Storage) error {
+	return LoadFileWithProgress(dir, filename, store, nil)
+}
+
+// LoadFileWithProgress loads an RDB file into storage, reporting progress if non-nil
+func LoadFileWithProgress(dir, filename string, store *storage.Storage, progress *Progress) error {
 	path := filepath.Join(dir, filename)
 
 	// Check if file exists
-	if _, err := os.Stat(path); os.IsNotExist(err) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
 		// No RDB file, that's ok
 		return nil
 	}
+	if err != nil {
+		return fmt.Errorf("failed to stat RDB file: %w", err)
+	}
 
 	file, err := os.Open(path)
 	if err != nil {
@@ -56,8 +104,21 @@ func LoadFile(dir, filename string, store *storage.Storage) error {
 	}
 	defer file.Close()
 
+	if progress != nil {
+		progress.totalBytes.Store(info.Size())
+	}
+
+	return Load(file, store, progress)
+}
+
+// Load reads an RDB payload from reader into storage, reporting progress if non-nil
+func Load(reader io.Reader, store *storage.Storage, progress *Progress) error {
+	if progress != nil {
+		reader = &countingReader{reader: reader, progress: progress}
+	}
+
 	loader := &Loader{
-		reader:  file,
+		reader:  bufio.NewReader(reader),
 		storage: store,
 	}
 
@@ -165,8 +226,8 @@ func (loader *Loader) readKeyValue(expiryMs uint64) error {
 }
 
 func (loader *Loader) readValue(valueType byte, expiryMs uint64) error {
-	// For now, we only support string values
-	if valueType != valueTypeString {
+	// For now, we only support string and plain list values
+	if valueType != valueTypeString && valueType != valueTypeList {
 		return fmt.Errorf("unsupported value type: %d", valueType)
 	}
 
@@ -177,7 +238,12 @@ func (loader *Loader) readValue(valueType byte, expiryMs uint64) error {
 	}
 
 	// Read value
-	value, err := loader.readString()
+	var value interface{}
+	if valueType == valueTypeList {
+		value, err = loader.readList()
+	} else {
+		value, err = loader.readString()
+	}
 	if err != nil {
 		return fmt.Errorf("failed to read value: %w", err)
 	}
@@ -195,6 +261,30 @@ func (loader *Loader) readValue(valueType byte, expiryMs uint64) error {
 	return nil
 }
 
+// readList reads the elements of a plain list
+func (loader *Loader) readList() (*storage.List, error) {
+	length, err := loader.readLength()
+	if err != nil {
+		return nil, err
+	}
+
+	// Elements are appended as they are read, so a corrupt length runs out
+	// of input instead of being allocated up front
+	list := storage.NewList()
+	for i := uint64(0); i < length; i++ {
+		element, err := loader.readString()
+		if err != nil {
+			return nil, err
+		}
+		list.PushRight(element)
+	}
+	if list.Len() == 0 {
+		return nil, fmt.Errorf("empty list")
+	}
+	list.FitEncoding(defaultListpackSize)
+	return list, nil
+}
+
 func (loader *Loader) readByte() (byte, error) {
 	buf := make([]byte, 1)
 	if _, err := io.ReadFull(loader.reader, buf); err != nil {
@@ -220,9 +310,16 @@ func (loader *Loader) readUint64() (uint64, error) {
 }
 
 func (loader *Loader) readLength() (uint64, error) {
+	length, _, err := loader.readLengthOrEncoding()
+	return length, err
+}
+
+// readLengthOrEncoding reads a length. If encoded is true the value is a
+// special string encoding byte (0xC0-0xC3) rather than a length.
+func (loader *Loader) readLengthOrEncoding() (length uint64, encoded bool, err error) {
 	firstByte, err := loader.readByte()
 	if err != nil {
-		return 0, err
+		return 0, false, err
 	}
 
 	// Check encoding type
@@ -231,41 +328,50 @@ func (loader *Loader) readLength() (uint64, error) {
 	switch encType {
 	case 0:
 		// Next 6 bits represent the length
-		return uint64(firstByte & 0x3F), nil
+		return uint64(firstByte & 0x3F), false, nil
 
 	case 1:
 		// Read one more byte, combined 14 bits represent the length
 		nextByte, err := loader.readByte()
 		if err != nil {
-			return 0, err
+			return 0, false, err
 		}
-		return uint64((firstByte&0x3F)<<8) | uint64(nextByte), nil
+		return uint64(firstByte&0x3F)<<8 | uint64(nextByte), false, nil
 
 	case 2:
+		if firstByte == 0x81 {
+			// Read 8 more bytes
+			buf := make([]byte, 8)
+			if _, err := io.ReadFull(loader.reader, buf); err != nil {
+				return 0, false, err
+			}
+			return binary.BigEndian.Uint64(buf), false, nil
+		}
+
 		// Read 4 more bytes
 		buf := make([]byte, 4)
 		if _, err := io.ReadFull(loader.reader, buf); err != nil {
-			return 0, err
+			return 0, false, err
 		}
-		return uint64(binary.BigEndian.Uint32(buf)), nil
+		return uint64(binary.BigEndian.Uint32(buf)), false, nil
 
 	case 3:
 		// Special encoding - return the byte as-is
-		return uint64(firstByte), nil
+		return uint64(firstByte), true, nil
 
 	default:
-		return 0, fmt.Errorf("unexpected encoding type")
+		return 0, false, fmt.Errorf("unexpected encoding type")
 	}
 }
 
 func (loader *Loader) readString() (string, error) {
-	length, err := loader.readLength()
+	length, encoded, err := loader.readLengthOrEncoding()
 	if err != nil {
 		return "", err
 	}
 
 	// Check if it's a special encoding (when encType was 3)
-	if length >= 0xC0 {
+	if encoded {
 		// Special encoding (integers)
 		switch byte(length) {
 		case stringTypeInt8:
@@ -295,10 +401,25 @@ func (loader *Loader) readString() (string, error) {
 	}
 
 	// Regular string
-	buf := make([]byte, length)
-	if _, err := io.ReadFull(loader.reader, buf); err != nil {
-		return "", err
+	if length > maxStringLen {
+		return "", fmt.Errorf("invalid string length: %d", length)
+	}
+	if length <= preallocStringLen {
+		buf := make([]byte, length)
+		if _, err := io.ReadFull(loader.reader, buf); err != nil {
+			return "", err
+		}
+		return string(buf), nil
 	}
 
-	return string(buf), nil
+	// Collected as it arrives, so a corrupt length runs out of input
+	// instead of being allocated up front
+	var buf strings.Builder
+	if _, err := io.CopyN(&buf, loader.reader, int64(length)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return "", err
+	}
+	return buf.String(), nil
 }
@@ -0,0 +1,68 @@
+package rdb
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/storage"
+)
+
+// validRDB returns an RDB payload holding a string, a string with a TTL and
+// a list, as Writer saves it
+func validRDB(f *testing.F) []byte {
+	store := storage.New()
+	defer store.Close()
+
+	expiry := time.Now().Add(time.Hour)
+	store.Set("string", "value", nil)
+	store.Set("expiring", "12345", &expiry)
+	list := storage.NewList()
+	list.PushRight("a", "b", "c")
+	store.Set("list", list, nil)
+
+	var buf bytes.Buffer
+	if err := NewWriter(&buf).Save(store); err != nil {
+		f.Fatalf("Save: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// FuzzLoad feeds arbitrary bytes to Load, which must return an error rather
+// than panic, hang or allocate what the input only claims to hold
+func FuzzLoad(f *testing.F) {
+	valid := validRDB(f)
+
+	// withHeader returns data after a fresh copy of the magic and version
+	withHeader := func(data ...byte) []byte {
+		return append([]byte(rdbMagic+rdbVersion), data...)
+	}
+
+	f.Add(valid)
+	f.Add(withHeader())
+	f.Add(withHeader(opEOF))
+
+	// Truncated
+	for _, n := range []int{3, len(rdbMagic+rdbVersion) + 1, len(valid) / 2, len(valid) - 9} {
+		f.Add(valid[:n])
+	}
+	f.Add(withHeader(valueTypeString, 0x03, 'k', 'e', 'y', 0x05, 'v'))
+	f.Add(withHeader(valueTypeList, 0x01, 'l', 0x03, 0x01, 'a'))
+	f.Add(withHeader(opExpireTimeMs, 0x01, 0x02))
+
+	// Huge length prefixes
+	f.Add(withHeader(valueTypeString, 0x81, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF))
+	f.Add(withHeader(valueTypeString, 0x01, 'k', 0x80, 0x1F, 0xFF, 0xFF, 0xFF, 'v'))
+	f.Add(withHeader(valueTypeList, 0x01, 'l', 0x81, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x00))
+	f.Add(withHeader(opResizeDB, 0x80, 0xFF, 0xFF, 0xFF, 0xFF, 0x80, 0xFF, 0xFF, 0xFF, 0xFF, opEOF))
+
+	// Unsupported encodings and types
+	f.Add(withHeader(valueTypeString, 0x01, 'k', stringTypeLZF, 0x01, 0x01, 'x'))
+	f.Add(withHeader(0x0E, 0x01, 'k', 0x00))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		store := storage.New()
+		defer store.Close()
+		Load(bytes.NewReader(data), store, nil)
+	})
+}
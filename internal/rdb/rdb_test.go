@@ -0,0 +1,87 @@
+package rdb
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/storage"
+)
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	expiry := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	long := strings.Repeat("x", 10000)
+
+	tests := []struct {
+		name   string
+		value  interface{}
+		expiry *time.Time
+	}{
+		{"string", "hello", nil},
+		{"string with expiry", "bye", &expiry},
+		{"list", []string{"a", "b", "c"}, nil},
+		{"list with expiry", []string{"1", "2"}, &expiry},
+		{"quicklist", []string{long, long}, nil},
+	}
+
+	store := storage.New()
+	defer store.Close()
+	for _, tt := range tests {
+		value := tt.value
+		if elements, ok := value.([]string); ok {
+			list := storage.NewList()
+			list.PushRight(elements...)
+			list.FitEncoding(defaultListpackSize)
+			value = list
+		}
+		store.Set(tt.name, value, tt.expiry)
+	}
+
+	var buf bytes.Buffer
+	if err := NewWriter(&buf).Save(store); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded := storage.New()
+	defer loaded.Close()
+	if err := Load(&buf, loaded, nil); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, ok := loaded.Get(tt.name)
+			if !ok {
+				t.Fatalf("key %q not loaded", tt.name)
+			}
+			switch want := tt.value.(type) {
+			case string:
+				if value != want {
+					t.Errorf("value = %v, want %q", value, want)
+				}
+			case []string:
+				list, ok := value.(*storage.List)
+				if !ok {
+					t.Fatalf("value is %T, want *storage.List", value)
+				}
+				if got := list.Values(); !slices.Equal(got, want) {
+					t.Errorf("elements = %q, want %q", got, want)
+				}
+				original, _ := store.Get(tt.name)
+				if got, want := list.Encoding(), original.(*storage.List).Encoding(); got != want {
+					t.Errorf("encoding = %s, want %s", got, want)
+				}
+			}
+
+			got, _ := loaded.Expiry(tt.name)
+			switch {
+			case tt.expiry == nil && got != nil:
+				t.Errorf("expiry = %v, want none", got)
+			case tt.expiry != nil && (got == nil || !got.Equal(*tt.expiry)):
+				t.Errorf("expiry = %v, want %v", got, tt.expiry)
+			}
+		})
+	}
+}
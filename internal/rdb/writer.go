@@ -0,0 +1,187 @@
+package rdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/logger"
+	"github.com/codecrafters-redis-go/internal/storage"
+)
+
+// rdbVersion is the RDB format version written by Writer
+const rdbVersion = "0011"
+
+// crc64JonesPoly is the reflected Jones polynomial Redis uses for RDB checksums
+const crc64JonesPoly = 0x95ac9329ac4bc9b5
+
+var crc64Table = makeCRC64Table()
+
+func makeCRC64Table() *[256]uint64 {
+	table := new([256]uint64)
+	for i := range table {
+		crc := uint64(i)
+		for bit := 0; bit < 8; bit++ {
+			if crc&1 == 1 {
+				crc = crc>>1 ^ crc64JonesPoly
+			} else {
+				crc >>= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}
+
+// Writer serializes a dataset in RDB format, checksumming everything it writes
+type Writer struct {
+	writer *bufio.Writer
+	crc    uint64
+	err    error
+}
+
+// NewWriter creates a new RDB writer
+func NewWriter(writer io.Writer) *Writer {
+	return &Writer{writer: bufio.NewWriter(writer)}
+}
+
+// SaveFile atomically writes the dataset in store to dir/filename
+func SaveFile(dir, filename string, store *storage.Storage) error {
+	path := filepath.Join(dir, filename)
+	tmpPath := filepath.Join(dir, fmt.Sprintf("temp-%d.rdb", os.Getpid()))
+
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create RDB file: %w", err)
+	}
+	defer os.Remove(tmpPath)
+
+	if err := NewWriter(file).Save(store); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to fsync RDB file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// Save writes a complete RDB payload for store
+func (w *Writer) Save(store *storage.Storage) error {
+	w.write([]byte(rdbMagic + rdbVersion))
+	w.writeAux("redis-ver", "7.2.0")
+	w.writeAux("redis-bits", "64")
+	w.writeAux("ctime", fmt.Sprintf("%d", time.Now().Unix()))
+
+	w.writeByte(opSelectDB)
+	w.writeLength(0)
+
+	store.ForEach(func(key string, value interface{}, expiry *time.Time) {
+		var str string
+		var list []string
+		valueType := byte(valueTypeString)
+		switch v := value.(type) {
+		case string:
+			str = v
+		case storage.StringValue:
+			str = v.Value
+		case *storage.List:
+			list = v.Values()
+			valueType = valueTypeList
+		default:
+			logger.Warn("RDB save skipping key %s of unsupported type %T", key, value)
+			return
+		}
+
+		if expiry != nil {
+			w.writeByte(opExpireTimeMs)
+			var buf [8]byte
+			binary.LittleEndian.PutUint64(buf[:], uint64(expiry.UnixMilli()))
+			w.write(buf[:])
+		}
+		w.writeByte(valueType)
+		w.writeString(key)
+		if valueType == valueTypeList {
+			// A plain list, which every RDB version loads
+			w.writeLength(uint64(len(list)))
+			for _, element := range list {
+				w.writeString(element)
+			}
+			return
+		}
+		w.writeString(str)
+	})
+
+	w.writeByte(opEOF)
+
+	// The checksum itself is not part of the checksummed data
+	var sum [8]byte
+	binary.LittleEndian.PutUint64(sum[:], w.crc)
+	if w.err == nil {
+		_, w.err = w.writer.Write(sum[:])
+	}
+	if w.err == nil {
+		w.err = w.writer.Flush()
+	}
+
+	if w.err != nil {
+		return fmt.Errorf("failed to write RDB: %w", w.err)
+	}
+	return nil
+}
+
+func (w *Writer) write(data []byte) {
+	if w.err != nil {
+		return
+	}
+	for _, b := range data {
+		w.crc = crc64Table[byte(w.crc)^b] ^ w.crc>>8
+	}
+	_, w.err = w.writer.Write(data)
+}
+
+func (w *Writer) writeByte(b byte) {
+	w.write([]byte{b})
+}
+
+// writeLength writes a length using the 6, 14 or 32 bit encodings
+func (w *Writer) writeLength(length uint64) {
+	switch {
+	case length < 1<<6:
+		w.writeByte(byte(length))
+	case length < 1<<14:
+		w.write([]byte{byte(length>>8) | 0x40, byte(length)})
+	case length <= 0xFFFFFFFF:
+		var buf [5]byte
+		buf[0] = 0x80
+		binary.BigEndian.PutUint32(buf[1:], uint32(length))
+		w.write(buf[:])
+	default:
+		var buf [9]byte
+		buf[0] = 0x81
+		binary.BigEndian.PutUint64(buf[1:], length)
+		w.write(buf[:])
+	}
+}
+
+// writeString writes a length prefixed string
+func (w *Writer) writeString(str string) {
+	w.writeLength(uint64(len(str)))
+	w.write([]byte(str))
+}
+
+// writeAux writes an auxiliary field
+func (w *Writer) writeAux(key, value string) {
+	w.writeByte(opAux)
+	w.writeString(key)
+	w.writeString(value)
+}
@@ -1,25 +1,78 @@
 package replication
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/codecrafters-redis-go/internal/logger"
 	"github.com/codecrafters-redis-go/internal/resp"
 )
 
+// dialTimeout bounds how long resolving and connecting to master may take
+const dialTimeout = 5 * time.Second
+
+// LinkState represents the state of the replica's link to its master
+type LinkState int
+
+const (
+	StateConnect    LinkState = iota // Not connected, must connect to master
+	StateConnecting                  // TCP connection and handshake in progress
+	StateSync                        // PSYNC accepted, receiving the RDB payload
+	StateConnected                   // Streaming commands from master
+)
+
+// String returns the name of the link state
+func (state LinkState) String() string {
+	switch state {
+	case StateConnect:
+		return "connect"
+	case StateConnecting:
+		return "connecting"
+	case StateSync:
+		return "sync"
+	case StateConnected:
+		return "connected"
+	default:
+		return "unknown"
+	}
+}
+
+// LinkInfo is a snapshot of the replica's link to its master
+type LinkInfo struct {
+	MasterHost       string
+	MasterPort       string
+	State            LinkState
+	LastIOSecondsAgo int // -1 if nothing was ever received from master
+	DownSeconds      int // How long the link has been down after being connected, -1 if up or never connected
+	Offset           int64
+}
+
 // Client handles replica's connection to master
 type Client struct {
-	masterHost   string
-	masterPort   string
-	replicaPort  int
-	conn         net.Conn
-	encoder      *resp.Encoder
-	parser       *resp.Parser
-	offset       int64 // Track bytes processed from master
+	masterHost  string
+	masterPort  string
+	replicaPort int
+	authUser    string
+	authPass    string
+	conn        net.Conn
+	encoder     *resp.Encoder
+	parser      *resp.Parser
+	offset      int64  // Track bytes processed from master
+	rdbData     []byte // RDB payload received during the last full resync
+
+	stateMu  sync.RWMutex
+	state    LinkState
+	masterIP string    // Address masterHost resolved to on the last connection
+	closed   bool      // Set by Close, the client doesn't connect again
+	lastIO   time.Time // Last time data was received from master
+	downAt   time.Time // When the link stopped being connected, zero while up or before the first sync
+
+	writeMu sync.Mutex // Serializes ACKs sent from the stream and the ack ticker
 }
 
 // NewClient creates a new replication client
@@ -31,36 +84,102 @@ func NewClient(host, port string, replicaPort int) *Client {
 	}
 }
 
-// Connect establishes connection to the master
+// SetAuth sets the credentials sent with AUTH during the handshake.
+// An empty user authenticates as the default user.
+func (c *Client) SetAuth(user, password string) {
+	c.authUser = user
+	c.authPass = password
+}
+
+// Connect establishes connection to the master. A master given by hostname
+// is resolved on every call, so reconnecting follows a DNS change.
 func (c *Client) Connect() error {
-	addr := fmt.Sprintf("%s:%s", c.masterHost, c.masterPort)
+	addr := net.JoinHostPort(c.masterHost, c.masterPort)
 	logger.Info("Connecting to master at %s", addr)
 
-	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	c.setState(StateConnecting)
+	c.stateMu.Lock()
+	if c.conn != nil {
+		// Left over from a link that broke
+		c.conn.Close()
+	}
+	c.stateMu.Unlock()
+	conn, ip, err := c.dial()
 	if err != nil {
+		c.setState(StateConnect)
 		return fmt.Errorf("failed to connect to master: %w", err)
 	}
 
+	c.stateMu.Lock()
+	if c.closed {
+		c.stateMu.Unlock()
+		conn.Close()
+		return fmt.Errorf("failed to connect to master: %w", net.ErrClosed)
+	}
+	if ip != c.masterHost && ip != c.masterIP {
+		logger.Info("Master host %s resolved to %s", c.masterHost, ip)
+	}
+	c.masterIP = ip
 	c.conn = conn
 	c.encoder = resp.NewEncoder(conn)
 	c.parser = resp.NewParser(conn)
+	c.stateMu.Unlock()
 
 	logger.Info("Connected to master successfully")
 	return nil
 }
 
-// Close closes the connection to master
+// dial resolves the master host and connects to the first of its addresses
+// that accepts, returning the connection and the address used
+func (c *Client) dial() (net.Conn, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+	ips, err := net.DefaultResolver.LookupHost(ctx, c.masterHost)
+	if err != nil {
+		return nil, "", err
+	}
+
+	dialer := net.Dialer{Timeout: dialTimeout}
+	for _, ip := range ips {
+		var conn net.Conn
+		if conn, err = dialer.Dial("tcp", net.JoinHostPort(ip, c.masterPort)); err == nil {
+			return conn, ip, nil
+		}
+	}
+	return nil, "", err
+}
+
+// Close closes the connection to master. The client can't connect again.
 func (c *Client) Close() error {
-	if c.conn != nil {
-		return c.conn.Close()
+	c.setState(StateConnect)
+	c.stateMu.Lock()
+	c.closed = true
+	conn := c.conn
+	c.stateMu.Unlock()
+	if conn != nil {
+		return conn.Close()
 	}
 	return nil
 }
 
+// Closed returns true once Close was called
+func (c *Client) Closed() bool {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	return c.closed
+}
+
 // Handshake performs the replication handshake with master
 func (c *Client) Handshake() error {
 	logger.Debug("Starting handshake with master")
 
+	// Step 0: Authenticate if the master requires a password
+	if c.authPass != "" {
+		if err := c.sendAuth(); err != nil {
+			return err
+		}
+	}
+
 	// Step 1: Send PING
 	if err := c.sendPing(); err != nil {
 		return err
@@ -75,6 +194,7 @@ func (c *Client) Handshake() error {
 	if err := c.sendPsync(); err != nil {
 		return err
 	}
+	c.setState(StateSync)
 
 	// Step 4: Receive RDB file (if sent)
 	logger.Debug("About to receive RDB...")
@@ -82,11 +202,44 @@ func (c *Client) Handshake() error {
 		logger.Warn("Failed to receive RDB: %v", err)
 		// Don't fail - some tests don't send RDB
 	}
+	c.setState(StateConnected)
 	logger.Debug("Handshake complete, returning")
 
 	return nil
 }
 
+// sendAuth sends AUTH with the configured credentials and waits for OK
+func (c *Client) sendAuth() error {
+	logger.Debug("Sending AUTH to master")
+
+	// Create AUTH command, including the user name only when configured
+	args := []resp.Value{resp.BulkStringValue("AUTH")}
+	if c.authUser != "" {
+		args = append(args, resp.BulkStringValue(c.authUser))
+	}
+	args = append(args, resp.BulkStringValue(c.authPass))
+	authCmd := resp.ArrayValue(args...)
+
+	// Send AUTH
+	if err := c.encoder.Encode(authCmd); err != nil {
+		return fmt.Errorf("failed to encode AUTH: %w", err)
+	}
+
+	// Read response
+	response, err := c.parser.Parse()
+	if err != nil {
+		return fmt.Errorf("failed to read AUTH response: %w", err)
+	}
+
+	// Check if response is OK
+	if response.Type != resp.SimpleString || response.Str != "OK" {
+		return fmt.Errorf("unable to AUTH to master: %v", response)
+	}
+
+	logger.Debug("Authenticated with master")
+	return nil
+}
+
 // sendPing sends PING command and waits for PONG response
 func (c *Client) sendPing() error {
 	logger.Debug("Sending PING to master")
@@ -231,13 +384,18 @@ func (c *Client) sendPsync() error {
 
 	// Extract replication ID and offset
 	replID := parts[1]
-	offset, err := strconv.Atoi(parts[2])
+	offset, err := strconv.ParseInt(parts[2], 10, 64)
 	if err != nil {
 		return fmt.Errorf("invalid offset in FULLRESYNC: %s", parts[2])
 	}
 
 	logger.Info("Received FULLRESYNC with replid=%s offset=%d", replID, offset)
 
+	// The stream starts over from the master's offset after a full resync
+	c.stateMu.Lock()
+	c.offset = offset
+	c.stateMu.Unlock()
+
 	// Don't create a new parser here - it might buffer the RDB data
 	// c.parser = resp.NewParser(c.conn)
 	// logger.Debug("Created new parser after FULLRESYNC")
@@ -255,112 +413,160 @@ func (c *Client) receiveRDB() error {
 	if err != nil {
 		return fmt.Errorf("failed to parse RDB: %w", err)
 	}
+	c.touch()
 
-	// Get the RDB data
-	rdbData := []byte(rdbValue.Str)
-
-	logger.Debug("Successfully received RDB: %d bytes", len(rdbData))
-	logger.Debug("receiveRDB completed, returning")
-	// TODO: Parse and apply RDB in future stages
+	// Keep the RDB data until the server applies it
+	c.rdbData = []byte(rdbValue.Str)
 
+	logger.Debug("Successfully received RDB: %d bytes", len(c.rdbData))
 	return nil
 }
 
+// TakeRDB returns the RDB payload received during the handshake and releases it
+func (c *Client) TakeRDB() []byte {
+	data := c.rdbData
+	c.rdbData = nil
+	return data
+}
+
 // ListenForCommands continuously reads commands from master and returns them
+// with the number of bytes they took on the wire.
 // This should be called in a goroutine after successful handshake
-func (c *Client) ListenForCommands() (resp.Value, error) {
+func (c *Client) ListenForCommands() (resp.Value, int64, error) {
 	// Read next command from master
+	start := c.parser.Consumed()
 	value, err := c.parser.Parse()
 	if err != nil {
-		return resp.Value{}, err
+		c.setState(StateConnect)
+		return resp.Value{}, 0, err
 	}
+	c.touch()
 
 	// Don't update offset here - let the caller decide based on command type
-	return value, nil
+	return value, c.parser.Consumed() - start, nil
 }
 
-// ProcessCommand updates the offset for all commands received from master
-func (c *Client) ProcessCommand(command resp.Value) {
-	// Always update offset for all commands
-	commandBytes := c.calculateCommandBytes(command)
-	c.offset += int64(commandBytes)
+// ProcessCommand adds the size of a command received from master to the offset
+func (c *Client) ProcessCommand(command resp.Value, size int64) {
+	c.stateMu.Lock()
+	c.offset += size
+	offset := c.offset
+	c.stateMu.Unlock()
 
 	cmdName, _ := command.GetCommand()
-	logger.Debug("Updated replication offset to %d after %s command (%d bytes)", c.offset, cmdName, commandBytes)
+	logger.Debug("Updated replication offset to %d after %s command (%d bytes)", offset, cmdName, size)
 }
 
-// calculateCommandBytes calculates the size of a command in RESP format
-func (c *Client) calculateCommandBytes(value resp.Value) int {
-	size := 0
+// State returns the current state of the link to master
+func (c *Client) State() LinkState {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	return c.state
+}
 
-	switch value.Type {
-	case resp.Array:
-		// Array: *<count>\r\n followed by elements
-		size += 1 // *
-		size += len(fmt.Sprintf("%d", len(value.Array))) // count
-		size += 2 // \r\n
+// Info returns a snapshot of the link to master for INFO replication
+func (c *Client) Info() LinkInfo {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
 
-		// Add size of each element
-		for _, elem := range value.Array {
-			size += c.calculateCommandBytes(elem)
-		}
+	lastIO := -1
+	if !c.lastIO.IsZero() {
+		lastIO = int(time.Since(c.lastIO).Seconds())
+	}
 
-	case resp.BulkString:
-		// Bulk string: $<length>\r\n<data>\r\n
-		size += 1 // $
-		if value.IsNull {
-			size += 2 // -1
-		} else {
-			size += len(fmt.Sprintf("%d", len(value.Str))) // length
-		}
-		size += 2 // \r\n
-		if !value.IsNull {
-			size += len(value.Str) // data
-			size += 2 // \r\n
-		}
+	down := -1
+	if c.state != StateConnected && !c.downAt.IsZero() {
+		down = int(time.Since(c.downAt).Seconds())
+	}
+
+	return LinkInfo{
+		MasterHost:       c.masterHost,
+		MasterPort:       c.masterPort,
+		State:            c.state,
+		LastIOSecondsAgo: lastIO,
+		DownSeconds:      down,
+		Offset:           c.offset,
+	}
+}
 
-	case resp.SimpleString:
-		// Simple string: +<data>\r\n
-		size += 1 // +
-		size += len(value.Str) // data
-		size += 2 // \r\n
+// LocalAddr returns the address of this end of the link to master, nil
+// before Connect
+func (c *Client) LocalAddr() net.Addr {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.LocalAddr()
+}
 
-	case resp.Error:
-		// Error: -<data>\r\n
-		size += 1 // -
-		size += len(value.Str) // data
-		size += 2 // \r\n
+func (c *Client) setState(state LinkState) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
 
-	case resp.Integer:
-		// Integer: :<number>\r\n
-		size += 1 // :
-		size += len(fmt.Sprintf("%d", value.Integer)) // number
-		size += 2 // \r\n
+	if c.state != state {
+		logger.Debug("Replication link state %s -> %s", c.state, state)
+		if c.state == StateConnected {
+			c.downAt = time.Now()
+		}
+		c.state = state
 	}
+}
 
-	return size
+// touch records an interaction with the master
+func (c *Client) touch() {
+	c.stateMu.Lock()
+	c.lastIO = time.Now()
+	c.stateMu.Unlock()
 }
 
 // GetOffset returns the current replication offset
 func (c *Client) GetOffset() int64 {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
 	return c.offset
 }
 
 // SendReplConfAck sends REPLCONF ACK with current offset to master
 func (c *Client) SendReplConfAck() error {
-	logger.Debug("Sending REPLCONF ACK %d to master", c.offset)
+	offset := c.GetOffset()
+	logger.Debug("Sending REPLCONF ACK %d to master", offset)
 
 	// Create REPLCONF ACK command
 	ackCmd := resp.ArrayValue(
 		resp.BulkStringValue("REPLCONF"),
 		resp.BulkStringValue("ACK"),
-		resp.BulkStringValue(fmt.Sprintf("%d", c.offset)),
+		resp.BulkStringValue(fmt.Sprintf("%d", offset)),
 	)
 
 	// Send ACK
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
 	if err := c.encoder.Encode(ackCmd); err != nil {
 		return fmt.Errorf("failed to send REPLCONF ACK: %w", err)
 	}
 
 	return nil
 }
+
+// RunAckLoop sends REPLCONF ACK with the current offset on every tick until
+// stop is closed or the link to master goes down
+func (c *Client) RunAckLoop(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if c.State() != StateConnected {
+				return
+			}
+			if err := c.SendReplConfAck(); err != nil {
+				logger.Warn("Failed to send periodic REPLCONF ACK: %v", err)
+				return
+			}
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Limits bound the size of client requests accepted by ParseCommand. A zero
+// field means no limit.
+type Limits struct {
+	MaxInlineLen    int // Longest inline request or multibulk header line
+	MaxBulkLen      int // Longest single argument
+	MaxMultibulkLen int // Most arguments in one request
+}
+
+// ProtocolError reports a malformed or oversized client request
+type ProtocolError struct {
+	Reason string
+	Resync bool // The whole request was consumed, so the next Parse starts at a command boundary
+}
+
+func (err *ProtocolError) Error() string {
+	return "Protocol error: " + err.Reason
+}
+
+// maxPreallocArgs is the most arguments of a request allocated before they
+// arrive, which matters when no multibulk limit is set
+const maxPreallocArgs = 64 * 1024
+
+// errLineTooLong is returned by readLimitedLine for lines over the limit
+var errLineTooLong = errors.New("line too long")
+
+// SetLimits sets the limits enforced by ParseCommand
+func (parser *Parser) SetLimits(limits Limits) {
+	parser.limits = limits
+}
+
+// ParseCommand reads the next client request, either a multibulk array of
+// bulk strings or an inline command line, and returns it as an array of bulk
+// strings. Empty inline lines are skipped. Malformed requests are reported as
+// a *ProtocolError. The arguments slice of the returned value is reused by
+// the next call, so it must be copied to be kept longer.
+func (parser *Parser) ParseCommand() (Value, error) {
+	if parser.request != nil {
+		putArgs(parser.request)
+		parser.request = nil
+	}
+
+	for {
+		next, err := parser.reader.Peek(1)
+		if err != nil {
+			return Value{}, err
+		}
+
+		var value Value
+		if Type(next[0]) == Array {
+			parser.reader.ReadByte()
+			value, err = parser.parseMultibulk()
+		} else {
+			value, err = parser.parseInline()
+		}
+		if err != nil || len(value.Array) > 0 {
+			return value, err
+		}
+	}
+}
+
+// parseInline reads a request sent as a plain line of space separated arguments
+func (parser *Parser) parseInline() (Value, error) {
+	line, err := parser.readLimitedLine(parser.limits.MaxInlineLen)
+	if err == errLineTooLong {
+		return Value{}, &ProtocolError{Reason: "too big inline request"}
+	}
+	if err != nil {
+		return Value{}, err
+	}
+
+	args, err := SplitArgs(string(line))
+	if err != nil {
+		// The whole line was consumed, the next one is a new request
+		return Value{}, &ProtocolError{Reason: "unbalanced quotes in request", Resync: true}
+	}
+	values := make([]Value, len(args))
+	for i, arg := range args {
+		values[i] = BulkStringValue(arg)
+	}
+	return ArrayValue(values...), nil
+}
+
+// parseMultibulk reads the arguments of a request after its '*'
+func (parser *Parser) parseMultibulk() (Value, error) {
+	count, err := parser.readLength('*')
+	if err != nil {
+		return Value{}, err
+	}
+	if parser.limits.MaxMultibulkLen > 0 && count > parser.limits.MaxMultibulkLen {
+		return Value{}, &ProtocolError{Reason: "invalid multibulk length"}
+	}
+	if count <= 0 {
+		// Like Redis, an empty request is skipped
+		return ArrayValue(), nil
+	}
+
+	// The count is not trusted until the arguments arrive
+	args := getArgs()
+	if count > maxPooledArgs {
+		*args = make([]Value, 0, min(count, maxPreallocArgs))
+	}
+	for i := 0; i < count; i++ {
+		arg, err := parser.parseArgument()
+		if err != nil {
+			putArgs(args)
+			return Value{}, err
+		}
+		*args = append(*args, arg)
+	}
+
+	parser.request = args
+	return ArrayValue(*args...), nil
+}
+
+// parseArgument reads one bulk string argument of a multibulk request
+func (parser *Parser) parseArgument() (Value, error) {
+	if err := parser.expect(BulkString); err != nil {
+		return Value{}, err
+	}
+	length, err := parser.readLength('$')
+	if err != nil {
+		return Value{}, err
+	}
+	if length < 0 || parser.limits.MaxBulkLen > 0 && length > parser.limits.MaxBulkLen {
+		return Value{}, &ProtocolError{Reason: "invalid bulk length"}
+	}
+
+	str, crlf, err := parser.readBulk(length)
+	if err != nil {
+		return Value{}, err
+	}
+	if !crlf {
+		return Value{}, &ProtocolError{Reason: "expected CRLF after bulk string"}
+	}
+	return BulkStringValue(str), nil
+}
+
+// expect consumes the type byte of the next value, which must be want
+func (parser *Parser) expect(want Type) error {
+	got, err := parser.reader.ReadByte()
+	if err != nil {
+		return err
+	}
+	if Type(got) != want {
+		return &ProtocolError{Reason: fmt.Sprintf("expected '%c', got '%c'", want, got)}
+	}
+	return nil
+}
+
+// readLength reads the length line following a '*' or '$' type byte
+func (parser *Parser) readLength(prefix Type) (int, error) {
+	line, err := parser.readLimitedLine(parser.limits.MaxInlineLen)
+	if err == errLineTooLong {
+		if prefix == Array {
+			return 0, &ProtocolError{Reason: "too big mbulk count string"}
+		}
+		return 0, &ProtocolError{Reason: "too big bulk count string"}
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	length, err := strconv.Atoi(string(line))
+	if err != nil {
+		if prefix == Array {
+			return 0, &ProtocolError{Reason: "invalid multibulk length"}
+		}
+		return 0, &ProtocolError{Reason: "invalid bulk length"}
+	}
+	return length, nil
+}
+
+// readLimitedLine reads a line without its line ending. It returns
+// errLineTooLong once more than max bytes were read without a newline. A line
+// that fits the read buffer is returned without copying, so it is only valid
+// until the next read.
+func (parser *Parser) readLimitedLine(max int) ([]byte, error) {
+	line, err := parser.reader.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		// Longer than the read buffer, collect it in a copy
+		line = append([]byte(nil), line...)
+		for err == bufio.ErrBufferFull && (max <= 0 || len(line) <= max+2) {
+			var chunk []byte
+			chunk, err = parser.reader.ReadSlice('\n')
+			line = append(line, chunk...)
+		}
+	}
+	if max > 0 && len(line) > max+2 {
+		return nil, errLineTooLong
+	}
+	if err != nil {
+		return nil, err
+	}
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	return bytes.TrimSuffix(line, []byte("\r")), nil
+}
@@ -0,0 +1,32 @@
+package resp
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func BenchmarkParseCommand(b *testing.B) {
+	request := []byte("*3\r\n$3\r\nSET\r\n$3\r\nkey\r\n$64\r\n" + strings.Repeat("v", 64) + "\r\n")
+	const batch = 1024
+	input := bytes.Repeat(request, batch)
+	reader := bytes.NewReader(input)
+	parser := NewParser(reader)
+
+	b.SetBytes(int64(len(request)))
+	b.ReportAllocs()
+	n := 0
+	for b.Loop() {
+		if n == batch {
+			b.StopTimer()
+			reader.Reset(input)
+			parser = NewParser(reader)
+			n = 0
+			b.StartTimer()
+		}
+		if _, err := parser.ParseCommand(); err != nil {
+			b.Fatal(err)
+		}
+		n++
+	}
+}
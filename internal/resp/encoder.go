@@ -3,11 +3,18 @@ package resp
 import (
 	"fmt"
 	"io"
+	"iter"
+	"strconv"
 )
 
+// streamFlushSize is how much of a streamed array is encoded before it is
+// written out, bounding the memory one large reply needs
+const streamFlushSize = 64 * 1024
+
 // Encoder encodes values to RESP format
 type Encoder struct {
-	writer io.Writer
+	writer   io.Writer
+	protocol int // 3 for RESP3, anything else encodes RESP2
 }
 
 // NewEncoder creates a new RESP encoder
@@ -15,61 +22,190 @@ func NewEncoder(writer io.Writer) *Encoder {
 	return &Encoder{writer: writer}
 }
 
-// Encode writes a RESP value to the writer
+// Encode writes a RESP value to the writer with a single write
 func (encoder *Encoder) Encode(value Value) error {
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+
+	data, err := encoder.appendValue(*buffer, value)
+	*buffer = data
+	if err != nil {
+		return err
+	}
+	_, err = encoder.writer.Write(data)
+	return err
+}
+
+// appendValue appends the encoding of value to data
+func (encoder *Encoder) appendValue(data []byte, value Value) ([]byte, error) {
 	switch value.Type {
 	case SimpleString:
-		return encoder.encodeSimpleString(value.Str)
+		return appendLine(data, '+', value.Str), nil
 	case Error:
-		return encoder.encodeError(value.Str)
+		return appendLine(data, '-', value.Str), nil
 	case Integer:
-		return encoder.encodeInteger(value.Integer)
+		return appendInteger(data, value.Integer), nil
 	case BulkString:
-		return encoder.encodeBulkString(value)
+		if value.IsNull {
+			return encoder.appendNull(data, BulkString), nil
+		}
+		return appendBulkString(data, value.Str), nil
 	case Array:
-		return encoder.encodeArray(value.Array)
+		if value.IsNull {
+			return encoder.appendNull(data, Array), nil
+		}
+		if value.Stream != nil {
+			return encoder.appendStream(data, value.Stream)
+		}
+		return encoder.appendArray(data, Array, len(value.Array), value.Array)
+	case Null:
+		return encoder.appendNull(data, BulkString), nil
+	case Double:
+		return encoder.appendDouble(data, value.Double), nil
+	case Boolean:
+		return encoder.appendBoolean(data, value.Bool), nil
+	case Map:
+		return encoder.appendMap(data, value.Array)
+	case Push:
+		return encoder.appendPush(data, value.Array)
+	case Verbatim:
+		return encoder.appendVerbatim(data, value.Str), nil
 	default:
-		return fmt.Errorf("unknown RESP type: %c", value.Type)
+		return data, fmt.Errorf("unknown RESP type: %c", value.Type)
 	}
 }
 
-func (encoder *Encoder) write(data string) error {
-	_, err := encoder.writer.Write([]byte(data))
-	return err
+// appendLine appends a value sent as a single line, like a simple string
+func appendLine(data []byte, prefix Type, line string) []byte {
+	data = append(data, byte(prefix))
+	data = append(data, line...)
+	return append(data, '\r', '\n')
 }
 
-func (encoder *Encoder) encodeSimpleString(str string) error {
-	return encoder.write(fmt.Sprintf("+%s\r\n", str))
+// appendLength appends the header line of an aggregate or bulk value
+func appendLength(data []byte, prefix Type, length int) []byte {
+	data = append(data, byte(prefix))
+	data = strconv.AppendInt(data, int64(length), 10)
+	return append(data, '\r', '\n')
 }
 
-func (encoder *Encoder) encodeError(str string) error {
-	return encoder.write(fmt.Sprintf("-%s\r\n", str))
+func appendInteger(data []byte, intValue int64) []byte {
+	data = append(data, byte(Integer))
+	data = strconv.AppendInt(data, intValue, 10)
+	return append(data, '\r', '\n')
 }
 
-func (encoder *Encoder) encodeInteger(intValue int) error {
-	return encoder.write(fmt.Sprintf(":%d\r\n", intValue))
+func appendBulkString(data []byte, str string) []byte {
+	data = appendLength(data, BulkString, len(str))
+	data = append(data, str...)
+	return append(data, '\r', '\n')
 }
 
-func (encoder *Encoder) encodeBulkString(value Value) error {
-	// Check for null bulk string
-	if value.IsNull {
-		return encoder.write("$-1\r\n")
+// appendStream appends a streamed array, writing out what was encoded so far
+// whenever it exceeds streamFlushSize. Elements missing from the stream are
+// sent as nulls and extra ones are dropped, so the count stays right.
+func (encoder *Encoder) appendStream(data []byte, stream *ArrayStream) ([]byte, error) {
+	data = appendLength(data, Array, stream.Len)
+	sent := 0
+	var err error
+	for element := range stream.Elements {
+		if sent == stream.Len {
+			break
+		}
+		if data, err = encoder.appendValue(data, element); err != nil {
+			return data, err
+		}
+		sent++
+		if len(data) >= streamFlushSize {
+			if _, err := encoder.writer.Write(data); err != nil {
+				return data[:0], err
+			}
+			data = data[:0]
+		}
+	}
+	for ; sent < stream.Len; sent++ {
+		data = encoder.appendNull(data, BulkString)
 	}
-	return encoder.write(fmt.Sprintf("$%d\r\n%s\r\n", len(value.Str), value.Str))
+	return data, nil
 }
 
-func (encoder *Encoder) encodeArray(array []Value) error {
-	if err := encoder.write(fmt.Sprintf("*%d\r\n", len(array))); err != nil {
-		return err
+// EncodedLen returns the number of bytes Encode writes for value on a RESP2
+// connection, computed without encoding it. Replication offsets count the
+// stream in these units.
+func EncodedLen(value Value) int {
+	switch value.Type {
+	case SimpleString, Error:
+		return 1 + len(value.Str) + 2
+	case Integer:
+		return integerLen(value.Integer)
+	case BulkString:
+		if value.IsNull {
+			return nullLen
+		}
+		return bulkStringLen(len(value.Str))
+	case Array, Map, Push:
+		if value.IsNull {
+			return nullLen
+		}
+		if value.Stream != nil {
+			return streamLen(value.Stream)
+		}
+		size := integerLen(int64(len(value.Array)))
+		for _, element := range value.Array {
+			size += EncodedLen(element)
+		}
+		return size
+	case Null:
+		return nullLen
+	case Double:
+		return bulkStringLen(len(FormatDouble(value.Double)))
+	case Boolean:
+		return integerLen(0)
+	case Verbatim:
+		return bulkStringLen(len(verbatimText(value.Str)))
+	default:
+		return 0
 	}
+}
 
-	for _, value := range array {
-		if err := encoder.Encode(value); err != nil {
-			return err
+// streamLen returns the encoded length of a streamed array like appendStream
+func streamLen(stream *ArrayStream) int {
+	size := integerLen(int64(stream.Len))
+	sent := 0
+	for element := range stream.Elements {
+		if sent == stream.Len {
+			break
 		}
+		size += EncodedLen(element)
+		sent++
 	}
+	return size + (stream.Len-sent)*nullLen
+}
+
+// nullLen is the length of the RESP2 nulls $-1 and *-1
+const nullLen = 5
+
+// integerLen returns the length of an integer line, which is also the length
+// of a bulk or aggregate header with intValue as its length
+func integerLen(intValue int64) int {
+	var digits [20]byte
+	return 1 + len(strconv.AppendInt(digits[:0], intValue, 10)) + 2
+}
+
+func bulkStringLen(length int) int {
+	return integerLen(int64(length)) + length + 2
+}
 
-	return nil
+// appendArray appends count followed by the elements, for arrays and maps
+func (encoder *Encoder) appendArray(data []byte, prefix Type, count int, elements []Value) ([]byte, error) {
+	data = appendLength(data, prefix, count)
+	for _, element := range elements {
+		var err error
+		if data, err = encoder.appendValue(data, element); err != nil {
+			return data, err
+		}
+	}
+	return data, nil
 }
 
 // Helper functions for common responses
@@ -85,7 +221,7 @@ func ErrorValue(str string) Value {
 }
 
 // Integer creates an integer value
-func IntegerValue(intValue int) Value {
+func IntegerValue(intValue int64) Value {
 	return Value{Type: Integer, Integer: intValue}
 }
 
@@ -99,11 +235,35 @@ func ArrayValue(values ...Value) Value {
 	return Value{Type: Array, Array: values}
 }
 
+// StreamArrayValue creates an array of count elements that are produced by
+// elements while the reply is encoded
+func StreamArrayValue(count int, elements iter.Seq[Value]) Value {
+	return Value{Type: Array, Stream: &ArrayStream{Len: count, Elements: elements}}
+}
+
+// BulkStringsValue creates an array of bulk strings streamed from strs,
+// without a Value per element
+func BulkStringsValue(strs []string) Value {
+	return StreamArrayValue(len(strs), func(yield func(Value) bool) {
+		for _, str := range strs {
+			if !yield(BulkStringValue(str)) {
+				return
+			}
+		}
+	})
+}
+
 // NullBulkString creates a null bulk string value
 func NullBulkString() Value {
 	return Value{Type: BulkString, IsNull: true}
 }
 
+// NullArray creates a null array value, the reply of a blocking command that
+// timed out or an aborted transaction
+func NullArray() Value {
+	return Value{Type: Array, IsNull: true}
+}
+
 // OK returns a standard OK simple string
 func OK() Value {
 	return SimpleStringValue("OK")
@@ -0,0 +1,45 @@
+package resp
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+// benchmarkReply is an array reply like LRANGE or MGET of ten elements
+var benchmarkReply = ArrayValue(
+	BulkStringValue("first"), BulkStringValue("second"), BulkStringValue("third"),
+	IntegerValue(42), IntegerValue(-1), NullBulkString(),
+	BulkStringValue(strings.Repeat("x", 64)), BulkStringValue(""), SimpleStringValue("OK"),
+	ArrayValue(BulkStringValue("nested"), IntegerValue(7)),
+)
+
+func BenchmarkEncodeArray(b *testing.B) {
+	encoder := NewEncoder(io.Discard)
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := encoder.Encode(benchmarkReply); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncodeSimple(b *testing.B) {
+	encoder := NewEncoder(io.Discard)
+	ok, integer := OK(), IntegerValue(1234567)
+	b.ReportAllocs()
+	for b.Loop() {
+		encoder.Encode(ok)
+		encoder.Encode(integer)
+	}
+}
+
+// BenchmarkEncodedLen measures the size accounting of a propagated command,
+// which replication offsets and the AOF count for every write
+func BenchmarkEncodedLen(b *testing.B) {
+	command := ArrayValue(BulkStringValue("SET"), BulkStringValue("key"), BulkStringValue("value"))
+	b.ReportAllocs()
+	for b.Loop() {
+		EncodedLen(command)
+	}
+}
@@ -0,0 +1,110 @@
+package resp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SplitArgs splits a command line into arguments the way redis-cli and
+// inline requests do. Double quoted arguments support \n, \r, \t, \b, \a,
+// \xHH and escaped quotes; single quoted arguments only support \'.
+func SplitArgs(line string) ([]string, error) {
+	var args []string
+	i := 0
+
+	for {
+		for i < len(line) && isSpace(line[i]) {
+			i++
+		}
+		if i >= len(line) {
+			return args, nil
+		}
+
+		var current strings.Builder
+		inDouble, inSingle := false, false
+		for done := false; !done; {
+			if i >= len(line) {
+				if inDouble || inSingle {
+					return nil, fmt.Errorf("unbalanced quotes")
+				}
+				break
+			}
+			char := line[i]
+
+			switch {
+			case inDouble:
+				switch {
+				case char == '\\' && i+3 < len(line) && line[i+1] == 'x' && isHex(line[i+2]) && isHex(line[i+3]):
+					b, _ := strconv.ParseUint(line[i+2:i+4], 16, 8)
+					current.WriteByte(byte(b))
+					i += 3
+				case char == '\\' && i+1 < len(line):
+					i++
+					current.WriteByte(unescape(line[i]))
+				case char == '"':
+					// The closing quote must be followed by a space or the end of the line
+					if i+1 < len(line) && !isSpace(line[i+1]) {
+						return nil, fmt.Errorf("unbalanced quotes")
+					}
+					done = true
+				default:
+					current.WriteByte(char)
+				}
+			case inSingle:
+				switch {
+				case char == '\\' && i+1 < len(line) && line[i+1] == '\'':
+					i++
+					current.WriteByte('\'')
+				case char == '\'':
+					if i+1 < len(line) && !isSpace(line[i+1]) {
+						return nil, fmt.Errorf("unbalanced quotes")
+					}
+					done = true
+				default:
+					current.WriteByte(char)
+				}
+			default:
+				switch {
+				case isSpace(char):
+					done = true
+				case char == '"':
+					inDouble = true
+				case char == '\'':
+					inSingle = true
+				default:
+					current.WriteByte(char)
+				}
+			}
+			i++
+		}
+
+		args = append(args, current.String())
+	}
+}
+
+// unescape returns the byte a backslash escape in double quotes stands for
+func unescape(char byte) byte {
+	switch char {
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	case 'b':
+		return '\b'
+	case 'a':
+		return '\a'
+	default:
+		return char
+	}
+}
+
+func isSpace(char byte) bool {
+	return char == ' ' || char == '\t' || char == '\n' || char == '\r'
+}
+
+func isHex(char byte) bool {
+	return char >= '0' && char <= '9' || char >= 'a' && char <= 'f' || char >= 'A' && char <= 'F'
+}
@@ -2,24 +2,55 @@ package resp
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
 	"strconv"
 	"strings"
 )
 
+// maxNesting is the deepest Parse reads aggregate values nested, so input
+// like "*1\r\n" repeated fails instead of exhausting the stack
+const maxNesting = 1000
+
 // Parser parses RESP protocol messages
 type Parser struct {
 	reader *bufio.Reader
+	source *countingReader
+	limits Limits // Enforced by ParseCommand
+	depth  int    // Aggregate values being parsed by Parse, see nest
+
+	request *[]Value // Arguments of the last ParseCommand, reused by the next
+}
+
+// countingReader counts the bytes read from the underlying reader
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (counter *countingReader) Read(data []byte) (int, error) {
+	n, err := counter.reader.Read(data)
+	counter.count += int64(n)
+	return n, err
 }
 
 // NewParser creates a new RESP parser
 func NewParser(reader io.Reader) *Parser {
+	source := &countingReader{reader: reader}
 	return &Parser{
-		reader: bufio.NewReader(reader),
+		reader: bufio.NewReader(source),
+		source: source,
 	}
 }
 
+// Consumed returns the number of bytes parsed so far. The difference before
+// and after a Parse is the exact wire size of the value it returned, which
+// re-encoding the value would not always reproduce.
+func (parser *Parser) Consumed() int64 {
+	return parser.source.count - int64(parser.reader.Buffered())
+}
+
 // Parse reads and parses the next RESP value
 func (parser *Parser) Parse() (Value, error) {
 	typeByte, err := parser.reader.ReadByte()
@@ -38,11 +69,30 @@ func (parser *Parser) Parse() (Value, error) {
 		return parser.parseBulkString()
 	case Array:
 		return parser.parseArray()
+	case Null:
+		return parser.parseNull()
+	case Push:
+		return parser.parsePush()
+	case Double:
+		return parser.parseDouble()
+	case Boolean:
+		return parser.parseBoolean()
+	case Map:
+		return parser.parseMap()
+	case Verbatim:
+		return parser.parseVerbatim()
 	default:
 		return Value{}, fmt.Errorf("unknown RESP type: %c", typeByte)
 	}
 }
 
+// WaitInput blocks until input is available without consuming it, or returns
+// the error that prevents reading
+func (parser *Parser) WaitInput() error {
+	_, err := parser.reader.Peek(1)
+	return err
+}
+
 func (parser *Parser) readLine() (string, error) {
 	line, err := parser.reader.ReadString('\n')
 	if err != nil {
@@ -74,7 +124,7 @@ func (parser *Parser) parseInteger() (Value, error) {
 		return Value{}, err
 	}
 
-	intValue, err := strconv.Atoi(line)
+	intValue, err := strconv.ParseInt(line, 10, 64)
 	if err != nil {
 		return Value{}, fmt.Errorf("invalid integer: %s", line)
 	}
@@ -102,15 +152,76 @@ func (parser *Parser) parseBulkString() (Value, error) {
 		return Value{}, fmt.Errorf("invalid bulk string length: %d", length)
 	}
 
-	// Read the bulk string data
-	data := make([]byte, length+2) // +2 for \r\n
-	_, err = io.ReadFull(parser.reader, data)
+	str, _, err := parser.readBulk(length)
 	if err != nil {
 		return Value{}, err
 	}
+	return Value{Type: BulkString, Str: str}, nil
+}
 
-	// Remove \r\n
-	return Value{Type: BulkString, Str: string(data[:length])}, nil
+// readBulk reads length bytes of bulk string data and the line ending after
+// them, reporting whether it was a CRLF. Small strings are read into a pooled
+// scratch buffer so only the string itself is allocated.
+func (parser *Parser) readBulk(length int) (string, bool, error) {
+	if length > maxPooledBuffer-2 {
+		data, err := parser.readData(length)
+		if err != nil {
+			return "", false, err
+		}
+		var end [2]byte
+		if _, err := io.ReadFull(parser.reader, end[:]); err != nil {
+			return "", false, err
+		}
+		return string(data), end == [2]byte{'\r', '\n'}, nil
+	}
+
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+	data := append(*buffer, make([]byte, length+2)...)
+	*buffer = data
+	if _, err := io.ReadFull(parser.reader, data); err != nil {
+		return "", false, err
+	}
+	return string(data[:length]), data[length] == '\r' && data[length+1] == '\n', nil
+}
+
+// readData reads length bytes. Data larger than a pooled buffer is collected
+// as it arrives, so a huge length sent by a broken or hostile peer runs out
+// of input instead of being allocated up front.
+func (parser *Parser) readData(length int) ([]byte, error) {
+	if length <= maxPooledBuffer {
+		data := make([]byte, length)
+		if _, err := io.ReadFull(parser.reader, data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+
+	var data bytes.Buffer
+	if _, err := io.CopyN(&data, parser.reader, int64(length)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return data.Bytes(), nil
+}
+
+// nest enters an aggregate value, failing when they are nested deeper than
+// maxNesting. Call the returned function once the value was parsed.
+func (parser *Parser) nest() (func(), error) {
+	if parser.depth >= maxNesting {
+		return nil, fmt.Errorf("aggregate values nested deeper than %d", maxNesting)
+	}
+	parser.depth++
+	return func() { parser.depth-- }, nil
+}
+
+// prealloc returns how many of the count elements of an aggregate value to
+// allocate before they arrive. The room halves with each level of nesting,
+// so a few bytes of nested headers can't allocate megabytes.
+func (parser *Parser) prealloc(count int) int {
+	return min(count, maxPooledArgs>>(parser.depth-1))
 }
 
 func (parser *Parser) parseArray() (Value, error) {
@@ -133,13 +244,20 @@ func (parser *Parser) parseArray() (Value, error) {
 		return Value{}, fmt.Errorf("invalid array count: %d", count)
 	}
 
-	array := make([]Value, count)
+	leave, err := parser.nest()
+	if err != nil {
+		return Value{}, err
+	}
+	defer leave()
+
+	// The count is not trusted until the elements arrive
+	array := make([]Value, 0, parser.prealloc(count))
 	for index := 0; index < count; index++ {
 		value, err := parser.Parse()
 		if err != nil {
 			return Value{}, err
 		}
-		array[index] = value
+		array = append(array, value)
 	}
 
 	return Value{Type: Array, Array: array}, nil
@@ -173,8 +291,7 @@ func (parser *Parser) ParseRDBBulkString() (Value, error) {
 	}
 
 	// Read exactly length bytes (no trailing CRLF for RDB)
-	data := make([]byte, length)
-	_, err = io.ReadFull(parser.reader, data)
+	data, err := parser.readData(length)
 	if err != nil {
 		return Value{}, err
 	}
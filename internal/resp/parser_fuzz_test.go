@@ -0,0 +1,97 @@
+package resp
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// parserSeeds are the seed corpus of FuzzParser: valid frames of every type,
+// truncated frames, huge length prefixes and deep nesting
+var parserSeeds = []string{
+	"+OK\r\n",
+	"-ERR unknown command\r\n",
+	":42\r\n",
+	":-9223372036854775808\r\n",
+	"$5\r\nhello\r\n",
+	"$0\r\n\r\n",
+	"$-1\r\n",
+	"*-1\r\n",
+	"*2\r\n$3\r\nGET\r\n$3\r\nkey\r\n",
+	"*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$1\r\nv\r\n*1\r\n$4\r\nPING\r\n",
+	"_\r\n",
+	",3.14\r\n",
+	",inf\r\n",
+	"#t\r\n",
+	"%1\r\n+key\r\n:1\r\n",
+	">2\r\n+message\r\n+hello\r\n",
+	"=15\r\ntxt:Some string\r\n",
+	"PING\r\n",
+	"SET k \"v\\x41\"\r\n",
+	"\r\n\r\nPING\n",
+
+	// Truncated
+	"+OK",
+	"$5\r\nhel",
+	"$5\r\nhello",
+	"*2\r\n$3\r\nGET\r\n",
+	"*2\r\n$3\r\nGET\r\n$3\r\nke",
+	"%2\r\n+key\r\n",
+	"=15\r\ntxt:",
+
+	// Malformed
+	"$5\r\nhelloXY",
+	"$abc\r\n",
+	"*x\r\n",
+	"$-2\r\n",
+	"*-5\r\n",
+	"?\r\n",
+	"SET k \"unterminated\r\n",
+
+	// Huge length prefixes
+	"$2147483647\r\nabc\r\n",
+	"$9223372036854775807\r\n",
+	"$99999999999999999999\r\n",
+	"*2147483647\r\n$1\r\na\r\n",
+	"*9223372036854775807\r\n",
+	"%9223372036854775807\r\n",
+	"=2147483647\r\ntxt:x\r\n",
+
+	// Deep nesting
+	strings.Repeat("*1\r\n", 2*maxNesting) + ":1\r\n",
+	strings.Repeat("%1\r\n+k\r\n", 2*maxNesting) + ":1\r\n",
+}
+
+// FuzzParser feeds arbitrary bytes to Parse and ParseCommand, which must
+// return errors rather than panic or hang on any input
+func FuzzParser(f *testing.F) {
+	for _, seed := range parserSeeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		parser := NewParser(bytes.NewReader(data))
+		for {
+			if _, err := parser.Parse(); err != nil {
+				break
+			}
+			if parser.Consumed() > int64(len(data)) {
+				t.Fatalf("consumed %d bytes of %d", parser.Consumed(), len(data))
+			}
+		}
+
+		parser = NewParser(bytes.NewReader(data))
+		parser.SetLimits(Limits{MaxInlineLen: 64 * 1024, MaxBulkLen: 1024 * 1024, MaxMultibulkLen: 1024})
+		for {
+			value, err := parser.ParseCommand()
+			if err != nil {
+				break
+			}
+			for _, arg := range value.Array {
+				if arg.Type != BulkString {
+					t.Fatalf("ParseCommand returned a %c argument", arg.Type)
+				}
+			}
+		}
+	})
+}
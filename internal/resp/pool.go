@@ -0,0 +1,57 @@
+package resp
+
+import "sync"
+
+// maxPooledBuffer is the largest buffer returned to a pool, so one huge reply
+// or argument doesn't stay allocated for the life of the process
+const maxPooledBuffer = 64 * 1024
+
+// maxPooledArgs is the most arguments a pooled argument slice keeps
+const maxPooledArgs = 1024
+
+// bufferPool holds scratch byte buffers for encoding replies and reading
+// bulk strings
+var bufferPool = sync.Pool{
+	New: func() any {
+		buffer := make([]byte, 0, 512)
+		return &buffer
+	},
+}
+
+// argsPool holds the argument slices of parsed requests
+var argsPool = sync.Pool{
+	New: func() any {
+		args := make([]Value, 0, 8)
+		return &args
+	},
+}
+
+// getBuffer returns an empty buffer from the pool
+func getBuffer() *[]byte {
+	buffer := bufferPool.Get().(*[]byte)
+	*buffer = (*buffer)[:0]
+	return buffer
+}
+
+// putBuffer returns buffer to the pool unless it grew too large
+func putBuffer(buffer *[]byte) {
+	if cap(*buffer) <= maxPooledBuffer {
+		bufferPool.Put(buffer)
+	}
+}
+
+// getArgs returns an empty argument slice from the pool
+func getArgs() *[]Value {
+	args := argsPool.Get().(*[]Value)
+	*args = (*args)[:0]
+	return args
+}
+
+// putArgs clears args and returns it to the pool unless it grew too large
+func putArgs(args *[]Value) {
+	if cap(*args) > maxPooledArgs {
+		return
+	}
+	clear((*args)[:cap(*args)])
+	argsPool.Put(args)
+}
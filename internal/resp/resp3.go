@@ -0,0 +1,214 @@
+package resp
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// SetProtocol selects the protocol version replies are encoded in. RESP3
+// types are sent as their RESP2 equivalent unless version is 3.
+func (encoder *Encoder) SetProtocol(version int) {
+	encoder.protocol = version
+}
+
+// Protocol returns the protocol version replies are encoded in
+func (encoder *Encoder) Protocol() int {
+	if encoder.protocol == 3 {
+		return 3
+	}
+	return 2
+}
+
+// appendNull appends the RESP3 null, or the null of kind on RESP2
+func (encoder *Encoder) appendNull(data []byte, kind Type) []byte {
+	if encoder.protocol == 3 {
+		return append(data, "_\r\n"...)
+	}
+	return append(data, byte(kind), '-', '1', '\r', '\n')
+}
+
+func (encoder *Encoder) appendDouble(data []byte, double float64) []byte {
+	if encoder.protocol != 3 {
+		return appendBulkString(data, FormatDouble(double))
+	}
+	return appendLine(data, Double, FormatDouble(double))
+}
+
+func (encoder *Encoder) appendBoolean(data []byte, boolean bool) []byte {
+	if encoder.protocol != 3 {
+		if boolean {
+			return appendInteger(data, 1)
+		}
+		return appendInteger(data, 0)
+	}
+	if boolean {
+		return append(data, "#t\r\n"...)
+	}
+	return append(data, "#f\r\n"...)
+}
+
+// appendVerbatim appends formatted text, as a bulk string without its format on RESP2
+func (encoder *Encoder) appendVerbatim(data []byte, str string) []byte {
+	if encoder.protocol != 3 {
+		return appendBulkString(data, verbatimText(str))
+	}
+	data = appendLength(data, Verbatim, len(str))
+	data = append(data, str...)
+	return append(data, '\r', '\n')
+}
+
+// appendMap appends alternating keys and values, as a flat array on RESP2
+func (encoder *Encoder) appendMap(data []byte, pairs []Value) ([]byte, error) {
+	if encoder.protocol != 3 {
+		return encoder.appendArray(data, Array, len(pairs), pairs)
+	}
+	return encoder.appendArray(data, Map, len(pairs)/2, pairs)
+}
+
+// appendPush appends out of band data, as a plain array on RESP2
+func (encoder *Encoder) appendPush(data []byte, elements []Value) ([]byte, error) {
+	if encoder.protocol != 3 {
+		return encoder.appendArray(data, Array, len(elements), elements)
+	}
+	return encoder.appendArray(data, Push, len(elements), elements)
+}
+
+func (parser *Parser) parsePush() (Value, error) {
+	value, err := parser.parseArray()
+	if err != nil {
+		return Value{}, err
+	}
+	return PushValue(value.Array...), nil
+}
+
+func (parser *Parser) parseNull() (Value, error) {
+	line, err := parser.readLine()
+	if err != nil {
+		return Value{}, err
+	}
+	if line != "" {
+		return Value{}, fmt.Errorf("invalid null: %s", line)
+	}
+	return NullValue(), nil
+}
+
+func (parser *Parser) parseDouble() (Value, error) {
+	line, err := parser.readLine()
+	if err != nil {
+		return Value{}, err
+	}
+	double, err := strconv.ParseFloat(line, 64)
+	if err != nil {
+		return Value{}, fmt.Errorf("invalid double: %s", line)
+	}
+	return DoubleValue(double), nil
+}
+
+func (parser *Parser) parseBoolean() (Value, error) {
+	line, err := parser.readLine()
+	if err != nil {
+		return Value{}, err
+	}
+	switch line {
+	case "t":
+		return BooleanValue(true), nil
+	case "f":
+		return BooleanValue(false), nil
+	default:
+		return Value{}, fmt.Errorf("invalid boolean: %s", line)
+	}
+}
+
+func (parser *Parser) parseMap() (Value, error) {
+	line, err := parser.readLine()
+	if err != nil {
+		return Value{}, err
+	}
+	count, err := strconv.Atoi(line)
+	if err != nil || count < 0 {
+		return Value{}, fmt.Errorf("invalid map count: %s", line)
+	}
+
+	leave, err := parser.nest()
+	if err != nil {
+		return Value{}, err
+	}
+	defer leave()
+
+	pairs := make([]Value, 0, 2*parser.prealloc(count))
+	for index := 0; index < count; index++ {
+		for range 2 {
+			value, err := parser.Parse()
+			if err != nil {
+				return Value{}, err
+			}
+			pairs = append(pairs, value)
+		}
+	}
+	return MapValue(pairs...), nil
+}
+
+func (parser *Parser) parseVerbatim() (Value, error) {
+	value, err := parser.parseBulkString()
+	if err != nil {
+		return Value{}, err
+	}
+	if len(value.Str) < 4 || value.Str[3] != ':' {
+		return Value{}, fmt.Errorf("invalid verbatim string: %q", value.Str)
+	}
+	return Value{Type: Verbatim, Str: value.Str}, nil
+}
+
+// verbatimText strips the format prefix of a verbatim string
+func verbatimText(str string) string {
+	if len(str) >= 4 && str[3] == ':' {
+		return str[4:]
+	}
+	return str
+}
+
+// FormatDouble formats a double the way Redis replies with it: the shortest
+// representation that parses back to the same value, or inf, -inf and nan
+func FormatDouble(double float64) string {
+	switch {
+	case math.IsInf(double, 1):
+		return "inf"
+	case math.IsInf(double, -1):
+		return "-inf"
+	case math.IsNaN(double):
+		return "nan"
+	}
+	return strconv.FormatFloat(double, 'g', -1, 64)
+}
+
+// NullValue creates the RESP3 null, sent as a null bulk string on RESP2
+func NullValue() Value {
+	return Value{Type: Null, IsNull: true}
+}
+
+// DoubleValue creates a double value
+func DoubleValue(double float64) Value {
+	return Value{Type: Double, Double: double}
+}
+
+// BooleanValue creates a boolean value
+func BooleanValue(boolean bool) Value {
+	return Value{Type: Boolean, Bool: boolean}
+}
+
+// VerbatimValue creates a verbatim string, format is txt for plain text or
+// mkd for markdown
+func VerbatimValue(format, text string) Value {
+	return Value{Type: Verbatim, Str: format + ":" + text}
+}
+
+// PushValue creates an out of band push message
+func PushValue(values ...Value) Value {
+	return Value{Type: Push, Array: values}
+}
+
+// MapValue creates a map from alternating keys and values
+func MapValue(pairs ...Value) Value {
+	return Value{Type: Map, Array: pairs}
+}
@@ -1,6 +1,10 @@
 package resp
 
-import "fmt"
+import (
+	"fmt"
+	"iter"
+	"strconv"
+)
 
 // Type represents the type of RESP value
 type Type byte
@@ -11,15 +15,35 @@ const (
 	Integer      Type = ':'
 	BulkString   Type = '$'
 	Array        Type = '*'
+
+	// RESP3 types, encoded as their RESP2 equivalent on RESP2 connections
+	Double  Type = ','
+	Boolean Type = '#'
+	Map     Type = '%'
+	Null    Type = '_' // A null bulk string on RESP2
+	Push    Type = '>' // Out of band data such as invalidations, an array on RESP2
+
+	Verbatim Type = '=' // Str holds a three letter format, a colon and the text
 )
 
 // Value represents a RESP value
 type Value struct {
 	Type    Type
-	Str     string  // Renamed from String to avoid conflict with String() method
-	Integer int
-	Array   []Value
-	IsNull  bool    // Indicates if this is a null value (for bulk strings or arrays)
+	Str     string // Renamed from String to avoid conflict with String() method
+	Integer int64
+	Array   []Value // Elements, or alternating keys and values for maps
+	Double  float64
+	Bool    bool
+	IsNull  bool         // Indicates if this is a null value (for bulk strings or arrays)
+	Stream  *ArrayStream // Set instead of Array for arrays produced while being encoded
+}
+
+// ArrayStream produces the elements of an array reply while it is encoded,
+// so a large result is never held as one []Value. Elements must yield Len
+// values and may be iterated more than once.
+type ArrayStream struct {
+	Len      int
+	Elements iter.Seq[Value]
 }
 
 // String returns a string representation of the value
@@ -33,8 +57,17 @@ func (value Value) String() string {
 		}
 		return value.Str
 	case Integer:
-		return fmt.Sprintf("%d", value.Integer)
-	case Array:
+		return strconv.FormatInt(value.Integer, 10)
+	case Verbatim:
+		return verbatimText(value.Str)
+	case Double:
+		return FormatDouble(value.Double)
+	case Boolean:
+		if value.Bool {
+			return "1"
+		}
+		return "0"
+	case Array, Map, Push:
 		return fmt.Sprintf("%v", value.Array)
 	default:
 		return ""
@@ -73,3 +106,22 @@ func (value Value) GetArgs() []string {
 
 	return args
 }
+
+// Args returns the arguments of a command exactly as sent, excluding the
+// command name. Unlike GetArgs it fails on elements that are not bulk
+// strings instead of flattening them, so every byte of an argument,
+// including CR, LF, NUL and invalid UTF-8, reaches the command unchanged.
+func (value Value) Args() ([]string, error) {
+	if value.Type != Array || len(value.Array) == 0 {
+		return nil, fmt.Errorf("invalid command format")
+	}
+
+	args := make([]string, len(value.Array)-1)
+	for index, arg := range value.Array[1:] {
+		if arg.Type != BulkString || arg.IsNull {
+			return nil, fmt.Errorf("argument %d is not a bulk string", index+1)
+		}
+		args[index] = arg.Str
+	}
+	return args, nil
+}
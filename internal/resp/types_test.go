@@ -0,0 +1,36 @@
+package resp
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		command Value
+		want    []string // nil if Args fails
+	}{
+		{"no arguments", ArrayValue(BulkStringValue("PING")), []string{}},
+		{"binary", ArrayValue(BulkStringValue("SET"), BulkStringValue("k"), BulkStringValue("a\r\nb\x00\xff")), []string{"k", "a\r\nb\x00\xff"}},
+		{"empty", ArrayValue(BulkStringValue("SET"), BulkStringValue(""), BulkStringValue("")), []string{"", ""}},
+		{"integer", ArrayValue(BulkStringValue("SET"), BulkStringValue("k"), IntegerValue(1)), nil},
+		{"array", ArrayValue(BulkStringValue("SET"), ArrayValue(BulkStringValue("k"))), nil},
+		{"null", ArrayValue(BulkStringValue("GET"), NullBulkString()), nil},
+		{"not an array", BulkStringValue("PING"), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := tt.command.Args()
+			switch {
+			case tt.want == nil && err == nil:
+				t.Errorf("Args = %q, want an error", args)
+			case tt.want != nil && err != nil:
+				t.Errorf("Args failed: %v", err)
+			case !slices.Equal(args, tt.want):
+				t.Errorf("Args = %q, want %q", args, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/aof"
+	"github.com/codecrafters-redis-go/internal/resp"
+	"github.com/codecrafters-redis-go/internal/storage"
+	"github.com/codecrafters-redis-go/internal/tracing"
+)
+
+// errAppendOnlyDisabled is returned when an AOF operation is requested with appendonly off
+var errAppendOnlyDisabled = errors.New("Append only file is not enabled")
+
+// openAppendOnly opens the AOF when appendonly is enabled. It reports whether
+// an AOF already existed and should be replayed instead of the RDB file.
+func (server *Server) openAppendOnly() (bool, error) {
+	if !server.config.AppendOnly {
+		return false, nil
+	}
+
+	cfg := server.config
+	existed := aof.Exists(cfg.Dir, cfg.AppendDirName, cfg.AppendFilename)
+	file, err := aof.Open(cfg.Dir, cfg.AppendDirName, cfg.AppendFilename, cfg.AppendFsync)
+	if err != nil {
+		return false, err
+	}
+
+	server.aof = file
+	return existed, nil
+}
+
+// loadAppendOnly replays the AOF into storage
+func (server *Server) loadAppendOnly() error {
+	start := server.clock.Now()
+	span := server.startSpan("aof.load")
+	count, err := server.aof.Load(func(command resp.Value) {
+		if response := server.registry.HandleCommand(command); response.Type == resp.Error {
+			cmdName, _ := command.GetCommand()
+			server.log.Warn("Error replaying %s from AOF: %s", cmdName, response.Str)
+		}
+	})
+	span.SetAttributes(tracing.Int("db.redis.commands", count))
+	tracing.EndWithError(span, err)
+	if err != nil {
+		return err
+	}
+
+	server.log.Info("DB loaded from append only file: %.3f seconds (%d commands)", server.clock.Now().Sub(start).Seconds(), count)
+	return nil
+}
+
+// feedAppendOnly appends a write command to the AOF if it is enabled
+func (server *Server) feedAppendOnly(command resp.Value) {
+	if server.aof == nil {
+		return
+	}
+	if err := server.aof.Append(command); err != nil {
+		server.log.Error("Failed to write to the append only file: %v", err)
+	}
+}
+
+// RewriteAppendOnlyFile starts a background rewrite of the AOF
+func (server *Server) RewriteAppendOnlyFile() error {
+	if server.aof == nil {
+		return errAppendOnlyDisabled
+	}
+	if server.aof.IsRewriting() {
+		return aof.ErrRewriteInProgress
+	}
+
+	go func() {
+		if err := server.rewriteAppendOnly(); err != nil {
+			server.log.Error("Background AOF rewrite failed: %v", err)
+		}
+	}()
+	return nil
+}
+
+// rewriteAppendOnly replaces the AOF with a base holding the current dataset
+func (server *Server) rewriteAppendOnly() error {
+	if err := server.loading.checkComplete(); err != nil {
+		return err
+	}
+	span := server.startSpan("aof.rewrite", tracing.Int("db.redis.keys", server.storage.Len()))
+	err := server.aof.Rewrite(server.snapshotCommands)
+	tracing.EndWithError(span, err)
+	return err
+}
+
+// AppendOnlyInfo reports whether the AOF is enabled and being rewritten, and
+// how often its fsync fell behind
+func (server *Server) AppendOnlyInfo() (enabled bool, rewriting bool, delayedFsyncs int64) {
+	if server.aof == nil {
+		return false, false, 0
+	}
+	return true, server.aof.IsRewriting(), server.aof.DelayedFsyncs()
+}
+
+// snapshotCommands returns the commands that recreate the current dataset
+func (server *Server) snapshotCommands() []resp.Value {
+	var commands []resp.Value
+
+	server.storage.ForEach(func(key string, value interface{}, expiry *time.Time) {
+		switch v := value.(type) {
+		case string:
+			commands = append(commands, setCommand(key, v, expiry))
+		case storage.StringValue:
+			commands = append(commands, setCommand(key, v.Value, expiry))
+		case *storage.Stream:
+			for _, entry := range v.GetEntries() {
+				args := []resp.Value{
+					resp.BulkStringValue("XADD"),
+					resp.BulkStringValue(key),
+					resp.BulkStringValue(entry.ID),
+				}
+				for field, fieldValue := range entry.Fields {
+					args = append(args, resp.BulkStringValue(field), resp.BulkStringValue(fieldValue))
+				}
+				commands = append(commands, resp.ArrayValue(args...))
+			}
+		case *storage.List:
+			commands = append(commands, pushCommands(key, v.Values())...)
+			if expiry != nil {
+				commands = append(commands, resp.ArrayValue(
+					resp.BulkStringValue("PEXPIREAT"),
+					resp.BulkStringValue(key),
+					resp.BulkStringValue(strconv.FormatInt(expiry.UnixMilli(), 10)),
+				))
+			}
+		default:
+			server.log.Warn("AOF rewrite skipping key %s of unsupported type %T", key, value)
+		}
+	})
+
+	return commands
+}
+
+// rewriteItemsPerCommand is the number of elements per command recreating an
+// aggregate value, so a large one doesn't become a single huge command
+const rewriteItemsPerCommand = 64
+
+// pushCommands builds the RPUSH commands recreating a list
+func pushCommands(key string, elements []string) []resp.Value {
+	var commands []resp.Value
+	for len(elements) > 0 {
+		n := min(len(elements), rewriteItemsPerCommand)
+		args := []resp.Value{resp.BulkStringValue("RPUSH"), resp.BulkStringValue(key)}
+		for _, element := range elements[:n] {
+			args = append(args, resp.BulkStringValue(element))
+		}
+		commands = append(commands, resp.ArrayValue(args...))
+		elements = elements[n:]
+	}
+	return commands
+}
+
+// setCommand builds a SET with an absolute expiration so replays are deterministic
+func setCommand(key, value string, expiry *time.Time) resp.Value {
+	args := []resp.Value{
+		resp.BulkStringValue("SET"),
+		resp.BulkStringValue(key),
+		resp.BulkStringValue(value),
+	}
+	if expiry != nil {
+		args = append(args,
+			resp.BulkStringValue("PXAT"),
+			resp.BulkStringValue(strconv.FormatInt(expiry.UnixMilli(), 10)),
+		)
+	}
+	return resp.ArrayValue(args...)
+}
@@ -0,0 +1,198 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/commands"
+	"github.com/codecrafters-redis-go/internal/pubsub"
+	"github.com/codecrafters-redis-go/internal/resp"
+	"github.com/codecrafters-redis-go/internal/tracking"
+)
+
+// deadlineWriter sets a write deadline before every write to the connection
+type deadlineWriter struct {
+	conn    net.Conn
+	timeout time.Duration
+}
+
+func (writer *deadlineWriter) Write(data []byte) (int, error) {
+	writer.conn.SetWriteDeadline(time.Now().Add(writer.timeout))
+	return writer.conn.Write(data)
+}
+
+// syncEncoder serializes the replies written to a connection, so pub/sub
+// messages published by other clients can be delivered between them.
+// Messages are queued rather than written by the publisher: a goroutine
+// started per burst writes them, and a reply writes the queue out first,
+// so a subscriber that stops reading never holds up the publisher.
+type syncEncoder struct {
+	mu      sync.Mutex // Held while writing to the connection
+	encoder *resp.Encoder
+	writer  *clientWriter
+
+	queueMu  sync.Mutex
+	queue    outputBuffer // Encoded messages not written yet
+	queued   *resp.Encoder
+	flushing bool // A goroutine is writing the queue
+}
+
+// newSyncEncoder creates the encoder of the connection writer writes to
+func newSyncEncoder(writer *clientWriter) *syncEncoder {
+	encoder := &syncEncoder{encoder: resp.NewEncoder(writer), writer: writer}
+	encoder.queued = resp.NewEncoder(&encoder.queue)
+	return encoder
+}
+
+// Encode writes value to the connection, after the queued messages
+func (encoder *syncEncoder) Encode(value resp.Value) error {
+	encoder.mu.Lock()
+	defer encoder.mu.Unlock()
+	if err := encoder.writeQueue(); err != nil {
+		return err
+	}
+	return encoder.encoder.Encode(value)
+}
+
+// Push writes an out of band message. RESP2 connections can't tell pushes
+// from replies, so it is dropped on them.
+func (encoder *syncEncoder) Push(value resp.Value) error {
+	encoder.mu.Lock()
+	defer encoder.mu.Unlock()
+	if encoder.encoder.Protocol() != 3 {
+		return nil
+	}
+	if err := encoder.writeQueue(); err != nil {
+		return err
+	}
+	return encoder.encoder.Encode(value)
+}
+
+// deliver queues a message from another client, a pushed one if push is
+// set. Queued messages count as pending output; once that breaks the
+// output limit the client is closed and the queue dropped.
+func (encoder *syncEncoder) deliver(value resp.Value, push bool) error {
+	encoder.queueMu.Lock()
+	if push && encoder.queued.Protocol() != 3 {
+		encoder.queueMu.Unlock()
+		return nil
+	}
+	encoder.queued.Encode(value)
+	queued := int64(len(encoder.queue.data))
+	encoder.writer.waiting.Store(queued)
+	if !encoder.writer.admit(queued + encoder.writer.writing.Load()) {
+		encoder.queue.data = nil
+		encoder.writer.waiting.Store(0)
+		encoder.queueMu.Unlock()
+		return errOutputBufferLimit
+	}
+	start := !encoder.flushing
+	encoder.flushing = true
+	encoder.queueMu.Unlock()
+
+	if start {
+		go encoder.flush()
+	}
+	return nil
+}
+
+// flush writes the queue until it is empty
+func (encoder *syncEncoder) flush() {
+	encoder.mu.Lock()
+	defer encoder.mu.Unlock()
+	// A broken connection also fails its next read, which closes it
+	encoder.writeQueue()
+}
+
+// writeQueue writes the queued messages, including those queued meanwhile.
+// Callers hold mu. The queue is dropped if a write fails.
+func (encoder *syncEncoder) writeQueue() error {
+	for {
+		encoder.queueMu.Lock()
+		data := encoder.queue.data
+		encoder.queue.data = nil
+		// The bytes taken count as writing once Write starts
+		encoder.writer.waiting.Store(0)
+		if len(data) == 0 {
+			encoder.flushing = false
+			encoder.queueMu.Unlock()
+			return nil
+		}
+		encoder.queueMu.Unlock()
+
+		if _, err := encoder.writer.Write(data); err != nil {
+			encoder.queueMu.Lock()
+			encoder.queue.data = nil
+			encoder.writer.waiting.Store(0)
+			encoder.flushing = false
+			encoder.queueMu.Unlock()
+			return err
+		}
+	}
+}
+
+// Pending returns the bytes of the write in progress and of the messages
+// queued behind it
+func (encoder *syncEncoder) Pending() int64 {
+	return encoder.writer.writing.Load() + encoder.writer.waiting.Load()
+}
+
+// SetProtocol selects the RESP version of later replies and messages
+func (encoder *syncEncoder) SetProtocol(version int) {
+	encoder.mu.Lock()
+	defer encoder.mu.Unlock()
+	encoder.encoder.SetProtocol(version)
+	encoder.queueMu.Lock()
+	encoder.queued.SetProtocol(version)
+	encoder.queueMu.Unlock()
+}
+
+// newClient creates the state of a new connection and the encoder its
+// replies, pub/sub messages and invalidations are written with
+func (server *Server) newClient(conn net.Conn) (*commands.Client, *syncEncoder) {
+	client := commands.NewClient(server.nextClientID.Add(1), conn.RemoteAddr().String(), conn.LocalAddr().String())
+	writer := &clientWriter{
+		deadlineWriter: deadlineWriter{conn: conn, timeout: replyWriteTimeout},
+		server:         server,
+		client:         client,
+	}
+	encoder := newSyncEncoder(writer)
+	client.Output = encoder
+	client.Subscriber = pubsub.NewSubscriber(func(message resp.Value) {
+		encoder.deliver(message, false)
+	})
+	client.Tracking = tracking.NewClient(func(message resp.Value) {
+		encoder.deliver(message, true)
+	})
+	return client, encoder
+}
+
+// isTimeout returns true if err is a network timeout
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// watchDisconnect watches for the client closing the connection while a
+// blocking command runs, and calls cancel if it does. stop ends the watch,
+// cancelling as well, and must be called before parser is read from again.
+func watchDisconnect(conn net.Conn, parser *resp.Parser, cancel context.CancelFunc) (stop func()) {
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		// Input from a pipelining client stays buffered for the next Parse
+		if err := parser.WaitInput(); err != nil && !isTimeout(err) {
+			cancel()
+		}
+	}()
+
+	return func() {
+		conn.SetReadDeadline(time.Now())
+		<-exited
+		conn.SetReadDeadline(time.Time{})
+		cancel()
+	}
+}
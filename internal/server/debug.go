@@ -0,0 +1,41 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+)
+
+// startDebug serves the net/http/pprof profiles on debug-port. Profiles
+// expose internals and can be costly to take, so it only listens on the
+// loopback interface.
+func (server *Server) startDebug() error {
+	port := server.config.DebugPort
+	if port == 0 {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return fmt.Errorf("failed to bind the debug endpoint to port %d: %w", port, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	// No write timeout: CPU profiles and traces stream for as long as asked
+	server.debug = &http.Server{Handler: mux, ReadHeaderTimeout: replyWriteTimeout}
+	go func() {
+		if err := server.debug.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			server.log.Error("Debug endpoint stopped: %v", err)
+		}
+	}()
+	server.log.Info("Debug endpoint listening on %s", listener.Addr())
+	return nil
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+
+	"github.com/codecrafters-redis-go/internal/commands"
+	"github.com/codecrafters-redis-go/internal/resp"
+)
+
+// embeddedAddr is the address in-process calls report, e.g. in SLOWLOG GET
+const embeddedAddr = "embedded"
+
+// Do runs a command in-process and returns its reply, without a connection
+// or RESP encoding. The call goes through the same checks, propagation and
+// hooks as one read from a client. The caller is trusted, so requirepass
+// doesn't apply. Each call is a client of its own: state set by commands
+// like MULTI, SELECT or CLIENT SETNAME doesn't carry over to the next call,
+// and commands needing a connection, like SUBSCRIBE, return an error.
+// ctx is the context of the call: cancelling it unblocks a blocking command
+// and interrupts long ones like KEYS.
+func (server *Server) Do(ctx context.Context, args ...string) (resp.Value, error) {
+	if err := ctx.Err(); err != nil {
+		return resp.Value{}, err
+	}
+	if len(args) == 0 {
+		return resp.ErrorValue("ERR empty command"), nil
+	}
+
+	command := make([]resp.Value, len(args))
+	for i, arg := range args {
+		command[i] = resp.BulkStringValue(arg)
+	}
+	if reply, blocked := server.loadingReply(args[0]); blocked {
+		return reply, nil
+	}
+
+	client := commands.NewClient(server.nextClientID.Add(1), embeddedAddr, embeddedAddr)
+	client.Authenticated = true
+	call := &commands.Call{Client: client, Command: resp.ArrayValue(command...), Ctx: ctx}
+	flags, _ := server.registry.CommandFlags(args[0])
+	release := server.limiter.acquire(flags)
+	reply := server.registry.Dispatch(call)
+	release()
+	server.stats.commandsProcessed.Add(1)
+	server.finishCall(client, call)
+
+	return collect(reply), nil
+}
+
+// collect returns value with its streamed arrays read into Array, so the
+// reply of Do can be inspected like any other value. Arrays holding no
+// stream are returned as they are, never modified.
+func collect(value resp.Value) resp.Value {
+	if value.Stream != nil {
+		elements := make([]resp.Value, 0, value.Stream.Len)
+		for element := range value.Stream.Elements {
+			if len(elements) == value.Stream.Len {
+				break
+			}
+			elements = append(elements, collect(element))
+		}
+		for len(elements) < value.Stream.Len {
+			elements = append(elements, resp.Value{Type: resp.BulkString, IsNull: true})
+		}
+		value.Array, value.Stream = elements, nil
+		return value
+	}
+
+	var elements []resp.Value
+	for i, element := range value.Array {
+		if !hasStream(element) {
+			continue
+		}
+		if elements == nil {
+			elements = append([]resp.Value(nil), value.Array...)
+		}
+		elements[i] = collect(element)
+	}
+	if elements != nil {
+		value.Array = elements
+	}
+	return value
+}
+
+// hasStream returns true if value is or holds a streamed array
+func hasStream(value resp.Value) bool {
+	if value.Stream != nil {
+		return true
+	}
+	for _, element := range value.Array {
+		if hasStream(element) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"net"
+	"sort"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/commands"
+)
+
+// drainTimeout bounds how long shutdown waits for in-flight commands to finish
+const drainTimeout = 5 * time.Second
+
+// trackConn registers a client connection so shutdown can drain it
+func (server *Server) trackConn(conn net.Conn, client *commands.Client) {
+	server.connsMu.Lock()
+	defer server.connsMu.Unlock()
+	server.conns[conn] = client
+}
+
+// untrackConn removes a closed client connection
+func (server *Server) untrackConn(conn net.Conn) {
+	server.connsMu.Lock()
+	defer server.connsMu.Unlock()
+	delete(server.conns, conn)
+}
+
+// Clients returns the connected clients in the order they connected
+func (server *Server) Clients() []*commands.Client {
+	server.connsMu.Lock()
+	clients := make([]*commands.Client, 0, len(server.conns))
+	for _, client := range server.conns {
+		clients = append(clients, client)
+	}
+	server.connsMu.Unlock()
+
+	sort.Slice(clients, func(i, j int) bool { return clients[i].ID < clients[j].ID })
+	return clients
+}
+
+// KillClients closes the connections of the clients match selects and
+// returns how many it closed. The caller's own connection is closed once its
+// reply has been written. Blocked clients notice the close and are released.
+func (server *Server) KillClients(match func(*commands.Client) bool, caller *commands.Client) int {
+	server.connsMu.Lock()
+	defer server.connsMu.Unlock()
+
+	killed := 0
+	for conn, client := range server.conns {
+		if !match(client) {
+			continue
+		}
+		killed++
+		if client == caller {
+			// Reading stops, so the connection closes after the reply
+			if tcp, ok := conn.(*net.TCPConn); ok {
+				tcp.CloseRead()
+				continue
+			}
+		}
+		conn.Close()
+	}
+	return killed
+}
+
+// drainConnections lets every connection finish the command it is executing
+// and reply, then closes it. Connections still busy after drainTimeout are
+// closed forcibly.
+func (server *Server) drainConnections() {
+	server.connsMu.Lock()
+	for conn := range server.conns {
+		// Unblock reads waiting for the next command; writes are unaffected
+		conn.SetReadDeadline(time.Now())
+	}
+	server.connsMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		server.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return
+	case <-time.After(drainTimeout):
+	}
+
+	server.connsMu.Lock()
+	server.log.Warn("Closing %d connections that did not drain in time", len(server.conns))
+	for conn := range server.conns {
+		conn.Close()
+	}
+	server.connsMu.Unlock()
+	<-done
+}
@@ -0,0 +1,23 @@
+package server
+
+import (
+	"github.com/codecrafters-redis-go/internal/pubsub"
+	"github.com/codecrafters-redis-go/internal/resp"
+)
+
+// expired is called by storage for every key removed because its TTL passed,
+// whether a read found it or the background cycle did. It is the one place
+// expirations are published and propagated, so both paths behave the same.
+func (server *Server) expired(key string) {
+	server.registry.GetContext().Tracking.Invalidate(key, nil)
+	server.registry.GetContext().Notifier.Notify(pubsub.ClassExpired, "expired", key)
+	server.addDirty(1)
+
+	// Replicas receive the DEL from their master instead
+	if !server.config.IsReplica() {
+		del := resp.ArrayValue(resp.BulkStringValue("DEL"), resp.BulkStringValue(key))
+		server.propagate([]resp.Value{del}, true)
+	}
+
+	server.keyExpired(key)
+}
@@ -0,0 +1,312 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/blocking"
+	"github.com/codecrafters-redis-go/internal/commands"
+	"github.com/codecrafters-redis-go/internal/replication"
+	"github.com/codecrafters-redis-go/internal/resp"
+)
+
+// masterReconnectDelay is how long a replica waits before connecting to
+// master again after the link failed
+const masterReconnectDelay = time.Second
+
+// failoverDialTimeout bounds how long FAILOVER waits to reach its target
+const failoverDialTimeout = 5 * time.Second
+
+// Failover states as reported by INFO replication
+const (
+	failoverNone       = "no-failover"
+	failoverWaiting    = "waiting-for-sync"
+	failoverInProgress = "failover-in-progress"
+)
+
+// failoverState tracks a coordinated failover started with FAILOVER
+type failoverState struct {
+	mu    sync.Mutex
+	state string        // Empty means failoverNone
+	abort chan struct{} // Closed by FAILOVER ABORT
+}
+
+// ReplicaOf makes the server a replica of host:port, or a master if host is
+// empty. The dataset is replaced by the new master's.
+func (server *Server) ReplicaOf(host, port string) error {
+	server.masterMu.Lock()
+	defer server.masterMu.Unlock()
+
+	// Sentinel repeats REPLICAOF to replicas it reconfigures, following the
+	// same master again must not resync
+	oldHost, oldPort := server.config.GetReplicaInfo()
+	if host != "" && server.replicationClient != nil && host == oldHost && port == oldPort {
+		server.log.Info("REPLICAOF would result into synchronization with the master we are already connected with. No operation performed.")
+		return nil
+	}
+
+	var localAddr net.Addr
+	if server.replicationClient != nil {
+		localAddr = server.replicationClient.LocalAddr()
+		server.replicationClient.Close()
+		server.replicationClient = nil
+	}
+
+	if host == "" {
+		server.config.SetReplicaOf("")
+		server.log.Info("MASTER MODE enabled")
+		if oldHost != "" {
+			server.publishSwitchMaster(oldHost, oldPort, localAddr)
+		}
+		return nil
+	}
+
+	server.config.SetReplicaOf(host + " " + port)
+	server.followMaster(host, port)
+	server.log.Info("REPLICAOF %s:%s enabled", host, port)
+	return nil
+}
+
+// switchMasterChannel is where Sentinel announces promotions
+const switchMasterChannel = "+switch-master"
+
+// publishSwitchMaster announces that this server, a replica of
+// oldHost:oldPort, was promoted, with the message Sentinel publishes:
+// "<master name> <old ip> <old port> <new ip> <new port>". The new ip is
+// the one the old master saw this server connect from.
+func (server *Server) publishSwitchMaster(oldHost, oldPort string, localAddr net.Addr) {
+	newHost := "127.0.0.1"
+	if tcpAddr, ok := localAddr.(*net.TCPAddr); ok {
+		newHost = tcpAddr.IP.String()
+	} else if tcpAddr, ok := server.Addr().(*net.TCPAddr); ok && !tcpAddr.IP.IsUnspecified() {
+		newHost = tcpAddr.IP.String()
+	}
+	message := strings.Join([]string{
+		server.config.GetSentinelMaster(), oldHost, oldPort, newHost, strconv.Itoa(server.Port()),
+	}, " ")
+	server.registry.GetContext().PubSub.Publish(switchMasterChannel, message)
+	server.log.Info("%s %s", switchMasterChannel, message)
+}
+
+// followMaster connects to a new master in the background. The master lock
+// must be held.
+func (server *Server) followMaster(host, port string) {
+	client := replication.NewClient(host, port, server.Port())
+	client.SetAuth(server.config.GetMasterAuth())
+	server.replicationClient = client
+
+	go server.replicate(client)
+}
+
+// replicate keeps the link to master up until client is closed by REPLICAOF
+// or shutdown. A failed or dropped link is retried after
+// masterReconnectDelay; each attempt resolves the master host again, so a
+// failover done by pointing its DNS name elsewhere is followed.
+func (server *Server) replicate(client *replication.Client) {
+	for {
+		err := server.connectToMaster(client)
+		if client.Closed() {
+			return
+		}
+		if err != nil {
+			server.log.Error("Failed to connect to master: %v", err)
+		} else {
+			server.log.Warn("Connection with master lost, reconnecting")
+		}
+
+		select {
+		case <-time.After(masterReconnectDelay):
+		case <-server.shutdown:
+			return
+		}
+		if client.Closed() {
+			return
+		}
+	}
+}
+
+// Failover hands the master role to a replica. Writes are held while the
+// target catches up, then the target is promoted and this server becomes
+// its replica, so the held writes are redirected instead of being lost.
+func (server *Server) Failover(opts commands.FailoverOptions) error {
+	if server.config.IsReplica() {
+		return errors.New("FAILOVER is not valid when server is a replica.")
+	}
+
+	target, err := server.failoverTarget(opts.Host, opts.Port)
+	if err != nil {
+		return err
+	}
+
+	server.failover.mu.Lock()
+	defer server.failover.mu.Unlock()
+	if server.failover.state != "" {
+		return errors.New("FAILOVER already in progress.")
+	}
+	server.failover.state = failoverWaiting
+	server.failover.abort = make(chan struct{})
+
+	go server.runFailover(target, opts, server.failover.abort)
+	return nil
+}
+
+// AbortFailover stops a failover that is still waiting for its target
+func (server *Server) AbortFailover() error {
+	server.failover.mu.Lock()
+	defer server.failover.mu.Unlock()
+	if server.failover.state == "" {
+		return errors.New("No failover in progress.")
+	}
+	if server.failover.state == failoverInProgress {
+		return errors.New("Failover is already in its final stage and can't be aborted.")
+	}
+	close(server.failover.abort)
+	return nil
+}
+
+// FailoverState returns the failover state reported by INFO replication
+func (server *Server) FailoverState() string {
+	server.failover.mu.Lock()
+	defer server.failover.mu.Unlock()
+	if server.failover.state == "" {
+		return failoverNone
+	}
+	return server.failover.state
+}
+
+// failoverTarget finds the replica to promote. Without an explicit target
+// the replica with the highest acknowledged offset is picked.
+func (server *Server) failoverTarget(host, port string) (*Replica, error) {
+	server.replicasMu.RLock()
+	defer server.replicasMu.RUnlock()
+
+	if len(server.replicas) == 0 {
+		return nil, errors.New("FAILOVER requires connected replicas.")
+	}
+
+	var best *Replica
+	var bestOffset int64
+	for _, replica := range server.replicas {
+		replicaHost, replicaPort := replica.address()
+		if host != "" && (replicaHost != host || replicaPort != port) {
+			continue
+		}
+		replica.mu.Lock()
+		offset := replica.offset
+		replica.mu.Unlock()
+		if best == nil || offset > bestOffset {
+			best, bestOffset = replica, offset
+		}
+	}
+	if best == nil {
+		return nil, errors.New("FAILOVER target HOST and PORT is not a replica.")
+	}
+	return best, nil
+}
+
+// runFailover performs the failover started by Failover
+func (server *Server) runFailover(target *Replica, opts commands.FailoverOptions, abort <-chan struct{}) {
+	host, port := target.address()
+	server.log.Info("FAILOVER requested to %s:%s", host, port)
+
+	pause := server.registry.GetContext().Pause
+	pause.Pause(commands.PauseByFailover, false, 0)
+	defer pause.Unpause(commands.PauseByFailover)
+	defer server.setFailoverState("")
+
+	// Writes that were running when the pause began may still advance the
+	// offset, so the target is compared against the current one
+	server.sendGetAckToAllReplicas()
+	outcome := server.blocked.BlockOnOffset(opts.Timeout, abort, func() bool {
+		target.mu.Lock()
+		defer target.mu.Unlock()
+		return target.offset >= atomic.LoadInt64(&server.masterOffset)
+	})
+
+	switch {
+	case outcome == blocking.Served:
+	case outcome == blocking.TimedOut && opts.Force:
+		server.log.Warn("FAILOVER target %s:%s did not catch up in time, forcing", host, port)
+	case outcome == blocking.TimedOut:
+		server.log.Warn("FAILOVER to %s:%s aborted: replica did not catch up in time", host, port)
+		return
+	default:
+		server.log.Warn("FAILOVER to %s:%s aborted", host, port)
+		return
+	}
+
+	server.setFailoverState(failoverInProgress)
+	if err := server.promote(host, port); err != nil {
+		server.log.Error("FAILOVER to %s:%s failed: %v", host, port, err)
+		return
+	}
+
+	// Become a replica before releasing the held writes, which then fail
+	// with READONLY and are retried against the new master by clients
+	server.ReplicaOf(host, port)
+	server.log.Info("FAILOVER to %s:%s completed", host, port)
+}
+
+// promote turns the replica at host:port into a master
+func (server *Server) promote(host, port string) error {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, port), failoverDialTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(failoverDialTimeout))
+
+	parser := resp.NewParser(conn)
+	encoder := resp.NewEncoder(conn)
+	send := func(args ...string) error {
+		values := make([]resp.Value, len(args))
+		for i, arg := range args {
+			values[i] = resp.BulkStringValue(arg)
+		}
+		if err := encoder.Encode(resp.ArrayValue(values...)); err != nil {
+			return err
+		}
+		reply, err := parser.Parse()
+		if err != nil {
+			return err
+		}
+		if reply.Type == resp.Error {
+			return fmt.Errorf("%s replied %s", strings.ToUpper(args[0]), reply.Str)
+		}
+		return nil
+	}
+
+	// The target usually shares our masterauth
+	if user, password := server.config.GetMasterAuth(); password != "" {
+		args := []string{"AUTH", password}
+		if user != "" {
+			args = []string{"AUTH", user, password}
+		}
+		if err := send(args...); err != nil {
+			return err
+		}
+	}
+	return send("REPLICAOF", "NO", "ONE")
+}
+
+// setFailoverState updates the failover state, empty when none is running
+func (server *Server) setFailoverState(state string) {
+	server.failover.mu.Lock()
+	defer server.failover.mu.Unlock()
+	server.failover.state = state
+}
+
+// address returns the host and port the replica serves clients on
+func (replica *Replica) address() (host, port string) {
+	host, port, _ = net.SplitHostPort(replica.conn.RemoteAddr().String())
+	if replica.listeningPort != "" {
+		port = replica.listeningPort
+	}
+	return host, port
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/codecrafters-redis-go/internal/replication"
+)
+
+// startHealth serves the HTTP liveness and readiness probes on health-port
+func (server *Server) startHealth() error {
+	port := server.config.HealthPort
+	if port == 0 {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", port))
+	if err != nil {
+		return fmt.Errorf("failed to bind the health endpoint to port %d: %w", port, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if reason := server.notReadyReason(); reason != "" {
+			http.Error(w, reason, http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+
+	server.health = &http.Server{Handler: mux, ReadHeaderTimeout: replyWriteTimeout}
+	go func() {
+		if err := server.health.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			server.log.Error("Health endpoint stopped: %v", err)
+		}
+	}()
+	server.log.Info("Health endpoint listening on %s", listener.Addr())
+	return nil
+}
+
+// notReadyReason explains why the server can't take traffic yet, or returns
+// an empty string if it can
+func (server *Server) notReadyReason() string {
+	select {
+	case <-server.shutdown:
+		return "shutting down"
+	case <-server.loaded:
+	default:
+		return "loading the dataset"
+	}
+	if server.loading.isLoading() {
+		return "loading the dataset"
+	}
+
+	if link, ok := server.MasterLinkInfo(); ok && link.State != replication.StateConnected {
+		return "master link is down"
+	}
+	return ""
+}
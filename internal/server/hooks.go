@@ -0,0 +1,76 @@
+package server
+
+import "net"
+
+// Hooks are callbacks for server events. Any field may be nil. Callbacks run
+// synchronously on the goroutine that raised the event, so they should return
+// quickly.
+type Hooks struct {
+	// OnClientConnect is called when a client connection is accepted
+	OnClientConnect func(addr net.Addr)
+	// OnClientDisconnect is called when a client connection is closed
+	OnClientDisconnect func(addr net.Addr)
+	// OnKeyWrite is called after a write command modified key, whether it
+	// came from a client or from the master link
+	OnKeyWrite func(key string, command string, args []string)
+	// OnKeyExpire is called after key was removed because it expired
+	OnKeyExpire func(key string)
+	// OnReplicaSync is called when a replica finished a full synchronization
+	OnReplicaSync func(addr net.Addr)
+}
+
+// WithHooks registers callbacks for server events. It may be given more than
+// once; every registered callback runs.
+func WithHooks(hooks Hooks) Option {
+	return func(server *Server) {
+		server.hooks = append(server.hooks, hooks)
+	}
+}
+
+// clientConnected runs the OnClientConnect hooks
+func (server *Server) clientConnected(addr net.Addr) {
+	for _, hooks := range server.hooks {
+		if hooks.OnClientConnect != nil {
+			hooks.OnClientConnect(addr)
+		}
+	}
+}
+
+// clientDisconnected runs the OnClientDisconnect hooks
+func (server *Server) clientDisconnected(addr net.Addr) {
+	for _, hooks := range server.hooks {
+		if hooks.OnClientDisconnect != nil {
+			hooks.OnClientDisconnect(addr)
+		}
+	}
+}
+
+// keyWritten runs the OnKeyWrite hooks for a propagated write
+func (server *Server) keyWritten(cmdName string, args []string) {
+	if len(args) == 0 {
+		return
+	}
+	for _, hooks := range server.hooks {
+		if hooks.OnKeyWrite != nil {
+			hooks.OnKeyWrite(args[0], cmdName, args)
+		}
+	}
+}
+
+// keyExpired runs the OnKeyExpire hooks
+func (server *Server) keyExpired(key string) {
+	for _, hooks := range server.hooks {
+		if hooks.OnKeyExpire != nil {
+			hooks.OnKeyExpire(key)
+		}
+	}
+}
+
+// replicaSynced runs the OnReplicaSync hooks
+func (server *Server) replicaSynced(addr net.Addr) {
+	for _, hooks := range server.hooks {
+		if hooks.OnReplicaSync != nil {
+			hooks.OnReplicaSync(addr)
+		}
+	}
+}
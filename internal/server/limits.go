@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net"
+
+	"github.com/codecrafters-redis-go/internal/commands"
+)
+
+// maxClientsErr is sent to connections accepted beyond maxclients
+const maxClientsErr = "-ERR max number of clients reached\r\n"
+
+// commandLimiter caps the number of commands executing at once, independent
+// of how many connections are open
+type commandLimiter struct {
+	slots chan struct{} // Nil when unlimited
+}
+
+// newCommandLimiter creates a limiter allowing limit concurrent commands, 0 for unlimited
+func newCommandLimiter(limit int) *commandLimiter {
+	if limit <= 0 {
+		return &commandLimiter{}
+	}
+	return &commandLimiter{slots: make(chan struct{}, limit)}
+}
+
+// acquire blocks until a slot is free. Commands that block waiting on other
+// clients don't take a slot, or they could starve the commands they wait for.
+func (limiter *commandLimiter) acquire(flags commands.Flags) func() {
+	if limiter.slots == nil || flags.Has(commands.FlagBlocking) {
+		return func() {}
+	}
+	limiter.slots <- struct{}{}
+	return func() { <-limiter.slots }
+}
+
+// admitConnection rejects the connection if maxclients is reached
+func (server *Server) admitConnection(conn net.Conn) bool {
+	server.connsMu.Lock()
+	count := len(server.conns)
+	server.connsMu.Unlock()
+
+	if count < server.config.GetMaxClients() {
+		return true
+	}
+
+	server.log.Warn("Rejecting client %s: max number of clients reached", conn.RemoteAddr())
+	server.stats.rejectedConnections.Add(1)
+	conn.Write([]byte(maxClientsErr))
+	conn.Close()
+	return false
+}
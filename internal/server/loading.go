@@ -0,0 +1,193 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/commands"
+	"github.com/codecrafters-redis-go/internal/rdb"
+	"github.com/codecrafters-redis-go/internal/resp"
+	"github.com/codecrafters-redis-go/internal/tracing"
+)
+
+// loadingErr is returned to data commands while the dataset is being loaded
+const loadingErr = "LOADING Redis is loading the dataset in memory"
+
+// errDatasetIncomplete is returned by saves and AOF rewrites after a load
+// failed, which would replace the only good copy of the data with part of it
+var errDatasetIncomplete = errors.New("the dataset failed to load, refusing to overwrite the files on disk until FLUSHALL")
+
+// loadingState tracks whether a dataset load is in progress
+type loadingState struct {
+	mu         sync.RWMutex
+	loading    bool
+	startTime  time.Time
+	progress   *rdb.Progress
+	incomplete bool  // The last load failed, possibly part way
+	fatal      error // Why loading the dataset on disk stopped the server
+}
+
+// begin marks the start of a load at now and returns the progress to report into
+func (state *loadingState) begin(now time.Time) *rdb.Progress {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	state.loading = true
+	state.startTime = now
+	state.progress = &rdb.Progress{}
+	return state.progress
+}
+
+// end marks the current load as finished
+func (state *loadingState) end() {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.loading = false
+}
+
+// fail records that the current load failed, so the dataset may hold only
+// part of the data. A non-nil fatal is the error the server stops with.
+func (state *loadingState) fail(fatal error) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.incomplete = true
+	if fatal != nil {
+		state.fatal = fatal
+	}
+}
+
+// flushed records that the dataset was emptied on purpose, or replaced by a
+// complete load, so it may be saved again
+func (state *loadingState) flushed() {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.incomplete = false
+}
+
+// checkComplete returns errDatasetIncomplete if the last load failed
+func (state *loadingState) checkComplete() error {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+	if state.incomplete {
+		return errDatasetIncomplete
+	}
+	return nil
+}
+
+// isLoading returns true while a load is in progress
+func (state *loadingState) isLoading() bool {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+	return state.loading
+}
+
+// info returns a snapshot of the current load
+func (state *loadingState) info() commands.LoadingInfo {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+
+	info := commands.LoadingInfo{
+		Loading:   state.loading,
+		StartTime: state.startTime,
+	}
+	if state.progress != nil {
+		info.TotalBytes = state.progress.TotalBytes()
+		info.LoadedBytes = state.progress.LoadedBytes()
+	}
+	return info
+}
+
+// loadingReply returns the -LOADING error if the command must wait for the load
+func (server *Server) loadingReply(cmdName string) (resp.Value, bool) {
+	if !server.loading.isLoading() {
+		return resp.Value{}, false
+	}
+	if flags, ok := server.registry.CommandFlags(cmdName); !ok || !flags.Has(commands.FlagLoading) {
+		return resp.ErrorValue(loadingErr), true
+	}
+	return resp.Value{}, false
+}
+
+// loadDataset loads the dataset from disk while connections are already being
+// served, reporting into the progress of a load Start began, so no command
+// runs against the dataset before it. An existing AOF takes precedence over
+// the RDB file. Like Redis, a file that fails to load stops the server, see
+// loadFailed.
+func (server *Server) loadDataset(fromAppendOnly bool, progress *rdb.Progress) {
+	defer close(server.loaded)
+	defer server.loading.end()
+
+	if fromAppendOnly {
+		if err := server.loadAppendOnly(); err != nil {
+			server.loadFailed(fmt.Errorf("failed to load append only file: %w", err))
+		}
+		return
+	}
+
+	start := server.clock.Now()
+	span := server.startSpan("rdb.load", tracing.String("db.redis.rdb_file", server.config.DBFilename))
+	err := rdb.LoadFileWithProgress(server.config.Dir, server.config.DBFilename, server.storage, progress)
+	span.SetAttributes(tracing.Int("db.redis.keys", server.storage.Len()))
+	tracing.EndWithError(span, err)
+	if err != nil {
+		server.loadFailed(fmt.Errorf("failed to load RDB file: %w", err))
+		return
+	}
+	server.log.Info("DB loaded from disk: %.3f seconds", server.clock.Now().Sub(start).Seconds())
+
+	// A freshly created AOF needs a base holding the data loaded from the RDB file
+	if server.aof != nil && server.storage.Len() > 0 {
+		if err := server.rewriteAppendOnly(); err != nil {
+			server.log.Error("Failed to create the AOF base from the RDB file: %v", err)
+		}
+	}
+}
+
+// loadFailed stops the server after the dataset on disk failed to load,
+// without saving, so the file keeps the data that couldn't be loaded
+func (server *Server) loadFailed(err error) {
+	server.log.Error("Fatal error loading the DB: %v. Exiting.", err)
+	server.loading.fail(err)
+	go server.stop()
+}
+
+// loadMasterRDB replaces the dataset with the RDB payload received from master
+func (server *Server) loadMasterRDB(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+
+	progress := server.loading.begin(server.clock.Now())
+	defer server.loading.end()
+
+	server.storage.Flush()
+	if err := rdb.Load(bytes.NewReader(data), server.storage, progress); err != nil {
+		server.log.Error("Failed to load RDB received from master: %v", err)
+		server.loading.fail(nil)
+		return
+	}
+	server.loading.flushed()
+	server.log.Info("MASTER <-> REPLICA sync: Finished with success (%d bytes)", len(data))
+}
+
+// DatasetFlushed lets the dataset be saved again after a failed load, as
+// FLUSHALL empties it on purpose
+func (server *Server) DatasetFlushed() {
+	server.loading.flushed()
+}
+
+// Err returns the error that stopped the server on its own, such as a
+// dataset that failed to load, or nil
+func (server *Server) Err() error {
+	server.loading.mu.RLock()
+	defer server.loading.mu.RUnlock()
+	return server.loading.fatal
+}
+
+// LoadingInfo returns the dataset loading progress for INFO persistence
+func (server *Server) LoadingInfo() commands.LoadingInfo {
+	return server.loading.info()
+}
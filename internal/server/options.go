@@ -0,0 +1,44 @@
+package server
+
+import (
+	"net"
+
+	"github.com/codecrafters-redis-go/internal/clock"
+	"github.com/codecrafters-redis-go/internal/logger"
+	"github.com/codecrafters-redis-go/internal/storage"
+)
+
+// Option configures a Server created by New
+type Option func(*Server)
+
+// WithListener makes the server accept connections on listener instead of
+// binding the configured port itself
+func WithListener(listener net.Listener) Option {
+	return func(server *Server) {
+		server.listener = listener
+	}
+}
+
+// WithLogger routes the server's log output to log
+func WithLogger(log logger.Interface) Option {
+	return func(server *Server) {
+		server.log = log
+	}
+}
+
+// WithClock replaces the wall clock used for server time computations: key
+// TTLs and the active expire cycle, relative expiries, stream auto IDs and
+// blocking timeouts. A clock implementing clock.TimerClock also runs the
+// timeouts, others only tell when they started.
+func WithClock(clk clock.Clock) Option {
+	return func(server *Server) {
+		server.clock = clk
+	}
+}
+
+// WithStorage serves the given, possibly pre-populated, storage
+func WithStorage(store *storage.Storage) Option {
+	return func(server *Server) {
+		server.storage = store
+	}
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/commands"
+	"github.com/codecrafters-redis-go/internal/config"
+)
+
+// errOutputBufferLimit fails writes to a client closed for breaking its
+// client-output-buffer-limit
+var errOutputBufferLimit = errors.New("client output buffer limit reached")
+
+// outputLimiter applies a client-output-buffer-limit to the pending output
+// of one connection
+type outputLimiter struct {
+	mu        sync.Mutex
+	softSince time.Time // When the output went over the soft limit, zero while under it
+}
+
+// exceeded returns true if pending bytes break limit at now
+func (limiter *outputLimiter) exceeded(limit config.OutputBufferLimit, pending int64, now time.Time) bool {
+	if limit.Hard > 0 && uint64(pending) > limit.Hard {
+		return true
+	}
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	if limit.Soft == 0 || uint64(pending) <= limit.Soft {
+		limiter.softSince = time.Time{}
+		return false
+	}
+	if limiter.softSince.IsZero() {
+		limiter.softSince = now
+	}
+	return now.Sub(limiter.softSince) > time.Duration(limit.SoftSeconds)*time.Second
+}
+
+// outputClass returns the client-output-buffer-limit class of client
+func outputClass(client *commands.Client) string {
+	switch {
+	case client.IsReplica():
+		return config.ClientReplica
+	case client.Subscriber != nil && client.Subscriber.Subscribed():
+		return config.ClientPubSub
+	default:
+		return config.ClientNormal
+	}
+}
+
+// closeForOutputLimit disconnects a client whose pending output broke the
+// limit of its class
+func (server *Server) closeForOutputLimit(conn net.Conn, class string, pending int64) {
+	server.log.Warn("Client %s closed for overcoming of output buffer limits (class %s, %d bytes pending)",
+		conn.RemoteAddr(), class, pending)
+	server.stats.outputLimitDisconnections.Add(1)
+	conn.Close()
+}
+
+// clientWriter writes the output of a client connection with a deadline.
+// Its pending output is the write in progress plus the pub/sub messages and
+// invalidations queued behind it; once that breaks the limit of the
+// client's class the connection is closed, so a client that stops reading
+// can't grow its queue without bound.
+type clientWriter struct {
+	deadlineWriter
+	server  *Server
+	client  *commands.Client
+	waiting atomic.Int64 // Bytes of messages queued by syncEncoder.deliver
+	writing atomic.Int64 // Bytes of the write in progress
+	limiter outputLimiter
+	closed  atomic.Bool
+}
+
+func (writer *clientWriter) Write(data []byte) (int, error) {
+	size := int64(len(data))
+	defer writer.writing.Add(-size)
+	if !writer.admit(writer.writing.Add(size) + writer.waiting.Load()) {
+		return 0, errOutputBufferLimit
+	}
+	return writer.deadlineWriter.Write(data)
+}
+
+// admit returns true if pending bytes are within the limit of the client's
+// class, and disconnects the client otherwise
+func (writer *clientWriter) admit(pending int64) bool {
+	if writer.closed.Load() {
+		return false
+	}
+	class := outputClass(writer.client)
+	limit := writer.server.config.GetOutputBufferLimit(class)
+	if limit == (config.OutputBufferLimit{}) || !writer.limiter.exceeded(limit, pending, time.Now()) {
+		return true
+	}
+	if writer.closed.CompareAndSwap(false, true) {
+		writer.server.closeForOutputLimit(writer.conn, class, pending)
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"github.com/codecrafters-redis-go/internal/logger"
+)
+
+// ReloadConfig re-reads the config file and applies the parameters that can
+// change while running. Parameters that need a restart are only reported.
+func (server *Server) ReloadConfig() error {
+	changed, needRestart, err := server.config.Reload()
+	for _, name := range changed {
+		server.log.Info("Config reload: applied new value of %s", name)
+		if name == "loglevel" {
+			value, _ := server.config.Get("loglevel")
+			if level, err := logger.ParseLevel(value); err == nil {
+				logger.SetLevel(level)
+			}
+		}
+	}
+	for _, name := range needRestart {
+		server.log.Warn("Config reload: %s changed but requires a restart to take effect", name)
+	}
+	if err != nil {
+		server.log.Error("Config reload failed: %v", err)
+		return err
+	}
+
+	if len(changed) == 0 && len(needRestart) == 0 {
+		server.log.Info("Config reload: no changes")
+	}
+	return nil
+}
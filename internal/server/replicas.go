@@ -0,0 +1,202 @@
+package server
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/commands"
+	"github.com/codecrafters-redis-go/internal/config"
+	"github.com/codecrafters-redis-go/internal/logger"
+	"github.com/codecrafters-redis-go/internal/resp"
+)
+
+// maxSpareOutput is the largest replica output buffer kept for reuse
+const maxSpareOutput = 1 << 20
+
+// outputBuffer collects encoded commands in memory
+type outputBuffer struct {
+	data []byte
+}
+
+func (buffer *outputBuffer) Write(data []byte) (int, error) {
+	buffer.data = append(buffer.data, data...)
+	return len(data), nil
+}
+
+// replicaOutput is the output buffer of a replica connection. Commands are
+// encoded into it by the propagating client and written by a goroutine of
+// the replica: whatever was propagated while the previous write was in
+// flight goes out in the next single write, and a slow replica never holds
+// up the client that propagates.
+type replicaOutput struct {
+	mu       sync.Mutex
+	buffer   outputBuffer // Encoded but not yet written
+	spare    []byte       // A written buffer kept for reuse
+	encoder  *resp.Encoder
+	inFlight atomic.Int64 // Bytes of the write in progress
+	admit    func(pending int64) bool
+	wake     chan struct{}
+	done     chan struct{}
+	stopped  bool
+}
+
+// newReplicaOutput starts writing the replication stream to conn. On a
+// write error the connection is closed, which removes the replica. admit is
+// checked with the bytes pending after every append; once it returns false
+// the output stops and whatever is pending is dropped.
+func newReplicaOutput(conn net.Conn, admit func(pending int64) bool) *replicaOutput {
+	output := &replicaOutput{
+		admit: admit,
+		wake:  make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+	output.encoder = resp.NewEncoder(&output.buffer)
+	go output.run(&deadlineWriter{conn: conn, timeout: replyWriteTimeout}, conn)
+	return output
+}
+
+// Append queues commands, to be sent together
+func (output *replicaOutput) Append(commands ...resp.Value) {
+	output.mu.Lock()
+	if output.stopped {
+		output.mu.Unlock()
+		return
+	}
+	for _, command := range commands {
+		output.encoder.Encode(command)
+	}
+	if !output.admit(int64(len(output.buffer.data)) + output.inFlight.Load()) {
+		output.buffer.data = nil
+		output.stopped = true
+		close(output.done)
+	}
+	output.mu.Unlock()
+
+	select {
+	case output.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Len returns the bytes queued or being written
+func (output *replicaOutput) Len() int64 {
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	return int64(len(output.buffer.data)) + output.inFlight.Load()
+}
+
+// Stop ends the writer goroutine, dropping what was not written yet
+func (output *replicaOutput) Stop() {
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	if !output.stopped {
+		output.stopped = true
+		close(output.done)
+	}
+}
+
+// run writes the queued commands until Stop or a write error
+func (output *replicaOutput) run(writer *deadlineWriter, conn net.Conn) {
+	for {
+		select {
+		case <-output.wake:
+		case <-output.done:
+			return
+		}
+
+		output.mu.Lock()
+		data := output.buffer.data
+		output.buffer.data, output.spare = output.spare, nil
+		output.inFlight.Store(int64(len(data)))
+		output.mu.Unlock()
+		if len(data) == 0 {
+			continue
+		}
+
+		_, err := writer.Write(data)
+
+		output.mu.Lock()
+		output.inFlight.Store(0)
+		if cap(data) <= maxSpareOutput {
+			output.spare = data[:0]
+		}
+		output.mu.Unlock()
+
+		if err != nil {
+			logger.Error("Failed to propagate commands to replica %s: %v", conn.RemoteAddr(), err)
+			conn.Close()
+			return
+		}
+	}
+}
+
+// replicaOutputLimit returns the admit function of the output of a replica
+// on conn, which applies the replica client-output-buffer-limit and closes
+// the connection of a replica lagging beyond it
+func (server *Server) replicaOutputLimit(conn net.Conn) func(pending int64) bool {
+	var limiter outputLimiter
+	return func(pending int64) bool {
+		limit := server.config.GetOutputBufferLimit(config.ClientReplica)
+		if !limiter.exceeded(limit, pending, time.Now()) {
+			return true
+		}
+		server.closeForOutputLimit(conn, config.ClientReplica, pending)
+		return false
+	}
+}
+
+// ReplicasInfo returns the fields INFO replication reports about connected
+// replicas, including the output buffer size of each replica
+func (server *Server) ReplicasInfo() []commands.InfoField {
+	server.replicasMu.RLock()
+	defer server.replicasMu.RUnlock()
+
+	fields := []commands.InfoField{
+		{Name: "connected_slaves", Value: strconv.Itoa(len(server.replicas))},
+	}
+	for i, replica := range server.replicas {
+		status := replica.status()
+		fields = append(fields, commands.InfoField{
+			Name: "slave" + strconv.Itoa(i),
+			Value: "ip=" + status.Host + ",port=" + status.Port + ",state=online" +
+				",offset=" + strconv.FormatInt(status.Offset, 10) +
+				",lag=" + strconv.Itoa(status.Lag) +
+				",output_buffer=" + strconv.FormatInt(replica.output.Len(), 10),
+		})
+	}
+	return fields
+}
+
+// ReplicaStatuses returns the address and progress of the connected
+// replicas, for ROLE
+func (server *Server) ReplicaStatuses() []commands.ReplicaStatus {
+	server.replicasMu.RLock()
+	defer server.replicasMu.RUnlock()
+
+	statuses := make([]commands.ReplicaStatus, len(server.replicas))
+	for i, replica := range server.replicas {
+		statuses[i] = replica.status()
+	}
+	return statuses
+}
+
+// MasterReplOffset returns the offset of the replication stream sent so far
+func (server *Server) MasterReplOffset() int64 {
+	return atomic.LoadInt64(&server.masterOffset)
+}
+
+// status returns the address and progress of the replica
+func (replica *Replica) status() commands.ReplicaStatus {
+	host, port := replica.address()
+	replica.mu.Lock()
+	defer replica.mu.Unlock()
+	return commands.ReplicaStatus{
+		Host:   host,
+		Port:   port,
+		Offset: replica.offset,
+		Lag:    int(time.Since(replica.lastAck).Seconds()),
+	}
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"errors"
+	"runtime"
+	"sync/atomic"
+)
+
+// errSaveInProgress is returned by BackgroundSave while a snapshot is written
+var errSaveInProgress = errors.New("Background save already in progress")
+
+// BackgroundSave writes a snapshot of the dataset to the RDB file without
+// waiting for it. It fails if a snapshot is already being written.
+func (server *Server) BackgroundSave() error {
+	if !server.snapshot.mu.TryLock() {
+		return errSaveInProgress
+	}
+	server.log.Info("Background saving started")
+	go func() {
+		defer server.snapshot.mu.Unlock()
+		if err := server.save(); err == nil {
+			server.log.Info("Background saving terminated with success")
+		}
+	}()
+	return nil
+}
+
+// LogStateReport logs a compact report of the server state: clients,
+// replicas with their offsets, memory and blocked clients. It doesn't pause
+// command processing, so operators can take it from a busy server.
+func (server *Server) LogStateReport() {
+	var memory runtime.MemStats
+	runtime.ReadMemStats(&memory)
+
+	server.connsMu.Lock()
+	clients := len(server.conns)
+	server.connsMu.Unlock()
+
+	role := "master"
+	if server.config.IsReplica() {
+		role = "replica"
+	}
+	server.log.Info("State report: role=%s clients=%d blocked_clients=%d keys=%d used_memory=%d heap_sys=%d goroutines=%d master_repl_offset=%d",
+		role, clients, server.blocked.Blocked(), server.storage.Len(), memory.HeapAlloc, memory.HeapSys,
+		runtime.NumGoroutine(), atomic.LoadInt64(&server.masterOffset))
+
+	if link, ok := server.MasterLinkInfo(); ok {
+		server.log.Info("State report: master %s:%s link=%s offset=%d last_io=%ds",
+			link.MasterHost, link.MasterPort, link.State, link.Offset, link.LastIOSecondsAgo)
+	}
+
+	server.replicasMu.RLock()
+	defer server.replicasMu.RUnlock()
+	for _, replica := range server.replicas {
+		status := replica.status()
+		server.log.Info("State report: replica %s:%s offset=%d lag=%ds output_buffer=%d",
+			status.Host, status.Port, status.Offset, status.Lag, replica.output.Len())
+	}
+}
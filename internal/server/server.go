@@ -1,30 +1,49 @@
 package server
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
+	"net/http"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/codecrafters-redis-go/internal/aof"
+	"github.com/codecrafters-redis-go/internal/blocking"
+	"github.com/codecrafters-redis-go/internal/clock"
 	"github.com/codecrafters-redis-go/internal/commands"
 	"github.com/codecrafters-redis-go/internal/config"
+	"github.com/codecrafters-redis-go/internal/daemon"
 	"github.com/codecrafters-redis-go/internal/logger"
 	"github.com/codecrafters-redis-go/internal/rdb"
 	"github.com/codecrafters-redis-go/internal/replication"
 	"github.com/codecrafters-redis-go/internal/resp"
 	"github.com/codecrafters-redis-go/internal/storage"
+	"github.com/codecrafters-redis-go/internal/tracing"
 )
 
+// replicaAckInterval is how often a replica reports its offset to master
+const replicaAckInterval = time.Second
+
+// replyWriteTimeout bounds how long a reply may take to reach a client, so a
+// peer that stopped reading cannot pin its goroutine and buffers forever
+const replyWriteTimeout = 30 * time.Second
+
 // Replica represents a connected replica
 type Replica struct {
 	conn    net.Conn
-	encoder *resp.Encoder
-	offset  int64 // Last acknowledged offset
+	output  *replicaOutput // Buffers the replication stream
+	offset  int64          // Last acknowledged offset
+	lastAck time.Time      // When the replica last acknowledged its offset
 	mu      sync.Mutex
+
+	listeningPort string // Port the replica serves clients on, used as FAILOVER target
 }
 
 // Server represents a Redis server
@@ -36,97 +55,249 @@ type Server struct {
 	listener          net.Listener
 	wg                sync.WaitGroup
 	shutdown          chan struct{}
-	replicationClient *replication.Client
+	ctx               context.Context // Cancelled on shutdown, the parent of every call's context
+	cancel            context.CancelFunc
+	replicationClient *replication.Client // Nil unless running as a replica
+	masterMu          sync.Mutex          // Guards replicationClient
 	replicas          []*Replica
 	replicasMu        sync.RWMutex
-	masterOffset      int64 // Current master replication offset
+	masterOffset      int64      // Current master replication offset
+	propagateMu       sync.Mutex // Keeps each batch of propagated commands together
+	loading           loadingState
+	loaded            chan struct{} // Closed once the dataset on disk is loaded
+	aof               *aof.AOF      // Nil unless appendonly is enabled
+	stopOnce          sync.Once
+	stopped           chan struct{} // Closed once shutdown has completed
+	conns             map[net.Conn]*commands.Client
+	connsMu           sync.Mutex
+	nextClientID      atomic.Int64
+	stats             serverStats
+	limiter           *commandLimiter
+	log               logger.Interface
+	clock             clock.Clock
+	hooks             []Hooks
+	blocked           *blocking.Manager
+	failover          failoverState
+	health            *http.Server // Nil unless health-port is set
+	debug             *http.Server // Nil unless debug-port is set
+	snapshot          snapshotState
+	runID             string // Random id of this run, reported by INFO server
+	started           time.Time
+	tracer            tracing.Tracer    // Nil unless tracing is enabled
+	exporter          *tracing.Exporter // Nil unless exporting to otel-exporter-otlp-endpoint
 }
 
 // New creates a new Redis server
-func New(cfg *config.Config) *Server {
-	store := storage.New()
+func New(cfg *config.Config, opts ...Option) *Server {
 	addr := fmt.Sprintf("0.0.0.0:%d", cfg.Port)
 
 	server := &Server{
 		addr:     addr,
 		config:   cfg,
-		storage:  store,
-		registry: commands.NewRegistry(cfg, store),
 		shutdown: make(chan struct{}),
 		replicas: make([]*Replica, 0),
+		loaded:   make(chan struct{}),
+		stopped:  make(chan struct{}),
+		conns:    make(map[net.Conn]*commands.Client),
+		limiter:  newCommandLimiter(cfg.MaxConcurrentCommands),
+		log:      logger.Default(),
+		clock:    clock.Real{},
 	}
+	server.ctx, server.cancel = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(server)
+	}
+	if server.storage == nil {
+		server.storage = storage.New()
+	}
+	server.storage.SetClock(server.clock)
+	server.blocked = blocking.NewManager(server.clock)
+	server.snapshot.lastSave = server.clock.Now()
+	server.started = server.clock.Now()
+	server.runID = newRunID()
+	server.registry = commands.NewRegistry(cfg, server.storage)
+	server.storage.OnExpire(server.expired)
 
 	// Set the propagation function in the registry
 	server.registry.SetPropagateFunc(server.propagateCommand)
 
 	// Set the server reference in the registry
 	server.registry.SetServer(server)
+	server.registry.SetBlocking(server.blocked)
+	server.registry.SetClock(server.clock)
 
 	return server
 }
 
 // Start begins listening for connections
 func (server *Server) Start() error {
-	// Load RDB file if it exists
-	if err := rdb.LoadFile(server.config.Dir, server.config.DBFilename, server.storage); err != nil {
-		logger.Warn("Failed to load RDB file: %v", err)
+	if err := server.config.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
 	}
 
-	listener, err := net.Listen("tcp", server.addr)
+	fromAppendOnly, err := server.openAppendOnly()
 	if err != nil {
-		return fmt.Errorf("failed to bind to %s: %w", server.addr, err)
+		return fmt.Errorf("failed to open append only file: %w", err)
 	}
 
-	server.listener = listener
-	logger.Info("Redis server listening on %s", server.addr)
+	// A listener passed with WithListener is used as is
+	if server.listener == nil {
+		listener, err := net.Listen("tcp", server.addr)
+		if err != nil {
+			return fmt.Errorf("failed to bind to %s: %w", server.addr, err)
+		}
+		server.listener = listener
+	}
+	server.log.Info("Redis server listening on %s", server.listener.Addr())
+
+	if pidFile := server.config.GetPidFile(); pidFile != "" {
+		if err := daemon.WritePidFile(pidFile); err != nil {
+			// Like Redis, a missing pidfile is not a reason to refuse to start
+			server.log.Warn("%v", err)
+		}
+	}
+
+	if err := server.startHealth(); err != nil {
+		server.listener.Close()
+		return err
+	}
+	if err := server.startDebug(); err != nil {
+		server.listener.Close()
+		if server.health != nil {
+			server.health.Close()
+		}
+		return err
+	}
+
+	server.startTracing()
+
+	// Load the RDB file in the background; data commands get -LOADING until it finishes
+	progress := server.loading.begin(server.clock.Now())
+	go server.loadDataset(fromAppendOnly, progress)
+	go server.notifyReady()
+	go server.runSaveRules()
 
 	// Accept connections in a goroutine
 	go server.acceptConnections()
 
-		// If configured as replica, connect to master
+	// If configured as replica, connect to master
 	if server.config.IsReplica() {
 		host, port := server.config.GetReplicaInfo()
 		if host != "" && port != "" {
-			server.replicationClient = replication.NewClient(host, port, server.config.Port)
-
-			// Connect to master in a goroutine
-			go func() {
-				if err := server.connectToMaster(); err != nil {
-					logger.Error("Failed to connect to master: %v", err)
-				}
-			}()
+			server.masterMu.Lock()
+			server.followMaster(host, port)
+			server.masterMu.Unlock()
 		}
 	}
 
 	return nil
 }
 
-// Stop gracefully shuts down the server
+// Stop gracefully shuts down the server, saving the dataset if save rules are configured
 func (server *Server) Stop() error {
+	select {
+	case <-server.shutdown:
+		// Already stopped, e.g. by the SHUTDOWN command
+		return nil
+	default:
+	}
+
+	if err := server.prepareShutdown(commands.ShutdownOptions{}); err != nil {
+		server.log.Error("%v", err)
+	}
+	return server.stop()
+}
+
+// stop closes the listener and connections and releases server resources
+func (server *Server) stop() error {
+	first := false
+	server.stopOnce.Do(func() { first = true })
+	if !first {
+		return nil
+	}
+
+	// Blocked clients are released before their calls are cancelled, so they
+	// get the null reply of a shutdown rather than the error of a disconnect
 	close(server.shutdown)
+	server.blocked.Close()
+	server.cancel()
 
 	if server.listener != nil {
 		server.listener.Close()
 	}
+	if server.health != nil {
+		server.health.Close()
+	}
+	if server.debug != nil {
+		server.debug.Close()
+	}
 
 	// Close replication client if exists
+	server.masterMu.Lock()
 	if server.replicationClient != nil {
 		server.replicationClient.Close()
 	}
+	server.masterMu.Unlock()
 
-	// Wait for all connections to finish
-	server.wg.Wait()
+	// Let in-flight commands reply, then close connections
+	server.drainConnections()
+
+	// Flush and close the append only file
+	if server.aof != nil {
+		if err := server.aof.Close(); err != nil {
+			server.log.Error("Failed to close append only file: %v", err)
+		}
+	}
 
 	// Close storage to stop background cleanup
 	server.storage.Close()
+	server.stopTracing()
+
+	if pidFile := server.config.GetPidFile(); pidFile != "" {
+		if err := daemon.RemovePidFile(pidFile); err != nil {
+			server.log.Warn("Failed to remove pidfile: %v", err)
+		}
+	}
 
-	logger.Info("Server stopped gracefully")
+	server.log.Info("Server stopped gracefully")
+	close(server.stopped)
 	return nil
 }
 
+// notifyReady tells the supervisor, if any, that the server is ready once
+// the dataset is loaded
+func (server *Server) notifyReady() {
+	select {
+	case <-server.loaded:
+	case <-server.shutdown:
+		return
+	}
+	server.log.Info("Ready to accept connections")
+	if err := daemon.NotifyReady(server.config.Supervised); err != nil {
+		server.log.Warn("Failed to notify the supervisor: %v", err)
+	}
+}
+
+// Addr returns the address the server is listening on, or nil before Start
+func (server *Server) Addr() net.Addr {
+	if server.listener == nil {
+		return nil
+	}
+	return server.listener.Addr()
+}
+
+// Port returns the TCP port the server is bound to, which differs from the
+// configured port when it was 0
+func (server *Server) Port() int {
+	if addr, ok := server.Addr().(*net.TCPAddr); ok {
+		return addr.Port
+	}
+	return server.config.Port
+}
+
 // Wait blocks until the server is shut down
 func (server *Server) Wait() {
-	<-server.shutdown
+	<-server.stopped
 }
 
 func (server *Server) acceptConnections() {
@@ -137,29 +308,40 @@ func (server *Server) acceptConnections() {
 			case <-server.shutdown:
 				return
 			default:
-				logger.Error("Error accepting connection: %v", err)
+				server.log.Error("Error accepting connection: %v", err)
 				continue
 			}
 		}
 
-		logger.Debug("Accepted connection from %s", conn.RemoteAddr())
+		server.log.Debug("Accepted connection from %s", conn.RemoteAddr())
+		server.stats.connectionsReceived.Add(1)
+		if !server.admitConnection(conn) {
+			continue
+		}
+		client, encoder := server.newClient(conn)
+		server.trackConn(conn, client)
+		server.clientConnected(conn.RemoteAddr())
 		server.wg.Add(1)
-		go server.handleConnection(conn)
+		go server.handleConnection(conn, client, encoder)
 	}
 }
 
-func (server *Server) handleConnection(conn net.Conn) {
+func (server *Server) handleConnection(conn net.Conn, client *commands.Client, encoder *syncEncoder) {
 	defer func() {
 		conn.Close()
+		server.untrackConn(conn)
 		server.wg.Done()
 		// Remove replica if this was a replica connection
 		server.removeReplica(conn)
-		logger.Debug("Closed connection from %s", conn.RemoteAddr())
+		server.clientDisconnected(conn.RemoteAddr())
+		server.log.Debug("Closed connection from %s", conn.RemoteAddr())
 	}()
 
 	parser := resp.NewParser(conn)
-	encoder := resp.NewEncoder(conn)
-	isReplica := false
+	connCtx, cancelConn := context.WithCancel(server.ctx)
+	defer cancelConn()
+	defer server.registry.GetContext().PubSub.Remove(client.Subscriber)
+	defer server.registry.GetContext().Tracking.Disable(client.Tracking)
 
 	for {
 		// Check for shutdown
@@ -169,24 +351,57 @@ func (server *Server) handleConnection(conn net.Conn) {
 		default:
 		}
 
-		// Parse the next command
-		value, err := parser.Parse()
+		// Replicas stream ACKs on their own schedule, only normal clients can be idle
+		if timeout := server.config.IdleTimeout(); timeout > 0 && !client.Replica {
+			conn.SetReadDeadline(server.clock.Now().Add(timeout))
+		}
+
+		// Parse the next command, the limits may have changed with CONFIG SET
+		parser.SetLimits(server.config.ProtocolLimits())
+		value, err := parser.ParseCommand()
 		if err != nil {
 			if err == io.EOF {
 				// Client disconnected
 				return
 			}
-			// Send error response
-			encoder.Encode(resp.ErrorValue("ERR " + err.Error()))
+			select {
+			case <-server.shutdown:
+				// Read interrupted by the shutdown drain
+				return
+			default:
+			}
+			if isTimeout(err) {
+				server.log.Debug("Closing idle client %s", conn.RemoteAddr())
+				server.stats.idleTimeouts.Add(1)
+				return
+			}
+			var protoErr *resp.ProtocolError
+			if !errors.As(err, &protoErr) {
+				server.log.Debug("Closing client %s after read error: %v", conn.RemoteAddr(), err)
+				return
+			}
+			server.stats.protocolErrors.Add(1)
+			encoder.Encode(resp.ErrorValue("ERR " + protoErr.Error()))
+			if !protoErr.Resync {
+				// Like Redis, the rest of the stream can't be trusted
+				server.log.Debug("Closing client %s: %v", conn.RemoteAddr(), protoErr)
+				server.stats.protocolDisconnects.Add(1)
+				return
+			}
 			continue
 		}
 
-				// Handle the command
+		// Handle the command
 		cmdName, _ := value.GetCommand()
-		logger.Debug("Handling command: %s", cmdName)
+		server.log.Debug("Handling command: %s", cmdName)
 
-				// Special handling for REPLCONF ACK from replicas
-		if isReplica && strings.ToUpper(cmdName) == "REPLCONF" {
+		// Special handling for REPLCONF ACK from replicas
+		if !client.Replica && strings.ToUpper(cmdName) == "REPLCONF" {
+			if args := value.GetArgs(); len(args) >= 2 && strings.EqualFold(args[0], "listening-port") {
+				client.ListeningPort = args[1]
+			}
+		}
+		if client.Replica && strings.ToUpper(cmdName) == "REPLCONF" {
 			args := value.GetArgs()
 			if len(args) >= 2 && strings.ToUpper(args[0]) == "ACK" {
 				// Parse the offset
@@ -198,59 +413,95 @@ func (server *Server) handleConnection(conn net.Conn) {
 			}
 		}
 
-		response := server.registry.HandleCommand(value)
+		// Only a few commands may run while the dataset is loading
+		if reply, blocked := server.loadingReply(cmdName); blocked {
+			if err := encoder.Encode(reply); err != nil {
+				server.log.Error("Error sending response: %v", err)
+				return
+			}
+			continue
+		}
+
+		call := &commands.Call{Client: client, Command: value, Ctx: connCtx}
+		replyMode := client.ReplyMode
+		flags, _ := server.registry.CommandFlags(cmdName)
+		release := server.limiter.acquire(flags)
+		var stopWatching func()
+		if flags.Has(commands.FlagBlocking) {
+			var cancelCall context.CancelFunc
+			call.Ctx, cancelCall = context.WithCancel(connCtx)
+			stopWatching = watchDisconnect(conn, parser, cancelCall)
+		}
+		response := server.registry.Dispatch(call)
+		if stopWatching != nil {
+			stopWatching()
+		}
+		release()
+		server.stats.commandsProcessed.Add(1)
 
 		// Special handling for PSYNC command
 		if strings.ToUpper(cmdName) == "PSYNC" {
 			// Check if this is a FULLRESYNC response
 			if response.Type == resp.SimpleString && strings.HasPrefix(response.Str, "FULLRESYNC") {
-				// Send the FULLRESYNC response first
-				if err := encoder.Encode(response); err != nil {
-					logger.Error("Error sending FULLRESYNC response: %v", err)
-					return
-				}
-
-				// Send empty RDB file as bulk string
-				emptyRDB := server.getEmptyRDB()
-				logger.Debug("Sending RDB file: %d bytes", len(emptyRDB))
-
-				// Send RDB as bulk string directly to connection
-				// without the trailing CRLF (non-standard RESP for replication)
-				header := fmt.Sprintf("$%d\r\n", len(emptyRDB))
-				if _, err := conn.Write([]byte(header)); err != nil {
-					logger.Error("Error sending RDB header: %v", err)
-					return
-				}
-
-				// Send RDB data
-				if _, err := conn.Write(emptyRDB); err != nil {
-					logger.Error("Error sending RDB data: %v", err)
+				if err := server.sendFullResync(conn, encoder, response); err != nil {
 					return
 				}
 
 				// Note: NOT sending trailing CRLF as expected by replication protocol
-				logger.Debug("Successfully sent RDB file without trailing CRLF")
+				server.log.Debug("Successfully sent RDB file without trailing CRLF")
 
 				// Mark this connection as a replica
-				isReplica = true
-				server.addReplica(conn)
+				client.SetReplica()
+				server.addReplica(conn, client.ListeningPort)
+				server.replicaSynced(conn.RemoteAddr())
 				continue
 			}
 		}
 
+		// CLIENT REPLY OFF and SKIP also silence their own reply, and SKIP
+		// then silences the next command's
+		quiet := replyMode != commands.ReplyOn || client.ReplyMode != commands.ReplyOn
+		if replyMode == commands.ReplySkip && client.ReplyMode == commands.ReplySkip {
+			client.ReplyMode = commands.ReplyOn
+		}
+
+		// The call has been applied to the dataset whether or not its reply
+		// reaches the client, so account for and propagate it first
+		server.finishCall(client, call)
+
 		// Send the response
-		logger.Debug("Sending normal response for command: %s", cmdName)
-		if err := encoder.Encode(response); err != nil {
-			logger.Error("Error sending response: %v", err)
+		server.log.Debug("Sending normal response for command: %s", cmdName)
+		if quiet {
+			server.log.Debug("Not replying to %s after CLIENT REPLY", cmdName)
+		} else if err := encoder.Encode(response); err != nil {
+			if errors.Is(err, errOutputBufferLimit) {
+				return
+			}
+			if isTimeout(err) {
+				server.log.Warn("Closing client %s that stopped reading replies", conn.RemoteAddr())
+				server.stats.writeTimeouts.Add(1)
+				return
+			}
+			server.log.Error("Error sending response: %v", err)
 			return
 		}
+	}
+}
 
-		// Propagate write commands to replicas (only if this is not a replica connection)
-		if !isReplica && server.shouldPropagate(cmdName) && response.Type != resp.Error {
-			logger.Debug("Propagating command %s to replicas", cmdName)
-			server.propagateCommand(value)
+// finishCall applies what a call did once it ran: the dirty count,
+// propagation to replicas and the AOF, and waking the clients blocked on
+// the keys it made ready
+func (server *Server) finishCall(client *commands.Client, call *commands.Call) {
+	server.addDirty(call.Dirty)
+
+	// Propagate write commands to replicas (only if this is not a replica connection)
+	if !client.Replica {
+		server.propagate(commands.Transaction(call.Propagation()), true)
+		if call.Propagate {
+			server.keyWritten(call.Name, call.Propagated().GetArgs())
 		}
 	}
+	server.blocked.SignalKeys(call.ReadyKeys)
 }
 
 // RegisterCommand adds a custom command implementation
@@ -259,30 +510,39 @@ func (server *Server) RegisterCommand(cmd commands.Command) {
 }
 
 // addReplica adds a new replica to the server's replica list
-func (server *Server) addReplica(conn net.Conn) {
+func (server *Server) addReplica(conn net.Conn, listeningPort string) {
 	server.replicasMu.Lock()
 	defer server.replicasMu.Unlock()
 
 	replica := &Replica{
-		conn:    conn,
-		encoder: resp.NewEncoder(conn),
+		conn:          conn,
+		output:        newReplicaOutput(conn, server.replicaOutputLimit(conn)),
+		lastAck:       time.Now(),
+		listeningPort: listeningPort,
 	}
 	server.replicas = append(server.replicas, replica)
-	logger.Info("Added new replica: %s", conn.RemoteAddr())
+	server.log.Info("Added new replica: %s", conn.RemoteAddr())
 }
 
-// removeReplica removes a replica from the server's replica list
+// removeReplica removes a replica from the server's replica list. Clients
+// waiting on every replica, like a shutdown, stop waiting for it.
 func (server *Server) removeReplica(conn net.Conn) {
 	server.replicasMu.Lock()
-	defer server.replicasMu.Unlock()
-
+	removed := false
 	for i, replica := range server.replicas {
 		if replica.conn == conn {
+			replica.output.Stop()
 			server.replicas = append(server.replicas[:i], server.replicas[i+1:]...)
-			logger.Info("Removed replica: %s", conn.RemoteAddr())
+			server.log.Info("Removed replica: %s", conn.RemoteAddr())
+			removed = true
 			break
 		}
 	}
+	server.replicasMu.Unlock()
+
+	if removed {
+		server.blocked.SignalOffset()
+	}
 }
 
 // GetReplicas returns a copy of the current replicas list
@@ -299,115 +559,118 @@ func (server *Server) GetReplicas() []interface{} {
 	return replicas
 }
 
+// MasterLinkInfo returns the state of the link to master when running as a replica
+func (server *Server) MasterLinkInfo() (replication.LinkInfo, bool) {
+	server.masterMu.Lock()
+	client := server.replicationClient
+	server.masterMu.Unlock()
+	if client == nil {
+		return replication.LinkInfo{}, false
+	}
+	return client.Info(), true
+}
+
+// propagate sends a batch of commands to the AOF and, if toReplicas is set,
+// to the replicas. Batches written concurrently never interleave.
+func (server *Server) propagate(batch []resp.Value, toReplicas bool) {
+	if len(batch) == 0 {
+		return
+	}
+
+	server.propagateMu.Lock()
+	defer server.propagateMu.Unlock()
+	if toReplicas {
+		server.propagateCommands(batch)
+	}
+	for _, command := range batch {
+		server.feedAppendOnly(command)
+	}
+}
+
 // propagateCommand sends a command to all connected replicas
 func (server *Server) propagateCommand(command resp.Value) {
+	server.propagateCommands([]resp.Value{command})
+}
+
+// propagateCommands queues a batch of commands for all connected replicas,
+// which each receive it in a single write
+func (server *Server) propagateCommands(batch []resp.Value) {
 	server.replicasMu.RLock()
 	defer server.replicasMu.RUnlock()
 
-	// Calculate the size of this command in bytes
-	commandSize := server.calculateCommandSize(command)
-
 	// Update master offset
-	atomic.AddInt64(&server.masterOffset, int64(commandSize))
+	for _, command := range batch {
+		atomic.AddInt64(&server.masterOffset, int64(server.calculateCommandSize(command)))
+	}
 
 	for _, replica := range server.replicas {
-		if err := replica.encoder.Encode(command); err != nil {
-			logger.Error("Failed to propagate command to replica %s: %v", replica.conn.RemoteAddr(), err)
-			// TODO: Remove failed replica
-		}
+		replica.output.Append(batch...)
 	}
 }
 
 // calculateCommandSize calculates the size of a command in RESP format
 func (server *Server) calculateCommandSize(value resp.Value) int {
-	size := 0
-
-	switch value.Type {
-	case resp.Array:
-		// Array: *<count>\r\n followed by elements
-		size += 1 // *
-		size += len(fmt.Sprintf("%d", len(value.Array))) // count
-		size += 2 // \r\n
-
-		// Add size of each element
-		for _, elem := range value.Array {
-			size += server.calculateCommandSize(elem)
-		}
-
-	case resp.BulkString:
-		// Bulk string: $<length>\r\n<data>\r\n
-		size += 1 // $
-		size += len(fmt.Sprintf("%d", len(value.Str))) // length
-		size += 2 // \r\n
-		size += len(value.Str) // data
-		size += 2 // \r\n
+	return resp.EncodedLen(value)
+}
 
-	case resp.SimpleString:
-		// Simple string: +<data>\r\n
-		size += 1 // +
-		size += len(value.Str) // data
-		size += 2 // \r\n
+// connectToMaster establishes connection to master and performs handshake
+func (server *Server) connectToMaster(client *replication.Client) error {
+	server.log.Debug("connectToMaster started")
+
+	// The local dataset must be loaded before the master's snapshot replaces it
+	select {
+	case <-server.loaded:
+	case <-server.shutdown:
+		return nil
+	}
 
-	case resp.Integer:
-		// Integer: :<number>\r\n
-		size += 1 // :
-		size += len(fmt.Sprintf("%d", value.Integer)) // number
-		size += 2 // \r\n
+	if err := server.syncWithMaster(client); err != nil {
+		return err
 	}
 
-	return size
-}
+	// Acknowledge our offset every second so the master can track lag
+	go client.RunAckLoop(replicaAckInterval, server.shutdown)
+
+	// Start listening for commands from master immediately (no goroutine delay)
+	// This will block, so the original goroutine in Start() serves this purpose
+	server.processReplicationStream(client)
 
-// shouldPropagate returns true if the command should be propagated to replicas
-func (server *Server) shouldPropagate(cmdName string) bool {
-	// List of write commands that should be propagated
-	writeCommands := map[string]bool{
-		"SET":    true,
-		"DEL":    true,
-		"EXPIRE": true,
-		"INCR":   true,
-		"DECR":   true,
-		"RPUSH":  true,
-		"LPUSH":  true,
-		"SADD":   true,
-		"SREM":   true,
-		"HSET":   true,
-		"HDEL":   true,
-	}
-
-	return writeCommands[strings.ToUpper(cmdName)]
+	return nil
 }
 
-// connectToMaster establishes connection to master and performs handshake
-func (server *Server) connectToMaster() error {
-	logger.Debug("connectToMaster started")
+// syncWithMaster connects to master, performs the handshake and loads the
+// snapshot sent with FULLRESYNC, before further commands are streamed
+func (server *Server) syncWithMaster(client *replication.Client) (err error) {
+	host, port := server.config.GetReplicaInfo()
+	span := server.startSpan("replication.sync", tracing.String("server.address", host), tracing.String("server.port", port))
+	defer func() { tracing.EndWithError(span, err) }()
 
 	// Connect to master
-	if err := server.replicationClient.Connect(); err != nil {
+	if err := client.Connect(); err != nil {
 		return err
 	}
 
 	// Perform handshake
-	logger.Debug("Starting handshake...")
-	if err := server.replicationClient.Handshake(); err != nil {
+	server.log.Debug("Starting handshake...")
+	if err := client.Handshake(); err != nil {
 		return err
 	}
-	logger.Debug("Handshake completed, starting processReplicationStream...")
-
-	// Start listening for commands from master immediately (no goroutine delay)
-	// This will block, so the original goroutine in Start() serves this purpose
-	server.processReplicationStream()
+	server.log.Debug("Handshake completed, starting processReplicationStream...")
 
+	data := client.TakeRDB()
+	span.SetAttributes(tracing.Int("db.redis.rdb_size", len(data)))
+	server.loadMasterRDB(data)
 	return nil
 }
 
 // processReplicationStream continuously reads and executes commands from master
-func (server *Server) processReplicationStream() {
-	logger.Info("Started processing replication stream from master")
+func (server *Server) processReplicationStream(client *replication.Client) {
+	server.log.Info("Started processing replication stream from master")
 
 	// Add a debug log to see if we're ready immediately
-	logger.Debug("Ready to receive commands from master")
+	server.log.Debug("Ready to receive commands from master")
 
+	var transaction []resp.Value // Non-nil between MULTI and EXEC
 	for {
 		// Check for shutdown
 		select {
@@ -417,55 +680,99 @@ func (server *Server) processReplicationStream() {
 		}
 
 		// Listen for command from master
-		command, err := server.replicationClient.ListenForCommands()
+		command, size, err := client.ListenForCommands()
 		if err != nil {
 			if err == io.EOF {
-				logger.Warn("Master connection closed")
+				server.log.Warn("Master connection closed")
 				return
 			}
-			logger.Error("Error reading command from master: %v", err)
-			continue
+			if errors.Is(err, net.ErrClosed) {
+				// Closed by REPLICAOF or shutdown
+				return
+			}
+			// The link is down and can't be read from again, replicate
+			// reconnects
+			server.log.Error("Error reading command from master: %v", err)
+			return
 		}
 
 		// Execute the command locally
 		cmdName, cmdErr := command.GetCommand()
 		if cmdErr != nil {
-			logger.Error("Error getting command name: %v", cmdErr)
+			server.log.Error("Error getting command name: %v", cmdErr)
 			continue
 		}
 		args := command.GetArgs()
-		logger.Debug("Received command from master: %s", cmdName)
+		server.log.Debug("Received command from master: %s", cmdName)
 
 		// Special handling for REPLCONF GETACK - send ACK before updating offset
 		if strings.ToUpper(cmdName) == "REPLCONF" && len(args) > 0 && strings.ToUpper(args[0]) == "GETACK" {
-			logger.Debug("Received REPLCONF GETACK, sending ACK")
+			server.log.Debug("Received REPLCONF GETACK, sending ACK")
 			// Send ACK with current offset (before processing this command)
-			if err := server.replicationClient.SendReplConfAck(); err != nil {
-				logger.Error("Failed to send REPLCONF ACK: %v", err)
+			if err := client.SendReplConfAck(); err != nil {
+				server.log.Error("Failed to send REPLCONF ACK: %v", err)
 			}
 			// Now update the offset for this command
-			server.replicationClient.ProcessCommand(command)
+			client.ProcessCommand(command, size)
 			continue
 		}
 
 		// For all other commands, update offset first
-		server.replicationClient.ProcessCommand(command)
+		client.ProcessCommand(command, size)
+
+		// A transaction is applied once EXEC arrives, so a broken link
+		// never leaves half of it applied
+		switch strings.ToUpper(cmdName) {
+		case "MULTI":
+			transaction = []resp.Value{}
+			continue
+		case "EXEC":
+			server.applyFromMaster(transaction)
+			transaction = nil
+			continue
+		}
+		if transaction != nil {
+			transaction = append(transaction, command)
+			continue
+		}
+		server.applyFromMaster([]resp.Value{command})
+	}
+}
 
+// applyFromMaster executes commands received from master and appends their
+// effects to the AOF as one batch
+func (server *Server) applyFromMaster(received []resp.Value) {
+	var batch []resp.Value
+	calls := make([]*commands.Call, 0, len(received))
+	for _, command := range received {
 		// Execute command through registry (this will update local storage)
-		response := server.registry.HandleCommand(command)
+		call := &commands.Call{Command: command}
+		response := server.registry.Dispatch(call)
+		batch = append(batch, call.Propagation()...)
+		calls = append(calls, call)
 
 		// Log any errors but don't stop replication
 		if response.Type == resp.Error {
-			logger.Error("Error executing replicated command %s: %s", cmdName, response.Str)
-		} else {
-			logger.Debug("Successfully executed replicated command: %s", cmdName)
+			server.log.Error("Error executing replicated command %s: %s", call.Name, response.Str)
+			continue
+		}
+		server.addDirty(call.Dirty)
+		server.log.Debug("Successfully executed replicated command: %s", call.Name)
+	}
+
+	server.propagate(commands.Transaction(batch), false)
+	for _, call := range calls {
+		if call.Propagate {
+			server.keyWritten(call.Name, call.Propagated().GetArgs())
 		}
+		server.blocked.SignalKeys(call.ReadyKeys)
 	}
 }
 
-// WaitForReplicas waits for the specified number of replicas to acknowledge up to the current offset
-// Returns the number of replicas that acknowledged within the timeout
-func (server *Server) WaitForReplicas(numReplicas int, timeout time.Duration) int {
+// WaitForReplicas waits for the specified number of replicas to acknowledge up to the current offset.
+// A zero timeout waits until enough replicas acknowledge, cancel is closed or the server shuts down.
+// Returns the number of replicas that acknowledged.
+func (server *Server) WaitForReplicas(numReplicas int, timeout time.Duration, cancel <-chan struct{}) int {
 	// Get current master offset
 	currentOffset := atomic.LoadInt64(&server.masterOffset)
 
@@ -477,33 +784,19 @@ func (server *Server) WaitForReplicas(numReplicas int, timeout time.Duration) in
 		return count
 	}
 
-	// Send REPLCONF GETACK to all replicas
-	server.sendGetAckToAllReplicas()
-
-	// Wait for acknowledgments with timeout
-	start := time.Now()
-	timeoutChan := time.After(timeout)
+	// Replicas ACK periodically, so enough of them may already be caught up
+	if count := server.countSynchronizedReplicas(currentOffset); count >= numReplicas {
+		return count
+	}
 
-	for {
-		select {
-		case <-timeoutChan:
-			// Timeout reached, return count of synchronized replicas
-			return server.countSynchronizedReplicas(currentOffset)
-		default:
-			// Check if we have enough synchronized replicas
-			count := server.countSynchronizedReplicas(currentOffset)
-			if count >= numReplicas {
-				return count
-			}
+	// Send REPLCONF GETACK to all replicas and wait for their ACKs
+	server.sendGetAckToAllReplicas()
+	server.blocked.BlockOnOffset(timeout, cancel, func() bool {
+		return server.countSynchronizedReplicas(currentOffset) >= numReplicas
+	})
 
-			// If timeout hasn't been reached, wait a bit and check again
-			if time.Since(start) < timeout {
-				time.Sleep(10 * time.Millisecond)
-			} else {
-				return count
-			}
-		}
-	}
+	// Whatever the outcome, reply with what we have so the client isn't left hanging
+	return server.countSynchronizedReplicas(currentOffset)
 }
 
 // sendGetAckToAllReplicas sends REPLCONF GETACK * to all connected replicas
@@ -519,10 +812,7 @@ func (server *Server) sendGetAckToAllReplicas() {
 	)
 
 	for _, replica := range server.replicas {
-		if err := replica.encoder.Encode(cmd); err != nil {
-			logger.Error("Failed to send REPLCONF GETACK to replica %s: %v",
-				replica.conn.RemoteAddr(), err)
-		}
+		replica.output.Append(cmd)
 	}
 }
 
@@ -552,31 +842,48 @@ func (server *Server) updateReplicaOffset(conn net.Conn, offset int64) {
 		if replica.conn == conn {
 			replica.mu.Lock()
 			replica.offset = offset
+			replica.lastAck = time.Now()
 			replica.mu.Unlock()
-			logger.Debug("Updated replica %s offset to %d", conn.RemoteAddr(), offset)
+			server.log.Debug("Updated replica %s offset to %d", conn.RemoteAddr(), offset)
+			server.blocked.SignalOffset()
 			break
 		}
 	}
 }
 
-// getEmptyRDB returns a minimal valid RDB file
-func (server *Server) getEmptyRDB() []byte {
-	// Minimal RDB format:
-	// - Magic string "REDIS" (5 bytes)
-	// - Version "0003" (4 bytes)
-	// - EOF marker 0xFF (1 byte)
-	// No checksum for version 3
-
-	rdb := make([]byte, 0, 10)
+// sendFullResync sends the FULLRESYNC reply to a replica followed by the
+// RDB file it loads before the replication stream
+func (server *Server) sendFullResync(conn net.Conn, encoder *syncEncoder, response resp.Value) (err error) {
+	span := server.startSpan("replication.fullsync", tracing.String("client.address", conn.RemoteAddr().String()))
+	defer func() { tracing.EndWithError(span, err) }()
 
-	// Magic string
-	rdb = append(rdb, []byte("REDIS")...)
-
-	// Version (RDB version 3)
-	rdb = append(rdb, []byte("0003")...)
+	// Send the FULLRESYNC response first
+	if err := encoder.Encode(response); err != nil {
+		server.log.Error("Error sending FULLRESYNC response: %v", err)
+		return err
+	}
 
-	// EOF marker
-	rdb = append(rdb, 0xFF)
+	// Send a snapshot of the dataset as bulk string
+	var snapshot bytes.Buffer
+	if err := rdb.NewWriter(&snapshot).Save(server.storage); err != nil {
+		server.log.Error("Error creating RDB for replica: %v", err)
+		return err
+	}
+	server.log.Debug("Sending RDB file: %d bytes", snapshot.Len())
+	span.SetAttributes(tracing.Int("db.redis.rdb_size", snapshot.Len()))
+
+	// Send RDB as bulk string directly to connection
+	// without the trailing CRLF (non-standard RESP for replication)
+	header := fmt.Sprintf("$%d\r\n", snapshot.Len())
+	if _, err := conn.Write([]byte(header)); err != nil {
+		server.log.Error("Error sending RDB header: %v", err)
+		return err
+	}
 
-	return rdb
+	// Send RDB data
+	if _, err := conn.Write(snapshot.Bytes()); err != nil {
+		server.log.Error("Error sending RDB data: %v", err)
+		return err
+	}
+	return nil
 }
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/commands"
+	"github.com/codecrafters-redis-go/internal/daemon"
+	"github.com/codecrafters-redis-go/internal/rdb"
+	"github.com/codecrafters-redis-go/internal/tracing"
+)
+
+// shutdownReplicaTimeout bounds how long shutdown waits for replicas to catch up
+const shutdownReplicaTimeout = 10 * time.Second
+
+// Save writes a snapshot of the dataset to the RDB file
+func (server *Server) Save() error {
+	server.snapshot.mu.Lock()
+	defer server.snapshot.mu.Unlock()
+	return server.save()
+}
+
+// save writes a snapshot, the caller holds snapshot.mu
+func (server *Server) save() error {
+	// Changes made while the snapshot is written still count towards the next one
+	dirty := server.snapshot.dirty.Load()
+	start := server.clock.Now()
+	server.snapshot.lastAttempt = start
+	err := server.loading.checkComplete()
+	if err == nil {
+		span := server.startSpan("rdb.save", tracing.String("db.redis.rdb_file", server.config.DBFilename), tracing.Int("db.redis.keys", server.storage.Len()))
+		err = rdb.SaveFile(server.config.Dir, server.config.DBFilename, server.storage)
+		tracing.EndWithError(span, err)
+	}
+	if err != nil {
+		server.log.Error("Error saving DB on disk: %v", err)
+		server.snapshot.lastFailed = true
+		return err
+	}
+	server.snapshot.dirty.Add(-dirty)
+	server.snapshot.lastSave = start
+	server.snapshot.lastFailed = false
+	server.log.Info("DB saved on disk in %.3f seconds", server.clock.Now().Sub(start).Seconds())
+	return nil
+}
+
+// Shutdown persists the dataset according to opts and stops the server.
+// It returns an error without stopping if the final save fails and opts.Force is not set.
+func (server *Server) Shutdown(opts commands.ShutdownOptions) error {
+	if err := server.prepareShutdown(opts); err != nil && !opts.Force {
+		return err
+	}
+
+	go server.stop()
+	return nil
+}
+
+// prepareShutdown lets replicas catch up, takes the final snapshot and syncs
+// the AOF, so nothing acknowledged to clients is lost when the process exits
+func (server *Server) prepareShutdown(opts commands.ShutdownOptions) error {
+	server.log.Info("User requested shutdown...")
+	if err := daemon.NotifyStopping(server.config.Supervised); err != nil {
+		server.log.Warn("Failed to notify the supervisor of shutdown: %v", err)
+	}
+
+	if !opts.Now {
+		server.waitReplicasForShutdown()
+	}
+
+	// Like Redis, a dataset still being loaded is never saved, as that would
+	// replace the file it comes from with the part loaded so far
+	save := opts.Save || (server.config.SaveEnabled() && !opts.NoSave)
+	if save && server.loading.isLoading() {
+		server.log.Warn("The dataset is still loading, exiting without saving the final RDB snapshot.")
+		save = false
+	}
+
+	var saveErr error
+	if save {
+		server.log.Info("Saving the final RDB snapshot before exiting.")
+		if err := server.Save(); err != nil {
+			saveErr = fmt.Errorf("failed to save the final RDB snapshot: %w", err)
+		}
+	}
+
+	if server.aof != nil {
+		server.log.Info("Calling fsync() on the AOF file.")
+		if err := server.aof.Flush(); err != nil {
+			server.log.Error("Failed to fsync the AOF file: %v", err)
+		}
+	}
+
+	return saveErr
+}
+
+// waitReplicasForShutdown gives connected replicas a chance to acknowledge
+// everything written so far before their connections are closed. Replicas
+// disconnecting meanwhile are not waited for.
+func (server *Server) waitReplicasForShutdown() {
+	if server.replicaCount() == 0 {
+		return
+	}
+
+	server.log.Info("Waiting for replicas before shutting down.")
+	offset := atomic.LoadInt64(&server.masterOffset)
+	server.sendGetAckToAllReplicas()
+	server.blocked.BlockOnOffset(shutdownReplicaTimeout, nil, func() bool {
+		return server.countSynchronizedReplicas(offset) >= server.replicaCount()
+	})
+	count := server.replicaCount()
+	if acked := server.countSynchronizedReplicas(offset); acked < count {
+		server.log.Warn("%d of %d replicas are lagging behind at shutdown", count-acked, count)
+	}
+}
+
+// replicaCount returns the number of connected replicas
+func (server *Server) replicaCount() int {
+	server.replicasMu.RLock()
+	defer server.replicasMu.RUnlock()
+	return len(server.replicas)
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// saveRetryDelay is how long the save rules wait after a failed snapshot
+// before trying again, so a full disk is not hammered every second
+const saveRetryDelay = 5 * time.Second
+
+// snapshotState tracks keyspace changes since the last successful snapshot
+type snapshotState struct {
+	dirty       atomic.Int64 // Changes since the last successful snapshot
+	mu          sync.Mutex   // Serializes snapshots and guards the fields below
+	lastSave    time.Time
+	lastAttempt time.Time
+	lastFailed  bool
+}
+
+// addDirty counts n keyspace changes towards the save rules
+func (server *Server) addDirty(n int) {
+	if n > 0 {
+		server.snapshot.dirty.Add(int64(n))
+	}
+}
+
+// runSaveRules snapshots the dataset whenever one of the configured save
+// rules is met, until the server shuts down
+func (server *Server) runSaveRules() {
+	select {
+	case <-server.loaded:
+	case <-server.shutdown:
+		return
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if server.saveRuleMet() {
+				server.Save()
+			}
+		case <-server.shutdown:
+			return
+		}
+	}
+}
+
+// saveRuleMet returns true if enough changes happened since the last
+// snapshot to satisfy a save rule
+func (server *Server) saveRuleMet() bool {
+	dirty := server.snapshot.dirty.Load()
+	if dirty == 0 {
+		return false
+	}
+
+	now := server.clock.Now()
+	server.snapshot.mu.Lock()
+	lastSave, lastAttempt, lastFailed := server.snapshot.lastSave, server.snapshot.lastAttempt, server.snapshot.lastFailed
+	server.snapshot.mu.Unlock()
+
+	if lastFailed && now.Sub(lastAttempt) < saveRetryDelay {
+		return false
+	}
+	for _, rule := range server.config.SaveRules() {
+		if dirty >= int64(rule.Changes) && now.Sub(lastSave) >= time.Duration(rule.Seconds)*time.Second {
+			server.log.Info("%d changes in %d seconds. Saving...", rule.Changes, rule.Seconds)
+			return true
+		}
+	}
+	return false
+}
+
+// SaveInfo returns the changes since the last snapshot and when it was taken
+func (server *Server) SaveInfo() (changes int64, lastSave time.Time, lastOK bool) {
+	server.snapshot.mu.Lock()
+	defer server.snapshot.mu.Unlock()
+	return server.snapshot.dirty.Load(), server.snapshot.lastSave, !server.snapshot.lastFailed
+}
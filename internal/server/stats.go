@@ -0,0 +1,69 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/codecrafters-redis-go/internal/commands"
+)
+
+// serverStats holds the counters reported in INFO stats
+type serverStats struct {
+	connectionsReceived       atomic.Int64
+	rejectedConnections       atomic.Int64 // Connections refused because of maxclients
+	commandsProcessed         atomic.Int64
+	idleTimeouts              atomic.Int64 // Clients closed after exceeding the timeout config
+	writeTimeouts             atomic.Int64 // Clients closed because a reply could not be written in time
+	outputLimitDisconnections atomic.Int64 // Clients closed for breaking client-output-buffer-limit
+	protocolErrors            atomic.Int64 // Malformed requests, whether or not the client was closed
+	protocolDisconnects       atomic.Int64 // Clients closed after a malformed request
+}
+
+// ServerInfo returns the fields of the INFO server section
+func (server *Server) ServerInfo() []commands.InfoField {
+	return []commands.InfoField{
+		{Name: "process_id", Value: strconv.Itoa(os.Getpid())},
+		{Name: "run_id", Value: server.runID},
+		{Name: "tcp_port", Value: strconv.Itoa(server.Port())},
+		{Name: "uptime_in_seconds", Value: strconv.FormatInt(int64(server.clock.Now().Sub(server.started).Seconds()), 10)},
+	}
+}
+
+// newRunID returns a random run id, 40 hex characters like Redis's
+func newRunID() string {
+	id := make([]byte, 20)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// StatsInfo returns the fields of the INFO stats section
+func (server *Server) StatsInfo() []commands.InfoField {
+	stats := &server.stats
+	return []commands.InfoField{
+		{Name: "total_connections_received", Value: strconv.FormatInt(stats.connectionsReceived.Load(), 10)},
+		{Name: "total_commands_processed", Value: strconv.FormatInt(stats.commandsProcessed.Load(), 10)},
+		{Name: "rejected_connections", Value: strconv.FormatInt(stats.rejectedConnections.Load(), 10)},
+		{Name: "client_idle_timeout_disconnections", Value: strconv.FormatInt(stats.idleTimeouts.Load(), 10)},
+		{Name: "client_write_timeout_disconnections", Value: strconv.FormatInt(stats.writeTimeouts.Load(), 10)},
+		{Name: "client_output_buffer_limit_disconnections", Value: strconv.FormatInt(stats.outputLimitDisconnections.Load(), 10)},
+		{Name: "total_protocol_errors", Value: strconv.FormatInt(stats.protocolErrors.Load(), 10)},
+		{Name: "client_protocol_error_disconnections", Value: strconv.FormatInt(stats.protocolDisconnects.Load(), 10)},
+		{Name: "tracking_total_prefixes", Value: strconv.Itoa(server.registry.GetContext().Tracking.Prefixes())},
+	}
+}
+
+// ClientsInfo returns the fields of the INFO clients section
+func (server *Server) ClientsInfo() []commands.InfoField {
+	server.connsMu.Lock()
+	connected := len(server.conns)
+	server.connsMu.Unlock()
+
+	return []commands.InfoField{
+		{Name: "connected_clients", Value: strconv.Itoa(connected)},
+		{Name: "blocked_clients", Value: strconv.Itoa(server.blocked.Blocked())},
+		{Name: "tracking_clients", Value: strconv.Itoa(server.registry.GetContext().Tracking.Clients())},
+	}
+}
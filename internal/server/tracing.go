@@ -0,0 +1,44 @@
+package server
+
+import (
+	"github.com/codecrafters-redis-go/internal/tracing"
+)
+
+// tracingService is the service.name of the spans exported over OTLP
+const tracingService = "redis"
+
+// WithTracer records spans for commands, replication syncs and persistence
+// with tracer, e.g. an adapter onto an OpenTelemetry SDK tracer. It takes
+// precedence over otel-exporter-otlp-endpoint; the caller owns tracer.
+func WithTracer(tracer tracing.Tracer) Option {
+	return func(server *Server) {
+		server.tracer = tracer
+	}
+}
+
+// startTracing exports spans to otel-exporter-otlp-endpoint unless a tracer
+// was given with WithTracer
+func (server *Server) startTracing() {
+	if server.tracer == nil && server.config.OTelEndpoint != "" {
+		server.exporter = tracing.NewExporter(server.config.OTelEndpoint, tracingService, server.config.GetOTelSamplerRatio, server.log)
+		server.tracer = server.exporter
+		server.log.Info("Exporting trace spans to %s", server.config.OTelEndpoint)
+	}
+	if server.tracer != nil {
+		server.registry.SetTracer(server.tracer)
+	}
+}
+
+// startSpan begins a span for server work not started by a command, like a
+// save triggered by the save rules or the sync with master
+func (server *Server) startSpan(name string, attrs ...tracing.Attribute) tracing.Span {
+	_, span := tracing.Start(server.ctx, server.tracer, name, attrs...)
+	return span
+}
+
+// stopTracing sends the spans not exported yet
+func (server *Server) stopTracing() {
+	if server.exporter != nil {
+		server.exporter.Close()
+	}
+}
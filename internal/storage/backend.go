@@ -0,0 +1,65 @@
+package storage
+
+import "sync/atomic"
+
+// Entry is a stored value
+type Entry struct {
+	Value interface{}
+
+	access *atomic.Uint32 // LRU clock at the last access, shared by copies of the entry
+}
+
+// Backend holds the raw entries behind a Storage. Storage keeps the expiries
+// in an index of its own, so a backend stores and returns entries without
+// interpreting them and keys without a TTL cost nothing for it.
+//
+// Storage serializes writes against everything else, but Get, Iterate and
+// Len may be called concurrently with each other.
+type Backend interface {
+	// Get returns the entry stored under key
+	Get(key string) (Entry, bool)
+	// Set stores entry under key, replacing any existing entry
+	Set(key string, entry Entry)
+	// Delete removes key and reports whether it existed
+	Delete(key string) bool
+	// Iterate calls fn for every entry until fn returns false. fn must not
+	// call back into the backend.
+	Iterate(fn func(key string, entry Entry) bool)
+	// Len returns the number of stored entries, expired or not
+	Len() int
+	// Flush removes every entry
+	Flush()
+	// Close releases the backend's resources
+	Close() error
+}
+
+// Compactor is implemented by backends that keep memory for deleted entries
+// and can release it by rebuilding their index. Storage calls it with
+// writes serialized, like Set.
+type Compactor interface {
+	// Compact rebuilds the index if it is oversized for the entries it
+	// holds, and reports whether it did
+	Compact() bool
+	// Capacity returns the number of entries the index has room for
+	Capacity() int
+}
+
+// Scanner is implemented by backends that can be walked a part at a time.
+// Storage calls ScanBucket with the lock held for that one part, so walking
+// a large keyspace never blocks writers for longer than a part takes.
+type Scanner interface {
+	// ScanBucket calls fn for every entry of the part cursor points to and
+	// returns the cursor of the next part, 0 after the last one. A key is
+	// always in the same part, so a key stored for the whole walk is
+	// visited exactly once. fn must not call back into the backend.
+	ScanBucket(cursor uint64, fn func(key string, entry Entry)) uint64
+}
+
+// Detacher is implemented by backends whose Flush takes time in proportion
+// to the entries they hold. FLUSHALL ASYNC detaches the entries with
+// writes serialized, like Flush, and releases them in the background.
+type Detacher interface {
+	// Detach empties the backend and returns a function releasing the
+	// entries it held, which runs in a goroutine of its own
+	Detach() (release func())
+}
@@ -0,0 +1,75 @@
+package storage
+
+import "time"
+
+// compactInterval is how often the backend is checked for an index left
+// sparse by mass deletions, an analog of Redis active defragmentation
+const compactInterval = 10 * time.Second
+
+// IndexStats describes how much room the key index keeps
+type IndexStats struct {
+	Keys           int   // Stored entries, expired or not
+	Capacity       int   // Entries the index has room for, Keys if unknown
+	Compactions    int64 // Times the index was rebuilt to release memory
+	LastCompaction time.Time
+}
+
+// Compact rebuilds the backend's index and the expires index if deletions
+// left them mostly empty, holding the storage locked meanwhile. It reports
+// whether it did.
+func (s *Storage) Compact() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	compacted := false
+	if compactor, ok := s.backend.(Compactor); ok && compactor.Compact() {
+		compacted = true
+	}
+	if s.expiresPeak >= compactMinPeak && len(s.expires) <= s.expiresPeak/4 {
+		expires := make(map[string]int64, len(s.expires))
+		for key, at := range s.expires {
+			expires[key] = at
+		}
+		s.expires = expires
+		s.expiresPeak = len(expires)
+		compacted = true
+	}
+	if !compacted {
+		return false
+	}
+	s.compactions++
+	s.lastCompaction = time.Now()
+	return true
+}
+
+// IndexStats returns the size of the key index and its compaction history
+func (s *Storage) IndexStats() IndexStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := IndexStats{
+		Keys:           s.backend.Len(),
+		Capacity:       s.backend.Len(),
+		Compactions:    s.compactions,
+		LastCompaction: s.lastCompaction,
+	}
+	if compactor, ok := s.backend.(Compactor); ok {
+		stats.Capacity = compactor.Capacity()
+	}
+	return stats
+}
+
+// runCompaction compacts the index periodically until the storage is closed
+func (s *Storage) runCompaction() {
+	ticker := time.NewTicker(compactInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.Compact()
+		case <-s.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package storage
+
+import "time"
+
+const (
+	// lazyExpireQueueLen bounds the expired keys waiting for removal. Keys
+	// found while it is full are left to the next access or runActiveExpire.
+	lazyExpireQueueLen = 4096
+	// lazyExpireBatch is the most keys removed under one write lock
+	lazyExpireBatch = 256
+
+	// activeExpireInterval is how often runActiveExpire samples the keys
+	// with a TTL, like the hz cycle of Redis
+	activeExpireInterval = 100 * time.Millisecond
+	// activeExpireSamples is the number of keys with a TTL checked per round
+	activeExpireSamples = 20
+	// activeExpireTimeLimit bounds the rounds of one cycle. A cycle keeps
+	// going while more than a quarter of its samples were expired.
+	activeExpireTimeLimit = 25 * time.Millisecond
+)
+
+// expiredAt returns true if key has a TTL that passed by now. Callers hold mu.
+func (s *Storage) expiredAt(key string, now time.Time) bool {
+	at, ok := s.expires[key]
+	return ok && now.UnixMilli() > at
+}
+
+// expiryOf returns the expiry of key, nil if it has none. Callers hold mu.
+func (s *Storage) expiryOf(key string) *time.Time {
+	at, ok := s.expires[key]
+	if !ok {
+		return nil
+	}
+	expiry := time.UnixMilli(at)
+	return &expiry
+}
+
+// setExpiry sets the expiry of key, which is kept with millisecond
+// precision like in Redis; nil removes it. Callers hold mu for writing.
+func (s *Storage) setExpiry(key string, expiry *time.Time) {
+	if expiry == nil {
+		delete(s.expires, key)
+		return
+	}
+	s.expires[key] = expiry.UnixMilli()
+	if len(s.expires) > s.expiresPeak {
+		s.expiresPeak = len(s.expires)
+	}
+}
+
+// remove deletes key and its expiry and reports whether the key existed.
+// Callers hold mu for writing.
+func (s *Storage) remove(key string) bool {
+	delete(s.expires, key)
+	return s.backend.Delete(key)
+}
+
+// queueExpired hands a key a reader found expired to runLazyExpire, so
+// reads never wait for the write lock
+func (s *Storage) queueExpired(key string) {
+	select {
+	case s.lazyExpired <- key:
+	default:
+	}
+}
+
+// runLazyExpire removes the keys queued by readers in batches until the
+// storage is closed
+func (s *Storage) runLazyExpire() {
+	keys := make([]string, 0, lazyExpireBatch)
+	for {
+		select {
+		case key := <-s.lazyExpired:
+			keys = append(keys[:0], key)
+		case <-s.done:
+			return
+		}
+	collect:
+		for len(keys) < lazyExpireBatch {
+			select {
+			case key := <-s.lazyExpired:
+				keys = append(keys, key)
+			default:
+				break collect
+			}
+		}
+		s.removeExpired(keys)
+	}
+}
+
+// removeExpired deletes the keys that are still expired, skipping keys that
+// were replaced or already removed since they were queued
+func (s *Storage) removeExpired(keys []string) {
+	s.mu.Lock()
+	now := s.clock.Now()
+	var expired []string
+	for _, key := range keys {
+		if s.expiredAt(key, now) && s.remove(key) {
+			expired = append(expired, key)
+		}
+	}
+	hooks := s.onExpire
+	s.mu.Unlock()
+	notifyExpired(hooks, expired)
+}
+
+// runActiveExpire removes expired keys nobody reads until the storage is
+// closed. Each round samples keys with a TTL under the read lock and hands
+// the expired ones to removeExpired, so keys without a TTL are never visited.
+func (s *Storage) runActiveExpire() {
+	ticker := time.NewTicker(activeExpireInterval)
+	defer ticker.Stop()
+
+	expired := make([]string, 0, activeExpireSamples)
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.done:
+			return
+		}
+
+		start := time.Now()
+		for {
+			expired = expired[:0]
+			sampled := 0
+			s.mu.RLock()
+			now := s.clock.Now()
+			// Map iteration starts at a random key, which makes this a sample
+			for key, at := range s.expires {
+				if now.UnixMilli() > at {
+					expired = append(expired, key)
+				}
+				sampled++
+				if sampled == activeExpireSamples {
+					break
+				}
+			}
+			s.mu.RUnlock()
+
+			if len(expired) == 0 {
+				break
+			}
+			s.removeExpired(expired)
+			if len(expired) <= activeExpireSamples/4 || time.Since(start) > activeExpireTimeLimit {
+				break
+			}
+		}
+	}
+}
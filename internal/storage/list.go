@@ -0,0 +1,344 @@
+package storage
+
+import (
+	"slices"
+	"sync"
+)
+
+// listpackSizeLimits are the listpack sizes in bytes a list-max-listpack-size
+// of -1 to -5 stands for
+var listpackSizeLimits = [...]int{4096, 8192, 16384, 32768, 65536}
+
+// listpackSafetyLimit caps the bytes of a listpack when a positive
+// list-max-listpack-size caps its entries instead
+const listpackSafetyLimit = 8192
+
+// List represents a Redis list. The elements live in one slice with spare
+// room kept at the front, so pushing at either end is amortized O(1).
+type List struct {
+	mu        sync.RWMutex
+	buf       []string
+	head      int  // Index of the first element in buf
+	bytes     int  // Estimated listpack size of the elements
+	quicklist bool // Outgrew a listpack, see FitEncoding
+}
+
+// NewList creates a new, empty list
+func NewList() *List {
+	return &List{}
+}
+
+// elements returns the elements in order. The caller must hold the lock.
+func (l *List) elements() []string {
+	return l.buf[l.head:]
+}
+
+// reset replaces the elements. The caller must hold the lock.
+func (l *List) reset(elements []string) {
+	l.buf, l.head = elements, 0
+	l.bytes = 0
+	for _, element := range elements {
+		l.bytes += listpackEntrySize(element)
+	}
+}
+
+// Len returns the number of elements in the list
+func (l *List) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return len(l.buf) - l.head
+}
+
+// PushLeft inserts values at the head one after the other, so the last one
+// ends up first, and returns the new length
+func (l *List) PushLeft(values ...string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.head < len(values) {
+		// Grow the room in front to the length of the list, at least
+		room := max(len(values), len(l.buf)-l.head, 4)
+		buf := make([]string, room+len(l.buf)-l.head)
+		copy(buf[room:], l.elements())
+		l.buf, l.head = buf, room
+	}
+	for _, value := range values {
+		l.head--
+		l.buf[l.head] = value
+		l.bytes += listpackEntrySize(value)
+	}
+	return len(l.buf) - l.head
+}
+
+// PushRight appends values at the tail and returns the new length
+func (l *List) PushRight(values ...string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.buf = append(l.buf, values...)
+	for _, value := range values {
+		l.bytes += listpackEntrySize(value)
+	}
+	return len(l.buf) - l.head
+}
+
+// PopLeft removes up to count elements from the head and returns them in
+// the order they were removed
+func (l *List) PopLeft(count int) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elements := l.elements()
+	n := min(count, len(elements))
+	popped := slices.Clone(elements[:n])
+	clear(elements[:n])
+	l.head += n
+	for _, value := range popped {
+		l.bytes -= listpackEntrySize(value)
+	}
+
+	// Drop the room in front once it outweighs the elements, or a list used
+	// as a queue would keep every element it ever held
+	if l.head > len(l.buf)-l.head {
+		l.buf, l.head = slices.Clone(l.elements()), 0
+	}
+	return popped
+}
+
+// PopRight removes up to count elements from the tail and returns them in
+// the order they were removed, the last element first
+func (l *List) PopRight(count int) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elements := l.elements()
+	n := min(count, len(elements))
+	popped := make([]string, n)
+	for i := range popped {
+		popped[i] = elements[len(elements)-1-i]
+		l.bytes -= listpackEntrySize(popped[i])
+	}
+	clear(elements[len(elements)-n:])
+	l.buf = l.buf[:len(l.buf)-n]
+	return popped
+}
+
+// index turns index, negative counting from the tail, into a position in
+// elements, or returns false if it is out of range. The caller must hold the
+// lock.
+func (l *List) index(index int) (int, bool) {
+	length := len(l.buf) - l.head
+	if index < 0 {
+		index += length
+	}
+	return index, index >= 0 && index < length
+}
+
+// Index returns the element at index, negative counting from the tail
+func (l *List) Index(index int) (string, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	i, ok := l.index(index)
+	if !ok {
+		return "", false
+	}
+	return l.elements()[i], true
+}
+
+// Set replaces the element at index, or returns false if it is out of range
+func (l *List) Set(index int, value string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	i, ok := l.index(index)
+	if !ok {
+		return false
+	}
+	elements := l.elements()
+	l.bytes += listpackEntrySize(value) - listpackEntrySize(elements[i])
+	elements[i] = value
+	return true
+}
+
+// span turns the inclusive range from start to stop, negative counting from
+// the tail, into bounds of elements like LRANGE does. The range is clamped
+// to the list, and empty if it doesn't overlap it. The caller must hold the
+// lock.
+func (l *List) span(start, stop int) (int, int) {
+	length := len(l.buf) - l.head
+	if start < 0 {
+		start = max(start+length, 0)
+	}
+	if stop < 0 {
+		stop += length
+	}
+	if start > stop || start >= length {
+		return 0, 0
+	}
+	return start, min(stop, length-1) + 1
+}
+
+// Range returns the elements from start to stop inclusive, see LRANGE
+func (l *List) Range(start, stop int) []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	from, to := l.span(start, stop)
+	return slices.Clone(l.elements()[from:to])
+}
+
+// Trim keeps only the elements from start to stop inclusive, see LTRIM, and
+// returns how many it removed
+func (l *List) Trim(start, stop int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	from, to := l.span(start, stop)
+	removed := len(l.buf) - l.head - (to - from)
+	if removed > 0 {
+		l.reset(slices.Clone(l.elements()[from:to]))
+	}
+	return removed
+}
+
+// Insert inserts value before or after the first occurrence of pivot and
+// returns the new length, or -1 if pivot is not in the list
+func (l *List) Insert(pivot, value string, before bool) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	i := slices.Index(l.elements(), pivot)
+	if i < 0 {
+		return -1
+	}
+	if !before {
+		i++
+	}
+	elements := slices.Insert(slices.Clone(l.elements()), i, value)
+	l.buf, l.head = elements, 0
+	l.bytes += listpackEntrySize(value)
+	return len(elements)
+}
+
+// Remove removes up to count occurrences of value, from the head if count
+// is positive, from the tail if negative, or all of them if zero, and
+// returns how many it removed
+func (l *List) Remove(count int, value string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elements := l.elements()
+	limit := count
+	if limit < 0 {
+		limit = -limit
+	}
+	drop := make([]bool, len(elements))
+	removed := 0
+	for n := range elements {
+		i := n
+		if count < 0 {
+			i = len(elements) - 1 - n
+		}
+		if elements[i] == value {
+			drop[i] = true
+			removed++
+			if removed == limit {
+				break
+			}
+		}
+	}
+	if removed == 0 {
+		return 0
+	}
+
+	kept := make([]string, 0, len(elements)-removed)
+	for i, element := range elements {
+		if !drop[i] {
+			kept = append(kept, element)
+		}
+	}
+	l.reset(kept)
+	return removed
+}
+
+// Values returns a copy of all the elements in order
+func (l *List) Values() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return slices.Clone(l.elements())
+}
+
+// Copy returns a list holding the same elements, for COPY
+func (l *List) Copy() interface{} {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return &List{buf: slices.Clone(l.elements()), bytes: l.bytes, quicklist: l.quicklist}
+}
+
+// FitEncoding converts the list between the listpack and quicklist
+// encodings, which OBJECT ENCODING reports, for the given
+// list-max-listpack-size. Writes call it on the list they changed. A list
+// only goes back to a listpack once it fits in half the limit, so one
+// element crossing the limit back and forth doesn't convert it every time.
+func (l *List) FitEncoding(listpackSize int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bytes, count := l.bytes, len(l.buf)-l.head
+	if l.quicklist {
+		bytes, count = bytes*2, count*2
+	}
+	l.quicklist = exceedsListpack(listpackSize, bytes, count)
+}
+
+// Encoding returns "listpack" or "quicklist", see FitEncoding
+func (l *List) Encoding() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.quicklist {
+		return "quicklist"
+	}
+	return "listpack"
+}
+
+// Type returns the type of this value (for the TYPE command)
+func (l *List) Type() string {
+	return TypeList
+}
+
+// exceedsListpack returns true if a listpack of the given bytes and entries
+// is too large for list-max-listpack-size
+func exceedsListpack(listpackSize, bytes, count int) bool {
+	if listpackSize < 0 {
+		level := min(-listpackSize, len(listpackSizeLimits)) - 1
+		return bytes > listpackSizeLimits[level]
+	}
+	return bytes > listpackSafetyLimit || count > max(listpackSize, 1)
+}
+
+// listpackEntrySize estimates the bytes value takes in a listpack: its
+// encoding header, the value itself and the back length
+func listpackEntrySize(value string) int {
+	size := len(value)
+	switch {
+	case size < 64:
+		size++
+	case size < 4096:
+		size += 2
+	default:
+		size += 5
+	}
+	switch {
+	case size < 128:
+		return size + 1
+	case size < 16384:
+		return size + 2
+	default:
+		return size + 5
+	}
+}
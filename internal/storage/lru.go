@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// lruClockInterval is how often the LRU clock advances. Accesses are stamped
+// with the clock instead of reading the time, which keeps them cheap.
+const lruClockInterval = 100 * time.Millisecond
+
+// lruResolution is the unit of the LRU clock
+const lruResolution = time.Second
+
+// lruNow returns the current LRU clock value
+func lruNow() uint32 {
+	return uint32(time.Now().Unix())
+}
+
+// newAccess returns an access stamp set to the current LRU clock
+func (s *Storage) newAccess() *atomic.Uint32 {
+	access := &atomic.Uint32{}
+	access.Store(s.lruClock.Load())
+	return access
+}
+
+// touch stamps e as accessed now
+func (s *Storage) touch(e Entry) {
+	if e.access != nil {
+		e.access.Store(s.lruClock.Load())
+	}
+}
+
+// runLRUClock advances the LRU clock until the storage is closed
+func (s *Storage) runLRUClock() {
+	ticker := time.NewTicker(lruClockInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.lruClock.Store(lruNow())
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// IdleTime returns how long ago key was last accessed, with a resolution
+// of one second. It does not count as an access itself.
+func (s *Storage) IdleTime(key string) (time.Duration, bool) {
+	s.mu.RLock()
+	e, exists := s.backend.Get(key)
+	expired := exists && s.expiredAt(key, s.clock.Now())
+	s.mu.RUnlock()
+	if !exists || expired {
+		return 0, false
+	}
+	if e.access == nil {
+		// Stored by a backend that does not keep access stamps
+		return 0, true
+	}
+
+	idle := int64(s.lruClock.Load()) - int64(e.access.Load())
+	if idle < 0 {
+		idle = 0
+	}
+	return time.Duration(idle) * lruResolution, true
+}
@@ -0,0 +1,140 @@
+package storage
+
+import "hash/maphash"
+
+const (
+	// memoryBuckets is the number of maps a MemoryBackend spreads its keys
+	// over. A key always hashes to the same bucket, which is what lets Scan
+	// resume from a bucket index.
+	memoryBuckets = 1024
+	// compactMinPeak is the smallest bucket worth rebuilding to release memory
+	compactMinPeak = 64
+)
+
+// memoryBucket is one of the maps of a MemoryBackend
+type memoryBucket struct {
+	data map[string]Entry
+	peak int // Most entries data held, its buckets still have room for them
+}
+
+// MemoryBackend keeps entries in Go maps, bucketed by key hash
+type MemoryBackend struct {
+	seed    maphash.Seed
+	buckets [memoryBuckets]memoryBucket
+	len     int
+}
+
+// NewMemoryBackend creates an empty in-memory backend
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{seed: maphash.MakeSeed()}
+}
+
+// bucket returns the bucket key belongs to
+func (m *MemoryBackend) bucket(key string) *memoryBucket {
+	return &m.buckets[maphash.String(m.seed, key)%memoryBuckets]
+}
+
+// Get returns the entry stored under key
+func (m *MemoryBackend) Get(key string) (Entry, bool) {
+	e, exists := m.bucket(key).data[key]
+	return e, exists
+}
+
+// Set stores entry under key
+func (m *MemoryBackend) Set(key string, entry Entry) {
+	bucket := m.bucket(key)
+	if bucket.data == nil {
+		bucket.data = make(map[string]Entry)
+	}
+	before := len(bucket.data)
+	bucket.data[key] = entry
+	m.len += len(bucket.data) - before
+	if len(bucket.data) > bucket.peak {
+		bucket.peak = len(bucket.data)
+	}
+}
+
+// Delete removes key and reports whether it existed
+func (m *MemoryBackend) Delete(key string) bool {
+	bucket := m.bucket(key)
+	_, exists := bucket.data[key]
+	if exists {
+		delete(bucket.data, key)
+		m.len--
+	}
+	return exists
+}
+
+// Iterate calls fn for every entry until fn returns false
+func (m *MemoryBackend) Iterate(fn func(key string, entry Entry) bool) {
+	for i := range m.buckets {
+		for key, e := range m.buckets[i].data {
+			if !fn(key, e) {
+				return
+			}
+		}
+	}
+}
+
+// ScanBucket calls fn for every entry of the bucket cursor points to and
+// returns the cursor of the next bucket, 0 after the last one
+func (m *MemoryBackend) ScanBucket(cursor uint64, fn func(key string, entry Entry)) uint64 {
+	if cursor >= memoryBuckets {
+		return 0
+	}
+	for key, e := range m.buckets[cursor].data {
+		fn(key, e)
+	}
+	if cursor+1 == memoryBuckets {
+		return 0
+	}
+	return cursor + 1
+}
+
+// Len returns the number of stored entries
+func (m *MemoryBackend) Len() int {
+	return m.len
+}
+
+// Flush removes every entry. It only drops the maps, which the garbage
+// collector reclaims concurrently, so it takes the same short time however
+// many entries there were and needs no Detach.
+func (m *MemoryBackend) Flush() {
+	m.buckets = [memoryBuckets]memoryBucket{}
+	m.len = 0
+}
+
+// Compact rebuilds the buckets holding less than a quarter of the most
+// entries they had. Go maps never shrink, so after mass deletions or
+// expirations the room of the deleted keys stays allocated until then.
+func (m *MemoryBackend) Compact() bool {
+	compacted := false
+	for i := range m.buckets {
+		bucket := &m.buckets[i]
+		if bucket.peak < compactMinPeak || len(bucket.data) > bucket.peak/4 {
+			continue
+		}
+		data := make(map[string]Entry, len(bucket.data))
+		for key, e := range bucket.data {
+			data[key] = e
+		}
+		bucket.data = data
+		bucket.peak = len(data)
+		compacted = true
+	}
+	return compacted
+}
+
+// Capacity returns the number of entries the maps have room for
+func (m *MemoryBackend) Capacity() int {
+	capacity := 0
+	for i := range m.buckets {
+		capacity += m.buckets[i].peak
+	}
+	return capacity
+}
+
+// Close is a no-op for the in-memory backend
+func (m *MemoryBackend) Close() error {
+	return nil
+}
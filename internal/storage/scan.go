@@ -0,0 +1,74 @@
+package storage
+
+import "time"
+
+const (
+	// defaultScanCount is the number of keys Scan visits when count is not positive
+	defaultScanCount = 10
+	// scanBatch is the count of the Scan calls walking the whole keyspace
+	scanBatch = 1024
+)
+
+// scanItem is an entry collected by Scan
+type scanItem struct {
+	key    string
+	value  interface{}
+	expiry *time.Time
+}
+
+// Scan calls fn for the non-expired keys from cursor on, a bucket of the
+// backend at a time, until at least count keys were visited, and returns
+// the cursor to continue from, 0 once the whole keyspace was walked. Start
+// from 0. Keys stored for the whole walk are visited exactly once; keys
+// written meanwhile may or may not be. The lock is held for one bucket at
+// a time and fn runs without it, so fn may call back into Storage.
+//
+// Backends that don't implement Scanner are walked in one go on cursor 0.
+func (s *Storage) Scan(cursor uint64, count int, fn func(key string, value interface{}, expiry *time.Time)) uint64 {
+	if count <= 0 {
+		count = defaultScanCount
+	}
+	var items []scanItem
+
+	scanner, ok := s.backend.(Scanner)
+	if !ok {
+		if cursor == 0 {
+			s.mu.RLock()
+			now := s.clock.Now()
+			s.backend.Iterate(func(key string, e Entry) bool {
+				if !s.expiredAt(key, now) {
+					items = append(items, scanItem{key: key, value: e.Value, expiry: s.expiryOf(key)})
+				}
+				return true
+			})
+			s.mu.RUnlock()
+		}
+		for _, it := range items {
+			fn(it.key, it.value, it.expiry)
+		}
+		return 0
+	}
+
+	visited := 0
+	for {
+		items = items[:0]
+		s.mu.RLock()
+		now := s.clock.Now()
+		cursor = scanner.ScanBucket(cursor, func(key string, e Entry) {
+			if s.expiredAt(key, now) {
+				s.queueExpired(key)
+				return
+			}
+			items = append(items, scanItem{key: key, value: e.Value, expiry: s.expiryOf(key)})
+		})
+		s.mu.RUnlock()
+
+		for _, it := range items {
+			fn(it.key, it.value, it.expiry)
+		}
+		visited += len(items)
+		if cursor == 0 || visited >= count {
+			return cursor
+		}
+	}
+}
@@ -2,8 +2,11 @@ package storage
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/codecrafters-redis-go/internal/clock"
+	"github.com/codecrafters-redis-go/internal/logger"
 	"github.com/codecrafters-redis-go/internal/utils"
 )
 
@@ -18,121 +21,566 @@ type StringValue struct {
 }
 
 func (s StringValue) Type() string {
-	return "string"
-}
-
-type entry struct {
-	value  interface{}
-	expiry *time.Time
+	return TypeString
 }
 
 type Storage struct {
-	mu      sync.RWMutex
-	data    map[string]entry
-	done    chan struct{}
-	stopped bool
+	mu       sync.RWMutex
+	backend  Backend
+	clock    clock.Clock // Tells when keys expire, see SetClock
+	onExpire []func(key string)
+	done     chan struct{}
+	stopped  bool
+	lruClock atomic.Uint32 // Coarse clock accesses are stamped with, see runLRUClock
+
+	compactions    int64 // Index rebuilds by Compact, guarded by mu
+	lastCompaction time.Time
+
+	lazyExpired chan string // Expired keys found by readers, removed by runLazyExpire
+
+	expires     map[string]int64 // Unix milliseconds each key with a TTL expires at
+	expiresPeak int              // Most keys expires held, see Compact
 }
 
+// New creates a storage backed by an in-memory map
 func New() *Storage {
+	return NewWithBackend(NewMemoryBackend())
+}
+
+// NewWithBackend creates a storage that keeps its entries in backend
+func NewWithBackend(backend Backend) *Storage {
 	s := &Storage{
-		data: make(map[string]entry),
-		done: make(chan struct{}),
+		backend:     backend,
+		clock:       clock.Real{},
+		done:        make(chan struct{}),
+		lazyExpired: make(chan string, lazyExpireQueueLen),
+		expires:     make(map[string]int64),
 	}
-	go s.cleanupExpired()
+	s.lruClock.Store(lruNow())
+	go s.runActiveExpire()
+	go s.runLRUClock()
+	go s.runCompaction()
+	go s.runLazyExpire()
 	return s
 }
 
-func (s *Storage) Set(key string, value interface{}, expiry *time.Time) {
+// SetClock makes clk tell when keys expire instead of the wall clock. Set it
+// before the storage is used: TTLs set earlier keep their deadlines.
+func (s *Storage) SetClock(clk clock.Clock) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.data[key] = entry{value: value, expiry: expiry}
+	s.clock = clk
 }
 
-func (s *Storage) Get(key string) (interface{}, bool) {
+// Now returns the time by the clock keys expire by
+func (s *Storage) Now() time.Time {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+	return s.clock.Now()
+}
+
+// OnExpire registers fn to be called with each key removed because it expired.
+// fn runs after the key is gone, without the storage locked.
+func (s *Storage) OnExpire(fn func(key string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onExpire = append(s.onExpire, fn)
+}
+
+// Set replaces key with value and expiry. Like SET without KEEPTTL, the
+// TTL of the old value is dropped; a nil expiry makes the key persistent.
+func (s *Storage) Set(key string, value interface{}, expiry *time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.backend.Set(key, Entry{Value: value, access: s.newAccess()})
+	s.setExpiry(key, expiry)
+}
+
+// SetIf replaces key with value and expiry like Set if cond returns true;
+// with keepTTL the key keeps its current TTL instead, like SET KEEPTTL.
+// cond gets the current value, or nil and false if the key is missing or
+// expired, and runs with the storage locked, so it must not call back into
+// Storage. SetIf returns whether the key was set.
+func (s *Storage) SetIf(key string, value interface{}, expiry *time.Time, keepTTL bool, cond func(current interface{}, exists bool) bool) bool {
+	s.mu.Lock()
+	var expired []string
+	e, exists := s.backend.Get(key)
+	if exists && s.expiredAt(key, s.clock.Now()) {
+		s.remove(key)
+		expired = append(expired, key)
+		exists = false
+	}
+
+	var current interface{}
+	if exists {
+		current = e.Value
+	}
+	ok := cond(current, exists)
+	if ok {
+		s.backend.Set(key, Entry{Value: value, access: s.newAccess()})
+		if !keepTTL {
+			s.setExpiry(key, expiry)
+		}
+	}
+	hooks := s.onExpire
+	s.mu.Unlock()
+
+	notifyExpired(hooks, expired)
+	return ok
+}
+
+// KeyValue is a key and the value to store in it, see SetMany
+type KeyValue struct {
+	Key   string
+	Value interface{}
+}
+
+// SetMany replaces the keys of entries like Set without an expiry, all
+// under one lock so no reader sees some written and others not. It returns
+// the keys that didn't exist before.
+func (s *Storage) SetMany(entries []KeyValue) (created []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	for _, entry := range entries {
+		if _, exists := s.backend.Get(entry.Key); !exists || s.expiredAt(entry.Key, now) {
+			created = append(created, entry.Key)
+		}
+		s.backend.Set(entry.Key, Entry{Value: entry.Value, access: s.newAccess()})
+		s.setExpiry(entry.Key, nil)
+	}
+	return created
+}
+
+// SetManyIfAbsent sets entries like SetMany only if none of their keys
+// exists, and returns whether it did
+func (s *Storage) SetManyIfAbsent(entries []KeyValue) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	for _, entry := range entries {
+		if _, exists := s.backend.Get(entry.Key); exists && !s.expiredAt(entry.Key, now) {
+			return false
+		}
+	}
+	for _, entry := range entries {
+		s.backend.Set(entry.Key, Entry{Value: entry.Value, access: s.newAccess()})
+		s.setExpiry(entry.Key, nil)
+	}
+	return true
+}
 
-	e, exists := s.data[key]
+// Copy stores a copy of the value and TTL of src in dst, replacing dst only
+// if replace is set. It returns false if src is missing or dst exists and
+// replace isn't set, and whether dst was created.
+func (s *Storage) Copy(src, dst string, replace bool) (copied, created bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	e, exists := s.backend.Get(src)
+	if !exists || s.expiredAt(src, now) {
+		return false, false
+	}
+	_, dstExists := s.backend.Get(dst)
+	dstExists = dstExists && !s.expiredAt(dst, now)
+	if dstExists && !replace {
+		return false, false
+	}
+
+	s.backend.Set(dst, Entry{Value: copyValue(e.Value), access: s.newAccess()})
+	s.setExpiry(dst, s.expiryOf(src))
+	return true, !dstExists
+}
+
+// Update replaces the value of key with the one fn returns, keeping the TTL
+// like APPEND, INCR or LPUSH do. fn gets the current value, or nil and false
+// if the key is missing or expired, in which case it is created without a
+// TTL. If fn returns an error the key is left as it was, and if it returns
+// a nil value the key is deleted, like a list losing its last element. fn
+// runs with the storage locked, so it must not call back into Storage.
+func (s *Storage) Update(key string, fn func(value interface{}, exists bool) (interface{}, error)) error {
+	s.mu.Lock()
+	var expired []string
+	e, exists := s.backend.Get(key)
+	if exists && s.expiredAt(key, s.clock.Now()) {
+		s.remove(key)
+		expired = append(expired, key)
+		exists = false
+	}
+
+	var current interface{}
+	if exists {
+		current = e.Value
+	}
+	value, err := fn(current, exists)
+	if err == nil {
+		s.put(key, e, exists, value)
+	}
+	hooks := s.onExpire
+	s.mu.Unlock()
+
+	notifyExpired(hooks, expired)
+	return err
+}
+
+// UpdateMany is Update for several distinct keys at once, for commands like
+// LMOVE that change more than one. fn gets the current value of each key,
+// nil if it is missing, and returns the new ones in the same order. Every
+// write takes the one storage lock, so holding it for the whole call makes
+// the change atomic without ordering per-key locks.
+func (s *Storage) UpdateMany(keys []string, fn func(values []interface{}) ([]interface{}, error)) error {
+	s.mu.Lock()
+	now := s.clock.Now()
+	var expired []string
+	entries := make([]Entry, len(keys))
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		e, exists := s.backend.Get(key)
+		if exists && s.expiredAt(key, now) {
+			s.remove(key)
+			expired = append(expired, key)
+			continue
+		}
+		if exists {
+			entries[i], values[i] = e, e.Value
+		}
+	}
+
+	results, err := fn(values)
+	if err == nil {
+		for i, key := range keys {
+			s.put(key, entries[i], values[i] != nil, results[i])
+		}
+	}
+	hooks := s.onExpire
+	s.mu.Unlock()
+
+	notifyExpired(hooks, expired)
+	return err
+}
+
+// put stores value as the new value of key, whose entry was e if it exists,
+// keeping its TTL, or deletes key if value is nil. The caller must hold the
+// write lock.
+func (s *Storage) put(key string, e Entry, exists bool, value interface{}) {
+	switch {
+	case value == nil:
+		s.remove(key)
+	case exists:
+		e.Value = value
+		s.touch(e)
+		s.backend.Set(key, e)
+	default:
+		s.backend.Set(key, Entry{Value: value, access: s.newAccess()})
+	}
+}
+
+// Get returns the value of key and stamps it as accessed
+func (s *Storage) Get(key string) (interface{}, bool) {
+	return s.Lookup(key, true)
+}
+
+// Lookup returns the value of key. touch controls whether the lookup counts
+// as an access for OBJECT IDLETIME, which CLIENT NO-TOUCH turns off. It only
+// takes the read lock: an expired key is reported missing right away and
+// removed shortly after, see runLazyExpire.
+func (s *Storage) Lookup(key string, touch bool) (interface{}, bool) {
+	s.mu.RLock()
+	e, exists := s.backend.Get(key)
+	expired := exists && s.expiredAt(key, s.clock.Now())
+	s.mu.RUnlock()
 	if !exists {
 		return nil, false
 	}
 
-	if e.expiry != nil && time.Now().After(*e.expiry) {
-		// Key has expired, remove it
-		s.mu.RUnlock()
-		s.mu.Lock()
-		delete(s.data, key)
-		s.mu.Unlock()
-		s.mu.RLock()
+	if expired {
+		s.queueExpired(key)
 		return nil, false
 	}
 
-	return e.value, true
+	if touch {
+		s.touch(e)
+	}
+	return e.Value, true
 }
 
-// GetString gets a value and returns it as a string if it's a string type
-func (s *Storage) GetString(key string) (string, bool) {
-	val, exists := s.Get(key)
-	if !exists {
-		return "", false
+// Exists returns how many of keys exist, counting a key given twice twice.
+// All of them are checked under one read lock, so the count is consistent
+// with a single instant. Checking doesn't count as an access.
+func (s *Storage) Exists(keys ...string) int {
+	return s.count(keys, false)
+}
+
+// Touch stamps the existing keys as accessed and returns how many of keys
+// exist, counted like Exists
+func (s *Storage) Touch(keys ...string) int {
+	return s.count(keys, true)
+}
+
+// count returns how many of keys exist, stamping them as accessed if touch
+// is set
+func (s *Storage) count(keys []string, touch bool) int {
+	s.mu.RLock()
+	now := s.clock.Now()
+	count := 0
+	var expired []string
+	for _, key := range keys {
+		e, exists := s.backend.Get(key)
+		if !exists {
+			continue
+		}
+		if s.expiredAt(key, now) {
+			expired = append(expired, key)
+			continue
+		}
+		if touch {
+			s.touch(e)
+		}
+		count++
 	}
+	s.mu.RUnlock()
 
-	switch v := val.(type) {
-	case string:
-		return v, true
-	case StringValue:
-		return v.Value, true
-	default:
-		return "", false
+	for _, key := range expired {
+		s.queueExpired(key)
 	}
+	return count
 }
 
-func (s *Storage) Delete(key string) {
+// Expire sets the expiry of an existing key; a nil expiry removes it.
+// It returns false if the key does not exist.
+func (s *Storage) Expire(key string, expiry *time.Time) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	delete(s.data, key)
+
+	if _, exists := s.backend.Get(key); !exists || s.expiredAt(key, s.clock.Now()) {
+		return false
+	}
+	s.setExpiry(key, expiry)
+	return true
 }
 
-func (s *Storage) Keys(pattern string) []string {
+// ExpireIf gives an existing key expiry if cond, given the current expiry
+// or nil for a persistent key, returns true. An expiry that already passed
+// removes the key instead, like EXPIRE with a time in the past; removed
+// says so. cond runs with the storage locked.
+func (s *Storage) ExpireIf(key string, expiry time.Time, cond func(current *time.Time) bool) (changed, removed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	if _, exists := s.backend.Get(key); !exists || s.expiredAt(key, now) {
+		return false, false
+	}
+	if !cond(s.expiryOf(key)) {
+		return false, false
+	}
+	if !expiry.After(now) {
+		s.remove(key)
+		return true, true
+	}
+	s.setExpiry(key, &expiry)
+	return true, false
+}
+
+// Persist removes the TTL of key and returns true if it had one
+func (s *Storage) Persist(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.expires[key]; !ok || s.expiredAt(key, s.clock.Now()) {
+		return false
+	}
+	s.setExpiry(key, nil)
+	return true
+}
+
+// Expiry returns the expiry of key, nil if it has none, and whether the key
+// exists. Keys with a TTL are answered from the expires index alone.
+func (s *Storage) Expiry(key string) (*time.Time, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	var keys []string
-	now := time.Now()
-
-	for key, e := range s.data {
-		// Skip expired keys
-		if e.expiry != nil && now.After(*e.expiry) {
-			continue
+	if _, ok := s.expires[key]; ok {
+		if s.expiredAt(key, s.clock.Now()) {
+			return nil, false
 		}
+		return s.expiryOf(key), true
+	}
+	_, exists := s.backend.Get(key)
+	return nil, exists
+}
 
-		if pattern == "*" || utils.MatchPattern(pattern, key) {
-			keys = append(keys, key)
+// notifyExpired runs the expire hooks for each expired key
+func notifyExpired(hooks []func(key string), keys []string) {
+	for _, key := range keys {
+		for _, fn := range hooks {
+			fn(key)
 		}
 	}
+}
+
+// GetString returns the value of key if it is a string, or ErrWrongType if
+// it holds another type
+func (s *Storage) GetString(key string) (string, bool, error) {
+	return s.LookupString(key, true)
+}
+
+// LookupString is GetString with control over the access stamp, see Lookup
+func (s *Storage) LookupString(key string, touch bool) (string, bool, error) {
+	val, exists, err := s.LookupType(key, TypeString, touch)
+	if !exists {
+		return "", false, err
+	}
 
-	return keys
+	str, err := AsString(val)
+	if err != nil {
+		return "", false, err
+	}
+	return str, true, nil
 }
 
-func (s *Storage) cleanupExpired() {
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			s.mu.Lock()
-			now := time.Now()
-			for key, e := range s.data {
-				if e.expiry != nil && now.After(*e.expiry) {
-					delete(s.data, key)
-				}
-			}
-			s.mu.Unlock()
-		case <-s.done:
+// Delete removes key and returns true if it existed. A key whose TTL already
+// passed counts as expired, not deleted.
+func (s *Storage) Delete(key string) bool {
+	s.mu.Lock()
+	expired := s.expiredAt(key, s.clock.Now())
+	if !s.remove(key) {
+		s.mu.Unlock()
+		return false
+	}
+	if !expired {
+		s.mu.Unlock()
+		return true
+	}
+	hooks := s.onExpire
+	s.mu.Unlock()
+	notifyExpired(hooks, []string{key})
+	return false
+}
+
+// GetDel removes key and returns the value it held, unless check returns an
+// error for that value, in which case the key stays and the error is
+// returned. A missing key is not an error.
+func (s *Storage) GetDel(key string, check func(value interface{}) error) (interface{}, bool, error) {
+	return s.getAnd(key, check, func(e Entry) {
+		s.remove(key)
+	})
+}
+
+// GetExpire returns the value of key and gives it expiry, nil making it
+// persistent, unless check returns an error for the value, in which case
+// the key is left as it was and the error is returned. The key is stamped
+// as accessed. A missing key is not an error. An expiry that already passed
+// removes the key instead, like GETEX EXAT with a time in the past. changed
+// is false if the key has no TTL to remove, and removed says the key is gone.
+func (s *Storage) GetExpire(key string, check func(value interface{}) error, expiry *time.Time) (value interface{}, exists, changed, removed bool, err error) {
+	value, exists, err = s.getAnd(key, check, func(e Entry) {
+		s.touch(e)
+		switch {
+		case expiry == nil:
+			_, changed = s.expires[key]
+		case !expiry.After(s.clock.Now()):
+			s.remove(key)
+			changed, removed = true, true
 			return
+		default:
+			changed = true
+		}
+		s.setExpiry(key, expiry)
+	})
+	return value, exists, changed, removed, err
+}
+
+// getAnd runs change on the entry of key under the write lock if check
+// returns nil for its value, removing it first if it expired
+func (s *Storage) getAnd(key string, check func(value interface{}) error, change func(e Entry)) (interface{}, bool, error) {
+	s.mu.Lock()
+	var expired []string
+	e, exists := s.backend.Get(key)
+	if exists && s.expiredAt(key, s.clock.Now()) {
+		s.remove(key)
+		expired = append(expired, key)
+		exists = false
+	}
+
+	var err error
+	if exists {
+		if err = check(e.Value); err == nil {
+			change(e)
 		}
 	}
+	hooks := s.onExpire
+	s.mu.Unlock()
+
+	notifyExpired(hooks, expired)
+	if !exists || err != nil {
+		return nil, false, err
+	}
+	return e.Value, true, nil
+}
+
+// ForEach calls fn for every non-expired key. It walks the keyspace with
+// Scan, so the lock is only held a bucket at a time and fn may call back
+// into Storage; keys written during the walk may or may not be visited.
+func (s *Storage) ForEach(fn func(key string, value interface{}, expiry *time.Time)) {
+	for cursor := s.Scan(0, scanBatch, fn); cursor != 0; {
+		cursor = s.Scan(cursor, scanBatch, fn)
+	}
+}
+
+// Len returns the number of keys, including expired keys not yet removed
+func (s *Storage) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.backend.Len()
+}
+
+// Flush removes all keys and returns how many there were, expired or not
+func (s *Storage) Flush() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	removed := s.backend.Len()
+	s.backend.Flush()
+	s.resetExpires()
+	return removed
+}
+
+// FlushAsync removes all keys like Flush, but a backend implementing
+// Detacher only hands its entries over under the lock and they are
+// released in the background, so a large dataset doesn't stall the
+// commands waiting for the lock.
+func (s *Storage) FlushAsync() int {
+	detacher, ok := s.backend.(Detacher)
+	if !ok {
+		return s.Flush()
+	}
+	s.mu.Lock()
+	removed := s.backend.Len()
+	release := detacher.Detach()
+	s.resetExpires()
+	s.mu.Unlock()
+
+	go release()
+	return removed
+}
+
+// resetExpires forgets every expiry. Callers hold mu for writing.
+func (s *Storage) resetExpires() {
+	s.expires = make(map[string]int64)
+	s.expiresPeak = 0
+}
+
+// Keys returns the non-expired keys matching pattern, walking the keyspace
+// with Scan rather than under one lock
+func (s *Storage) Keys(pattern string) []string {
+	var keys []string
+	s.ForEach(func(key string, value interface{}, expiry *time.Time) {
+		if pattern == "*" || utils.MatchPattern(pattern, key) {
+			keys = append(keys, key)
+		}
+	})
+	return keys
 }
 
 func (s *Storage) Close() {
@@ -140,6 +588,9 @@ func (s *Storage) Close() {
 	if !s.stopped {
 		s.stopped = true
 		close(s.done)
+		if err := s.backend.Close(); err != nil {
+			logger.Error("Failed to close storage backend: %v", err)
+		}
 	}
 	s.mu.Unlock()
 }
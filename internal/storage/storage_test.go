@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/clock"
+)
+
+func TestWriteTTLSemantics(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	ttl := start.Add(10 * time.Second)
+	later := start.Add(time.Minute)
+
+	tests := []struct {
+		name  string
+		write func(s *Storage)
+		want  *time.Time // TTL of "key" after the write, nil for none
+	}{
+		{"Set clears the TTL", func(s *Storage) {
+			s.Set("key", "new", nil)
+		}, nil},
+		{"Set replaces the TTL", func(s *Storage) {
+			s.Set("key", "new", &later)
+		}, &later},
+		{"SetIf clears the TTL", func(s *Storage) {
+			s.SetIf("key", "new", nil, false, func(interface{}, bool) bool { return true })
+		}, nil},
+		{"SetIf with keepTTL keeps it", func(s *Storage) {
+			s.SetIf("key", "new", nil, true, func(interface{}, bool) bool { return true })
+		}, &ttl},
+		{"SetIf that doesn't set keeps it", func(s *Storage) {
+			s.SetIf("key", "new", nil, false, func(interface{}, bool) bool { return false })
+		}, &ttl},
+		{"Update keeps the TTL", func(s *Storage) {
+			s.Update("key", func(value interface{}, exists bool) (interface{}, error) {
+				return value.(string) + "suffix", nil
+			})
+		}, &ttl},
+		{"UpdateMany keeps the TTL", func(s *Storage) {
+			s.UpdateMany([]string{"key"}, func(values []interface{}) ([]interface{}, error) {
+				return []interface{}{"new"}, nil
+			})
+		}, &ttl},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			defer s.Close()
+			s.SetClock(clock.NewManual(start))
+			s.Set("key", "old", &ttl)
+
+			tt.write(s)
+			got, exists := s.Expiry("key")
+			switch {
+			case !exists:
+				t.Fatal("key is gone")
+			case tt.want == nil && got != nil:
+				t.Errorf("expiry = %v, want none", got)
+			case tt.want != nil && (got == nil || !got.Equal(*tt.want)):
+				t.Errorf("expiry = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateExpiredKey(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	clk := clock.NewManual(start)
+	s := New()
+	defer s.Close()
+	s.SetClock(clk)
+
+	ttl := start.Add(time.Second)
+	s.Set("key", "old", &ttl)
+	clk.Advance(2 * time.Second)
+
+	// An expired key is missing to the update, which recreates it without
+	// the TTL
+	s.Update("key", func(value interface{}, exists bool) (interface{}, error) {
+		if exists {
+			t.Errorf("Update saw expired value %v", value)
+		}
+		return "new", nil
+	})
+	if got, exists := s.Expiry("key"); !exists || got != nil {
+		t.Errorf("Expiry = %v, %v, want no TTL", got, exists)
+	}
+}
+
+// BenchmarkLookupParallel measures concurrent reads, which share the read
+// lock, alone and mixed with expired keys or writes. Run it with -cpu 1,4,8
+// to see how reads scale.
+func BenchmarkLookupParallel(b *testing.B) {
+	const keys = 1 << 14
+	names := make([]string, keys)
+	for i := range names {
+		names[i] = "key:" + strconv.Itoa(i)
+	}
+
+	tests := []struct {
+		name         string
+		expiredEvery int // Every nth key has expired, 0 for none
+		writeEvery   int // Every nth operation is a Set, 0 for none
+	}{
+		{"live", 0, 0},
+		{"expired", 10, 0},
+		{"writes", 0, 16},
+	}
+
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			start := time.Unix(1700000000, 0)
+			clk := clock.NewManual(start)
+			s := New()
+			defer s.Close()
+			s.SetClock(clk)
+
+			expiry := start.Add(time.Second)
+			for i, name := range names {
+				if tt.expiredEvery > 0 && i%tt.expiredEvery == 0 {
+					s.Set(name, "value", &expiry)
+				} else {
+					s.Set(name, "value", nil)
+				}
+			}
+			clk.Advance(2 * time.Second)
+
+			var worker atomic.Int64
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := int(worker.Add(1)) * 7919
+				for pb.Next() {
+					name := names[i%keys]
+					if tt.writeEvery > 0 && i%tt.writeEvery == 0 {
+						s.Set(name, "value", nil)
+					} else {
+						s.Lookup(name, true)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
@@ -59,6 +59,22 @@ func (s *Stream) GetEntries() []StreamEntry {
 	return result
 }
 
+// Copy returns a stream holding the same entries, for COPY
+func (s *Stream) Copy() interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := make([]StreamEntry, len(s.entries))
+	for i, entry := range s.entries {
+		fields := make(map[string]string, len(entry.Fields))
+		for field, value := range entry.Fields {
+			fields[field] = value
+		}
+		entries[i] = StreamEntry{ID: entry.ID, Fields: fields}
+	}
+	return &Stream{entries: entries}
+}
+
 // CompareStreamIDs compares two stream IDs
 // Returns -1 if id1 < id2, 0 if id1 == id2, 1 if id1 > id2
 func CompareStreamIDs(id1, id2 string) int {
@@ -99,5 +115,5 @@ func (s *Stream) Len() int {
 
 // Type returns the type of this value (for the TYPE command)
 func (s *Stream) Type() string {
-	return "stream"
+	return TypeStream
 }
@@ -0,0 +1,73 @@
+package storage
+
+import "github.com/codecrafters-redis-go/internal/errors"
+
+// Type names reported by TYPE and expected by LookupType
+const (
+	TypeString = "string"
+	TypeList   = "list"
+	TypeSet    = "set"
+	TypeZSet   = "zset"
+	TypeHash   = "hash"
+	TypeStream = "stream"
+)
+
+// TypeOf returns the type name of a stored value. Values that don't
+// implement ValueType, like plain Go strings, are strings.
+func TypeOf(value interface{}) string {
+	if v, ok := value.(ValueType); ok {
+		return v.Type()
+	}
+	return TypeString
+}
+
+// CheckType returns ErrWrongType unless value is of type want. Commands
+// call it on values they got from Update.
+func CheckType(value interface{}, want string) error {
+	if TypeOf(value) != want {
+		return errors.ErrWrongType
+	}
+	return nil
+}
+
+// Copier is implemented by values holding state that can change in place,
+// like streams. Copy returns an independent duplicate, so COPY doesn't leave
+// two keys sharing one value.
+type Copier interface {
+	Copy() interface{}
+}
+
+// copyValue returns a value equal to value that doesn't share its state
+func copyValue(value interface{}) interface{} {
+	if copier, ok := value.(Copier); ok {
+		return copier.Copy()
+	}
+	// Strings are immutable
+	return value
+}
+
+// AsString returns the contents of a string value, or ErrWrongType if value
+// is of another type. Commands call it on values they got from Update.
+func AsString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case StringValue:
+		return v.Value, nil
+	default:
+		return "", errors.ErrWrongType
+	}
+}
+
+// LookupType returns the value of key like Lookup, or ErrWrongType if the
+// key holds another type. A missing key is not an error.
+func (s *Storage) LookupType(key, want string, touch bool) (interface{}, bool, error) {
+	value, exists := s.Lookup(key, touch)
+	if !exists {
+		return nil, false, nil
+	}
+	if err := CheckType(value, want); err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
@@ -0,0 +1,341 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/logger"
+)
+
+const (
+	exportInterval = 5 * time.Second  // Longest a finished span waits to be sent
+	exportBatch    = 512              // Spans sent in one request at most, unless closing
+	exportQueue    = 4096             // Finished spans waiting to be sent before new ones are dropped
+	exportTimeout  = 10 * time.Second // Longest one request to the collector may take
+)
+
+// OTLP span kind and status codes
+const (
+	spanKindInternal = 1
+	statusError      = 2
+)
+
+// scopeName identifies the instrumentation in exported spans
+const scopeName = "github.com/codecrafters-redis-go/internal/tracing"
+
+// Exporter is a Tracer sending spans to an OpenTelemetry collector with OTLP
+// over HTTP, encoded as JSON. Finished spans are batched and sent in the
+// background; when the collector can't keep up they are dropped rather than
+// slowing commands down. Whether a trace is recorded is decided when its
+// root span starts, and its children follow.
+type Exporter struct {
+	endpoint string
+	service  string
+	ratio    func() float64 // Fraction of traces recorded
+	client   *http.Client
+	log      logger.Interface
+
+	queue     chan *span
+	closing   chan struct{}
+	exited    chan struct{}
+	closeOnce sync.Once
+	dropped   atomic.Int64 // Spans dropped since the last export
+}
+
+// NewExporter starts an exporter sending the spans of service to the
+// collector at endpoint, e.g. http://localhost:4318. ratio is called for
+// every new trace. Close flushes the spans not sent yet.
+func NewExporter(endpoint, service string, ratio func() float64, log logger.Interface) *Exporter {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(endpoint, "/v1/traces") {
+		endpoint += "/v1/traces"
+	}
+	exporter := &Exporter{
+		endpoint: endpoint,
+		service:  service,
+		ratio:    ratio,
+		client:   &http.Client{Timeout: exportTimeout},
+		log:      log,
+		queue:    make(chan *span, exportQueue),
+		closing:  make(chan struct{}),
+		exited:   make(chan struct{}),
+	}
+	go exporter.run()
+	return exporter
+}
+
+// spanKey is the context key of the span a context carries
+type spanKey struct{}
+
+// span is a span started by an Exporter
+type span struct {
+	exporter *Exporter
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte // Zero for root spans
+	sampled  bool    // Unsampled spans only pass their trace on to children
+	name     string
+	start    time.Time
+
+	mu     sync.Mutex
+	end    time.Time
+	attrs  []Attribute
+	failed bool
+	reason string // Error message, if failed
+	ended  bool
+}
+
+// Start begins a span, recorded if its trace is
+func (exporter *Exporter) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	s := &span{exporter: exporter, name: name, start: time.Now()}
+	if parent, ok := ctx.Value(spanKey{}).(*span); ok {
+		s.traceID, s.parentID, s.sampled = parent.traceID, parent.spanID, parent.sampled
+	} else {
+		ratio := exporter.ratio()
+		s.sampled = ratio >= 1 || rand.Float64() < ratio
+		putID(s.traceID[:8])
+		putID(s.traceID[8:])
+	}
+	putID(s.spanID[:])
+	if s.sampled {
+		s.attrs = append(s.attrs, attrs...)
+	}
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// putID fills id with random bytes, never all zero, which OTLP reserves for
+// invalid ids
+func putID(id []byte) {
+	for {
+		n := rand.Uint64()
+		if n == 0 {
+			continue
+		}
+		for i := range id {
+			id[i] = byte(n >> (8 * i))
+		}
+		return
+	}
+}
+
+func (s *span) SetAttributes(attrs ...Attribute) {
+	if !s.sampled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.attrs = append(s.attrs, attrs...)
+	}
+}
+
+func (s *span) SetError(message string) {
+	if !s.sampled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.failed, s.reason = true, message
+	}
+}
+
+// End queues the span for export. Calls after the first are ignored.
+func (s *span) End() {
+	if !s.sampled {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended, s.end = true, time.Now()
+	s.mu.Unlock()
+
+	select {
+	case s.exporter.queue <- s:
+	default:
+		s.exporter.dropped.Add(1)
+	}
+}
+
+// run sends the queued spans in batches until the exporter is closed
+func (exporter *Exporter) run() {
+	defer close(exporter.exited)
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := make([]*span, 0, exportBatch)
+	for {
+		select {
+		case s := <-exporter.queue:
+			if batch = append(batch, s); len(batch) < exportBatch {
+				continue
+			}
+		case <-ticker.C:
+		case <-exporter.closing:
+			for len(exporter.queue) > 0 {
+				batch = append(batch, <-exporter.queue)
+			}
+			exporter.export(batch)
+			return
+		}
+		exporter.export(batch)
+		batch = batch[:0]
+	}
+}
+
+// Close sends the spans ended so far and stops the exporter. Spans ended
+// afterwards are dropped.
+func (exporter *Exporter) Close() error {
+	exporter.closeOnce.Do(func() { close(exporter.closing) })
+	<-exporter.exited
+	return nil
+}
+
+// export sends batch to the collector
+func (exporter *Exporter) export(batch []*span) {
+	if dropped := exporter.dropped.Swap(0); dropped > 0 {
+		exporter.log.Warn("Dropped %d trace spans, the collector is not keeping up", dropped)
+	}
+	if len(batch) == 0 {
+		return
+	}
+
+	spans := make([]spanData, len(batch))
+	for i, s := range batch {
+		spans[i] = s.data()
+	}
+	request := exportRequest{ResourceSpans: []resourceSpans{{
+		Resource:   resource{Attributes: keyValues([]Attribute{String("service.name", exporter.service)})},
+		ScopeSpans: []scopeSpans{{Scope: scope{Name: scopeName}, Spans: spans}},
+	}}}
+	body, err := json.Marshal(request)
+	if err != nil {
+		exporter.log.Error("Failed to encode %d trace spans: %v", len(batch), err)
+		return
+	}
+
+	if err := exporter.post(body); err != nil {
+		exporter.log.Warn("Failed to export %d trace spans to %s: %v", len(batch), exporter.endpoint, err)
+	}
+}
+
+// post sends an encoded export request
+func (exporter *Exporter) post(body []byte) error {
+	request, err := http.NewRequest(http.MethodPost, exporter.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := exporter.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	// Drained so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64*1024))
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("collector replied %s", response.Status)
+	}
+	return nil
+}
+
+// data returns the OTLP form of an ended span
+func (s *span) data() spanData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data := spanData{
+		TraceID:           hex.EncodeToString(s.traceID[:]),
+		SpanID:            hex.EncodeToString(s.spanID[:]),
+		Name:              s.name,
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+		Attributes:        keyValues(s.attrs),
+	}
+	if s.parentID != ([8]byte{}) {
+		data.ParentSpanID = hex.EncodeToString(s.parentID[:])
+	}
+	if s.failed {
+		data.Status = status{Code: statusError, Message: s.reason}
+	}
+	return data
+}
+
+// keyValues returns the OTLP form of attrs
+func keyValues(attrs []Attribute) []keyValue {
+	values := make([]keyValue, len(attrs))
+	for i, attr := range attrs {
+		values[i].Key = attr.Key
+		switch value := attr.Value.(type) {
+		case string:
+			values[i].Value.StringValue = &value
+		case int64:
+			values[i].Value.IntValue = strconv.FormatInt(value, 10)
+		case bool:
+			values[i].Value.BoolValue = &value
+		default:
+			text := fmt.Sprint(value)
+			values[i].Value.StringValue = &text
+		}
+	}
+	return values
+}
+
+// The OTLP/JSON encoding of an ExportTraceServiceRequest
+type (
+	exportRequest struct {
+		ResourceSpans []resourceSpans `json:"resourceSpans"`
+	}
+	resourceSpans struct {
+		Resource   resource     `json:"resource"`
+		ScopeSpans []scopeSpans `json:"scopeSpans"`
+	}
+	resource struct {
+		Attributes []keyValue `json:"attributes"`
+	}
+	scopeSpans struct {
+		Scope scope      `json:"scope"`
+		Spans []spanData `json:"spans"`
+	}
+	scope struct {
+		Name string `json:"name"`
+	}
+	spanData struct {
+		TraceID           string     `json:"traceId"`
+		SpanID            string     `json:"spanId"`
+		ParentSpanID      string     `json:"parentSpanId,omitempty"`
+		Name              string     `json:"name"`
+		Kind              int        `json:"kind"`
+		StartTimeUnixNano string     `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+		Attributes        []keyValue `json:"attributes,omitempty"`
+		Status            status     `json:"status"`
+	}
+	status struct {
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	}
+	keyValue struct {
+		Key   string   `json:"key"`
+		Value anyValue `json:"value"`
+	}
+	anyValue struct {
+		StringValue *string `json:"stringValue,omitempty"`
+		IntValue    string  `json:"intValue,omitempty"`
+		BoolValue   *bool   `json:"boolValue,omitempty"`
+	}
+)
@@ -0,0 +1,84 @@
+// Package tracing records spans for commands, replication and persistence,
+// so the server can take part in distributed traces. Tracer is small on
+// purpose: an adapter onto an OpenTelemetry SDK tracer is a few lines, and
+// Exporter sends spans over OTLP without one.
+package tracing
+
+import "context"
+
+// Tracer starts spans
+type Tracer interface {
+	// Start begins a span named name, a child of the span carried by ctx if
+	// any, and returns a context carrying the new span
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span is an operation being traced. It is recorded once End is called.
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	// SetError marks the operation as failed with message
+	SetError(message string)
+	End()
+}
+
+// Attribute is a key and a string, int64 or bool value describing a span
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// String returns a string attribute
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int returns an integer attribute
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: int64(value)}
+}
+
+// Bool returns a boolean attribute
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Noop is a tracer that records nothing, used while tracing is disabled
+var Noop Tracer = noopTracer{}
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(attrs ...Attribute) {}
+func (noopSpan) SetError(message string)          {}
+func (noopSpan) End()                             {}
+
+// Enabled returns true if tracer records spans. Callers check it to skip
+// computing attributes while tracing is disabled.
+func Enabled(tracer Tracer) bool {
+	return tracer != nil && tracer != Noop
+}
+
+// Start begins a span with tracer, which may be nil, under a background
+// context if ctx is nil
+func Start(ctx context.Context, tracer Tracer, name string, attrs ...Attribute) (context.Context, Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !Enabled(tracer) {
+		return ctx, noopSpan{}
+	}
+	return tracer.Start(ctx, name, attrs...)
+}
+
+// EndWithError ends span, marking it failed if err is not nil
+func EndWithError(span Span, err error) {
+	if err != nil {
+		span.SetError(err.Error())
+	}
+	span.End()
+}
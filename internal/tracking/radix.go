@@ -0,0 +1,111 @@
+package tracking
+
+import "strings"
+
+// node is a node of a radix tree of prefixes. The prefix a node stands for
+// is the concatenation of the labels from the root down to it.
+type node struct {
+	label    string
+	children map[byte]*node // Keyed by the first byte of the child's label
+	clients  map[*Client]struct{}
+}
+
+func newNode(label string) *node {
+	return &node{
+		label:    label,
+		children: make(map[byte]*node),
+		clients:  make(map[*Client]struct{}),
+	}
+}
+
+// insert returns the node for prefix below n, splitting edges as needed
+func (n *node) insert(prefix string) *node {
+	for prefix != "" {
+		child, ok := n.children[prefix[0]]
+		if !ok {
+			child = newNode(prefix)
+			n.children[prefix[0]] = child
+			return child
+		}
+
+		common := commonPrefixLen(child.label, prefix)
+		if common < len(child.label) {
+			// prefix ends or diverges inside the edge, split it
+			middle := newNode(child.label[:common])
+			child.label = child.label[common:]
+			middle.children[child.label[0]] = child
+			n.children[prefix[0]] = middle
+			child = middle
+		}
+		n = child
+		prefix = prefix[common:]
+	}
+	return n
+}
+
+// remove drops client from the node for prefix below n and prunes nodes
+// left without clients. It returns true if n itself became empty.
+func (n *node) remove(prefix string, client *Client) bool {
+	if prefix == "" {
+		delete(n.clients, client)
+	} else if child, ok := n.children[prefix[0]]; ok && strings.HasPrefix(prefix, child.label) {
+		if child.remove(prefix[len(child.label):], client) {
+			delete(n.children, prefix[0])
+		} else if len(child.clients) == 0 && len(child.children) == 1 {
+			// Merge the child with its only child, keeping the tree compressed
+			for _, grandchild := range child.children {
+				grandchild.label = child.label + grandchild.label
+				n.children[prefix[0]] = grandchild
+			}
+		}
+	}
+	return len(n.clients) == 0 && len(n.children) == 0
+}
+
+// walk calls fn with the clients of every node whose prefix is a prefix of key
+func (n *node) walk(key string, fn func(map[*Client]struct{})) {
+	for {
+		if len(n.clients) > 0 {
+			fn(n.clients)
+		}
+		if key == "" {
+			return
+		}
+		child, ok := n.children[key[0]]
+		if !ok || !strings.HasPrefix(key, child.label) {
+			return
+		}
+		key = key[len(child.label):]
+		n = child
+	}
+}
+
+// count returns the number of nodes at or below n that clients track
+func (n *node) count() int {
+	total := 0
+	if len(n.clients) > 0 {
+		total++
+	}
+	for _, child := range n.children {
+		total += child.count()
+	}
+	return total
+}
+
+func commonPrefixLen(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// each calls fn for the clients of n and of every node below it
+func (n *node) each(fn func(map[*Client]struct{})) {
+	if len(n.clients) > 0 {
+		fn(n.clients)
+	}
+	for _, child := range n.children {
+		child.each(fn)
+	}
+}
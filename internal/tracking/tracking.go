@@ -0,0 +1,177 @@
+// Package tracking sends client side caching invalidations in broadcast
+// mode: clients register key prefixes and are told about every modified key
+// under them, so the server never remembers which client read which key.
+package tracking
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/codecrafters-redis-go/internal/resp"
+)
+
+// Client is a connection that may enable tracking. Its deliver function
+// must be safe to call from any goroutine.
+type Client struct {
+	deliver  func(resp.Value)
+	enabled  bool                // Guarded by the table lock, like the fields below
+	noLoop   bool                // Skip keys the client modified itself
+	prefixes map[string]struct{} // The empty prefix tracks every key
+}
+
+// NewClient creates a client sending invalidation messages with deliver
+func NewClient(deliver func(resp.Value)) *Client {
+	return &Client{deliver: deliver, prefixes: make(map[string]struct{})}
+}
+
+// Table indexes the prefixes tracked by all clients
+type Table struct {
+	mu      sync.Mutex
+	root    *node
+	clients int // Clients with tracking enabled
+}
+
+// NewTable creates a table without tracking clients
+func NewTable() *Table {
+	return &Table{root: newNode("")}
+}
+
+// Enable turns on broadcast tracking of prefixes for client, or adds them
+// if it is already on. No prefix means every key. A client's prefixes must
+// not overlap, or one write would be reported twice.
+func (table *Table) Enable(client *Client, prefixes []string, noLoop bool) error {
+	if len(prefixes) == 0 {
+		prefixes = []string{""}
+	}
+
+	table.mu.Lock()
+	defer table.mu.Unlock()
+
+	for i, prefix := range prefixes {
+		for existing := range client.prefixes {
+			if existing != prefix && overlaps(existing, prefix) {
+				return overlapError(prefix, existing)
+			}
+		}
+		for _, other := range prefixes[:i] {
+			if other != prefix && overlaps(other, prefix) {
+				return overlapError(prefix, other)
+			}
+		}
+	}
+
+	if !client.enabled {
+		client.enabled = true
+		table.clients++
+	}
+	client.noLoop = noLoop
+	for _, prefix := range prefixes {
+		if _, ok := client.prefixes[prefix]; ok {
+			continue
+		}
+		client.prefixes[prefix] = struct{}{}
+		table.root.insert(prefix).clients[client] = struct{}{}
+	}
+	return nil
+}
+
+// Disable turns off tracking for client and forgets its prefixes
+func (table *Table) Disable(client *Client) {
+	table.mu.Lock()
+	defer table.mu.Unlock()
+
+	if !client.enabled {
+		return
+	}
+	for prefix := range client.prefixes {
+		table.root.remove(prefix, client)
+	}
+	client.prefixes = make(map[string]struct{})
+	client.enabled = false
+	client.noLoop = false
+	table.clients--
+}
+
+// Info returns whether tracking is on for client, with its options
+func (table *Table) Info(client *Client) (enabled, noLoop bool, prefixes []string) {
+	table.mu.Lock()
+	defer table.mu.Unlock()
+
+	for prefix := range client.prefixes {
+		prefixes = append(prefixes, prefix)
+	}
+	return client.enabled, client.noLoop, prefixes
+}
+
+// Clients returns the number of clients with tracking enabled
+func (table *Table) Clients() int {
+	table.mu.Lock()
+	defer table.mu.Unlock()
+	return table.clients
+}
+
+// Prefixes returns the number of distinct tracked prefixes
+func (table *Table) Prefixes() int {
+	table.mu.Lock()
+	defer table.mu.Unlock()
+	return table.root.count()
+}
+
+// Invalidate tells the clients tracking a prefix of key that it changed.
+// origin is the client that modified it, or nil.
+func (table *Table) Invalidate(key string, origin *Client) {
+	table.mu.Lock()
+	if table.clients == 0 {
+		table.mu.Unlock()
+		return
+	}
+	var targets []*Client
+	table.root.walk(key, func(clients map[*Client]struct{}) {
+		for client := range clients {
+			if client != origin || !client.noLoop {
+				targets = append(targets, client)
+			}
+		}
+	})
+	table.mu.Unlock()
+
+	if len(targets) == 0 {
+		return
+	}
+	// Deliver without the lock, a slow client must not hold up writers
+	message := resp.PushValue(
+		resp.BulkStringValue("invalidate"),
+		resp.ArrayValue(resp.BulkStringValue(key)),
+	)
+	for _, client := range targets {
+		client.deliver(message)
+	}
+}
+
+// InvalidateAll tells every tracking client that all keys changed, with a
+// null key list, after the keyspace was flushed
+func (table *Table) InvalidateAll() {
+	table.mu.Lock()
+	targets := make(map[*Client]struct{})
+	table.root.each(func(clients map[*Client]struct{}) {
+		for client := range clients {
+			targets[client] = struct{}{}
+		}
+	})
+	table.mu.Unlock()
+
+	message := resp.PushValue(resp.BulkStringValue("invalidate"), resp.NullArray())
+	for client := range targets {
+		client.deliver(message)
+	}
+}
+
+// overlaps returns true if one prefix is a prefix of the other
+func overlaps(a, b string) bool {
+	return strings.HasPrefix(a, b) || strings.HasPrefix(b, a)
+}
+
+func overlapError(prefix, existing string) error {
+	return fmt.Errorf("ERR Prefix '%s' overlaps with an existing prefix '%s'. Prefixes for a single client must not overlap.", prefix, existing)
+}
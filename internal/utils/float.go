@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// longDoublePrec is the mantissa of the x87 long double Redis computes
+// INCRBYFLOAT with. The extra bits over a float64 round away the error of
+// decimal inputs, so 0.1 plus 0.2 is printed as 0.3.
+const longDoublePrec = 64
+
+// ParseFloat64 parses a finite floating point number the way Redis does for
+// commands like INCRBYFLOAT: no spaces around it, and NaN or infinity are
+// rejected
+func ParseFloat64(str string) (float64, bool) {
+	value, err := strconv.ParseFloat(str, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, false
+	}
+	return value, true
+}
+
+// ParseLongDouble parses a number like ParseFloat64, but keeps the
+// precision of a long double
+func ParseLongDouble(str string) (*big.Float, bool) {
+	value, ok := ParseFloat64(str)
+	if !ok {
+		return nil, false
+	}
+	if parsed, _, err := big.ParseFloat(str, 0, longDoublePrec, big.ToNearestEven); err == nil {
+		return parsed, true
+	}
+	return new(big.Float).SetPrec(longDoublePrec).SetFloat64(value), true
+}
+
+// FormatLongDouble formats value like Redis stores the result of
+// INCRBYFLOAT: 17 decimals, as printf %.17Lf does, with the trailing zeros
+// removed
+func FormatLongDouble(value *big.Float) string {
+	str := value.Text('f', 17)
+	str = strings.TrimRight(strings.TrimRight(str, "0"), ".")
+	if str == "-0" {
+		return "0"
+	}
+	return str
+}
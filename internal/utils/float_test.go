@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestLongDoubleSum(t *testing.T) {
+	// Results of SET key <current> then INCRBYFLOAT key <delta> on Redis
+	tests := []struct {
+		current, delta string
+		want           string
+	}{
+		{"0.1", "0.2", "0.3"},
+		{"10.50", "0.1", "10.6"},
+		{"5.0e3", "2.0e2", "5200"},
+		{"1", "1.5", "2.5"},
+		{"0.1", "-0.1", "0"},
+		{"-0.5", "0.25", "-0.25"},
+		{"3", "-5", "-2"},
+		{"0", "1e-17", "0.00000000000000001"},
+		{"0", "1e-18", "0"},
+		{"1e18", "1", "1000000000000000001"},
+		{"1e20", "1", "100000000000000000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.current+"+"+tt.delta, func(t *testing.T) {
+			current, ok := ParseLongDouble(tt.current)
+			if !ok {
+				t.Fatalf("ParseLongDouble(%q) failed", tt.current)
+			}
+			delta, ok := ParseLongDouble(tt.delta)
+			if !ok {
+				t.Fatalf("ParseLongDouble(%q) failed", tt.delta)
+			}
+			if got := FormatLongDouble(new(big.Float).Add(current, delta)); got != tt.want {
+				t.Errorf("sum = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLongDoubleRejects(t *testing.T) {
+	for _, str := range []string{"", "abc", " 1", "1 ", "nan", "inf", "-inf", "1e400"} {
+		if _, ok := ParseLongDouble(str); ok {
+			t.Errorf("ParseLongDouble(%q) succeeded", str)
+		}
+	}
+}
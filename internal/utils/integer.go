@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"math"
+	"strconv"
+)
+
+// ParseInt64 parses a decimal integer the way Redis does: an optional minus
+// sign and digits without leading zeros, spaces or a plus sign, within the
+// int64 range
+func ParseInt64(str string) (int64, bool) {
+	digits := str
+	if len(digits) > 0 && digits[0] == '-' {
+		digits = digits[1:]
+	}
+	if digits == "" || digits[0] < '0' || digits[0] > '9' || digits[0] == '0' && len(str) > 1 {
+		return 0, false
+	}
+	value, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
+// AddInt64 returns a+b, or false if the sum does not fit in an int64
+func AddInt64(a, b int64) (int64, bool) {
+	if b > 0 && a > math.MaxInt64-b || b < 0 && a < math.MinInt64-b {
+		return 0, false
+	}
+	return a + b, true
+}
@@ -2,54 +2,102 @@ package utils
 
 import "strings"
 
-// MatchPattern checks if a string matches a glob-style pattern
+// MatchPattern checks if a string matches a glob-style pattern, byte by
+// byte like Redis does
 // Supports:
 //   - * matches any number of characters
 //   - ? matches a single character
 //   - [abc] matches any character in the set
 //   - [a-z] matches any character in the range
+//   - [^a] matches any character not in the set
+//   - \x matches x literally, also inside a set
 func MatchPattern(pattern, str string) bool {
 	// Special case: * matches everything
 	if pattern == "*" {
 		return true
 	}
 
-	// For now, implement basic * wildcard support
-	// This can be extended to support full glob patterns
-	if strings.Contains(pattern, "*") {
-		// Convert pattern to parts split by *
-		parts := strings.Split(pattern, "*")
-
-		// Check if string starts with first part
-		if len(parts[0]) > 0 && !strings.HasPrefix(str, parts[0]) {
-			return false
+	// Match left to right, and on a mismatch let the last * seen swallow
+	// one more byte. Backtracking to earlier stars is never needed, so the
+	// cost stays at most proportional to len(pattern) * len(str).
+	p, s := 0, 0
+	star, starS := -1, 0
+	for s < len(str) {
+		if p < len(pattern) && pattern[p] == '*' {
+			for p < len(pattern) && pattern[p] == '*' {
+				p++
+			}
+			star, starS = p, s
+			continue
 		}
-
-		// Check if string ends with last part
-		lastPart := parts[len(parts)-1]
-		if len(lastPart) > 0 && !strings.HasSuffix(str, lastPart) {
+		if p < len(pattern) {
+			if ok, width := matchByte(pattern[p:], str[s]); ok {
+				p += width
+				s++
+				continue
+			}
+		}
+		if star < 0 {
 			return false
 		}
+		starS++
+		p, s = star, starS
+	}
 
-		// Simple implementation: check if all parts exist in order
-		currentPos := 0
-		for _, part := range parts {
-			if part == "" {
-				continue
-			}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
 
-			idx := strings.Index(str[currentPos:], part)
-			if idx == -1 {
-				return false
-			}
-			currentPos += idx + len(part)
+// matchByte reports whether c matches the element pattern starts with, and
+// how many bytes of pattern that element spans. An unterminated set spans
+// the rest of the pattern.
+func matchByte(pattern string, c byte) (bool, int) {
+	switch pattern[0] {
+	case '?':
+		return true, 1
+	case '\\':
+		if len(pattern) >= 2 {
+			return pattern[1] == c, 2
 		}
+		return c == '\\', 1
+	case '[':
+		return matchSet(pattern, c)
+	}
+	return pattern[0] == c, 1
+}
 
-		return true
+// matchSet matches c against the set pattern starts with
+func matchSet(pattern string, c byte) (bool, int) {
+	i := 1
+	negate := i < len(pattern) && pattern[i] == '^'
+	if negate {
+		i++
 	}
 
-	// Exact match
-	return pattern == str
+	matched := false
+	for {
+		switch {
+		case i >= len(pattern):
+			return matched != negate, len(pattern)
+		case pattern[i] == '\\' && i+1 < len(pattern):
+			matched = matched || pattern[i+1] == c
+			i += 2
+		case pattern[i] == ']':
+			return matched != negate, i + 1
+		case i+2 < len(pattern) && pattern[i+1] == '-':
+			start, end := pattern[i], pattern[i+2]
+			if start > end {
+				start, end = end, start
+			}
+			matched = matched || (c >= start && c <= end)
+			i += 3
+		default:
+			matched = matched || pattern[i] == c
+			i++
+		}
+	}
 }
 
 // IsGlobPattern returns true if the pattern contains glob metacharacters
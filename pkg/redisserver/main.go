@@ -0,0 +1,99 @@
+package redisserver
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/codecrafters-redis-go/internal/daemon"
+	"github.com/codecrafters-redis-go/internal/logger"
+)
+
+// Main runs the redis-server program: it configures a server from the
+// command line flags and serves until it is signalled to stop. It returns
+// the exit status of the process.
+func Main() int {
+	// Create configuration and parse command-line flags
+	cfg := NewConfig()
+	checkConfig := flag.Bool("check-config", false, "Validate the configuration, print the effective settings and exit")
+	cfg.ParseFlags()
+
+	if *checkConfig {
+		fmt.Print(cfg.Dump())
+		if err := cfg.Validate(); err != nil {
+			fmt.Printf("Configuration is invalid:\n%v\n", err)
+			return 1
+		}
+		fmt.Println("Configuration OK")
+		return 0
+	}
+
+	// Re-execute in the background and let the detached copy run the server
+	if cfg.Daemonize && !daemon.IsChild() {
+		if err := daemon.Daemonize(); err != nil {
+			fmt.Printf("Failed to daemonize: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if err := logger.Configure(logger.Options{
+		Level:          cfg.LogLevel,
+		File:           cfg.LogFile,
+		Syslog:         cfg.SyslogEnabled,
+		SyslogIdent:    cfg.SyslogIdent,
+		SyslogFacility: cfg.SyslogFacility,
+	}); err != nil {
+		fmt.Printf("Failed to configure logging: %v\n", err)
+		return 1
+	}
+
+	// Create and start the server with configuration
+	srv, err := Start(cfg)
+	if err != nil {
+		fmt.Printf("Failed to start server: %v\n", err)
+		return 1
+	}
+
+	// Reload the config file on SIGHUP
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+
+	go func() {
+		for range hupChan {
+			srv.ReloadConfig()
+		}
+	}()
+
+	// Log a state report and take a background save on SIGUSR1
+	usr1Chan := make(chan os.Signal, 1)
+	signal.Notify(usr1Chan, syscall.SIGUSR1)
+
+	go func() {
+		for range usr1Chan {
+			srv.LogStateReport()
+			if err := srv.BackgroundSave(); err != nil {
+				logger.Warn("SIGUSR1 received but no background save started: %v", err)
+			}
+		}
+	}()
+
+	// Handle graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		<-sigChan
+		fmt.Println("\nShutting down server...")
+		srv.Stop()
+	}()
+
+	// Wait for server to shut down
+	srv.Wait()
+	if err := srv.Err(); err != nil {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,213 @@
+// Package redisserver runs the Redis server inside a Go program.
+//
+// It is meant for applications that embed the server and for integration
+// tests that need a real Redis endpoint without an external process:
+//
+//	cfg := redisserver.NewConfig()
+//	cfg.Port = 0 // pick a free port
+//	srv, err := redisserver.Start(cfg)
+//	if err != nil {
+//		return err
+//	}
+//	defer srv.Stop()
+//	client := connect(srv.Addr().String())
+//
+// Commands can also run in-process with Do, skipping the network:
+//
+//	reply, err := srv.Do(ctx, "SET", "key", "value")
+package redisserver
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/clock"
+	"github.com/codecrafters-redis-go/internal/config"
+	"github.com/codecrafters-redis-go/internal/logger"
+	"github.com/codecrafters-redis-go/internal/resp"
+	"github.com/codecrafters-redis-go/internal/server"
+	"github.com/codecrafters-redis-go/internal/storage"
+	"github.com/codecrafters-redis-go/internal/tracing"
+)
+
+// Config holds the server configuration
+type Config = config.Config
+
+// NewConfig creates a configuration with the default values
+func NewConfig() *Config {
+	return config.New()
+}
+
+// Logger receives the server's log output
+type Logger = logger.Interface
+
+// Clock tells the server the current time
+type Clock = clock.Clock
+
+// ManualClock is a Clock that only moves when advanced, so tests can expire
+// keys and time out blocked clients without sleeping
+type ManualClock = clock.Manual
+
+// NewManualClock creates a clock stopped at now
+func NewManualClock(now time.Time) *ManualClock {
+	return clock.NewManual(now)
+}
+
+// Storage is the in-memory dataset a server serves
+type Storage = storage.Storage
+
+// NewStorage creates an empty dataset that can be populated before New
+func NewStorage() *Storage {
+	return storage.New()
+}
+
+// Backend holds the entries behind a Storage
+type Backend = storage.Backend
+
+// NewStorageWithBackend creates an empty dataset kept in backend
+func NewStorageWithBackend(backend Backend) *Storage {
+	return storage.NewWithBackend(backend)
+}
+
+// Option configures a server created by New or Start
+type Option = server.Option
+
+// WithListener serves connections accepted on listener instead of binding cfg.Port
+func WithListener(listener net.Listener) Option {
+	return server.WithListener(listener)
+}
+
+// WithLogger sends log output to log instead of stdout
+func WithLogger(log Logger) Option {
+	return server.WithLogger(log)
+}
+
+// WithClock replaces the wall clock key TTLs, stream IDs and blocking
+// timeouts follow
+func WithClock(clk Clock) Option {
+	return server.WithClock(clk)
+}
+
+// WithStorage serves store instead of an empty dataset
+func WithStorage(store *Storage) Option {
+	return server.WithStorage(store)
+}
+
+// Hooks are callbacks for server events such as client connections and key writes
+type Hooks = server.Hooks
+
+// WithHooks registers callbacks for server events
+func WithHooks(hooks Hooks) Option {
+	return server.WithHooks(hooks)
+}
+
+// Tracer records spans for commands, replication syncs and persistence. An
+// adapter onto an OpenTelemetry SDK tracer makes the server part of the
+// application's traces; spans started by Do are children of the span in
+// its context if the adapter finds one there.
+type Tracer = tracing.Tracer
+
+// Span is an operation traced by a Tracer
+type Span = tracing.Span
+
+// Attribute describes a span, its Value is a string, int64 or bool
+type Attribute = tracing.Attribute
+
+// WithTracer records spans with tracer, instead of exporting them to the
+// otel-exporter-otlp-endpoint collector
+func WithTracer(tracer Tracer) Option {
+	return server.WithTracer(tracer)
+}
+
+// Server is an embedded Redis server
+type Server struct {
+	server *server.Server
+}
+
+// New creates a server that is not yet listening
+func New(cfg *Config, opts ...Option) *Server {
+	return &Server{server: server.New(cfg, opts...)}
+}
+
+// Start creates a server and starts listening
+func Start(cfg *Config, opts ...Option) (*Server, error) {
+	srv := New(cfg, opts...)
+	if err := srv.Start(); err != nil {
+		return nil, err
+	}
+	return srv, nil
+}
+
+// Start binds the listener and begins serving connections in the background
+func (srv *Server) Start() error {
+	return srv.server.Start()
+}
+
+// Addr returns the address the server is listening on, or nil before Start
+func (srv *Server) Addr() net.Addr {
+	return srv.server.Addr()
+}
+
+// Port returns the TCP port the server is bound to
+func (srv *Server) Port() int {
+	return srv.server.Port()
+}
+
+// Stop gracefully shuts down the server and waits for it to finish
+func (srv *Server) Stop() error {
+	return srv.server.Stop()
+}
+
+// ReloadConfig re-reads the config file and applies runtime-mutable parameters
+func (srv *Server) ReloadConfig() error {
+	return srv.server.ReloadConfig()
+}
+
+// BackgroundSave writes a snapshot to the RDB file without waiting for it,
+// failing if one is already being written
+func (srv *Server) BackgroundSave() error {
+	return srv.server.BackgroundSave()
+}
+
+// LogStateReport logs the clients, replicas, memory and blocked clients of
+// the server
+func (srv *Server) LogStateReport() {
+	srv.server.LogStateReport()
+}
+
+// Reply is the reply to a command run with Do. Type says which fields hold
+// it, such as Str for bulk strings, Integer for integers and Array for
+// arrays; IsNull is set for nil replies.
+type Reply = resp.Value
+
+// ReplyError is the error reply of a command run with Do, such as
+// "WRONGTYPE Operation against a key holding the wrong kind of value"
+type ReplyError string
+
+func (err ReplyError) Error() string {
+	return string(err)
+}
+
+// Do runs a command in-process and returns its reply, without going through
+// a connection or RESP. An error reply is returned as a ReplyError along
+// with the reply; any other error is that of ctx. Each call is independent,
+// so state set by commands like MULTI doesn't carry over to the next one.
+func (srv *Server) Do(ctx context.Context, args ...string) (Reply, error) {
+	reply, err := srv.server.Do(ctx, args...)
+	if err == nil && reply.IsError() {
+		return reply, ReplyError(reply.Str)
+	}
+	return reply, err
+}
+
+// Wait blocks until the server is shut down
+func (srv *Server) Wait() {
+	srv.server.Wait()
+}
+
+// Err returns the error that stopped the server on its own, such as an RDB
+// or AOF file that failed to load, or nil
+func (srv *Server) Err() error {
+	return srv.server.Err()
+}
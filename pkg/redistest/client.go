@@ -0,0 +1,122 @@
+package redistest
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/codecrafters-redis-go/internal/resp"
+	"github.com/codecrafters-redis-go/pkg/redisserver"
+)
+
+// requestTimeout is the longest a Client waits for a reply
+const requestTimeout = 5 * time.Second
+
+// Client is a RESP2 connection to a server. Unlike Server.Do it goes through
+// the network and keeps connection state like MULTI or SELECT between calls.
+// It is safe for concurrent use, requests are sent one at a time.
+type Client struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	parser  *resp.Parser
+	encoder *resp.Encoder
+}
+
+// Dial connects a client to the server at addr
+func Dial(addr string) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", addr, requestTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		conn:    conn,
+		parser:  resp.NewParser(conn),
+		encoder: resp.NewEncoder(conn),
+	}, nil
+}
+
+// Do sends a command and returns its reply. An error reply is returned as a
+// redisserver.ReplyError along with the reply; any other error means the
+// connection failed.
+func (client *Client) Do(args ...string) (redisserver.Reply, error) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	client.conn.SetDeadline(time.Now().Add(requestTimeout))
+	if err := client.encoder.Encode(resp.BulkStringsValue(args)); err != nil {
+		return redisserver.Reply{}, err
+	}
+	reply, err := client.parser.Parse()
+	if err != nil {
+		return redisserver.Reply{}, err
+	}
+	if reply.IsError() {
+		return reply, redisserver.ReplyError(reply.Str)
+	}
+	return reply, nil
+}
+
+// String runs a command replying with a simple or bulk string. A nil reply
+// is returned as "", use StringOrNil to tell it apart.
+func (client *Client) String(args ...string) (string, error) {
+	str, _, err := client.StringOrNil(args...)
+	return str, err
+}
+
+// StringOrNil runs a command replying with a string, reporting false for a
+// nil reply like GET of a missing key
+func (client *Client) StringOrNil(args ...string) (string, bool, error) {
+	reply, err := client.Do(args...)
+	if err != nil {
+		return "", false, err
+	}
+	switch {
+	case reply.IsNull:
+		return "", false, nil
+	case reply.Type == resp.SimpleString, reply.Type == resp.BulkString, reply.Type == resp.Verbatim:
+		return reply.Str, true, nil
+	default:
+		return "", false, fmt.Errorf("%s replied with %c, not a string", args[0], reply.Type)
+	}
+}
+
+// Int runs a command replying with an integer, like INCR or DBSIZE
+func (client *Client) Int(args ...string) (int64, error) {
+	reply, err := client.Do(args...)
+	if err != nil {
+		return 0, err
+	}
+	switch reply.Type {
+	case resp.Integer:
+		return reply.Integer, nil
+	case resp.BulkString:
+		// Some replies, such as CONFIG GET values, carry numbers as strings
+		return strconv.ParseInt(reply.Str, 10, 64)
+	default:
+		return 0, fmt.Errorf("%s replied with %c, not an integer", args[0], reply.Type)
+	}
+}
+
+// Strings runs a command replying with an array of strings, like KEYS or
+// LRANGE. Nil elements are returned as "".
+func (client *Client) Strings(args ...string) ([]string, error) {
+	reply, err := client.Do(args...)
+	if err != nil {
+		return nil, err
+	}
+	if reply.Type != resp.Array {
+		return nil, fmt.Errorf("%s replied with %c, not an array", args[0], reply.Type)
+	}
+	strs := make([]string, len(reply.Array))
+	for i, element := range reply.Array {
+		strs[i] = element.Str
+	}
+	return strs, nil
+}
+
+// Close closes the connection
+func (client *Client) Close() error {
+	return client.conn.Close()
+}
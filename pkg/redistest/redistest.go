@@ -0,0 +1,273 @@
+// Package redistest runs master/replica topologies inside a test process, so
+// replication can be tested end to end without scripts starting servers:
+//
+//	func TestReplication(t *testing.T) {
+//		topology := redistest.Start(t, 2)
+//		topology.Master.Client.Do("SET", "key", "value")
+//		topology.WaitForOffsetSync()
+//		value, _ := topology.Replicas[0].Client.String("GET", "key")
+//	}
+//
+// Every server listens on an ephemeral port and keeps its files in a
+// temporary directory. Everything is stopped when the test ends.
+package redistest
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-redis-go/pkg/redisserver"
+)
+
+const (
+	defaultTimeout = 10 * time.Second      // How long the Wait helpers wait by default
+	pollInterval   = 10 * time.Millisecond // How often the Wait helpers check
+)
+
+// Node is one server of a topology
+type Node struct {
+	Name   string // "master" or "replica-<n>", prefixes its log lines
+	Server *redisserver.Server
+	Client *Client // Connected to Server, closed when the test ends
+	Dir    string  // Working directory holding the RDB and AOF files
+}
+
+// Addr returns the address the node listens on
+func (node *Node) Addr() string {
+	return node.Server.Addr().String()
+}
+
+// Info returns the fields of an INFO section, e.g. "replication"
+func (node *Node) Info(section string) (map[string]string, error) {
+	reply, err := node.Server.Do(context.Background(), "INFO", section)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]string)
+	for _, line := range strings.Split(reply.Str, "\r\n") {
+		if name, value, ok := strings.Cut(line, ":"); ok && !strings.HasPrefix(line, "#") {
+			fields[name] = value
+		}
+	}
+	return fields, nil
+}
+
+// ReplOffset returns the replication offset of the node: the offset of its
+// replication stream on a master, the offset it processed on a replica
+func (node *Node) ReplOffset() (int64, error) {
+	fields, err := node.Info("replication")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(fields["master_repl_offset"], 10, 64)
+}
+
+// Topology is a master and its replicas
+type Topology struct {
+	Master   *Node
+	Replicas []*Node
+
+	t       testing.TB
+	options options
+}
+
+// options are set by the Options given to Start
+type options struct {
+	configure     func(name string, cfg *redisserver.Config)
+	serverOptions []redisserver.Option
+	timeout       time.Duration
+	quiet         bool
+}
+
+// Option configures a topology started by Start
+type Option func(*options)
+
+// WithConfig changes the configuration of each node before it starts. name
+// is "master" or "replica-<n>"; ports, directories and replicaof are set
+// already and shouldn't be changed.
+func WithConfig(configure func(name string, cfg *redisserver.Config)) Option {
+	return func(options *options) {
+		options.configure = configure
+	}
+}
+
+// WithServerOptions passes opts to every server, e.g. redisserver.WithClock
+func WithServerOptions(opts ...redisserver.Option) Option {
+	return func(options *options) {
+		options.serverOptions = append(options.serverOptions, opts...)
+	}
+}
+
+// WithTimeout sets how long Start and the Wait helpers wait before failing
+// the test, 10 seconds by default
+func WithTimeout(timeout time.Duration) Option {
+	return func(options *options) {
+		options.timeout = timeout
+	}
+}
+
+// WithoutLogs drops the server logs instead of adding them to the test log
+func WithoutLogs() Option {
+	return func(options *options) {
+		options.quiet = true
+	}
+}
+
+// Start runs a master and the given number of replicas following it, and
+// returns once every replica completed its initial sync. The test fails if
+// a server doesn't start or a replica doesn't sync in time.
+func Start(t testing.TB, replicas int, opts ...Option) *Topology {
+	t.Helper()
+	topology := &Topology{t: t, options: options{timeout: defaultTimeout}}
+	for _, opt := range opts {
+		opt(&topology.options)
+	}
+
+	topology.Master = topology.startNode("master", "")
+	for i := 0; i < replicas; i++ {
+		replicaOf := fmt.Sprintf("127.0.0.1 %d", topology.Master.Server.Port())
+		topology.Replicas = append(topology.Replicas, topology.startNode(fmt.Sprintf("replica-%d", i), replicaOf))
+	}
+	topology.WaitForReplicas()
+	return topology
+}
+
+// startNode starts one server, stopped when the test ends
+func (topology *Topology) startNode(name, replicaOf string) *Node {
+	t := topology.t
+	t.Helper()
+
+	cfg := redisserver.NewConfig()
+	cfg.Port = 0
+	cfg.Dir = t.TempDir()
+	cfg.ReplicaOf = replicaOf
+	cfg.Save = ""
+	if topology.options.configure != nil {
+		topology.options.configure(name, cfg)
+	}
+
+	log := &testLogger{t: t, name: name, quiet: topology.options.quiet}
+	opts := append([]redisserver.Option{redisserver.WithLogger(log)}, topology.options.serverOptions...)
+	server, err := redisserver.Start(cfg, opts...)
+	if err != nil {
+		t.Fatalf("redistest: starting %s: %v", name, err)
+	}
+	// Cleanups run last in first out, so replicas stop before their master
+	t.Cleanup(func() {
+		server.Stop()
+		log.stop()
+	})
+
+	client, err := Dial(server.Addr().String())
+	if err != nil {
+		t.Fatalf("redistest: connecting to %s: %v", name, err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return &Node{Name: name, Server: server, Client: client, Dir: cfg.Dir}
+}
+
+// WaitForReplicas waits until the master counts every replica as connected
+// and each replica reports its link to the master up
+func (topology *Topology) WaitForReplicas() {
+	topology.t.Helper()
+	topology.waitFor("replicas to connect", func() (bool, error) {
+		fields, err := topology.Master.Info("replication")
+		if err != nil {
+			return false, err
+		}
+		if fields["connected_slaves"] != strconv.Itoa(len(topology.Replicas)) {
+			return false, nil
+		}
+		for _, replica := range topology.Replicas {
+			fields, err := replica.Info("replication")
+			if err != nil {
+				return false, err
+			}
+			if fields["master_link_status"] != "up" || fields["master_sync_in_progress"] != "0" {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// WaitForOffsetSync waits until every replica processed the replication
+// stream up to the master's current offset, so writes made on the master
+// before the call are visible on the replicas
+func (topology *Topology) WaitForOffsetSync() {
+	topology.t.Helper()
+	target, err := topology.Master.ReplOffset()
+	if err != nil {
+		topology.t.Fatalf("redistest: reading the master offset: %v", err)
+	}
+	topology.waitFor(fmt.Sprintf("replicas to reach offset %d", target), func() (bool, error) {
+		for _, replica := range topology.Replicas {
+			offset, err := replica.ReplOffset()
+			if err != nil || offset < target {
+				return false, err
+			}
+		}
+		return true, nil
+	})
+}
+
+// waitFor polls done until it returns true, failing the test if it returns
+// an error or the timeout expires
+func (topology *Topology) waitFor(what string, done func() (bool, error)) {
+	topology.t.Helper()
+	deadline := time.Now().Add(topology.options.timeout)
+	for {
+		ok, err := done()
+		if err != nil {
+			topology.t.Fatalf("redistest: waiting for %s: %v", what, err)
+		}
+		if ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			topology.t.Fatalf("redistest: timed out after %s waiting for %s", topology.options.timeout, what)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// testLogger adds the log lines of a server to the test log, prefixed with
+// the node name. Like the default log level, debug lines are dropped. Lines logged once the server stopped are dropped, since the
+// testing package panics on logging after a test ended.
+type testLogger struct {
+	t     testing.TB
+	name  string
+	quiet bool
+
+	mu      sync.Mutex
+	stopped bool
+}
+
+func (log *testLogger) logf(level, format string, args ...interface{}) {
+	if log.quiet {
+		return
+	}
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if !log.stopped {
+		log.t.Logf("[%s] [%s] %s", log.name, level, fmt.Sprintf(format, args...))
+	}
+}
+
+// stop drops the lines logged from now on
+func (log *testLogger) stop() {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	log.stopped = true
+}
+
+func (log *testLogger) Debug(format string, args ...interface{}) {}
+func (log *testLogger) Info(format string, args ...interface{})  { log.logf("INFO", format, args...) }
+func (log *testLogger) Warn(format string, args ...interface{})  { log.logf("WARN", format, args...) }
+func (log *testLogger) Error(format string, args ...interface{}) { log.logf("ERROR", format, args...) }